@@ -0,0 +1,146 @@
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cprobe/cprobe/lib/discoveryutils"
+)
+
+var configMap = discoveryutils.NewConfigMap()
+
+type apiConfig struct {
+	client     *discoveryutils.Client
+	token      string
+	datacenter string
+	query      url.Values
+}
+
+func newAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	ac, err := sdc.HTTPClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse auth config: %w", err)
+	}
+	apiServer := sdc.Server
+	if apiServer == "" {
+		apiServer = "localhost:8500"
+	}
+	if !strings.Contains(apiServer, "://") {
+		scheme := sdc.Scheme
+		if scheme == "" {
+			scheme = "http"
+			if sdc.HTTPClientConfig.TLSConfig != nil {
+				scheme = "https"
+			}
+		}
+		apiServer = scheme + "://" + apiServer
+	}
+	proxyAC, err := sdc.ProxyClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse proxy auth config: %w", err)
+	}
+	client, err := discoveryutils.NewClient(apiServer, ac, sdc.ProxyURL, proxyAC, &sdc.HTTPClientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create HTTP client for %q: %w", apiServer, err)
+	}
+
+	token, err := getToken(sdc.Token.String(), baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if sdc.Datacenter != "" {
+		query.Set("dc", sdc.Datacenter)
+	}
+	if sdc.Namespace != "" {
+		query.Set("ns", sdc.Namespace)
+	}
+	if sdc.Partition != "" {
+		query.Set("partition", sdc.Partition)
+	}
+	if sdc.AllowStale == nil || *sdc.AllowStale {
+		query.Set("stale", "")
+	}
+
+	cfg := &apiConfig{
+		client:     client,
+		token:      token,
+		datacenter: sdc.Datacenter,
+		query:      query,
+	}
+	return cfg, nil
+}
+
+// getToken falls back to CONSUL_HTTP_TOKEN_FILE and CONSUL_HTTP_TOKEN like the consul cli does
+func getToken(token, baseDir string) (string, error) {
+	if token != "" {
+		return token, nil
+	}
+	if tokenFile := os.Getenv("CONSUL_HTTP_TOKEN_FILE"); tokenFile != "" {
+		if !filepath.IsAbs(tokenFile) {
+			tokenFile = filepath.Join(baseDir, tokenFile)
+		}
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("cannot read consul token file %q: %w", tokenFile, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return os.Getenv("CONSUL_HTTP_TOKEN"), nil
+}
+
+func getAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	v, err := configMap.Get(sdc, func() (interface{}, error) { return newAPIConfig(sdc, baseDir) })
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiConfig), nil
+}
+
+func (cfg *apiConfig) getAPIResponse(path string, query url.Values, v interface{}) error {
+	q := url.Values{}
+	for k, vs := range cfg.query {
+		q[k] = vs
+	}
+	for k, vs := range query {
+		q[k] = vs
+	}
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	data, err := cfg.client.GetAPIResponseWithReqParams(path, func(req *http.Request) {
+		if cfg.token != "" {
+			req.Header.Set("X-Consul-Token", cfg.token)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("cannot query consul api %q: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("cannot parse consul api response from %q: %w", path, err)
+	}
+	return nil
+}
+
+// getDatacenter returns the datacenter of the agent if it is not configured
+func (cfg *apiConfig) getDatacenter() (string, error) {
+	if cfg.datacenter != "" {
+		return cfg.datacenter, nil
+	}
+	var agent struct {
+		Config struct {
+			Datacenter string
+		}
+	}
+	if err := cfg.getAPIResponse("/v1/agent/self", nil, &agent); err != nil {
+		return "", err
+	}
+	return agent.Config.Datacenter, nil
+}
@@ -0,0 +1,243 @@
+package consul
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cprobe/cprobe/lib/discoveryutils"
+	"github.com/cprobe/cprobe/lib/promauth"
+	"github.com/cprobe/cprobe/lib/promutils"
+	"github.com/cprobe/cprobe/lib/proxy"
+)
+
+// SDConfig represents service discovery config for Consul.
+//
+// See https://prometheus.io/docs/prometheus/latest/configuration/configuration/#consul_sd_config
+type SDConfig struct {
+	Server            string                     `yaml:"server,omitempty"`
+	Token             *promauth.Secret           `yaml:"token"`
+	Datacenter        string                     `yaml:"datacenter"`
+	Namespace         string                     `yaml:"namespace,omitempty"`
+	Partition         string                     `yaml:"partition,omitempty"`
+	Scheme            string                     `yaml:"scheme,omitempty"`
+	Services          []string                   `yaml:"services,omitempty"`
+	Tags              []string                   `yaml:"tags,omitempty"`
+	NodeMeta          map[string]string          `yaml:"node_meta,omitempty"`
+	TagSeparator      *string                    `yaml:"tag_separator,omitempty"`
+	AllowStale        *bool                      `yaml:"allow_stale,omitempty"`
+	HTTPClientConfig  promauth.HTTPClientConfig  `yaml:",inline"`
+	ProxyURL          *proxy.URL                 `yaml:"proxy_url,omitempty"`
+	ProxyClientConfig promauth.ProxyClientConfig `yaml:",inline"`
+}
+
+// ServiceNode is Consul service node.
+//
+// See https://developer.hashicorp.com/consul/api-docs/health#list-nodes-for-service
+type ServiceNode struct {
+	Service Service
+	Node    Node
+	Checks  []Check
+}
+
+// Service is Consul service.
+type Service struct {
+	ID        string
+	Service   string
+	Address   string
+	Namespace string
+	Partition string
+	Port      int
+	Tags      []string
+	Meta      map[string]string
+}
+
+// Node is Consul node.
+type Node struct {
+	Address         string
+	Datacenter      string
+	Node            string
+	Meta            map[string]string
+	TaggedAddresses map[string]string
+}
+
+// Check is Consul check.
+type Check struct {
+	CheckID string
+	Status  string
+}
+
+// GetLabels returns Consul labels according to sdc.
+func (sdc *SDConfig) GetLabels(baseDir string) ([]*promutils.Labels, error) {
+	cfg, err := getAPIConfig(sdc, baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get API config: %w", err)
+	}
+
+	dc, err := cfg.getDatacenter()
+	if err != nil {
+		return nil, fmt.Errorf("cannot obtain consul datacenter: %w", err)
+	}
+
+	serviceNames, err := getServiceNames(cfg, sdc)
+	if err != nil {
+		return nil, err
+	}
+
+	tagSeparator := ","
+	if sdc.TagSeparator != nil {
+		tagSeparator = *sdc.TagSeparator
+	}
+
+	var ms []*promutils.Labels
+	for _, serviceName := range serviceNames {
+		sns, err := getServiceNodes(cfg, sdc, serviceName)
+		if err != nil {
+			return nil, err
+		}
+		for i := range sns {
+			ms = append(ms, sns[i].appendTargetLabels(serviceName, tagSeparator, dc))
+		}
+	}
+	return ms, nil
+}
+
+// MustStop stops further usage for sdc.
+func (sdc *SDConfig) MustStop() {
+	v := configMap.Delete(sdc)
+	if v != nil {
+		cfg := v.(*apiConfig)
+		cfg.client.Stop()
+	}
+}
+
+// getServiceNames returns the sorted names of the services matching sdc.Services and sdc.Tags
+func getServiceNames(cfg *apiConfig, sdc *SDConfig) ([]string, error) {
+	var services map[string][]string
+	if err := cfg.getAPIResponse("/v1/catalog/services", nodeMetaQuery(sdc.NodeMeta), &services); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name, tags := range services {
+		if !isServiceWanted(name, tags, sdc.Services, sdc.Tags) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func isServiceWanted(name string, tags, services, wantTags []string) bool {
+	if len(services) > 0 {
+		found := false
+		for _, s := range services {
+			if s == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, wantTag := range wantTags {
+		found := false
+		for _, tag := range tags {
+			if tag == wantTag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func getServiceNodes(cfg *apiConfig, sdc *SDConfig, serviceName string) ([]ServiceNode, error) {
+	query := nodeMetaQuery(sdc.NodeMeta)
+	for _, tag := range sdc.Tags {
+		query.Add("tag", tag)
+	}
+
+	var sns []ServiceNode
+	if err := cfg.getAPIResponse("/v1/health/service/"+url.PathEscape(serviceName), query, &sns); err != nil {
+		return nil, err
+	}
+	return sns, nil
+}
+
+func nodeMetaQuery(nodeMeta map[string]string) url.Values {
+	query := url.Values{}
+	for k, v := range nodeMeta {
+		query.Add("node-meta", k+":"+v)
+	}
+	return query
+}
+
+func (sn *ServiceNode) appendTargetLabels(serviceName, tagSeparator, dc string) *promutils.Labels {
+	var addr string
+	if sn.Service.Address != "" {
+		addr = discoveryutils.JoinHostPort(sn.Service.Address, sn.Service.Port)
+	} else {
+		addr = discoveryutils.JoinHostPort(sn.Node.Address, sn.Service.Port)
+	}
+
+	m := promutils.NewLabels(16)
+	m.Add("__address__", addr)
+	m.Add("__meta_consul_address", sn.Node.Address)
+	m.Add("__meta_consul_dc", dc)
+	m.Add("__meta_consul_health", aggregatedStatus(sn.Checks))
+	m.Add("__meta_consul_namespace", sn.Service.Namespace)
+	m.Add("__meta_consul_partition", sn.Service.Partition)
+	m.Add("__meta_consul_node", sn.Node.Node)
+	m.Add("__meta_consul_service", serviceName)
+	m.Add("__meta_consul_service_address", sn.Service.Address)
+	m.Add("__meta_consul_service_id", sn.Service.ID)
+	m.Add("__meta_consul_service_port", strconv.Itoa(sn.Service.Port))
+
+	// We surround the separated list with the separator as well. This way regular expressions
+	// in relabeling rules don't have to consider tag positions.
+	m.Add("__meta_consul_tags", tagSeparator+strings.Join(sn.Service.Tags, tagSeparator)+tagSeparator)
+
+	for k, v := range sn.Node.Meta {
+		m.Add(discoveryutils.SanitizeLabelName("__meta_consul_metadata_"+k), v)
+	}
+	for k, v := range sn.Service.Meta {
+		m.Add(discoveryutils.SanitizeLabelName("__meta_consul_service_metadata_"+k), v)
+	}
+	for k, v := range sn.Node.TaggedAddresses {
+		m.Add(discoveryutils.SanitizeLabelName("__meta_consul_tagged_address_"+k), v)
+	}
+	return m
+}
+
+// aggregatedStatus returns the worst status of the checks: maintenance > critical > warning > passing
+func aggregatedStatus(checks []Check) string {
+	var warning, critical, maintenance bool
+	for _, check := range checks {
+		switch check.Status {
+		case "warning":
+			warning = true
+		case "critical":
+			critical = true
+		case "maintenance":
+			maintenance = true
+		}
+	}
+	switch {
+	case maintenance:
+		return "maintenance"
+	case critical:
+		return "critical"
+	case warning:
+		return "warning"
+	default:
+		return "passing"
+	}
+}
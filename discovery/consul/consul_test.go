@@ -0,0 +1,82 @@
+package consul
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cprobe/cprobe/lib/discoveryutils"
+	"github.com/cprobe/cprobe/lib/promutils"
+)
+
+func TestServiceNodeAppendTargetLabels(t *testing.T) {
+	data := `
+[
+  {
+    "Node": {
+      "Node": "foobar",
+      "Address": "10.1.10.12",
+      "Datacenter": "dc1",
+      "TaggedAddresses": {"lan": "10.1.10.12", "wan": "10.1.10.12"},
+      "Meta": {"instance_type": "t2.medium"}
+    },
+    "Service": {
+      "ID": "mysql-1",
+      "Service": "mysql",
+      "Tags": ["primary", "v1"],
+      "Address": "",
+      "Meta": {"cprobe-rule": "mysql/rule_primary.toml"},
+      "Port": 3306,
+      "Namespace": "ns-dev",
+      "Partition": "part-app"
+    },
+    "Checks": [
+      {"CheckID": "serfHealth", "Status": "passing"},
+      {"CheckID": "service:mysql-1", "Status": "warning"}
+    ]
+  }
+]`
+	var sns []ServiceNode
+	if err := json.Unmarshal([]byte(data), &sns); err != nil {
+		t.Fatalf("cannot parse data: %s", err)
+	}
+	if len(sns) != 1 {
+		t.Fatalf("unexpected length of ServiceNodes; got %d; want %d", len(sns), 1)
+	}
+
+	labelss := []*promutils.Labels{sns[0].appendTargetLabels("mysql", ",", "dc1")}
+	expectedLabelss := []*promutils.Labels{
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                                "10.1.10.12:3306",
+			"__meta_consul_address":                      "10.1.10.12",
+			"__meta_consul_dc":                           "dc1",
+			"__meta_consul_health":                       "warning",
+			"__meta_consul_metadata_instance_type":       "t2.medium",
+			"__meta_consul_namespace":                    "ns-dev",
+			"__meta_consul_partition":                    "part-app",
+			"__meta_consul_node":                         "foobar",
+			"__meta_consul_service":                      "mysql",
+			"__meta_consul_service_address":              "",
+			"__meta_consul_service_id":                   "mysql-1",
+			"__meta_consul_service_metadata_cprobe_rule": "mysql/rule_primary.toml",
+			"__meta_consul_service_port":                 "3306",
+			"__meta_consul_tagged_address_lan":           "10.1.10.12",
+			"__meta_consul_tagged_address_wan":           "10.1.10.12",
+			"__meta_consul_tags":                         ",primary,v1,",
+		}),
+	}
+	discoveryutils.TestEqualLabelss(t, labelss, expectedLabelss)
+}
+
+func TestIsServiceWanted(t *testing.T) {
+	f := func(name string, tags, services, wantTags []string, expected bool) {
+		t.Helper()
+		if got := isServiceWanted(name, tags, services, wantTags); got != expected {
+			t.Fatalf("unexpected result for %q; got %v; want %v", name, got, expected)
+		}
+	}
+	f("mysql", nil, nil, nil, true)
+	f("mysql", nil, []string{"redis", "mysql"}, nil, true)
+	f("mysql", nil, []string{"redis"}, nil, false)
+	f("mysql", []string{"primary", "v1"}, nil, []string{"primary"}, true)
+	f("mysql", []string{"replica"}, nil, []string{"primary"}, false)
+}
@@ -0,0 +1,120 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+
+	"github.com/cprobe/cprobe/lib/discoveryutils"
+	"github.com/cprobe/cprobe/lib/promauth"
+)
+
+var configMap = discoveryutils.NewConfigMap()
+
+const (
+	serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	serviceAccountCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+type apiConfig struct {
+	client     *discoveryutils.Client
+	namespaces []string
+	query      url.Values
+}
+
+func newAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	switch sdc.Role {
+	case "", "endpoints":
+	default:
+		return nil, fmt.Errorf("unsupported role %q; only `endpoints` is supported", sdc.Role)
+	}
+
+	hcc := sdc.HTTPClientConfig
+	apiServer := sdc.APIServer
+	if apiServer == "" {
+		// Assume we run in the cluster, use the credentials of the service account
+		host := os.Getenv("KUBERNETES_SERVICE_HOST")
+		port := os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("api_server is missing and cannot obtain it from KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT env vars")
+		}
+		apiServer = "https://" + net.JoinHostPort(host, port)
+		if hcc.BearerToken == nil && hcc.BearerTokenFile == "" && hcc.Authorization == nil {
+			hcc.BearerTokenFile = serviceAccountTokenFile
+		}
+		if hcc.TLSConfig == nil {
+			hcc.TLSConfig = &promauth.TLSConfig{
+				CAFile: serviceAccountCAFile,
+			}
+		}
+	}
+
+	ac, err := hcc.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse auth config: %w", err)
+	}
+	proxyAC, err := sdc.ProxyClientConfig.NewConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse proxy auth config: %w", err)
+	}
+	client, err := discoveryutils.NewClient(apiServer, ac, sdc.ProxyURL, proxyAC, &hcc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create HTTP client for %q: %w", apiServer, err)
+	}
+
+	query := url.Values{}
+	if sdc.LabelSelector != "" {
+		query.Set("labelSelector", sdc.LabelSelector)
+	}
+	if sdc.FieldSelector != "" {
+		query.Set("fieldSelector", sdc.FieldSelector)
+	}
+
+	cfg := &apiConfig{
+		client:     client,
+		namespaces: sdc.Namespaces.Names,
+		query:      query,
+	}
+	return cfg, nil
+}
+
+func getAPIConfig(sdc *SDConfig, baseDir string) (*apiConfig, error) {
+	v, err := configMap.Get(sdc, func() (interface{}, error) { return newAPIConfig(sdc, baseDir) })
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiConfig), nil
+}
+
+// getEndpoints returns the endpoints in all the configured namespaces
+func (cfg *apiConfig) getEndpoints() ([]Endpoints, error) {
+	namespaces := cfg.namespaces
+	if len(namespaces) == 0 {
+		// empty namespace means all the namespaces
+		namespaces = []string{""}
+	}
+
+	var eps []Endpoints
+	for _, ns := range namespaces {
+		path := "/api/v1/endpoints"
+		if ns != "" {
+			path = "/api/v1/namespaces/" + url.PathEscape(ns) + "/endpoints"
+		}
+		if len(cfg.query) > 0 {
+			path += "?" + cfg.query.Encode()
+		}
+
+		data, err := cfg.client.GetAPIResponse(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot query kubernetes api %q: %w", path, err)
+		}
+		var epl EndpointsList
+		if err := json.Unmarshal(data, &epl); err != nil {
+			return nil, fmt.Errorf("cannot parse kubernetes api response from %q: %w", path, err)
+		}
+		eps = append(eps, epl.Items...)
+	}
+	return eps, nil
+}
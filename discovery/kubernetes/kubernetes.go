@@ -0,0 +1,146 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cprobe/cprobe/lib/discoveryutils"
+	"github.com/cprobe/cprobe/lib/promauth"
+	"github.com/cprobe/cprobe/lib/promutils"
+	"github.com/cprobe/cprobe/lib/proxy"
+)
+
+// SDConfig represents service discovery config for Kubernetes, only the endpoints role is supported.
+//
+// See https://prometheus.io/docs/prometheus/latest/configuration/configuration/#kubernetes_sd_config
+type SDConfig struct {
+	APIServer         string                     `yaml:"api_server,omitempty"`
+	Role              string                     `yaml:"role,omitempty"`
+	Namespaces        Namespaces                 `yaml:"namespaces,omitempty"`
+	LabelSelector     string                     `yaml:"label_selector,omitempty"`
+	FieldSelector     string                     `yaml:"field_selector,omitempty"`
+	HTTPClientConfig  promauth.HTTPClientConfig  `yaml:",inline"`
+	ProxyURL          *proxy.URL                 `yaml:"proxy_url,omitempty"`
+	ProxyClientConfig promauth.ProxyClientConfig `yaml:",inline"`
+}
+
+// Namespaces represents namespaces for SDConfig
+type Namespaces struct {
+	Names []string `yaml:"names"`
+}
+
+// EndpointsList implements k8s endpoints list.
+//
+// See https://kubernetes.io/docs/reference/generated/kubernetes-api/v1.27/#endpointslist-v1-core
+type EndpointsList struct {
+	Items []Endpoints
+}
+
+// Endpoints implements k8s endpoints.
+type Endpoints struct {
+	Metadata ObjectMeta
+	Subsets  []EndpointSubset
+}
+
+// ObjectMeta represents ObjectMeta from k8s API.
+type ObjectMeta struct {
+	Name      string
+	Namespace string
+	Labels    map[string]string
+}
+
+// EndpointSubset implements k8s endpoint subset.
+type EndpointSubset struct {
+	Addresses         []EndpointAddress
+	NotReadyAddresses []EndpointAddress
+	Ports             []EndpointPort
+}
+
+// EndpointAddress implements k8s endpoint address.
+type EndpointAddress struct {
+	Hostname  string
+	IP        string
+	NodeName  string
+	TargetRef ObjectReference
+}
+
+// ObjectReference implements k8s object reference.
+type ObjectReference struct {
+	Kind      string
+	Name      string
+	Namespace string
+}
+
+// EndpointPort implements k8s endpoint port.
+type EndpointPort struct {
+	Name     string
+	Port     int
+	Protocol string
+}
+
+// GetLabels returns Kubernetes labels according to sdc.
+func (sdc *SDConfig) GetLabels(baseDir string) ([]*promutils.Labels, error) {
+	cfg, err := getAPIConfig(sdc, baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get API config: %w", err)
+	}
+	eps, err := cfg.getEndpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	var ms []*promutils.Labels
+	for i := range eps {
+		ms = eps[i].appendTargetLabels(ms)
+	}
+	return ms, nil
+}
+
+// MustStop stops further usage for sdc.
+func (sdc *SDConfig) MustStop() {
+	v := configMap.Delete(sdc)
+	if v != nil {
+		cfg := v.(*apiConfig)
+		cfg.client.Stop()
+	}
+}
+
+func (eps *Endpoints) appendTargetLabels(ms []*promutils.Labels) []*promutils.Labels {
+	for _, ess := range eps.Subsets {
+		for _, epp := range ess.Ports {
+			for _, ea := range ess.Addresses {
+				ms = append(ms, eps.getEndpointLabels(ea, epp, "true"))
+			}
+			for _, ea := range ess.NotReadyAddresses {
+				ms = append(ms, eps.getEndpointLabels(ea, epp, "false"))
+			}
+		}
+	}
+	return ms
+}
+
+func (eps *Endpoints) getEndpointLabels(ea EndpointAddress, epp EndpointPort, ready string) *promutils.Labels {
+	m := promutils.NewLabels(16)
+	m.Add("__address__", discoveryutils.JoinHostPort(ea.IP, epp.Port))
+	m.Add("__meta_kubernetes_namespace", eps.Metadata.Namespace)
+	m.Add("__meta_kubernetes_endpoints_name", eps.Metadata.Name)
+	for k, v := range eps.Metadata.Labels {
+		m.Add(discoveryutils.SanitizeLabelName("__meta_kubernetes_endpoints_label_"+k), v)
+		m.Add(discoveryutils.SanitizeLabelName("__meta_kubernetes_endpoints_labelpresent_"+k), "true")
+	}
+	m.Add("__meta_kubernetes_endpoint_ready", ready)
+	m.Add("__meta_kubernetes_endpoint_port_name", epp.Name)
+	m.Add("__meta_kubernetes_endpoint_port_number", strconv.Itoa(epp.Port))
+	m.Add("__meta_kubernetes_endpoint_port_protocol", epp.Protocol)
+	if ea.Hostname != "" {
+		m.Add("__meta_kubernetes_endpoint_hostname", ea.Hostname)
+	}
+	if ea.NodeName != "" {
+		m.Add("__meta_kubernetes_endpoint_node_name", ea.NodeName)
+	}
+	if ea.TargetRef.Kind != "" {
+		m.Add("__meta_kubernetes_endpoint_address_target_kind", ea.TargetRef.Kind)
+		m.Add("__meta_kubernetes_endpoint_address_target_name", ea.TargetRef.Name)
+	}
+	return m
+}
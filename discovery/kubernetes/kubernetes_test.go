@@ -0,0 +1,80 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cprobe/cprobe/lib/discoveryutils"
+	"github.com/cprobe/cprobe/lib/promutils"
+)
+
+func TestEndpointsAppendTargetLabels(t *testing.T) {
+	data := `
+{
+  "items": [
+    {
+      "metadata": {
+        "name": "mysql",
+        "namespace": "db",
+        "labels": {"app.kubernetes.io/name": "mysql"}
+      },
+      "subsets": [
+        {
+          "addresses": [
+            {
+              "ip": "10.0.0.5",
+              "hostname": "mysql-0",
+              "nodeName": "node-1",
+              "targetRef": {"kind": "Pod", "name": "mysql-0", "namespace": "db"}
+            }
+          ],
+          "notReadyAddresses": [
+            {"ip": "10.0.0.6"}
+          ],
+          "ports": [
+            {"name": "mysql", "port": 3306, "protocol": "TCP"}
+          ]
+        }
+      ]
+    }
+  ]
+}`
+	var epl EndpointsList
+	if err := json.Unmarshal([]byte(data), &epl); err != nil {
+		t.Fatalf("cannot parse data: %s", err)
+	}
+	if len(epl.Items) != 1 {
+		t.Fatalf("unexpected length of Endpoints; got %d; want %d", len(epl.Items), 1)
+	}
+
+	labelss := epl.Items[0].appendTargetLabels(nil)
+	expectedLabelss := []*promutils.Labels{
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                                                     "10.0.0.5:3306",
+			"__meta_kubernetes_namespace":                                     "db",
+			"__meta_kubernetes_endpoints_name":                                "mysql",
+			"__meta_kubernetes_endpoints_label_app_kubernetes_io_name":        "mysql",
+			"__meta_kubernetes_endpoints_labelpresent_app_kubernetes_io_name": "true",
+			"__meta_kubernetes_endpoint_ready":                                "true",
+			"__meta_kubernetes_endpoint_port_name":                            "mysql",
+			"__meta_kubernetes_endpoint_port_number":                          "3306",
+			"__meta_kubernetes_endpoint_port_protocol":                        "TCP",
+			"__meta_kubernetes_endpoint_hostname":                             "mysql-0",
+			"__meta_kubernetes_endpoint_node_name":                            "node-1",
+			"__meta_kubernetes_endpoint_address_target_kind":                  "Pod",
+			"__meta_kubernetes_endpoint_address_target_name":                  "mysql-0",
+		}),
+		promutils.NewLabelsFromMap(map[string]string{
+			"__address__":                                                     "10.0.0.6:3306",
+			"__meta_kubernetes_namespace":                                     "db",
+			"__meta_kubernetes_endpoints_name":                                "mysql",
+			"__meta_kubernetes_endpoints_label_app_kubernetes_io_name":        "mysql",
+			"__meta_kubernetes_endpoints_labelpresent_app_kubernetes_io_name": "true",
+			"__meta_kubernetes_endpoint_ready":                                "false",
+			"__meta_kubernetes_endpoint_port_name":                            "mysql",
+			"__meta_kubernetes_endpoint_port_number":                          "3306",
+			"__meta_kubernetes_endpoint_port_protocol":                        "TCP",
+		}),
+	}
+	discoveryutils.TestEqualLabelss(t, labelss, expectedLabelss)
+}
@@ -0,0 +1,52 @@
+package httpd
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authMiddleware accepts the requests with the basic auth credentials of -http.username and -http.password,
+// or the bearer token of -http.bearerToken, the others get 401. It returns nil if neither is configured.
+func authMiddleware(username, password, bearerToken string) gin.HandlerFunc {
+	basicEnabled := username != "" && password != ""
+	if !basicEnabled && bearerToken == "" {
+		return nil
+	}
+
+	return func(c *gin.Context) {
+		authorization := c.GetHeader("Authorization")
+		if bearerToken != "" && strings.HasPrefix(authorization, "Bearer ") {
+			if secureCompare(strings.TrimPrefix(authorization, "Bearer "), bearerToken) {
+				c.Next()
+				return
+			}
+		}
+
+		if basicEnabled {
+			if user, pass, ok := c.Request.BasicAuth(); ok {
+				// both are compared to not reveal which one is wrong by the time
+				userOK := secureCompare(user, username)
+				passOK := secureCompare(pass, password)
+				if userOK && passOK {
+					c.Next()
+					return
+				}
+			}
+			c.Header("WWW-Authenticate", `Basic realm="cprobe"`)
+		} else {
+			c.Header("WWW-Authenticate", `Bearer realm="cprobe"`)
+		}
+		c.AbortWithStatus(http.StatusUnauthorized)
+	}
+}
+
+// secureCompare compares in constant time, the hashes are compared so the time does not depend on the length either
+func secureCompare(given, expected string) bool {
+	g := sha256.Sum256([]byte(given))
+	e := sha256.Sum256([]byte(expected))
+	return subtle.ConstantTimeCompare(g[:], e[:]) == 1
+}
@@ -0,0 +1,48 @@
+package httpd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	if authMiddleware("", "", "") != nil {
+		t.Fatalf("expected no auth if nothing is configured")
+	}
+
+	newRouter := func(auth gin.HandlerFunc) *gin.Engine {
+		r := gin.New()
+		r.Use(auth)
+		r.GET("/metrics", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+		return r
+	}
+
+	tests := []struct {
+		name     string
+		auth     gin.HandlerFunc
+		setup    func(req *http.Request)
+		expected int
+	}{
+		{"no credentials", authMiddleware("admin", "pass", "token"), func(req *http.Request) {}, http.StatusUnauthorized},
+		{"basic auth", authMiddleware("admin", "pass", "token"), func(req *http.Request) { req.SetBasicAuth("admin", "pass") }, http.StatusOK},
+		{"wrong password", authMiddleware("admin", "pass", "token"), func(req *http.Request) { req.SetBasicAuth("admin", "passx") }, http.StatusUnauthorized},
+		{"bearer token", authMiddleware("admin", "pass", "token"), func(req *http.Request) { req.Header.Set("Authorization", "Bearer token") }, http.StatusOK},
+		{"wrong bearer token", authMiddleware("", "", "token"), func(req *http.Request) { req.Header.Set("Authorization", "Bearer tok") }, http.StatusUnauthorized},
+		{"basic auth without basic configured", authMiddleware("", "", "token"), func(req *http.Request) { req.SetBasicAuth("admin", "token") }, http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		test.setup(req)
+		w := httptest.NewRecorder()
+		newRouter(test.auth).ServeHTTP(w, req)
+		if w.Code != test.expected {
+			t.Errorf("%s: got status %d, expected %d", test.name, w.Code, test.expected)
+		}
+	}
+}
@@ -7,14 +7,18 @@ var (
 	HTTPPort                        int
 	HTTPUsername                    string
 	HTTPPassword                    string
+	HTTPBearerToken                 string
+	HTTPBearerTokenFile             string
 	HTTPMode                        string
 	HTTPPProf                       bool
+	HTTPStream                      bool
 	HTTPReadHeaderTimeout           time.Duration
 	HTTPIdleTimeout                 time.Duration
 	HTTPConnTimeout                 time.Duration
 	HTTPTLSEnable                   bool
 	HTTPTLSCertFile                 string
 	HTTPTLSKeyFile                  string
+	HTTPTLSClientCAFile             string
 	HTTPTLSCipherSuitesString       string
 	HTTPTLSCipherSuitesArray        []string
 	HTTPTLSMinVersion               string
@@ -21,6 +21,7 @@ import (
 	"github.com/cprobe/cprobe/writer"
 	"gopkg.in/yaml.v2"
 
+	"github.com/VictoriaMetrics/metrics"
 	"github.com/cprobe/cprobe/lib/buildinfo"
 	"github.com/cprobe/cprobe/lib/fasttime"
 	"github.com/cprobe/cprobe/lib/ginx"
@@ -50,14 +51,19 @@ func init() {
 	flag.StringVar(&HTTPListen, "http.listen", "0.0.0.0:5858", "Address to listen for http connections.")
 	flag.StringVar(&HTTPUsername, "http.username", "", "Username for basic http authentication. No authentication is performed if username is empty.")
 	flag.StringVar(&HTTPPassword, "http.password", "", "Password for basic http authentication. No authentication is performed if password is empty.")
+	flag.StringVar(&HTTPBearerToken, "http.bearerToken", "", "Bearer token for http authentication, requests with either the bearer token or the basic auth credentials are accepted. No bearer token authentication is performed if empty.")
+	flag.StringVar(&HTTPBearerTokenFile, "http.bearerTokenFile", "", "Path to file with the bearer token for http authentication, read once at startup. Takes effect if -http.bearerToken is empty.")
 	flag.StringVar(&HTTPMode, "http.mode", "release", "Gin mode. One of: {debug|release|test}")
 	flag.BoolVar(&HTTPPProf, "http.pprof", false, "Enable pprof http handlers. This is insecure and should be disabled in production.")
+	flag.BoolVar(&HTTPStream, "http.stream", false, "Enable the websocket endpoint /stream?target=<address>&job=<job> streaming the samples of every scrape of the target in JSON for debugging. "+
+		"It is only enabled if -http.username and -http.password, or -http.bearerToken is set.")
 	flag.DurationVar(&HTTPReadHeaderTimeout, "http.readTimeout", time.Second*5, "Maximum duration for reading request header.")
 	flag.DurationVar(&HTTPIdleTimeout, "http.idleTimeout", time.Minute, "Maximum amount of time to wait for the next request when keep-alives are enabled.")
 	flag.DurationVar(&HTTPConnTimeout, "http.connTimeout", 2*time.Minute, `Incoming http connections are closed after the configured timeout. This may help to spread the incoming load among a cluster of services behind a load balancer. Please note that the real timeout may be bigger by up to 10% as a protection against the thundering herd problem`)
 	flag.BoolVar(&HTTPTLSEnable, "http.tls", false, "Whether to enable TLS for incoming HTTP requests at -http.listen (aka https). -http.tlsCertFile and -http.tlsKeyFile must be set if -http.tls is set")
 	flag.StringVar(&HTTPTLSCertFile, "http.tlsCertFile", "", "Path to file with TLS certificate if -http.tls is set. Prefer ECDSA certs instead of RSA certs as RSA certs are slower. The provided certificate file is automatically re-read every second, so it can be dynamically updated")
 	flag.StringVar(&HTTPTLSKeyFile, "http.tlsKeyFile", "", "Path to file with TLS key if -http.tls is set. The provided key file is automatically re-read every second, so it can be dynamically updated")
+	flag.StringVar(&HTTPTLSClientCAFile, "http.tlsClientCAFile", "", "Optional path to file with the CA certificates to verify the client certificates of incoming requests if -http.tls is set (aka mTLS), the requests without a valid client certificate are refused. The provided file is automatically re-read every second, so it can be dynamically updated")
 	flag.StringVar(&HTTPTLSCipherSuitesString, "http.tlsCipherSuites", "", "Optional list of TLS cipher suites for incoming requests over HTTPS if -http.tls is set. split by comma. See the list of supported cipher suites at https://pkg.go.dev/crypto/tls#pkg-constants")
 	flag.StringVar(&HTTPTLSMinVersion, "http.tlsMinVersion", "", "Optional minimum TLS version to use for incoming requests over HTTPS if -http.tls is set. "+
 		"Supported values: TLS10, TLS11, TLS12, TLS13")
@@ -80,6 +86,18 @@ func init() {
 	}
 }
 
+// httpBearerToken returns -http.bearerToken, or the content of -http.bearerTokenFile
+func httpBearerToken() string {
+	if HTTPBearerToken != "" || HTTPBearerTokenFile == "" {
+		return HTTPBearerToken
+	}
+	bs, err := os.ReadFile(HTTPBearerTokenFile)
+	if err != nil {
+		logger.Fatalf("cannot read -http.bearerTokenFile %q: %s", HTTPBearerTokenFile, err)
+	}
+	return strings.TrimSpace(string(bs))
+}
+
 type HTTPRouter struct {
 	engine *gin.Engine
 }
@@ -90,16 +108,21 @@ func Router() *HTTPRouter {
 	r := gin.New()
 	r.Use(gin.Recovery())
 	r.Use(ginx.BombRecovery())
-	if HTTPUsername != "" && HTTPPassword != "" {
-		r.Use(gin.BasicAuth(gin.Accounts{
-			HTTPUsername: HTTPPassword,
-		}))
+	auth := authMiddleware(HTTPUsername, HTTPPassword, httpBearerToken())
+	if auth != nil {
+		r.Use(auth)
 	}
 
 	if HTTPPProf {
 		pprof.Register(r, "/debug/pprof")
 	}
 
+	if HTTPStream && auth != nil {
+		r.GET("/stream", streamSamples)
+	} else if HTTPStream {
+		logger.Warnf("-http.stream is ignored since the http authentication is not configured")
+	}
+
 	r.GET("/", func(c *gin.Context) {
 		endpoints := map[string]string{
 			"targets": "status for discovered active targets",
@@ -123,6 +146,11 @@ func Router() *HTTPRouter {
 		parse, _ := template.New("index").Parse(indexHtlm)
 		parse.Execute(c.Writer, temp)
 	})
+	r.GET("/metrics", func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		metrics.WritePrometheus(c.Writer, true)
+		probe.WriteScrapeResults(c.Writer)
+	})
 	r.GET("/flags", func(c *gin.Context) {
 		flagutil.WriteFlags(c.Writer)
 	})
@@ -202,7 +230,7 @@ func (r *HTTPRouter) Start() func() error {
 	go func() {
 		var tlsConfig *tls.Config
 		if HTTPTLSEnable {
-			tc, err := httptls.GetServerTLSConfig(HTTPTLSCertFile, HTTPTLSKeyFile, HTTPTLSMinVersion, HTTPTLSCipherSuitesArray)
+			tc, err := httptls.GetServerTLSConfig(HTTPTLSCertFile, HTTPTLSKeyFile, HTTPTLSClientCAFile, HTTPTLSMinVersion, HTTPTLSCipherSuitesArray)
 			if err != nil {
 				logger.Fatalf("cannot get TLS config for http server: %s", err)
 			}
@@ -0,0 +1,76 @@
+package httpd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/cprobe/cprobe/probe"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// streamSamples upgrades to websocket and sends the probe.StreamMessage of every scrape of the target
+// as a text message, until the client goes away
+func streamSamples(c *gin.Context) {
+	target := c.Query("target")
+	if target == "" {
+		c.String(http.StatusBadRequest, "target is required")
+		return
+	}
+	job := c.Query("job")
+
+	server := websocket.Server{
+		Handshake: checkSameOrigin,
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			ch, unsubscribe := probe.SubscribeSamples(job, target)
+			defer unsubscribe()
+
+			// the client sends nothing, the read returns when it closes the connection
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var discard []byte
+				for websocket.Message.Receive(ws, &discard) == nil {
+				}
+			}()
+
+			for {
+				select {
+				case msg := <-ch:
+					if err := websocket.Message.Send(ws, string(msg)); err != nil {
+						return
+					}
+				case <-closed:
+					return
+				case <-c.Request.Context().Done():
+					return
+				}
+			}
+		},
+	}
+
+	logger.Infof("streaming the samples of target %s to %s", target, c.Request.RemoteAddr)
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// checkSameOrigin refuses the browsers of the other sites, the clients other than browsers usually send no Origin
+func checkSameOrigin(config *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if u.Host != req.Host {
+		return fmt.Errorf("origin %s is not allowed", origin)
+	}
+	config.Origin = u
+	return nil
+}
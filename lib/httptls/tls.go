@@ -2,15 +2,22 @@ package httptls
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
 	"strings"
 	"sync"
 
 	"github.com/cprobe/cprobe/lib/fasttime"
+	"github.com/cprobe/cprobe/lib/logger"
 )
 
 // GetServerTLSConfig returns TLS config for the server.
-func GetServerTLSConfig(tlsCertFile, tlsKeyFile, tlsMinVersion string, tlsCipherSuites []string) (*tls.Config, error) {
+//
+// The client certificates are required and verified by the CA certificates of tlsClientCAFile if it is set.
+// Both the cert and the client CA files are re-read every second, so they can be dynamically updated.
+// The last loaded client CA is kept if the client CA file cannot be re-read.
+func GetServerTLSConfig(tlsCertFile, tlsKeyFile, tlsClientCAFile, tlsMinVersion string, tlsCipherSuites []string) (*tls.Config, error) {
 	var certLock sync.Mutex
 	var certDeadline uint64
 	var cert *tls.Certificate
@@ -46,9 +53,50 @@ func GetServerTLSConfig(tlsCertFile, tlsKeyFile, tlsMinVersion string, tlsCipher
 		},
 		CipherSuites: cipherSuites,
 	}
+
+	if tlsClientCAFile == "" {
+		return cfg, nil
+	}
+
+	var caLock sync.Mutex
+	var caDeadline uint64
+	clientCAs, err := loadCertPool(tlsClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	cfg.ClientCAs = clientCAs
+	cfg.GetConfigForClient = func(info *tls.ClientHelloInfo) (*tls.Config, error) {
+		caLock.Lock()
+		defer caLock.Unlock()
+		if fasttime.UnixTimestamp() > caDeadline {
+			// a half-written file during the rotation must not fail the handshakes, keep the last good pool until the next try
+			if pool, err := loadCertPool(tlsClientCAFile); err != nil {
+				logger.Errorf("cannot reload TLS client CA, keep using the last loaded one: %s", err)
+			} else {
+				clientCAs = pool
+			}
+			caDeadline = fasttime.UnixTimestamp() + 1
+		}
+		c := cfg.Clone()
+		c.ClientCAs = clientCAs
+		return c, nil
+	}
 	return cfg, nil
 }
 
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read TLS client CA from clientCAFile=%q: %w", caFile, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("cannot parse TLS client CA from clientCAFile=%q: no PEM-encoded certificates found", caFile)
+	}
+	return pool, nil
+}
+
 func cipherSuitesFromNames(cipherSuiteNames []string) ([]uint16, error) {
 	if len(cipherSuiteNames) == 0 {
 		return nil, nil
@@ -0,0 +1,171 @@
+package httptls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCert(t *testing.T, name string, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate key: %s", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	signerCert, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("cannot create certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("cannot parse certificate: %s", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("cannot marshal key: %s", err)
+	}
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("cannot write %s: %s", path, err)
+	}
+}
+
+// handshake connects to a server with serverCfg, returns the error of the client side
+func handshake(t *testing.T, serverCfg *tls.Config, clientCert *testCert, rootCA *testCert) error {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverCfg)
+	if err != nil {
+		t.Fatalf("cannot listen: %s", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.(*tls.Conn).Handshake()
+		conn.Read(make([]byte, 1))
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(rootCA.cert)
+	clientCfg := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+	if clientCert != nil {
+		pair, err := tls.X509KeyPair(clientCert.certPEM, clientCert.keyPEM)
+		if err != nil {
+			t.Fatalf("cannot load client cert: %s", err)
+		}
+		clientCfg.Certificates = []tls.Certificate{pair}
+	}
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), clientCfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// TLS 1.3 reports the refused client certificate on the first read
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return nil
+	}
+	return err
+}
+
+func TestGetServerTLSConfigClientCA(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil)
+	otherCA := newTestCert(t, "other-ca", nil)
+	server := newTestCert(t, "server", ca)
+	client := newTestCert(t, "client", ca)
+	otherClient := newTestCert(t, "other-client", otherCA)
+
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+	writeFile(t, certFile, server.certPEM)
+	writeFile(t, keyFile, server.keyPEM)
+	writeFile(t, caFile, ca.certPEM)
+
+	cfg, err := GetServerTLSConfig(certFile, keyFile, caFile, "TLS12", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cfg.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("unexpected client auth: %v", cfg.ClientAuth)
+	}
+
+	if err := handshake(t, cfg, client, ca); err != nil {
+		t.Fatalf("client with a valid certificate is refused: %s", err)
+	}
+	if err := handshake(t, cfg, nil, ca); err == nil {
+		t.Fatalf("client without certificate is accepted")
+	}
+	if err := handshake(t, cfg, otherClient, ca); err == nil {
+		t.Fatalf("client with a certificate of another CA is accepted")
+	}
+
+	// the new client CA takes effect after the reload interval
+	writeFile(t, caFile, otherCA.certPEM)
+	time.Sleep(2100 * time.Millisecond)
+	if err := handshake(t, cfg, otherClient, ca); err != nil {
+		t.Fatalf("client with a certificate of the reloaded CA is refused: %s", err)
+	}
+
+	// a broken client CA file keeps the last loaded CA
+	writeFile(t, caFile, []byte("half written"))
+	time.Sleep(1100 * time.Millisecond)
+	if err := handshake(t, cfg, otherClient, ca); err != nil {
+		t.Fatalf("client with a certificate of the last loaded CA is refused: %s", err)
+	}
+	if err := handshake(t, cfg, client, ca); err == nil {
+		t.Fatalf("client with a certificate of the replaced CA is accepted")
+	}
+
+	if _, err := GetServerTLSConfig(certFile, keyFile, filepath.Join(dir, "missing.pem"), "", nil); err == nil {
+		t.Fatalf("expected error for a missing client CA file")
+	}
+}
@@ -1,4 +1,6 @@
-package exporter
+// Package scramclient implements sarama.SCRAMClient with github.com/xdg/scram,
+// it is shared by the kafka plugin and the kafka writer.
+package scramclient
 
 import (
 	"crypto/sha256"
@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/cprobe/cprobe/flags"
 	"github.com/cprobe/cprobe/httpd"
@@ -33,6 +34,15 @@ var (
 	update     = flag.Bool("update", false, "Update binary")
 	updateFile = flag.String("update.file", "", "new version tar.gz file or url")
 	nohttp     = flag.Bool("no-httpd", false, "Disable http server")
+	check      = flag.Bool("check-config", false, "Validate the configs under -conf.d without scraping and exit, exit code is nonzero on any error")
+
+	startupProbe               = flag.Bool("startup-probe", false, "Connect to every static and file_sd target once before starting, exit code is nonzero if more than -startup-probe.maxFailedRatio of them fail")
+	startupProbeMaxFailedRatio = flag.Float64("startup-probe.maxFailedRatio", 0, "The ratio of the targets allowed to fail the startup probe, 0 means any failure blocks the start")
+	startupProbeTimeout        = flag.Duration("startup-probe.timeout", 30*time.Second, "Timeout of the startup probe, the targets not connected within it are failed")
+
+	oneshot               = flag.Bool("oneshot", false, "Scrape every target once, flush the samples to the writers and exit, exit code is nonzero if more than -oneshot.maxFailedRatio of the targets fail")
+	oneshotMaxFailedRatio = flag.Float64("oneshot.maxFailedRatio", 0, "The ratio of the targets allowed to fail in the oneshot mode, 0 means any failure makes the exit code nonzero")
+	oneshotTimeout        = flag.Duration("oneshot.timeout", time.Minute, "Timeout of the scrapes in the oneshot mode, the samples are flushed to the writers within another timeout")
 )
 
 func main() {
@@ -55,6 +65,14 @@ func main() {
 		return
 	}
 
+	if *check {
+		if err := checkConfig(); err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	buildinfo.Init()
 	logger.Init()
 	runner.PrintRuntime()
@@ -65,6 +83,19 @@ func main() {
 		logger.Fatalf("cannot init writer: %v", err)
 	}
 
+	if *startupProbe {
+		if err := probe.StartupProbe(flags.ConfigDirectory, os.Stdout, *startupProbeMaxFailedRatio, *startupProbeTimeout); err != nil {
+			logger.Fatalf("startup probe failed: %v", err)
+		}
+	}
+
+	if *oneshot {
+		if err := runOneshot(); err != nil {
+			logger.Fatalf("oneshot failed: %v", err)
+		}
+		return
+	}
+
 	if err := probe.Start(ctx, flags.ConfigDirectory); err != nil {
 		logger.Fatalf("cannot start probe: %v", err)
 	}
@@ -103,6 +134,26 @@ EXIT:
 	cancel()
 }
 
+// checkConfig validates writer.yaml and the plugin configs, no target is connected
+func checkConfig() error {
+	if err := writer.Check(flags.ConfigDirectory); err != nil {
+		return errors.WithMessage(err, "cannot check writer")
+	}
+	return probe.CheckConfig(flags.ConfigDirectory, os.Stdout)
+}
+
+// runOneshot scrapes every target once and waits for the writers to send the samples
+func runOneshot() error {
+	ctx, cancel := context.WithTimeout(context.Background(), *oneshotTimeout)
+	defer cancel()
+
+	scrapeErr := probe.Oneshot(ctx, flags.ConfigDirectory, *oneshotMaxFailedRatio)
+	if err := writer.Flush(*oneshotTimeout); err != nil {
+		return err
+	}
+	return scrapeErr
+}
+
 func usage() {
 	const s = `
 cprobe is a frankenstein made up of vmagent and exporters.
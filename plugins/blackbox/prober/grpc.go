@@ -96,6 +96,11 @@ func ProbeGRPC(ctx context.Context, target string, module Module, registry *prom
 			Help: "Response HealthCheck response",
 		}, []string{"serving_status"})
 
+		healthCheckStatusGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_grpc_healthcheck_status",
+			Help: "Response HealthCheck serving status: 0 UNKNOWN, 1 SERVING, 2 NOT_SERVING, 3 SERVICE_UNKNOWN",
+		})
+
 		probeSSLEarliestCertExpiryGauge = prometheus.NewGauge(sslEarliestCertExpiryGaugeOpts)
 
 		probeTLSVersion = prometheus.NewGaugeVec(
@@ -120,6 +125,7 @@ func ProbeGRPC(ctx context.Context, target string, module Module, registry *prom
 	registry.MustRegister(isSSLGauge)
 	registry.MustRegister(statusCodeGauge)
 	registry.MustRegister(healthCheckResponseGaugeVec)
+	registry.MustRegister(healthCheckStatusGauge)
 	registry.MustRegister(probeSSLEarliestCertExpiryGauge)
 	registry.MustRegister(probeTLSVersion)
 	registry.MustRegister(probeSSLLastInformation)
@@ -183,11 +189,13 @@ func ProbeGRPC(ctx context.Context, target string, module Module, registry *prom
 	conn, err := grpc.Dial(target, opts...)
 	if err != nil {
 		logger.Errorf("cannot dial grpc server(%s): %v", target, err)
+		return false
 	}
 
 	client := NewGrpcHealthCheckClient(conn)
 	defer conn.Close()
-	ok, statusCode, serverPeer, servingStatus, err := client.Check(context.Background(), module.GRPC.Service)
+	// ctx carries the module timeout
+	ok, statusCode, serverPeer, servingStatus, err := client.Check(ctx, module.GRPC.Service)
 	durationGaugeVec.WithLabelValues("check").Add(time.Since(checkStart).Seconds())
 
 	for servingStatusName := range grpc_health_v1.HealthCheckResponse_ServingStatus_value {
@@ -195,6 +203,7 @@ func ProbeGRPC(ctx context.Context, target string, module Module, registry *prom
 	}
 	if servingStatus != "" {
 		healthCheckResponseGaugeVec.WithLabelValues(servingStatus).Set(float64(1))
+		healthCheckStatusGauge.Set(float64(grpc_health_v1.HealthCheckResponse_ServingStatus_value[servingStatus]))
 	}
 
 	if serverPeer != nil {
@@ -0,0 +1,17 @@
+package plugins
+
+import "context"
+
+type serverLabelKey struct{}
+
+// WithServerLabel sets the __server_label__ label of the target the scrape is for
+func WithServerLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, serverLabelKey{}, label)
+}
+
+// ServerLabel returns the __server_label__ label of the target, the plugins use it instead of the address of the
+// target in the instance label and the logs, e.g. the name of the instance behind a proxy or VIP. Blank if not set.
+func ServerLabel(ctx context.Context) string {
+	label, _ := ctx.Value(serverLabelKey{}).(string)
+	return label
+}
@@ -13,6 +13,18 @@ type Plugin interface {
 	Scrape(ctx context.Context, target string, cfg any, ss *types.Samples) error
 }
 
+// TargetValidator is optionally implemented by the plugins to validate a target without connecting to it,
+// e.g. the mysql plugin forms the DSN, it is used by -check-config
+type TargetValidator interface {
+	ValidateTarget(target string, cfg any) error
+}
+
+// TargetProber is optionally implemented by the plugins to check a target can be connected to without scraping,
+// it is used by -startup-probe, the plugins not implementing it are scraped once instead
+type TargetProber interface {
+	ProbeTarget(ctx context.Context, target string, cfg any) error
+}
+
 var registry = make(map[string]Plugin)
 
 func GetPlugin(pluginName string) (Plugin, bool) {
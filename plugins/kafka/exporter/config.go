@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/Shopify/sarama"
+	"github.com/cprobe/cprobe/lib/scramclient"
 )
 
 func fillSaslFields(config *sarama.Config, opts KafkaOpts) error {
@@ -16,10 +17,10 @@ func fillSaslFields(config *sarama.Config, opts KafkaOpts) error {
 		opts.SaslMechanism = strings.ToLower(opts.SaslMechanism)
 		switch opts.SaslMechanism {
 		case "scram-sha512":
-			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &XDGSCRAMClient{HashGeneratorFcn: SHA512} }
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramclient.XDGSCRAMClient{HashGeneratorFcn: scramclient.SHA512} }
 			config.Net.SASL.Mechanism = sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA512)
 		case "scram-sha256":
-			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &XDGSCRAMClient{HashGeneratorFcn: SHA256} }
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramclient.XDGSCRAMClient{HashGeneratorFcn: scramclient.SHA256} }
 			config.Net.SASL.Mechanism = sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA256)
 		case "gssapi":
 			config.Net.SASL.Mechanism = sarama.SASLMechanism(sarama.SASLTypeGSSAPI)
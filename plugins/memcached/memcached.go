@@ -0,0 +1,229 @@
+package memcached
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cprobe/cprobe/plugins"
+	"github.com/cprobe/cprobe/types"
+)
+
+type Global struct {
+	Timeout time.Duration `toml:"timeout"`
+	// Username and Password are used by the text protocol authentication of memcached -Y <authfile>,
+	// SASL only works with the binary protocol and is not supported
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+}
+
+type Config struct {
+	BaseDir string  `toml:"-"`
+	Global  *Global `toml:"global"`
+
+	CollectItems struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_items"`
+	CollectSlabs struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_slabs"`
+}
+
+type Memcached struct {
+}
+
+func init() {
+	plugins.RegisterPlugin(types.PluginMemcached, &Memcached{})
+}
+
+func (*Memcached) ParseConfig(baseDir string, bs []byte) (any, error) {
+	var c Config
+	err := toml.Unmarshal(bs, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.BaseDir = baseDir
+
+	if c.Global == nil {
+		c.Global = &Global{}
+	}
+	if c.Global.Timeout == 0 {
+		c.Global.Timeout = 3 * time.Second
+	}
+
+	return &c, nil
+}
+
+// Scrape 通过文本协议执行 stats、stats items、stats slabs，target 是 host:port 或者 unix:///var/run/memcached.sock
+func (*Memcached) Scrape(ctx context.Context, address string, c any, ss *types.Samples) error {
+	cfg := c.(*Config)
+
+	network, addr := "tcp", address
+	if prefix := "unix://"; strings.HasPrefix(address, prefix) {
+		network, addr = "unix", address[len(prefix):]
+	} else if _, _, err := net.SplitHostPort(address); err != nil {
+		return fmt.Errorf("failed to parse target: %s", err)
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.Global.Timeout}
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return fmt.Errorf("cannot connect to memcached %s: %s", address, err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(cfg.Global.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	if cfg.Global.Username != "" {
+		if err := auth(rw, cfg.Global.Username, cfg.Global.Password); err != nil {
+			return fmt.Errorf("failed to authenticate to memcached %s: %s", address, err)
+		}
+	}
+
+	general, err := stats(rw, "stats")
+	if err != nil {
+		return err
+	}
+	addStats(general, ss)
+
+	if cfg.CollectItems.Enabled {
+		items, err := stats(rw, "stats items")
+		if err != nil {
+			return err
+		}
+		addSlabStats("items", items, ss)
+	}
+
+	if cfg.CollectSlabs.Enabled {
+		slabs, err := stats(rw, "stats slabs")
+		if err != nil {
+			return err
+		}
+		addSlabStats("slab", slabs, ss)
+	}
+
+	return nil
+}
+
+// auth authenticates with the text protocol, the credentials are sent as the value of a set command
+func auth(rw *bufio.ReadWriter, username, password string) error {
+	credentials := username + " " + password
+	if _, err := fmt.Fprintf(rw, "set auth 0 0 %d\r\n%s\r\n", len(credentials), credentials); err != nil {
+		return err
+	}
+	if err := rw.Flush(); err != nil {
+		return err
+	}
+
+	line, err := rw.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if line = strings.TrimSpace(line); line != "STORED" {
+		return fmt.Errorf("unexpected response: %s", line)
+	}
+	return nil
+}
+
+// stats sends the stats command and returns the STAT lines as name => value
+func stats(rw *bufio.ReadWriter, command string) (map[string]string, error) {
+	if _, err := rw.WriteString(command + "\r\n"); err != nil {
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]string)
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response of %s: %s", command, err)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "END" {
+			return ret, nil
+		}
+
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 || fields[0] != "STAT" {
+			return nil, fmt.Errorf("unexpected response of %s: %s", command, line)
+		}
+		ret[fields[1]] = fields[2]
+	}
+}
+
+func addStats(stats map[string]string, ss *types.Samples) {
+	fields := make(map[string]interface{})
+	for name, value := range stats {
+		if name == "pid" || name == "time" {
+			continue
+		}
+		if name == "rusage_user" || name == "rusage_system" {
+			// seconds:microseconds in old versions
+			value = strings.Replace(value, ":", ".", 1)
+		}
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			fields[name] = f
+		}
+	}
+
+	hits, hasHits := fields["get_hits"].(float64)
+	misses, hasMisses := fields["get_misses"].(float64)
+	if hasHits && hasMisses && hits+misses > 0 {
+		fields["get_hit_ratio"] = hits / (hits + misses)
+	}
+
+	ss.AddMetric(types.PluginMemcached, fields)
+
+	if version, ok := stats["version"]; ok {
+		ss.AddMetric(types.PluginMemcached, map[string]interface{}{"version": 1.0}, map[string]string{"version": version})
+	}
+}
+
+// addSlabStats adds the stats of stats items (items:<slab>:<name>) and stats slabs (<slab>:<name>),
+// the stats not belonging to a slab, e.g. active_slabs, are added without the slab label
+func addSlabStats(prefix string, stats map[string]string, ss *types.Samples) {
+	slabs := make(map[string]map[string]interface{})
+	global := make(map[string]interface{})
+	for key, value := range stats {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+
+		parts := strings.Split(strings.TrimPrefix(key, "items:"), ":")
+		if len(parts) != 2 {
+			global[key] = f
+			continue
+		}
+
+		if slabs[parts[0]] == nil {
+			slabs[parts[0]] = make(map[string]interface{})
+		}
+		slabs[parts[0]][parts[1]] = f
+	}
+
+	mesurement := types.PluginMemcached + "_" + prefix
+	for slab, fields := range slabs {
+		ss.AddMetric(mesurement, fields, map[string]string{"slab": slab})
+	}
+	if len(global) > 0 {
+		ss.AddMetric(mesurement+"s", global)
+	}
+}
@@ -0,0 +1,109 @@
+package memcached
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/cprobe/cprobe/types"
+)
+
+var responses = map[string]string{
+	"set auth 0 0 9": "STORED\r\n",
+	"stats":          "STAT pid 1\r\nSTAT version 1.6.21\r\nSTAT curr_connections 2\r\nSTAT get_hits 3\r\nSTAT get_misses 1\r\nSTAT evictions 5\r\nEND\r\n",
+	"stats items":    "STAT items:1:number 10\r\nSTAT items:1:evicted 2\r\nEND\r\n",
+	"stats slabs":    "STAT 1:chunk_size 96\r\nSTAT 1:used_chunks 10\r\nSTAT active_slabs 1\r\nSTAT total_malloced 1048576\r\nEND\r\n",
+}
+
+func serve(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "set ") {
+				// skip the credentials
+				if _, err := r.ReadString('\n'); err != nil {
+					return
+				}
+			}
+			conn.Write([]byte(responses[line]))
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestScrape(t *testing.T) {
+	c, err := (&Memcached{}).ParseConfig("", []byte(`
+[global]
+username = 'user'
+password = 'pass'
+
+[collect_items]
+enabled = true
+
+[collect_slabs]
+enabled = true
+`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %s", err)
+	}
+
+	ss := types.NewSamples()
+	if err := (&Memcached{}).Scrape(context.Background(), serve(t), c, ss); err != nil {
+		t.Fatalf("failed to scrape: %s", err)
+	}
+
+	got := make(map[string]float64)
+	for _, m := range ss.PopBackAll() {
+		for field, value := range m.Fields() {
+			name := m.Name()
+			if field != "" {
+				name += "_" + field
+			}
+			if slab, ok := m.Tags()["slab"]; ok {
+				name += "{" + slab + "}"
+			}
+			got[name] = value.(float64)
+		}
+	}
+
+	want := map[string]float64{
+		"memcached_curr_connections":     2,
+		"memcached_evictions":            5,
+		"memcached_get_hit_ratio":        0.75,
+		"memcached_version":              1,
+		"memcached_items_number{1}":      10,
+		"memcached_items_evicted{1}":     2,
+		"memcached_slab_chunk_size{1}":   96,
+		"memcached_slab_used_chunks{1}":  10,
+		"memcached_slabs_active_slabs":   1,
+		"memcached_slabs_total_malloced": 1048576,
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %v, want %v", name, got[name], value)
+		}
+	}
+	if _, ok := got["memcached_pid"]; ok {
+		t.Errorf("pid should be skipped")
+	}
+}
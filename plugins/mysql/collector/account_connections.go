@@ -0,0 +1,96 @@
+// Scrape the per account connection limits from `mysql.user` and the usage from `information_schema.processlist`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/cprobe/cprobe/lib/logger"
+	MySQL "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	accountConnections = "account_connections"
+	// Queries.
+	// The accounts without MAX_USER_CONNECTIONS are limited by @@global.max_user_connections, 0 means no limit.
+	accountConnectionsQuery = `
+		SELECT u.User, u.Host,
+		       IF(u.max_user_connections > 0, u.max_user_connections, @@global.max_user_connections),
+		       COALESCE(p.connections, 0)
+		  FROM mysql.user u
+		  LEFT JOIN (
+		    SELECT USER, COUNT(*) AS connections
+		      FROM information_schema.processlist
+		      GROUP BY USER
+		  ) p ON p.USER = u.User
+		  WHERE u.max_user_connections > 0 OR @@global.max_user_connections > 0
+		`
+)
+
+// Metric descriptors.
+var (
+	accountConnectionsUsedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "account", "connections_used"),
+		"Number of connections of the user, counted by user name as processlist doesn't tell the matched account host. Requires PROCESS to see all the connections.",
+		[]string{"user", "host"}, nil,
+	)
+	accountConnectionsMaxDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "account", "max_user_connections"),
+		"The MAX_USER_CONNECTIONS of the account, or @@global.max_user_connections if not set.",
+		[]string{"user", "host"}, nil,
+	)
+)
+
+// ScrapeAccountConnections collects the connection limits usage of the accounts.
+type ScrapeAccountConnections struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeAccountConnections) Name() string {
+	return accountConnections
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeAccountConnections) Help() string {
+	return "Collect the connections used and the max_user_connections of the accounts with a connection limit"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeAccountConnections) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeAccountConnections) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, accountConnectionsQuery)
+	if err != nil {
+		if mysqlErr, ok := err.(*MySQL.MySQLError); ok {
+			// Check for error 1142: SELECT command denied, mysql.user is not granted
+			if mysqlErr.Number == 1142 {
+				logger.Warnf("cannot scrape %s, SELECT on mysql.user is not granted: %s", accountConnections, err)
+				return nil
+			}
+		}
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		user, host     string
+		maxConnections uint64
+		used           uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&user, &host, &maxConnections, &used); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(accountConnectionsUsedDesc, prometheus.GaugeValue, float64(used), user, host)
+		ch <- prometheus.MustNewConstMetric(accountConnectionsMaxDesc, prometheus.GaugeValue, float64(maxConnections), user, host)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeAccountConnections{}
@@ -0,0 +1,81 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	MySQL "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeAccountConnections(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"User", "Host", "max_user_connections", "connections"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", "10.0.0.%", 100, 87).
+		AddRow("report", "%", 10, 0)
+	mock.ExpectQuery(sanitizeQuery(accountConnectionsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeAccountConnections{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"user": "app", "host": "10.0.0.%"}, value: 87, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"user": "app", "host": "10.0.0.%"}, value: 100, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"user": "report", "host": "%"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"user": "report", "host": "%"}, value: 10, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestScrapeAccountConnectionsDenied(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(accountConnectionsQuery)).
+		WillReturnError(&MySQL.MySQLError{Number: 1142, Message: "SELECT command denied to user 'exporter'@'localhost' for table 'user'"})
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeAccountConnections{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	convey.Convey("No metrics", t, func() {
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
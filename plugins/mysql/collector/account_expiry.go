@@ -0,0 +1,108 @@
+// Scrape the password expiry and the lock status of the accounts from `mysql.user`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/cprobe/cprobe/lib/logger"
+	MySQL "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	accountExpiry = "account_expiry"
+	// Query, the accounts without PASSWORD EXPIRE INTERVAL use @@global.default_password_lifetime, 0 means never.
+	accountExpiryQuery = `
+		SELECT User, Host, account_locked, password_expired,
+		       COALESCE(password_lifetime, @@global.default_password_lifetime),
+		       TIMESTAMPDIFF(SECOND, password_last_changed, NOW())
+		  FROM mysql.user
+		`
+)
+
+// Metric descriptors.
+var (
+	accountPasswordExpiresInDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "user", "password_expires_in_days"),
+		"Days left before the password of the account expires, negative if already expired. Only reported for the accounts whose password expires.",
+		[]string{"user", "host"}, nil,
+	)
+	accountPasswordExpiredDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "user", "password_expired"),
+		"Whether the password of the account is marked as expired.",
+		[]string{"user", "host"}, nil,
+	)
+	accountLockedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "user", "account_locked"),
+		"Whether the account is locked.",
+		[]string{"user", "host"}, nil,
+	)
+)
+
+// ScrapeAccountExpiry collects the password expiry and the lock status of the accounts.
+type ScrapeAccountExpiry struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeAccountExpiry) Name() string {
+	return accountExpiry
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeAccountExpiry) Help() string {
+	return "Collect the password expiry and the lock status of the accounts from mysql.user"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeAccountExpiry) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeAccountExpiry) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, accountExpiryQuery)
+	if err != nil {
+		if mysqlErr, ok := err.(*MySQL.MySQLError); ok {
+			switch mysqlErr.Number {
+			case 1142:
+				// SELECT command denied, mysql.user is not granted
+				logger.Warnf("cannot scrape %s, SELECT on mysql.user is not granted: %s", accountExpiry, err)
+				return nil
+			case 1054:
+				// Unknown column, the server before 5.7.6 doesn't support password expiry and account locking
+				logger.Warnf("cannot scrape %s, password expiry is not supported: %s", accountExpiry, err)
+				return nil
+			}
+		}
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		user, host      string
+		locked, expired sql.RawBytes
+		lifetime        sql.NullInt64
+		age             sql.NullInt64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&user, &host, &locked, &expired, &lifetime, &age); err != nil {
+			return err
+		}
+		if v, ok := parsePrivilege(locked); ok {
+			ch <- prometheus.MustNewConstMetric(accountLockedDesc, prometheus.GaugeValue, v, user, host)
+		}
+		if v, ok := parsePrivilege(expired); ok {
+			ch <- prometheus.MustNewConstMetric(accountPasswordExpiredDesc, prometheus.GaugeValue, v, user, host)
+		}
+		if lifetime.Valid && lifetime.Int64 > 0 && age.Valid {
+			left := float64(lifetime.Int64) - float64(age.Int64)/86400
+			ch <- prometheus.MustNewConstMetric(accountPasswordExpiresInDesc, prometheus.GaugeValue, left, user, host)
+		}
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeAccountExpiry{}
@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	MySQL "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeAccountExpiry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"User", "Host", "account_locked", "password_expired", "password_lifetime", "age"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", "10.0.0.%", "N", "N", 90, 86*86400+43200).
+		AddRow("old", "%", "Y", "Y", 30, 40*86400).
+		AddRow("root", "localhost", "N", "N", 0, 1000)
+	mock.ExpectQuery(sanitizeQuery(accountExpiryQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeAccountExpiry{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"user": "app", "host": "10.0.0.%"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"user": "app", "host": "10.0.0.%"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"user": "app", "host": "10.0.0.%"}, value: 3.5, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"user": "old", "host": "%"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"user": "old", "host": "%"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"user": "old", "host": "%"}, value: -10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"user": "root", "host": "localhost"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"user": "root", "host": "localhost"}, value: 0, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestScrapeAccountExpiryDenied(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(accountExpiryQuery)).
+		WillReturnError(&MySQL.MySQLError{Number: 1142, Message: "SELECT command denied to user 'exporter'@'localhost' for table 'user'"})
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeAccountExpiry{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	convey.Convey("No metrics", t, func() {
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,133 @@
+// Scrape the Aurora MySQL replica lag, which CloudWatch reports with a delay.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	MySQL "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	aurora = "aurora"
+	// Queries.
+	auroraVersionQuery    = `SELECT @@aurora_version`
+	auroraReplicaLagQuery = `
+		SELECT SERVER_ID
+		     , if(SESSION_ID='MASTER_SESSION_ID','writer','reader') AS ROLE
+		     , REPLICA_LAG_IN_MILLISECONDS
+		  FROM information_schema.replica_host_status
+		`
+	// Only available on the clusters of an Aurora global database.
+	auroraGlobalDBLagQuery = `
+		SELECT SERVER_ID
+		     , AWS_REGION
+		     , VISIBILITY_LAG_IN_MSEC
+		  FROM information_schema.aurora_global_db_instance_status
+		`
+)
+
+// Metric descriptors.
+var (
+	auroraVersionDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, aurora, "version_info"),
+		"The Aurora MySQL version from @@aurora_version, always 1.",
+		[]string{"version"}, nil,
+	)
+	auroraReplicaLagDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, aurora, "replica_lag_ms"),
+		"The lag of the instance of the cluster behind the writer in milliseconds, from information_schema.replica_host_status.",
+		[]string{"server_id", "role"}, nil,
+	)
+	auroraGlobalDBVisibilityLagDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, aurora, "global_db_visibility_lag_ms"),
+		"The lag of the instance of the global database behind the primary cluster in milliseconds.",
+		[]string{"server_id", "aws_region"}, nil,
+	)
+)
+
+// ScrapeAurora collects the Aurora specific metrics, nothing is collected on the other servers.
+type ScrapeAurora struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeAurora) Name() string {
+	return aurora
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeAurora) Help() string {
+	return "Collect the Aurora MySQL replica lag, skipped on the other servers"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeAurora) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeAurora) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var version string
+	if err := db.QueryRowContext(ctx, auroraVersionQuery).Scan(&version); err != nil {
+		// Error 1193: Unknown system variable, not Aurora
+		if isMySQLError(err, 1193) {
+			return nil
+		}
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(auroraVersionDesc, prometheus.GaugeValue, 1, version)
+
+	rows, err := db.QueryContext(ctx, auroraReplicaLagQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var serverID, role string
+	var lag float64
+	for rows.Next() {
+		if err := rows.Scan(&serverID, &role, &lag); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(auroraReplicaLagDesc, prometheus.GaugeValue, lag, serverID, role)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	globalRows, err := db.QueryContext(ctx, auroraGlobalDBLagQuery)
+	if err != nil {
+		// Error 1109: Unknown table, the versions without global database
+		if isMySQLError(err, 1109) {
+			return nil
+		}
+		return err
+	}
+	defer globalRows.Close()
+
+	var region string
+	var visibilityLag sql.NullFloat64
+	for globalRows.Next() {
+		if err := globalRows.Scan(&serverID, &region, &visibilityLag); err != nil {
+			return err
+		}
+		// NULL for the instances of the primary cluster
+		if !visibilityLag.Valid {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(auroraGlobalDBVisibilityLagDesc, prometheus.GaugeValue, visibilityLag.Float64, serverID, region)
+	}
+	return globalRows.Err()
+}
+
+// isMySQLError reports whether err is the server error of number.
+func isMySQLError(err error, number uint16) bool {
+	var mysqlErr *MySQL.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == number
+}
+
+// check interface
+var _ Scraper = ScrapeAurora{}
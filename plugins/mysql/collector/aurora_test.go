@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	MySQL "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeAurora(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(auroraVersionQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@aurora_version"}).AddRow("3.04.0"))
+	mock.ExpectQuery(sanitizeQuery(auroraReplicaLagQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"SERVER_ID", "ROLE", "REPLICA_LAG_IN_MILLISECONDS"}).
+			AddRow("db-writer", "writer", 0).
+			AddRow("db-reader-1", "reader", 18.5))
+	mock.ExpectQuery(sanitizeQuery(auroraGlobalDBLagQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"SERVER_ID", "AWS_REGION", "VISIBILITY_LAG_IN_MSEC"}).
+			AddRow("db-writer", "us-east-1", nil).
+			AddRow("db-secondary", "eu-west-1", 950))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeAurora{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"version": "3.04.0"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"server_id": "db-writer", "role": "writer"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"server_id": "db-reader-1", "role": "reader"}, value: 18.5, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"server_id": "db-secondary", "aws_region": "eu-west-1"}, value: 950, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestScrapeAuroraNotAurora(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(auroraVersionQuery)).WillReturnError(&MySQL.MySQLError{Number: 1193, Message: "Unknown system variable 'aurora_version'"})
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeAurora{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	convey.Convey("No metrics on the other servers", t, func() {
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,94 @@
+// Scrape aborted connections and `Connection_errors_%` from `SHOW GLOBAL STATUS`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	connectionErrors = "connection_errors"
+	// Query.
+	connectionErrorsQuery = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Aborted_clients', 'Aborted_connects', 'Connections') OR Variable_name LIKE 'Connection_errors_%'`
+)
+
+// Metric descriptors.
+var (
+	connectionErrorsAbortedClientsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, connectionErrors, "aborted_clients_total"),
+		"Number of connections that were aborted because the client died without closing the connection properly.",
+		[]string{}, nil,
+	)
+	connectionErrorsAbortedConnectsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, connectionErrors, "aborted_connects_total"),
+		"Number of failed attempts to connect to the MySQL server.",
+		[]string{}, nil,
+	)
+	connectionErrorsAbortedConnectsRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, connectionErrors, "aborted_connects_ratio"),
+		"Ratio of Aborted_connects to Connections since the server started.",
+		[]string{}, nil,
+	)
+	connectionErrorsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, connectionErrors, "total"),
+		"Number of connection errors by reason, from Connection_errors_%.",
+		[]string{"error"}, nil,
+	)
+)
+
+// ScrapeConnectionErrors collects aborted connections and connection errors.
+type ScrapeConnectionErrors struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeConnectionErrors) Name() string {
+	return connectionErrors
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeConnectionErrors) Help() string {
+	return "Collect aborted connections and connection errors from SHOW GLOBAL STATUS"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeConnectionErrors) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeConnectionErrors) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	values, err := globalStatusValues(ctx, db, connectionErrorsQuery)
+	if err != nil {
+		return err
+	}
+
+	if v, ok := values["aborted_clients"]; ok {
+		ch <- prometheus.MustNewConstMetric(connectionErrorsAbortedClientsDesc, prometheus.CounterValue, v)
+	}
+	if v, ok := values["aborted_connects"]; ok {
+		ch <- prometheus.MustNewConstMetric(connectionErrorsAbortedConnectsDesc, prometheus.CounterValue, v)
+		if connections := values["connections"]; connections > 0 {
+			ch <- prometheus.MustNewConstMetric(connectionErrorsAbortedConnectsRatioDesc, prometheus.GaugeValue, v/connections)
+		}
+	}
+
+	var reasons []string
+	for key := range values {
+		if strings.HasPrefix(key, "connection_errors_") {
+			reasons = append(reasons, key)
+		}
+	}
+	sort.Strings(reasons)
+	for _, key := range reasons {
+		ch <- prometheus.MustNewConstMetric(connectionErrorsDesc, prometheus.CounterValue, values[key], strings.TrimPrefix(key, "connection_errors_"))
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeConnectionErrors{}
@@ -0,0 +1,57 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeConnectionErrors(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"Variable_name", "Value"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("Aborted_clients", "12").
+		AddRow("Aborted_connects", "25").
+		AddRow("Connection_errors_accept", "0").
+		AddRow("Connection_errors_internal", "3").
+		AddRow("Connection_errors_max_connections", "7").
+		AddRow("Connections", "100")
+	mock.ExpectQuery(sanitizeQuery(connectionErrorsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeConnectionErrors{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, value: 12, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{}, value: 25, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{}, value: 0.25, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"error": "accept"}, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"error": "internal"}, value: 3, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"error": "max_connections"}, value: 7, metricType: dto.MetricType_COUNTER},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Scrape the saturation of the connections from `SHOW GLOBAL STATUS` and `@@max_connections`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scraper name.
+	connectionSaturation = "connection_saturation"
+	// Queries.
+	connectionSaturationQuery          = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Threads_connected', 'Max_used_connections')`
+	connectionSaturationVariablesQuery = `SELECT @@max_connections`
+)
+
+// Metric descriptors.
+var (
+	connectionsUsedRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "connections", "used_ratio"),
+		"Threads_connected divided by @@max_connections, new connections are refused at 1 except the one reserved for CONNECTION_ADMIN.",
+		[]string{}, nil,
+	)
+	maxUsedConnectionsRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "max_used_connections_ratio"),
+		"Max_used_connections since the server started divided by @@max_connections.",
+		[]string{}, nil,
+	)
+)
+
+// ScrapeConnectionSaturation collects the ratios of the connections used to @@max_connections, to be alerted on
+// before the connections run out.
+type ScrapeConnectionSaturation struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeConnectionSaturation) Name() string {
+	return connectionSaturation
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeConnectionSaturation) Help() string {
+	return "Collect the ratios of the connections used to max_connections"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeConnectionSaturation) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeConnectionSaturation) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	values, err := globalStatusValues(ctx, db, connectionSaturationQuery)
+	if err != nil {
+		return err
+	}
+
+	var maxConnections float64
+	if err := db.QueryRowContext(ctx, connectionSaturationVariablesQuery).Scan(&maxConnections); err != nil {
+		return err
+	}
+	if maxConnections <= 0 {
+		return nil
+	}
+
+	if v, ok := values["threads_connected"]; ok {
+		ch <- prometheus.MustNewConstMetric(connectionsUsedRatioDesc, prometheus.GaugeValue, v/maxConnections)
+	}
+	if v, ok := values["max_used_connections"]; ok {
+		ch <- prometheus.MustNewConstMetric(maxUsedConnectionsRatioDesc, prometheus.GaugeValue, v/maxConnections)
+	}
+
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeConnectionSaturation{}
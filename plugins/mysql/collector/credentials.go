@@ -0,0 +1,42 @@
+package collector
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// CredentialsProvider returns the user and password for every new connection, e.g. from a secret store,
+// so that they are never part of the DSN and can be rotated without reloading the config
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (user, password string, err error)
+}
+
+// credentialsConnector asks the provider for the credentials right before connecting
+type credentialsConnector struct {
+	cfg      *mysql.Config
+	provider CredentialsProvider
+}
+
+func (c *credentialsConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	user, password, err := c.provider.Credentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials: %w", err)
+	}
+
+	cfg := c.cfg.Clone()
+	cfg.User = user
+	cfg.Passwd = password
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *credentialsConnector) Driver() driver.Driver {
+	return &mysql.MySQLDriver{}
+}
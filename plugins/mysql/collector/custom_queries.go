@@ -3,13 +3,29 @@ package collector
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"math"
+	"regexp"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
+	"github.com/VictoriaMetrics/metrics"
 	"github.com/cprobe/cprobe/lib/conv"
 	"github.com/cprobe/cprobe/lib/logger"
 	"github.com/cprobe/cprobe/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	unitRE = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+	metricNameRE        = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	invalidMetricCharRE = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+	labelNameRE         = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	invalidLabelCharRE  = regexp.MustCompile(`[^a-zA-Z0-9_]`)
 )
 
 type CustomQuery struct {
@@ -19,30 +35,156 @@ type CustomQuery struct {
 	FieldToAppend string        `toml:"field_to_append"`
 	Timeout       time.Duration `toml:"timeout"`
 	Request       string        `toml:"request"`
+
+	// MetricType is one of gauge, counter, untyped, defaults to untyped, exemplars can only be attached to counters
+	MetricType string `toml:"metric_type"`
+	// Help is the HELP text of the metrics, defaults to "Custom query <mesurement>"
+	Help string `toml:"help"`
+	// Unit is appended to the metric names as a suffix, e.g. seconds, bytes
+	Unit string `toml:"unit"`
+	// ExemplarLabelField is the column used as the exemplar label, e.g. trace_id
+	ExemplarLabelField string `toml:"exemplar_label_field"`
+	// ExemplarValueField is the column used as the exemplar value, defaults to the metric value
+	ExemplarValueField string `toml:"exemplar_value_field"`
+	// OnReplica runs the query on one of Options.Replicas instead of the target, the results are tagged with the server label
+	OnReplica bool `toml:"on_replica"`
+	// ValueMapping maps the string values of ValueMappingField to numbers, e.g. { ONLINE = 1, OFFLINE = 0 }
+	ValueMapping map[string]float64 `toml:"value_mapping"`
+	// ValueMappingField is the one of MetricFields the value mapping is applied to
+	ValueMappingField string `toml:"value_mapping_field"`
+	// ValueMappingDefault is used for the unmapped values, which are skipped if not set
+	ValueMappingDefault *float64 `toml:"value_mapping_default"`
+	// Retries is how many times the query is run again on lock wait timeout (1205) or deadlock (1213), within Timeout
+	Retries int `toml:"retries"`
+	// RetryBackoff is the wait before the first retry, doubled for every next one, defaults to 100ms
+	RetryBackoff time.Duration `toml:"retry_backoff"`
+	// NullValue is how a NULL of MetricFields is handled: skip (default) the sample, emit nan, or emit NullValueDefault
+	NullValue string `toml:"null_value"`
+	// NullValueDefault is the value emitted for NULL if NullValue is default
+	NullValueDefault *float64 `toml:"null_value_default"`
+
+	// server is the replica the query is run on
+	server string
+}
+
+// Handling of the NULL values of the metric fields.
+const (
+	NullValueSkip    = "skip"
+	NullValueNaN     = "nan"
+	NullValueDefault = "default"
+)
+
+// Validate checks the metric type and the exemplar fields of the query.
+func (q CustomQuery) Validate() error {
+	switch q.MetricType {
+	case "", "gauge", "counter", "untyped":
+	default:
+		return fmt.Errorf("invalid metric_type %q of query %s", q.MetricType, q.Mesurement)
+	}
+
+	if q.Unit != "" && !unitRE.MatchString(q.Unit) {
+		return fmt.Errorf("invalid unit %q of query %s, must be lower case letters, digits and underscores, e.g. seconds", q.Unit, q.Mesurement)
+	}
+
+	if q.ExemplarValueField != "" && q.ExemplarLabelField == "" {
+		return fmt.Errorf("exemplar_value_field requires exemplar_label_field, query: %s", q.Mesurement)
+	}
+
+	if q.ExemplarLabelField != "" && q.MetricType != "counter" {
+		return fmt.Errorf("exemplars can only be attached to counters, query: %s, metric_type: %q", q.Mesurement, q.MetricType)
+	}
+
+	if (len(q.ValueMapping) > 0 || q.ValueMappingDefault != nil) != (q.ValueMappingField != "") {
+		return fmt.Errorf("value_mapping and value_mapping_field should be set together, query: %s", q.Mesurement)
+	}
+
+	if q.Retries < 0 || q.RetryBackoff < 0 {
+		return fmt.Errorf("retries and retry_backoff of query %s should not be negative", q.Mesurement)
+	}
+
+	switch q.NullValue {
+	case "", NullValueSkip, NullValueNaN:
+		if q.NullValueDefault != nil {
+			return fmt.Errorf("null_value_default requires null_value = %q, query: %s", NullValueDefault, q.Mesurement)
+		}
+	case NullValueDefault:
+		if q.NullValueDefault == nil {
+			return fmt.Errorf("null_value = %q requires null_value_default, query: %s", NullValueDefault, q.Mesurement)
+		}
+	default:
+		return fmt.Errorf("invalid null_value %q of query %s, should be skip, nan or default", q.NullValue, q.Mesurement)
+	}
+
+	if q.ValueMappingField != "" {
+		found := false
+		for _, field := range q.MetricFields {
+			if field == q.ValueMappingField {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("value_mapping_field %s is not one of metric_fields, query: %s", q.ValueMappingField, q.Mesurement)
+		}
+	}
+
+	return nil
 }
 
-func (e *Exporter) collectCustomQueries(ctx context.Context, db *sql.DB, ss *types.Samples, queries []CustomQuery) {
+func (e *Exporter) collectCustomQueries(ctx context.Context, conns *isolatedConns, db *sql.DB, ss *types.Samples, queries []CustomQuery) {
 	if len(queries) == 0 {
 		return
 	}
 
+	var replicas *replicaPool
+	if len(e.opts.Replicas) > 0 {
+		replicas = newReplicaPool(e)
+		defer replicas.close()
+	}
+
 	wg := new(sync.WaitGroup)
 	defer wg.Wait()
 
 	for i := 0; i < len(queries); i++ {
+		query := queries[i]
+		queryDB := db
+		onTarget := true
+		if query.OnReplica && replicas != nil {
+			onTarget = false
+			var ok bool
+			if queryDB, query.server, ok = replicas.get(ctx); !ok {
+				logger.Errorf("no available replica for query %s, target: %s", query.Mesurement, e.getTargetFromDsn())
+				continue
+			}
+		}
+
 		wg.Add(1)
-		go func(query CustomQuery) {
+		go func(db *sql.DB, query CustomQuery, onTarget bool) {
 			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Errorf("panic in custom query: %s, target: %s, error: %v\n%s", query.Mesurement, e.getTargetFromDsn(), r, debug.Stack())
+				}
+			}()
+			if onTarget {
+				var release func()
+				var err error
+				if db, release, err = conns.get(ctx, db); err != nil {
+					logger.Errorf("failed to connect for custom query: %s, error: %s", query.Mesurement, err)
+					return
+				}
+				defer release()
+			}
 			e.collectCustomQuery(ctx, db, ss, query)
-		}(queries[i])
+		}(queryDB, query, onTarget)
 	}
 }
 
 func (e *Exporter) collectCustomQuery(ctx context.Context, db *sql.DB, ss *types.Samples, query CustomQuery) {
-	ctx, cancel := context.WithTimeout(ctx, query.Timeout)
+	ctx, cancel := context.WithTimeout(withQueryName(ctx, query.Mesurement), query.Timeout)
 	defer cancel()
 
-	rows, err := db.QueryContext(ctx, query.Request)
+	rows, err := queryWithRetries(ctx, db, query)
 	if ctx.Err() == context.DeadlineExceeded {
 		logger.Errorf("query timeout, request: %s", query.Request)
 		return
@@ -61,8 +203,19 @@ func (e *Exporter) collectCustomQuery(ctx context.Context, db *sql.DB, ss *types
 		return
 	}
 
+	// the NULL metric fields are left out of the rows, so the metric fields not in the columns are checked here
+	if missing := missingMetricFields(cols, query.MetricFields); len(missing) > 0 {
+		logger.Errorf("metric fields %v are not in the columns %v, query: %s", missing, cols, query.Mesurement)
+		return
+	}
+
+	metricFields := make(map[string]struct{}, len(query.MetricFields))
+	for _, field := range query.MetricFields {
+		metricFields[field] = struct{}{}
+	}
+
 	for rows.Next() {
-		columns := make([]sql.RawBytes, len(cols))
+		columns := make([]sql.NullString, len(cols))
 		columnPointers := make([]interface{}, len(cols))
 		for i := range columns {
 			columnPointers[i] = &columns[i]
@@ -74,10 +227,15 @@ func (e *Exporter) collectCustomQuery(ctx context.Context, db *sql.DB, ss *types
 			return
 		}
 
+		// only the NULL metric fields are left out for the null_value policy, the NULL labels are "" as before
 		row := make(map[string]string)
 		for i, colName := range cols {
-			val := columnPointers[i].(*sql.RawBytes)
-			row[strings.ToLower(colName)] = string(*val)
+			name := strings.ToLower(colName)
+			if columns[i].Valid {
+				row[name] = columns[i].String
+			} else if _, isMetric := metricFields[name]; !isMetric {
+				row[name] = ""
+			}
 		}
 
 		if err = e.parseRow(row, query, ss); err != nil {
@@ -86,38 +244,326 @@ func (e *Exporter) collectCustomQuery(ctx context.Context, db *sql.DB, ss *types
 	}
 }
 
+func missingMetricFields(cols, fields []string) []string {
+	names := make(map[string]struct{}, len(cols))
+	for _, col := range cols {
+		names[strings.ToLower(col)] = struct{}{}
+	}
+
+	var missing []string
+	for _, field := range fields {
+		if _, has := names[field]; !has {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+const defaultCustomQueryRetryBackoff = 100 * time.Millisecond
+
+// queryWithRetries runs the query again on ER_LOCK_WAIT_TIMEOUT and ER_LOCK_DEADLOCK, which are returned before
+// the rows, until the retries are used up or the next one would not finish within the timeout of the query
+func queryWithRetries(ctx context.Context, db *sql.DB, query CustomQuery) (*sql.Rows, error) {
+	backoff := query.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultCustomQueryRetryBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		rows, err := db.QueryContext(ctx, query.Request)
+		if err == nil || attempt >= query.Retries || !(isMySQLError(err, 1205) || isMySQLError(err, 1213)) {
+			return rows, err
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return nil, err
+		}
+
+		metrics.GetOrCreateCounter(fmt.Sprintf(`cprobe_mysql_custom_query_retries_total{query=%q}`, query.Mesurement)).Inc()
+		logger.Warnf("retry query %s in %s, attempt %d of %d, error: %s", query.Mesurement, backoff, attempt+1, query.Retries, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
 func (e *Exporter) parseRow(row map[string]string, query CustomQuery, ss *types.Samples) error {
 	labels := make(map[string]string)
 
+	sanitized := false
 	for _, label := range query.LabelFields {
 		labelValue, has := row[label]
 		if has {
-			labels[label] = strings.Replace(labelValue, " ", "_", -1)
+			name, changed := sanitizeLabelName(label)
+			sanitized = sanitized || changed
+			labels[name] = strings.Replace(labelValue, " ", "_", -1)
 		}
 	}
+	if query.server != "" {
+		labels["server"] = query.server
+	}
 
 	for _, column := range query.MetricFields {
-		value, err := conv.ToFloat64(row[column])
-		if err != nil {
-			logger.Errorf("failed to convert field: %s, value: %v, error: %s", column, row[column], err)
-			return err
+		var value float64
+		if _, has := row[column]; !has {
+			metrics.GetOrCreateCounter(fmt.Sprintf(`cprobe_mysql_custom_query_nulls_total{query=%q,field=%q}`, query.Mesurement, column)).Inc()
+			nullValue, ok := query.nullValue()
+			if !ok {
+				continue
+			}
+			value = nullValue
+		} else if column == query.ValueMappingField {
+			mapped, ok := query.mapValue(row[column])
+			if !ok {
+				logger.Warnf("unmapped value of field: %s, value: %v, query: %s", column, row[column], query.Mesurement)
+				continue
+			}
+			value = mapped
+		} else {
+			converted, err := conv.ToFloat64(row[column])
+			if err != nil {
+				logger.Errorf("failed to convert field: %s, value: %v, error: %s", column, row[column], err)
+				return err
+			}
+			value = converted
 		}
 
-		if query.FieldToAppend == "" {
-			ss.AddMetric(query.Mesurement, map[string]interface{}{
-				column: value,
-			}, labels)
-		} else {
-			suffix := cleanName(row[query.FieldToAppend])
-			ss.AddMetric(query.Mesurement+"_"+suffix, map[string]interface{}{
-				column: value,
-			}, labels)
+		mesurement := query.Mesurement
+		if query.FieldToAppend != "" {
+			mesurement = query.Mesurement + "_" + cleanName(row[query.FieldToAppend])
+		}
+
+		name := mesurement + "_" + column
+		if query.Unit != "" && !strings.HasSuffix(name, "_"+query.Unit) {
+			name += "_" + query.Unit
+		}
+		name, changed := sanitizeMetricName(name)
+		sanitized = sanitized || changed
+
+		m, err := customQueryMetric(name, value, labels, row, query)
+		if err == nil {
+			err = ss.AddPromMetric(m)
+		}
+		if err != nil {
+			logger.Warnf("failed to build metric %s, query: %s, error: %s", name, query.Mesurement, err)
+			ss.AddMetric(name, map[string]interface{}{"": value}, labels)
 		}
 	}
 
+	if sanitized {
+		metrics.GetOrCreateCounter(fmt.Sprintf(`cprobe_mysql_custom_query_sanitized_names_total{query=%q}`, query.Mesurement)).Inc()
+	}
+
 	return nil
 }
 
+// ValidateNames checks the metric and label names built from the query are valid Prometheus names,
+// the names are sanitized at scrape time if this is not called.
+func (q CustomQuery) ValidateNames() error {
+	for _, column := range q.MetricFields {
+		if name := q.Mesurement + "_" + column; !metricNameRE.MatchString(name) {
+			return fmt.Errorf("invalid metric name %q of query %s", name, q.Mesurement)
+		}
+	}
+	for _, label := range q.LabelFields {
+		if !labelNameRE.MatchString(label) {
+			return fmt.Errorf("invalid label name %q of query %s", label, q.Mesurement)
+		}
+	}
+	if q.ExemplarLabelField != "" && !labelNameRE.MatchString(q.ExemplarLabelField) {
+		return fmt.Errorf("invalid exemplar label name %q of query %s", q.ExemplarLabelField, q.Mesurement)
+	}
+	return nil
+}
+
+// sanitizeMetricName replaces the invalid characters with underscores, changed is true if name is not valid
+func sanitizeMetricName(name string) (string, bool) {
+	if metricNameRE.MatchString(name) {
+		return name, false
+	}
+	name = invalidMetricCharRE.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name, true
+}
+
+// sanitizeLabelName replaces the invalid characters with underscores, changed is true if name is not valid
+func sanitizeLabelName(name string) (string, bool) {
+	if labelNameRE.MatchString(name) {
+		return name, false
+	}
+	name = invalidLabelCharRE.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name, true
+}
+
+// mapValue looks up the value mapping, falls back to the default
+func (q CustomQuery) mapValue(value string) (float64, bool) {
+	if mapped, has := q.ValueMapping[value]; has {
+		return mapped, true
+	}
+	if q.ValueMappingDefault != nil {
+		return *q.ValueMappingDefault, true
+	}
+	return 0, false
+}
+
+// nullValue returns the value emitted for NULL, false if the sample is skipped
+func (q CustomQuery) nullValue() (float64, bool) {
+	switch q.NullValue {
+	case NullValueNaN:
+		return math.NaN(), true
+	case NullValueDefault:
+		return *q.NullValueDefault, true
+	}
+	return 0, false
+}
+
+// customQueryMetric builds the metric with the help and the type of the query,
+// an exemplar is attached if configured, an invalid exemplar is skipped with a warning
+func customQueryMetric(name string, value float64, labels, row map[string]string, query CustomQuery) (prometheus.Metric, error) {
+	help := query.Help
+	if help == "" {
+		help = "Custom query " + query.Mesurement
+	}
+
+	valueType := prometheus.UntypedValue
+	switch query.MetricType {
+	case "gauge":
+		valueType = prometheus.GaugeValue
+	case "counter":
+		valueType = prometheus.CounterValue
+	}
+
+	m, err := prometheus.NewConstMetric(prometheus.NewDesc(name, help, nil, labels), valueType, value)
+	if err != nil {
+		return nil, err
+	}
+	if query.ExemplarLabelField == "" || row[query.ExemplarLabelField] == "" {
+		return m, nil
+	}
+
+	withExemplar, err := attachExemplar(m, value, row, query)
+	if err != nil {
+		logger.Warnf("failed to attach exemplar, query: %s, error: %s", query.Mesurement, err)
+		return m, nil
+	}
+	return withExemplar, nil
+}
+
+func attachExemplar(m prometheus.Metric, value float64, row map[string]string, query CustomQuery) (prometheus.Metric, error) {
+	exemplarValue := value
+	if query.ExemplarValueField != "" {
+		v, err := conv.ToFloat64(row[query.ExemplarValueField])
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert exemplar field: %s, value: %v, error: %s", query.ExemplarValueField, row[query.ExemplarValueField], err)
+		}
+		exemplarValue = v
+	}
+
+	return prometheus.NewMetricWithExemplars(m, prometheus.Exemplar{
+		Value:     exemplarValue,
+		Labels:    prometheus.Labels{query.ExemplarLabelField: row[query.ExemplarLabelField]},
+		Timestamp: time.Now(),
+	})
+}
+
+// writingClauses make a SELECT write files on the server or take row locks, matched by the words so that the spacing
+// and the case do not matter, a string literal with these words is rejected too, which errs on the safe side
+var writingClauses = [][]string{
+	{"INTO", "OUTFILE"},
+	{"INTO", "DUMPFILE"},
+	{"FOR", "UPDATE"},
+	{"FOR", "SHARE"},
+	{"LOCK", "IN", "SHARE", "MODE"},
+}
+
+// IsReadOnlyQuery reports whether the request is a single SELECT or SHOW statement without writingClauses,
+// leading comments and parentheses are skipped.
+func IsReadOnlyQuery(request string) bool {
+	s, ok := trimQueryPrefix(request)
+	if !ok {
+		return false
+	}
+
+	// multiple statements are not allowed
+	if strings.Contains(strings.TrimRight(s, "; \t\r\n"), ";") {
+		return false
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT":
+		return !hasWritingClause(s)
+	case "SHOW":
+		return true
+	}
+	return false
+}
+
+func hasWritingClause(query string) bool {
+	words := strings.FieldsFunc(strings.ToUpper(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+
+	for i := range words {
+		for _, clause := range writingClauses {
+			if i+len(clause) <= len(words) && equalWords(words[i:i+len(clause)], clause) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// trimQueryPrefix strips the leading spaces, comments and parentheses of the request,
+// ok is false if a comment is not terminated.
+func trimQueryPrefix(request string) (string, bool) {
+	s := strings.TrimSpace(request)
+	for {
+		var idx int
+		switch {
+		case strings.HasPrefix(s, "--"), strings.HasPrefix(s, "#"):
+			if idx = strings.IndexByte(s, '\n'); idx < 0 {
+				return "", false
+			}
+			s = s[idx+1:]
+		case strings.HasPrefix(s, "/*"):
+			if idx = strings.Index(s, "*/"); idx < 0 {
+				return "", false
+			}
+			s = s[idx+2:]
+		case strings.HasPrefix(s, "("):
+			s = s[1:]
+		default:
+			return s, true
+		}
+		s = strings.TrimSpace(s)
+	}
+}
+
 func cleanName(s string) string {
 	s = strings.Replace(s, " ", "_", -1) // Remove spaces
 	s = strings.Replace(s, "(", "", -1)  // Remove open parenthesis
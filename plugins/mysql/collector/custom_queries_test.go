@@ -0,0 +1,374 @@
+package collector
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/cprobe/cprobe/types"
+	MySQL "github.com/go-sql-driver/mysql"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseRowWithExemplar(t *testing.T) {
+	query := CustomQuery{
+		Mesurement:         "slow_query",
+		MetricFields:       []string{"total"},
+		LabelFields:        []string{"schema"},
+		MetricType:         "counter",
+		ExemplarLabelField: "trace_id",
+		ExemplarValueField: "max_latency",
+	}
+
+	convey.Convey("Exemplar attached", t, func() {
+		ss := types.NewSamples()
+		row := map[string]string{"schema": "test", "total": "12", "trace_id": "4bf92f3577b34da6", "max_latency": "1.5"}
+		err := new(Exporter).parseRow(row, query, ss)
+		convey.So(err, convey.ShouldBeNil)
+
+		ms := ss.PopBackAll()
+		convey.So(ms, convey.ShouldHaveLength, 1)
+		convey.So(ms[0].Name(), convey.ShouldEqual, "slow_query_total")
+		convey.So(ms[0].Tags(), convey.ShouldResemble, map[string]string{"schema": "test"})
+
+		e := ms[0].Exemplar()
+		convey.So(e, convey.ShouldNotBeNil)
+		convey.So(e.Value, convey.ShouldEqual, 1.5)
+		convey.So(e.Labels, convey.ShouldResemble, map[string]string{"trace_id": "4bf92f3577b34da6"})
+	})
+
+	convey.Convey("Invalid exemplar is skipped", t, func() {
+		ss := types.NewSamples()
+		row := map[string]string{"schema": "test", "total": "12", "trace_id": strings.Repeat("x", 200), "max_latency": "1.5"}
+		err := new(Exporter).parseRow(row, query, ss)
+		convey.So(err, convey.ShouldBeNil)
+
+		ms := ss.PopBackAll()
+		convey.So(ms, convey.ShouldHaveLength, 1)
+		convey.So(ms[0].Name(), convey.ShouldEqual, "slow_query_total")
+		convey.So(ms[0].Fields(), convey.ShouldResemble, map[string]interface{}{"": 12.0})
+		convey.So(ms[0].Exemplar(), convey.ShouldBeNil)
+	})
+}
+
+func TestCustomQueryMetric(t *testing.T) {
+	query := CustomQuery{
+		Mesurement:   "replication",
+		MetricFields: []string{"delay"},
+		MetricType:   "gauge",
+		Help:         "Replication delay measured by the heartbeat table.",
+		Unit:         "seconds",
+	}
+
+	convey.Convey("Help, type and unit", t, func() {
+		m, err := customQueryMetric("replication_delay_seconds", 1.5, map[string]string{"channel": "a"}, nil, query)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(m.Desc().String(), convey.ShouldContainSubstring, `help: "Replication delay measured by the heartbeat table."`)
+		convey.So(readMetric(m), convey.ShouldResemble, MetricResult{labels: labelMap{"channel": "a"}, value: 1.5, metricType: dto.MetricType_GAUGE})
+
+		ss := types.NewSamples()
+		err = new(Exporter).parseRow(map[string]string{"delay": "1.5"}, query, ss)
+		convey.So(err, convey.ShouldBeNil)
+		ms := ss.PopBackAll()
+		convey.So(ms, convey.ShouldHaveLength, 1)
+		convey.So(ms[0].Name(), convey.ShouldEqual, "replication_delay_seconds")
+	})
+
+	convey.Convey("Untyped by default", t, func() {
+		m, err := customQueryMetric("lock_wait_total", 3, nil, nil, CustomQuery{Mesurement: "lock_wait"})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(m.Desc().String(), convey.ShouldContainSubstring, `help: "Custom query lock_wait"`)
+		convey.So(readMetric(m).metricType, convey.ShouldEqual, dto.MetricType_UNTYPED)
+	})
+}
+
+func TestCustomQueryValidate(t *testing.T) {
+	tests := []struct {
+		query CustomQuery
+		valid bool
+	}{
+		{CustomQuery{}, true},
+		{CustomQuery{MetricType: "counter", ExemplarLabelField: "trace_id"}, true},
+		{CustomQuery{MetricType: "histogram"}, false},
+		{CustomQuery{MetricType: "gauge", ExemplarLabelField: "trace_id"}, false},
+		{CustomQuery{MetricType: "counter", ExemplarValueField: "latency"}, false},
+		{CustomQuery{Unit: "seconds"}, true},
+		{CustomQuery{Unit: "Seconds"}, false},
+		{CustomQuery{MetricFields: []string{"state"}, ValueMappingField: "state", ValueMapping: map[string]float64{"ONLINE": 1}}, true},
+		{CustomQuery{MetricFields: []string{"state"}, ValueMapping: map[string]float64{"ONLINE": 1}}, false},
+		{CustomQuery{MetricFields: []string{"total"}, ValueMappingField: "state", ValueMapping: map[string]float64{"ONLINE": 1}}, false},
+		{CustomQuery{Retries: 2, RetryBackoff: time.Millisecond}, true},
+		{CustomQuery{Retries: -1}, false},
+		{CustomQuery{NullValue: "nan"}, true},
+		{CustomQuery{NullValue: "zero"}, false},
+		{CustomQuery{NullValue: "default"}, false},
+		{CustomQuery{NullValueDefault: new(float64)}, false},
+		{CustomQuery{NullValue: "default", NullValueDefault: new(float64)}, true},
+	}
+
+	for _, test := range tests {
+		err := test.query.Validate()
+		if (err == nil) != test.valid {
+			t.Errorf("Validate(%+v) = %v, want valid: %v", test.query, err, test.valid)
+		}
+	}
+}
+
+func TestIsReadOnlyQuery(t *testing.T) {
+	tests := []struct {
+		request  string
+		readOnly bool
+	}{
+		{"SELECT 1", true},
+		{"\n  select count(*) as total from t\n", true},
+		{"SHOW GLOBAL STATUS;", true},
+		{"-- comment\n/* hint */ (SELECT 1) UNION (SELECT 2)", true},
+		{"# comment\nshow slave status", true},
+		{"DELETE FROM t", false},
+		{"SELECT 1; DROP TABLE t", false},
+		{"/* unterminated SELECT 1", false},
+		{"-- SELECT 1", false},
+		{"SELECTX 1", false},
+		{"SELECT * FROM t INTO OUTFILE '/tmp/t.csv'", false},
+		{"select * from t into\n  dumpfile '/tmp/t'", false},
+		{"SELECT * FROM t WHERE id = 1 FOR UPDATE", false},
+		{"(SELECT * FROM t) for share;", false},
+		{"SELECT * FROM t LOCK IN SHARE MODE", false},
+		{"SELECT update_time, share_mode FROM t", true},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if got := IsReadOnlyQuery(test.request); got != test.readOnly {
+			t.Errorf("IsReadOnlyQuery(%q) = %v, want %v", test.request, got, test.readOnly)
+		}
+	}
+}
+
+func TestParseRowOnReplica(t *testing.T) {
+	query := CustomQuery{Mesurement: "biz_orders", MetricFields: []string{"total"}, OnReplica: true, server: "10.0.0.2:3306"}
+
+	convey.Convey("Server label", t, func() {
+		ss := types.NewSamples()
+		err := new(Exporter).parseRow(map[string]string{"total": "3"}, query, ss)
+		convey.So(err, convey.ShouldBeNil)
+
+		ms := ss.PopBackAll()
+		convey.So(ms, convey.ShouldHaveLength, 1)
+		convey.So(ms[0].Tags(), convey.ShouldResemble, map[string]string{"server": "10.0.0.2:3306"})
+	})
+}
+
+func TestParseRowValueMapping(t *testing.T) {
+	query := CustomQuery{
+		Mesurement:        "group_replication",
+		MetricFields:      []string{"state", "errors"},
+		LabelFields:       []string{"member"},
+		ValueMappingField: "state",
+		ValueMapping:      map[string]float64{"ONLINE": 1, "RECOVERING": 2, "OFFLINE": 0},
+	}
+
+	convey.Convey("Mapped value", t, func() {
+		ss := types.NewSamples()
+		err := new(Exporter).parseRow(map[string]string{"member": "a", "state": "RECOVERING", "errors": "3"}, query, ss)
+		convey.So(err, convey.ShouldBeNil)
+
+		ms := ss.PopBackAll()
+		convey.So(ms, convey.ShouldHaveLength, 2)
+		convey.So(ms[0].Name(), convey.ShouldEqual, "group_replication_state")
+		convey.So(ms[0].Fields(), convey.ShouldResemble, map[string]interface{}{"": 2.0})
+		convey.So(ms[1].Name(), convey.ShouldEqual, "group_replication_errors")
+	})
+
+	convey.Convey("Unmapped value is skipped", t, func() {
+		ss := types.NewSamples()
+		err := new(Exporter).parseRow(map[string]string{"member": "a", "state": "ERROR", "errors": "3"}, query, ss)
+		convey.So(err, convey.ShouldBeNil)
+
+		ms := ss.PopBackAll()
+		convey.So(ms, convey.ShouldHaveLength, 1)
+		convey.So(ms[0].Name(), convey.ShouldEqual, "group_replication_errors")
+	})
+
+	convey.Convey("Unmapped value with default", t, func() {
+		def := -1.0
+		query := query
+		query.ValueMappingDefault = &def
+
+		ss := types.NewSamples()
+		err := new(Exporter).parseRow(map[string]string{"member": "a", "state": "ERROR", "errors": "3"}, query, ss)
+		convey.So(err, convey.ShouldBeNil)
+
+		ms := ss.PopBackAll()
+		convey.So(ms, convey.ShouldHaveLength, 2)
+		convey.So(ms[0].Fields(), convey.ShouldResemble, map[string]interface{}{"": -1.0})
+	})
+}
+
+func TestCollectCustomQueryNullValue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	query := CustomQuery{
+		Mesurement:   "biz_orders",
+		MetricFields: []string{"total", "amount"},
+		LabelFields:  []string{"shop"},
+		Timeout:      time.Second,
+		Request:      "select shop, total, amount from biz.orders",
+	}
+	collect := func(query CustomQuery) []float64 {
+		rows := sqlmock.NewRows([]string{"shop", "total", "amount"}).AddRow("a", 3, nil)
+		mock.ExpectQuery(sanitizeQuery(query.Request)).WillReturnRows(rows)
+
+		ss := types.NewSamples()
+		new(Exporter).collectCustomQuery(context.Background(), db, ss, query)
+
+		var values []float64
+		for _, m := range ss.PopBackAll() {
+			values = append(values, m.Fields()[""].(float64))
+		}
+		return values
+	}
+
+	nulls := metrics.GetOrCreateCounter(`cprobe_mysql_custom_query_nulls_total{query="biz_orders",field="amount"}`)
+	before := nulls.Get()
+
+	convey.Convey("NULL is skipped by default", t, func() {
+		convey.So(collect(query), convey.ShouldResemble, []float64{3})
+	})
+
+	convey.Convey("NULL as NaN", t, func() {
+		query := query
+		query.NullValue = NullValueNaN
+		values := collect(query)
+		convey.So(values, convey.ShouldHaveLength, 2)
+		convey.So(math.IsNaN(values[1]), convey.ShouldBeTrue)
+	})
+
+	convey.Convey("NULL as the default", t, func() {
+		def := -1.0
+		query := query
+		query.NullValue, query.NullValueDefault = NullValueDefault, &def
+		convey.So(collect(query), convey.ShouldResemble, []float64{3, -1})
+	})
+
+	convey.Convey("NULLs are counted", t, func() {
+		convey.So(nulls.Get()-before, convey.ShouldEqual, 3)
+	})
+
+	convey.Convey("NULL labels are blank", t, func() {
+		rows := sqlmock.NewRows([]string{"shop", "total", "amount"}).AddRow(nil, 3, 4)
+		mock.ExpectQuery(sanitizeQuery(query.Request)).WillReturnRows(rows)
+
+		ss := types.NewSamples()
+		new(Exporter).collectCustomQuery(context.Background(), db, ss, query)
+
+		ms := ss.PopBackAll()
+		convey.So(ms, convey.ShouldHaveLength, 2)
+		for _, m := range ms {
+			convey.So(m.Tags(), convey.ShouldContainKey, "shop")
+			convey.So(m.Tags()["shop"], convey.ShouldEqual, "")
+		}
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestCustomQueryNames(t *testing.T) {
+	convey.Convey("Sanitize", t, func() {
+		name, changed := sanitizeMetricName("biz_users_total")
+		convey.So(name, convey.ShouldEqual, "biz_users_total")
+		convey.So(changed, convey.ShouldBeFalse)
+
+		name, changed = sanitizeMetricName("biz-users_total.count")
+		convey.So(name, convey.ShouldEqual, "biz_users_total_count")
+		convey.So(changed, convey.ShouldBeTrue)
+
+		name, changed = sanitizeLabelName("1st:region")
+		convey.So(name, convey.ShouldEqual, "_1st_region")
+		convey.So(changed, convey.ShouldBeTrue)
+	})
+
+	convey.Convey("Invalid names are sanitized at scrape time", t, func() {
+		query := CustomQuery{Mesurement: "biz-users", MetricFields: []string{"total"}, LabelFields: []string{"service.name"}}
+		convey.So(query.ValidateNames(), convey.ShouldNotBeNil)
+
+		ss := types.NewSamples()
+		err := new(Exporter).parseRow(map[string]string{"service.name": "n9e", "total": "3"}, query, ss)
+		convey.So(err, convey.ShouldBeNil)
+
+		ms := ss.PopBackAll()
+		convey.So(ms, convey.ShouldHaveLength, 1)
+		convey.So(ms[0].Name(), convey.ShouldEqual, "biz_users_total")
+		convey.So(ms[0].Tags(), convey.ShouldResemble, map[string]string{"service_name": "n9e"})
+	})
+
+	convey.Convey("Valid names", t, func() {
+		query := CustomQuery{Mesurement: "biz_users", MetricFields: []string{"total"}, LabelFields: []string{"service"}}
+		convey.So(query.ValidateNames(), convey.ShouldBeNil)
+	})
+}
+
+func TestQueryWithRetries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	query := CustomQuery{Mesurement: "biz_report", Request: "SELECT total FROM report", Retries: 2, RetryBackoff: time.Millisecond}
+	lockWaitTimeout := &MySQL.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded; try restarting transaction"}
+	deadlock := &MySQL.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock; try restarting transaction"}
+	retries := metrics.GetOrCreateCounter(`cprobe_mysql_custom_query_retries_total{query="biz_report"}`)
+
+	convey.Convey("Retried on the lock errors", t, func() {
+		mock.ExpectQuery(sanitizeQuery(query.Request)).WillReturnError(lockWaitTimeout)
+		mock.ExpectQuery(sanitizeQuery(query.Request)).WillReturnError(deadlock)
+		mock.ExpectQuery(sanitizeQuery(query.Request)).WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(3))
+
+		before := retries.Get()
+		rows, err := queryWithRetries(context.Background(), db, query)
+		convey.So(err, convey.ShouldBeNil)
+		rows.Close()
+		convey.So(retries.Get()-before, convey.ShouldEqual, 2)
+	})
+
+	convey.Convey("Failed after the retries", t, func() {
+		for i := 0; i <= query.Retries; i++ {
+			mock.ExpectQuery(sanitizeQuery(query.Request)).WillReturnError(lockWaitTimeout)
+		}
+		_, err := queryWithRetries(context.Background(), db, query)
+		convey.So(isMySQLError(err, 1205), convey.ShouldBeTrue)
+	})
+
+	convey.Convey("Not retried on the other errors", t, func() {
+		mock.ExpectQuery(sanitizeQuery(query.Request)).WillReturnError(&MySQL.MySQLError{Number: 1146, Message: "Table 'report' doesn't exist"})
+		_, err := queryWithRetries(context.Background(), db, query)
+		convey.So(isMySQLError(err, 1146), convey.ShouldBeTrue)
+	})
+
+	convey.Convey("Not retried beyond the timeout", t, func() {
+		mock.ExpectQuery(sanitizeQuery(query.Request)).WillReturnError(lockWaitTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		q := query
+		q.RetryBackoff = time.Minute
+		_, err := queryWithRetries(ctx, db, q)
+		convey.So(isMySQLError(err, 1205), convey.ShouldBeTrue)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// The kinds of ScrapeError, check them by errors.Is, e.g. an ErrAuth is not
+// going to be fixed by retrying, while an ErrPing may be a transient network failure.
+var (
+	ErrConnect   = errors.New("cannot connect to mysql")
+	ErrPing      = errors.New("cannot ping mysql")
+	ErrAuth      = errors.New("cannot authenticate to mysql")
+	ErrCollector = errors.New("cannot scrape")
+)
+
+// ScrapeError is the error of a scrape, it wraps the driver error so that
+// errors.As(err, &mysqlErr) works, and errors.Is(err, ErrAuth) matches the kind.
+type ScrapeError struct {
+	// Kind is one of ErrConnect, ErrPing, ErrAuth and ErrCollector
+	Kind error
+	// Addr is the host of the target the error comes from
+	Addr string
+	// Collector is the name of the failed collector, only for ErrCollector
+	Collector string
+	Err       error
+}
+
+func (e *ScrapeError) Error() string {
+	if e.Kind == ErrCollector {
+		return fmt.Sprintf("%s: %s, target: %s, error: %s", e.Kind, e.Collector, e.Addr, e.Err)
+	}
+	return fmt.Sprintf("%s %s, error: %s", e.Kind, e.Addr, e.Err)
+}
+
+func (e *ScrapeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ScrapeError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// authErrorNumbers are the server errors of a rejected login
+var authErrorNumbers = map[uint16]bool{
+	1044: true, // ER_DBACCESS_DENIED_ERROR
+	1045: true, // ER_ACCESS_DENIED_ERROR
+	1698: true, // ER_ACCESS_DENIED_NO_PASSWORD_ERROR
+	1862: true, // ER_MUST_CHANGE_PASSWORD_LOGIN
+}
+
+// pingError classifies the error of a ping, the login happens on the first connection
+func pingError(addr string, err error) *ScrapeError {
+	kind := ErrPing
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && authErrorNumbers[mysqlErr.Number] {
+		kind = ErrAuth
+	}
+	if errors.Is(err, mysql.ErrNativePassword) || errors.Is(err, mysql.ErrCleartextPassword) || errors.Is(err, mysql.ErrOldPassword) || errors.Is(err, mysql.ErrUnknownPlugin) {
+		kind = ErrAuth
+	}
+	return &ScrapeError{Kind: kind, Addr: addr, Err: err}
+}
@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPingError(t *testing.T) {
+	convey.Convey("Access denied is an auth error", t, func() {
+		driverErr := &mysql.MySQLError{Number: 1045, Message: "Access denied for user 'exporter'@'10.0.0.9'"}
+		err := fmt.Errorf("no available host: %w", pingError("10.0.0.1:3306", driverErr))
+
+		convey.So(errors.Is(err, ErrAuth), convey.ShouldBeTrue)
+		convey.So(errors.Is(err, ErrPing), convey.ShouldBeFalse)
+
+		var mysqlErr *mysql.MySQLError
+		convey.So(errors.As(err, &mysqlErr), convey.ShouldBeTrue)
+		convey.So(mysqlErr.Number, convey.ShouldEqual, 1045)
+
+		var scrapeErr *ScrapeError
+		convey.So(errors.As(err, &scrapeErr), convey.ShouldBeTrue)
+		convey.So(scrapeErr.Addr, convey.ShouldEqual, "10.0.0.1:3306")
+	})
+
+	convey.Convey("Other failures are ping errors", t, func() {
+		err := pingError("10.0.0.1:3306", errors.New("dial tcp 10.0.0.1:3306: connect: connection refused"))
+		convey.So(errors.Is(err, ErrPing), convey.ShouldBeTrue)
+		convey.So(errors.Is(err, ErrAuth), convey.ShouldBeFalse)
+		convey.So(err.Error(), convey.ShouldEqual, "cannot ping mysql 10.0.0.1:3306, error: dial tcp 10.0.0.1:3306: connect: connection refused")
+	})
+
+	convey.Convey("Collector error", t, func() {
+		err := &ScrapeError{Kind: ErrCollector, Addr: "10.0.0.1:3306", Collector: "global_status", Err: errors.New("timeout")}
+		convey.So(errors.Is(err, ErrCollector), convey.ShouldBeTrue)
+		convey.So(err.Error(), convey.ShouldEqual, "cannot scrape: global_status, target: 10.0.0.1:3306, error: timeout")
+	})
+}
@@ -18,6 +18,7 @@ import (
 	"database/sql"
 	"fmt"
 	"regexp"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
@@ -70,42 +71,146 @@ var (
 		"Collector time duration.",
 		[]string{"collector"}, nil,
 	)
+	mysqlVersionDetected = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "version_detected"),
+		"Whether the MySQL version was detected, if not all the collectors are run.",
+		nil, nil,
+	)
+	mysqlActiveHost = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, exporter, "active_host"),
+		"The host scraped among the hosts of the target, only reported for multi-host targets.",
+		[]string{"host"}, nil,
+	)
+	mysqlScrapeSeriesLimitExceeded = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, exporter, "series_limit_exceeded"),
+		"Whether a collector emitted more series than series_limit_per_collector, the overflow is dropped.",
+		[]string{"collector"}, nil,
+	)
+	mysqlScrapeCollectorSkipped = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, exporter, "collector_skipped"),
+		"Whether a collector enabled was skipped, e.g. reason version: the server is older than the collector requires. Only reported if report_skipped_collectors is enabled.",
+		[]string{"collector", "reason"}, nil,
+	)
+	mysqlConnectionInterface = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, exporter, "connection_interface"),
+		"The interface scraped through, regular or admin, only reported if admin_port is set.",
+		[]string{"interface"}, nil,
+	)
 )
 
 // Verify if Exporter implements prometheus.Collector
 // var _ prometheus.Collector = (*Exporter)(nil)
 
+// Options contains the per target settings of the Exporter.
+type Options struct {
+	LockWaitTimeout int
+	LogSlowFilter   bool
+	// SeriesLimit caps the series emitted by each scraper per scrape, 0 means no limit
+	SeriesLimit int
+	// SessionStatements are executed on every new connection, e.g. SET SESSION ...
+	SessionStatements []string
+	// InitSQLFile is a file of statements executed on every new connection after SessionStatements
+	InitSQLFile string
+	// VersionQuery overrides the query used to detect the version, defaults to SELECT @@version
+	VersionQuery string
+	// PrimaryDetection only scrapes the host with @@read_only = 0 among the hosts of the target
+	PrimaryDetection bool
+	// ScrapeEvery runs the scrapers every N scrapes instead of every scrape, keyed by the scraper name
+	ScrapeEvery map[string]int
+	// SkippedPolicy handles the metrics of the last run on the skipped scrapes: SkippedHold (default), SkippedDrop or SkippedStale
+	SkippedPolicy string
+	// Replicas are the read replicas the custom queries with on_replica are spread across
+	Replicas []Replica
+	// IsolateConnections runs every scraper and custom query on a dedicated connection instead of the shared one
+	IsolateConnections bool
+	// MaxOpenConns is the size of the shared pool, defaults to 1 so the scrapers are serialized on one connection.
+	// With IsolateConnections it is the number of dedicated connections open at the same time, defaults to 4
+	MaxOpenConns int
+	// ServerLabel identifies the target in the logs and the query comments instead of the address of the DSN,
+	// it is only for display, the cached results are keyed by the address
+	ServerLabel string
+	// Credentials provides the user and password of the connections instead of the DSN
+	Credentials CredentialsProvider
+	// AdminDSNs are the DSNs of the admin interface of the hosts in the same order as the DSNs, blank if a host has none
+	AdminDSNs []string
+	// AdminMode is AdminFallback (default) to connect to the admin interface when the regular one has too many connections,
+	// or AdminAlways to always connect to it
+	AdminMode string
+	// ReportSkipped reports the scrapers skipped because of the version by mysql_exporter_collector_skipped
+	ReportSkipped bool
+	// QueryComment prepends a comment of the target and the name of the scraper or custom query to every statement
+	QueryComment bool
+}
+
 // Exporter collects MySQL metrics. It implements prometheus.Collector.
 type Exporter struct {
 	ctx      context.Context
 	dsn      string
+	dsns     []string
 	scrapers []Scraper
 	ss       *types.Samples
 	queries  []CustomQuery
+	opts     Options
+
+	// adminDSNs maps the DSNs to the ones of the admin interface
+	adminDSNs map[string]string
+	// connDSN is the DSN connected to, the one of the admin interface of e.dsn if used
+	connDSN string
 }
 
-// New returns a new MySQL exporter for the provided DSN.
-func New(ctx context.Context, dsn string, scrapers []Scraper, ss *types.Samples, queries []CustomQuery, lockWaitTimeout int, logSlowFilter bool) *Exporter {
+// New returns a new MySQL exporter for the provided DSNs, one DSN per host of the target.
+// The first available host is scraped, see Options.PrimaryDetection.
+func New(ctx context.Context, dsns []string, scrapers []Scraper, ss *types.Samples, queries []CustomQuery, opts Options) *Exporter {
 	// Setup extra params for the DSN, default to having a lock timeout.
-	dsnParams := []string{fmt.Sprintf(timeoutParam, lockWaitTimeout)}
+	dsnParams := []string{fmt.Sprintf(timeoutParam, opts.LockWaitTimeout)}
 
-	if logSlowFilter {
+	if opts.LogSlowFilter {
 		dsnParams = append(dsnParams, sessionSettingsParam)
 	}
 
-	if strings.Contains(dsn, "?") {
-		dsn = dsn + "&"
-	} else {
-		dsn = dsn + "?"
+	addParams := func(dsn string) string {
+		if strings.Contains(dsn, "?") {
+			dsn = dsn + "&"
+		} else {
+			dsn = dsn + "?"
+		}
+		return dsn + strings.Join(dsnParams, "&")
+	}
+
+	withParams := make([]string, 0, len(dsns))
+	for _, dsn := range dsns {
+		withParams = append(withParams, addParams(dsn))
+	}
+
+	if len(opts.Replicas) > 0 {
+		replicas := make([]Replica, len(opts.Replicas))
+		for i, r := range opts.Replicas {
+			r.DSN = addParams(r.DSN)
+			replicas[i] = r
+		}
+		opts.Replicas = replicas
+	}
+
+	var adminDSNs map[string]string
+	for i, dsn := range opts.AdminDSNs {
+		if dsn == "" || i >= len(withParams) {
+			continue
+		}
+		if adminDSNs == nil {
+			adminDSNs = make(map[string]string, len(opts.AdminDSNs))
+		}
+		adminDSNs[withParams[i]] = addParams(dsn)
 	}
-	dsn += strings.Join(dsnParams, "&")
 
 	return &Exporter{
-		ctx:      ctx,
-		dsn:      dsn,
-		scrapers: scrapers,
-		ss:       ss,
-		queries:  queries,
+		ctx:       ctx,
+		dsn:       withParams[0],
+		dsns:      withParams,
+		scrapers:  scrapers,
+		ss:        ss,
+		queries:   queries,
+		opts:      opts,
+		adminDSNs: adminDSNs,
 	}
 }
 
@@ -113,6 +218,11 @@ func New(ctx context.Context, dsn string, scrapers []Scraper, ss *types.Samples,
 func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- mysqlScrapeDurationSeconds
 	ch <- mysqlScrapeCollectorSuccess
+	ch <- mysqlVersionDetected
+	ch <- mysqlActiveHost
+	ch <- mysqlScrapeSeriesLimitExceeded
+	ch <- mysqlConnectionInterface
+	ch <- mysqlScrapeCollectorSkipped
 }
 
 // Collect implements prometheus.Collector.
@@ -120,33 +230,63 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) error {
 	return e.scrape(e.ctx, ch)
 }
 
+// Ping connects to the first available host of the target, as scrape does, and closes the connection.
+func (e *Exporter) Ping(ctx context.Context) error {
+	db, err := e.connect(ctx)
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
+
 // scrape collects metrics from the target, returns an up metric value.
 func (e *Exporter) scrape(ctx context.Context, ch chan<- prometheus.Metric) error {
 	scrapeTime := time.Now()
-	db, err := sql.Open("mysql", e.dsn)
+	db, err := e.connect(ctx)
 	if err != nil {
-		return fmt.Errorf("cannot opening connection to database: %s, error: %s", e.dsn, err)
+		return err
 	}
 
 	defer db.Close()
 
-	// By design exporter should use maximum one connection per request.
-	db.SetMaxOpenConns(1)
-	db.SetMaxIdleConns(1)
-	// Set max lifetime for a connection.
-	db.SetConnMaxLifetime(1 * time.Minute)
-
-	if err := db.PingContext(ctx); err != nil {
-		return fmt.Errorf("cannot ping mysql %s, error: %s", e.getTargetFromDsn(), err)
+	if !e.opts.IsolateConnections && e.opts.MaxOpenConns > 1 {
+		db.SetMaxOpenConns(e.opts.MaxOpenConns)
+		db.SetMaxIdleConns(e.opts.MaxOpenConns)
 	}
 
 	ch <- prometheus.MustNewConstMetric(mysqlScrapeDurationSeconds, prometheus.GaugeValue, time.Since(scrapeTime).Seconds(), "connection")
 
-	version := getMySQLVersion(db)
+	if len(e.dsns) > 1 {
+		ch <- prometheus.MustNewConstMetric(mysqlActiveHost, prometheus.GaugeValue, 1, dsnAddr(e.dsn))
+	}
+
+	if len(e.adminDSNs) > 0 {
+		iface := interfaceRegular
+		if e.connDSN == e.adminDSNs[e.dsn] {
+			iface = interfaceAdmin
+		}
+		ch <- prometheus.MustNewConstMetric(mysqlConnectionInterface, prometheus.GaugeValue, 1, iface)
+	}
+
+	query := e.opts.VersionQuery
+	if query == "" {
+		query = versionQuery
+	}
+
+	version, err := getMySQLVersion(ctx, db, query)
+	versionDetected := 1.0
+	if err != nil {
+		logger.Warnf("cannot detect mysql version, all the collectors will be run, target: %s, error: %s", e.getTargetFromDsn(), err)
+		versionDetected = 0.0
+	}
+	ch <- prometheus.MustNewConstMetric(mysqlVersionDetected, prometheus.GaugeValue, versionDetected)
+
+	conns := newIsolatedConns(e)
+
 	var wg sync.WaitGroup
 	defer wg.Wait()
 	for _, scraper := range e.scrapers {
-		if version < scraper.Version() {
+		if !e.supported(version, scraper, ch) {
 			continue
 		}
 
@@ -156,8 +296,9 @@ func (e *Exporter) scrape(ctx context.Context, ch chan<- prometheus.Metric) erro
 			label := "collect." + scraper.Name()
 			scrapeTime := time.Now()
 			collectorSuccess := 1.0
-			if err := scraper.Scrape(ctx, db, ch); err != nil {
-				logger.Errorf("cannot scrape: %s, target: %s, error: %s", scraper.Name(), e.getTargetFromDsn(), err)
+			if err := e.scrapeIsolated(withQueryName(ctx, scraper.Name()), conns, db, scraper, label, ch); err != nil {
+				// the failure of a collector does not fail the scrape, it is reported by mysql_exporter_collector_success
+				logger.Errorf("%s", &ScrapeError{Kind: ErrCollector, Addr: e.getTargetFromDsn(), Collector: scraper.Name(), Err: err})
 				// level.Error(e.logger).Log("msg", "Error from scraper", "scraper", scraper.Name(), "target", e.getTargetFromDsn(), "err", err)
 				collectorSuccess = 0.0
 			}
@@ -167,14 +308,89 @@ func (e *Exporter) scrape(ctx context.Context, ch chan<- prometheus.Metric) erro
 	}
 
 	// 添加自定义采集的逻辑
-	e.collectCustomQueries(ctx, db, e.ss, e.queries)
+	e.collectCustomQueries(ctx, conns, db, e.ss, e.queries)
 
 	return nil
 }
 
+// supported reports whether the server of version supports the scraper, the skipped one is reported if Options.ReportSkipped
+func (e *Exporter) supported(version float64, scraper Scraper, ch chan<- prometheus.Metric) bool {
+	if version >= scraper.Version() {
+		return true
+	}
+	if e.opts.ReportSkipped {
+		ch <- prometheus.MustNewConstMetric(mysqlScrapeCollectorSkipped, prometheus.GaugeValue, 1, "collect."+scraper.Name(), "version")
+	}
+	return false
+}
+
+// scrapeIsolated runs the scraper on the connection got from conns
+func (e *Exporter) scrapeIsolated(ctx context.Context, conns *isolatedConns, shared *sql.DB, scraper Scraper, label string, ch chan<- prometheus.Metric) error {
+	db, release, err := conns.get(ctx, shared)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return e.scrapeWithInterval(ctx, db, scraper, label, ch)
+}
+
+// scrapeWithLimit runs the scraper and forwards at most e.opts.SeriesLimit metrics to ch,
+// a misconfigured collector could emit tens of thousands of series and blow up the backend
+func (e *Exporter) scrapeWithLimit(ctx context.Context, db *sql.DB, scraper Scraper, label string, ch chan<- prometheus.Metric) error {
+	if e.opts.SeriesLimit <= 0 {
+		return runScraper(ctx, db, scraper, ch)
+	}
+
+	limitCh := make(chan prometheus.Metric)
+	droppedCh := make(chan int)
+	go func() {
+		count, dropped := 0, 0
+		for m := range limitCh {
+			if count >= e.opts.SeriesLimit {
+				dropped++
+				continue
+			}
+			count++
+			ch <- m
+		}
+		droppedCh <- dropped
+	}()
+
+	err := runScraper(ctx, db, scraper, limitCh)
+	close(limitCh)
+
+	exceeded := 0.0
+	if dropped := <-droppedCh; dropped > 0 {
+		logger.Warnf("series limit exceeded: %s, target: %s, limit: %d, dropped: %d", scraper.Name(), e.getTargetFromDsn(), e.opts.SeriesLimit, dropped)
+		exceeded = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(mysqlScrapeSeriesLimitExceeded, prometheus.GaugeValue, exceeded, label)
+
+	return err
+}
+
+// runScraper returns the panic of the scraper as an error, e.g. MustNewConstMetric with a malformed result,
+// so that only this collector fails and the other scrapers finish
+func runScraper(ctx context.Context, db *sql.DB, scraper Scraper, ch chan<- prometheus.Metric) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return scraper.Scrape(ctx, db, ch)
+}
+
 func (e *Exporter) getTargetFromDsn() string {
+	if e.opts.ServerLabel != "" {
+		return e.opts.ServerLabel
+	}
+	return dsnAddr(e.dsn)
+}
+
+func dsnAddr(dsn string) string {
 	// Get target from DSN.
-	dsnConfig, err := mysql.ParseDSN(e.dsn)
+	dsnConfig, err := mysql.ParseDSN(dsn)
 	if err != nil {
 		logger.Errorf("Error parsing DSN: %s", err)
 		// level.Error(e.logger).Log("msg", "Error parsing DSN", "err", err)
@@ -183,19 +399,17 @@ func (e *Exporter) getTargetFromDsn() string {
 	return dsnConfig.Addr
 }
 
-func getMySQLVersion(db *sql.DB) float64 {
+// getMySQLVersion returns the version like 5.7 or 8.0, if we can't match/parse the version,
+// it returns some big value that matches all versions with the error.
+func getMySQLVersion(ctx context.Context, db *sql.DB, query string) (float64, error) {
 	var versionStr string
-	var versionNum float64
-	if err := db.QueryRow(versionQuery).Scan(&versionStr); err == nil {
-		versionNum, _ = strconv.ParseFloat(versionRE.FindString(versionStr), 64)
-	}
-	// else {
-	// 	level.Debug(logger).Log("msg", "Error querying version", "err", err)
-	// }
-	// If we can't match/parse the version, set it some big value that matches all versions.
-	if versionNum == 0 {
-		// level.Debug(logger).Log("msg", "Error parsing version string", "version", versionStr)
-		versionNum = 999
-	}
-	return versionNum
+	if err := db.QueryRowContext(ctx, query).Scan(&versionStr); err != nil {
+		return 999, err
+	}
+
+	versionNum, err := strconv.ParseFloat(versionRE.FindString(versionStr), 64)
+	if err != nil || versionNum == 0 {
+		return 999, fmt.Errorf("cannot parse version string: %q", versionStr)
+	}
+	return versionNum, nil
 }
@@ -13,6 +13,22 @@
 
 package collector
 
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cprobe/cprobe/lib/decimal"
+	"github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
 // const dsn = "root@/mysql"
 
 // func TestExporter(t *testing.T) {
@@ -71,3 +87,267 @@ package collector
 // 		convey.So(getMySQLVersion(db), convey.ShouldBeBetweenOrEqual, 5.6, 11.0)
 // 	})
 // }
+
+func TestNewNamedPipeDSN(t *testing.T) {
+	convey.Convey("Named pipe DSN", t, func() {
+		cfg := mysql.NewConfig()
+		cfg.User = "root"
+		cfg.Net = "pipe"
+		cfg.Addr = `\\.\pipe\MySQL`
+
+		exporter := New(context.Background(), []string{cfg.FormatDSN()}, nil, nil, nil, Options{LockWaitTimeout: 2, LogSlowFilter: true})
+
+		parsed, err := mysql.ParseDSN(exporter.dsn)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(parsed.Net, convey.ShouldEqual, "pipe")
+		convey.So(parsed.Params["lock_wait_timeout"], convey.ShouldEqual, "2")
+		convey.So(parsed.Params, convey.ShouldContainKey, "log_slow_filter")
+		convey.So(exporter.getTargetFromDsn(), convey.ShouldEqual, `\\.\pipe\MySQL`)
+	})
+}
+
+func TestServerLabel(t *testing.T) {
+	convey.Convey("Server label overrides the DSN address", t, func() {
+		dsn := "root@tcp(10.0.0.100:3306)/"
+		convey.So(New(context.Background(), []string{dsn}, nil, nil, nil, Options{}).getTargetFromDsn(), convey.ShouldEqual, "10.0.0.100:3306")
+		convey.So(New(context.Background(), []string{dsn}, nil, nil, nil, Options{ServerLabel: "order-db-1"}).getTargetFromDsn(), convey.ShouldEqual, "order-db-1")
+	})
+}
+
+type countScraper struct {
+	count int
+}
+
+func (countScraper) Name() string     { return "count" }
+func (countScraper) Help() string     { return "Emit a fixed number of series" }
+func (countScraper) Version() float64 { return 5.1 }
+
+func (s countScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	desc := prometheus.NewDesc("mysql_count", "Fake series.", []string{"i"}, nil)
+	for i := 0; i < s.count; i++ {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(i), strconv.Itoa(i))
+	}
+	return nil
+}
+
+func TestScrapeWithLimit(t *testing.T) {
+	convey.Convey("Series limit", t, func() {
+		for _, tc := range []struct {
+			limit    int
+			emitted  int
+			exceeded float64
+		}{
+			{limit: 3, emitted: 10, exceeded: 1},
+			{limit: 10, emitted: 10, exceeded: 0},
+		} {
+			exporter := New(context.Background(), []string{"root@tcp(127.0.0.1:3306)/"}, nil, nil, nil, Options{LockWaitTimeout: 2, SeriesLimit: tc.limit})
+
+			ch := make(chan prometheus.Metric)
+			go func() {
+				if err := exporter.scrapeWithLimit(context.Background(), nil, countScraper{count: tc.emitted}, "collect.count", ch); err != nil {
+					t.Errorf("error calling function on test: %s", err)
+				}
+				close(ch)
+			}()
+
+			var metrics []MetricResult
+			for m := range ch {
+				metrics = append(metrics, readMetric(m))
+			}
+
+			convey.So(metrics, convey.ShouldHaveLength, tc.limit+1)
+			convey.So(metrics[len(metrics)-1], convey.ShouldResemble, MetricResult{
+				labels: labelMap{"collector": "collect.count"}, value: tc.exceeded, metricType: dto.MetricType_GAUGE,
+			})
+		}
+	})
+}
+
+type panicScraper struct{}
+
+func (panicScraper) Name() string     { return "panic" }
+func (panicScraper) Help() string     { return "Panic with an invalid metric" }
+func (panicScraper) Version() float64 { return 5.1 }
+
+func (panicScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	desc := prometheus.NewDesc("mysql_panic", "Fake series.", []string{"i"}, nil)
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1)
+	return nil
+}
+
+func TestScrapeWithLimitPanic(t *testing.T) {
+	convey.Convey("Panic of the scraper is returned as an error", t, func() {
+		for _, limit := range []int{0, 3} {
+			exporter := New(context.Background(), []string{"root@tcp(127.0.0.1:3306)/"}, nil, nil, nil, Options{SeriesLimit: limit})
+
+			ch := make(chan prometheus.Metric)
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- exporter.scrapeWithLimit(context.Background(), nil, panicScraper{}, "collect.panic", ch)
+				close(ch)
+			}()
+			for range ch {
+			}
+
+			err := <-errCh
+			convey.So(err, convey.ShouldNotBeNil)
+			convey.So(err.Error(), convey.ShouldStartWith, "panic: ")
+		}
+	})
+}
+
+func TestGetMySQLVersionWithQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	query := "SELECT VERSION()"
+	mock.ExpectQuery(sanitizeQuery(query)).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("8.0.32-proxysql"))
+	mock.ExpectQuery(sanitizeQuery(query)).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("unknown"))
+	mock.ExpectQuery(sanitizeQuery(query)).WillReturnError(fmt.Errorf("not supported"))
+
+	convey.Convey("Version detection", t, func() {
+		version, err := getMySQLVersion(context.Background(), db, query)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(version, convey.ShouldEqual, 8.0)
+
+		version, err = getMySQLVersion(context.Background(), db, query)
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(version, convey.ShouldEqual, 999)
+
+		version, err = getMySQLVersion(context.Background(), db, query)
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(version, convey.ShouldEqual, 999)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+type intervalScraper struct {
+	countScraper
+	runs *int
+}
+
+func (intervalScraper) Interval() time.Duration { return time.Hour }
+
+func (s intervalScraper) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	*s.runs++
+	return s.countScraper.Scrape(ctx, db, ch)
+}
+
+func TestScrapeWithInterval(t *testing.T) {
+	convey.Convey("Last result is reused within the interval", t, func() {
+		exporter := New(context.Background(), []string{"root@tcp(127.0.0.1:3306)/"}, nil, nil, nil, Options{LockWaitTimeout: 2})
+		scraper := intervalScraper{countScraper: countScraper{count: 2}, runs: new(int)}
+
+		for i := 0; i < 2; i++ {
+			ch := make(chan prometheus.Metric)
+			go func() {
+				if err := exporter.scrapeWithInterval(context.Background(), nil, scraper, "collect.count", ch); err != nil {
+					t.Errorf("error calling function on test: %s", err)
+				}
+				close(ch)
+			}()
+
+			var metrics []MetricResult
+			for m := range ch {
+				metrics = append(metrics, readMetric(m))
+			}
+			convey.So(metrics, convey.ShouldHaveLength, 2)
+		}
+		convey.So(*scraper.runs, convey.ShouldEqual, 1)
+	})
+
+	convey.Convey("Targets with the same server label have their own results", t, func() {
+		scraper := intervalScraper{countScraper: countScraper{count: 2}, runs: new(int)}
+		for _, dsn := range []string{"root@tcp(127.0.0.1:3310)/", "root@tcp(127.0.0.1:3311)/"} {
+			exporter := New(context.Background(), []string{dsn}, nil, nil, nil, Options{ServerLabel: "order-db"})
+			ch := make(chan prometheus.Metric)
+			go func() {
+				if err := exporter.scrapeWithInterval(context.Background(), nil, scraper, "collect.count", ch); err != nil {
+					t.Errorf("error calling function on test: %s", err)
+				}
+				close(ch)
+			}()
+			for range ch {
+			}
+		}
+		convey.So(*scraper.runs, convey.ShouldEqual, 2)
+	})
+}
+
+func TestScrapeEvery(t *testing.T) {
+	scrape := func(exporter *Exporter, scraper Scraper) []prometheus.Metric {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := exporter.scrapeWithInterval(context.Background(), nil, scraper, "collect.count", ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		var metrics []prometheus.Metric
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		return metrics
+	}
+
+	convey.Convey("Scraper runs every 3 scrapes and holds the last values", t, func() {
+		exporter := New(context.Background(), []string{"root@tcp(127.0.0.1:3307)/"}, nil, nil, nil, Options{
+			ScrapeEvery: map[string]int{"count": 3},
+		})
+		scraper := intervalScraper{countScraper: countScraper{count: 2}, runs: new(int)}
+
+		for i := 0; i < 4; i++ {
+			convey.So(scrape(exporter, scraper), convey.ShouldHaveLength, 2)
+		}
+		convey.So(*scraper.runs, convey.ShouldEqual, 2)
+	})
+
+	convey.Convey("Skipped scrapes are stale-marked once", t, func() {
+		exporter := New(context.Background(), []string{"root@tcp(127.0.0.1:3308)/"}, nil, nil, nil, Options{
+			ScrapeEvery:   map[string]int{"count": 3},
+			SkippedPolicy: SkippedStale,
+		})
+		scraper := countScraper{count: 2}
+
+		convey.So(scrape(exporter, scraper), convey.ShouldHaveLength, 2)
+
+		metrics := scrape(exporter, scraper)
+		convey.So(metrics, convey.ShouldHaveLength, 2)
+		convey.So(decimal.IsStaleNaN(readMetric(metrics[0]).value), convey.ShouldBeTrue)
+
+		convey.So(scrape(exporter, scraper), convey.ShouldHaveLength, 0)
+		convey.So(scrape(exporter, scraper), convey.ShouldHaveLength, 2)
+	})
+}
+
+func TestReportSkipped(t *testing.T) {
+	convey.Convey("Scrapers skipped by version are reported", t, func() {
+		for _, report := range []bool{false, true} {
+			exporter := New(context.Background(), []string{"root@tcp(127.0.0.1:3306)/"}, nil, nil, nil, Options{ReportSkipped: report})
+
+			ch := make(chan prometheus.Metric, 2)
+			convey.So(exporter.supported(8.0, ScrapePerfThreads{}, ch), convey.ShouldBeTrue)
+			convey.So(exporter.supported(5.7, ScrapePerfThreads{}, ch), convey.ShouldBeFalse)
+			close(ch)
+
+			var metrics []MetricResult
+			for m := range ch {
+				metrics = append(metrics, readMetric(m))
+			}
+			if !report {
+				convey.So(metrics, convey.ShouldBeEmpty)
+				continue
+			}
+			convey.So(metrics, convey.ShouldResemble, []MetricResult{
+				{labels: labelMap{"collector": "collect.perf_schema.threads", "reason": "version"}, value: 1, metricType: dto.MetricType_GAUGE},
+			})
+		}
+	})
+}
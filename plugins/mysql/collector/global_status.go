@@ -226,3 +226,26 @@ func (ScrapeGlobalStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<- prom
 
 // check interface
 var _ Scraper = ScrapeGlobalStatus{}
+
+// globalStatusValues runs a `SHOW GLOBAL STATUS WHERE ...` query and returns the
+// parsable values keyed by the lower-cased variable name.
+func globalStatusValues(ctx context.Context, db *sql.DB, query string) (map[string]float64, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var key string
+	var val sql.RawBytes
+	values := make(map[string]float64)
+	for rows.Next() {
+		if err := rows.Scan(&key, &val); err != nil {
+			return nil, err
+		}
+		if floatVal, ok := parseStatus(val); ok {
+			values[strings.ToLower(key)] = floatVal
+		}
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,252 @@
+// Scrape `@@gtid_executed` and friends.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	gtid = "gtid"
+	// Queries.
+	gtidQuery         = `SELECT @@gtid_mode, @@gtid_executed, @@gtid_purged`
+	gtidReceivedQuery = `SELECT CHANNEL_NAME, RECEIVED_TRANSACTION_SET FROM performance_schema.replication_connection_status`
+)
+
+// Metric descriptors.
+var (
+	gtidExecutedTransactionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, gtid, "executed_transactions"),
+		"Number of transactions in @@gtid_executed.",
+		[]string{}, nil,
+	)
+	gtidPurgedTransactionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, gtid, "purged_transactions"),
+		"Number of transactions in @@gtid_purged.",
+		[]string{}, nil,
+	)
+	gtidRetrievedTransactionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, gtid, "retrieved_transactions"),
+		"Number of transactions received by the replication channel.",
+		[]string{"channel_name"}, nil,
+	)
+)
+
+// ScrapeGtid collects the size of the GTID sets.
+type ScrapeGtid struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeGtid) Name() string {
+	return gtid
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeGtid) Help() string {
+	return "Collect the number of transactions in the executed, purged and retrieved GTID sets"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeGtid) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeGtid) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var mode, executed, purged string
+	if err := db.QueryRowContext(ctx, gtidQuery).Scan(&mode, &executed, &purged); err != nil {
+		return err
+	}
+	if mode != "ON" {
+		return nil
+	}
+
+	executedCount, err := gtidSetCount(executed)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(
+		gtidExecutedTransactionsDesc, prometheus.GaugeValue, float64(executedCount),
+	)
+
+	purgedCount, err := gtidSetCount(purged)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(
+		gtidPurgedTransactionsDesc, prometheus.GaugeValue, float64(purgedCount),
+	)
+
+	rows, err := db.QueryContext(ctx, gtidReceivedQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var channelName, received string
+	for rows.Next() {
+		if err := rows.Scan(&channelName, &received); err != nil {
+			return err
+		}
+		receivedCount, err := gtidSetCount(received)
+		if err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			gtidRetrievedTransactionsDesc, prometheus.GaugeValue, float64(receivedCount), channelName,
+		)
+	}
+	return rows.Err()
+}
+
+// gtidSetCount returns the number of transactions in a GTID set such as
+// `3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5:11,\n8f782839-34f7-11e7-a774-060ac4f023ae:4`.
+// MySQL 8.3+ tagged GTIDs (uuid:tag:1-5) are supported, tags are skipped.
+func gtidSetCount(set string) (uint64, error) {
+	var total uint64
+	for _, item := range strings.Split(set, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 {
+			return 0, fmt.Errorf("invalid gtid set item: %q", item)
+		}
+
+		for _, interval := range parts[1:] {
+			start, end, ok, err := parseGtidInterval(interval)
+			if err != nil {
+				return 0, fmt.Errorf("invalid gtid set item: %q: %w", item, err)
+			}
+			if !ok {
+				// tag
+				continue
+			}
+			total += end - start + 1
+		}
+	}
+	return total, nil
+}
+
+// parseGtidInterval parses `n` or `n-m`, ok is false if the interval is a tag.
+func parseGtidInterval(interval string) (start, end uint64, ok bool, err error) {
+	interval = strings.TrimSpace(interval)
+	if interval == "" {
+		return 0, 0, false, fmt.Errorf("empty interval")
+	}
+	if c := interval[0]; c < '0' || c > '9' {
+		return 0, 0, false, nil
+	}
+
+	bounds := strings.SplitN(interval, "-", 2)
+	if start, err = strconv.ParseUint(bounds[0], 10, 64); err != nil {
+		return 0, 0, false, err
+	}
+	end = start
+	if len(bounds) == 2 {
+		if end, err = strconv.ParseUint(bounds[1], 10, 64); err != nil {
+			return 0, 0, false, err
+		}
+	}
+	if end < start {
+		return 0, 0, false, fmt.Errorf("interval %q end is less than start", interval)
+	}
+	return start, end, true, nil
+}
+
+// gtidInterval is a closed interval of transaction numbers
+type gtidInterval struct {
+	start, end uint64
+}
+
+// parseGtidSet parses a GTID set into the merged intervals keyed by the lower-cased uuid,
+// or uuid:tag for the tagged GTIDs.
+func parseGtidSet(set string) (map[string][]gtidInterval, error) {
+	ret := make(map[string][]gtidInterval)
+	for _, item := range strings.Split(set, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid gtid set item: %q", item)
+		}
+
+		uuid := strings.ToLower(strings.TrimSpace(parts[0]))
+		key := uuid
+		for _, interval := range parts[1:] {
+			start, end, ok, err := parseGtidInterval(interval)
+			if err != nil {
+				return nil, fmt.Errorf("invalid gtid set item: %q: %w", item, err)
+			}
+			if !ok {
+				key = uuid + ":" + strings.ToLower(strings.TrimSpace(interval))
+				continue
+			}
+			ret[key] = append(ret[key], gtidInterval{start: start, end: end})
+		}
+	}
+
+	for key, intervals := range ret {
+		sort.Slice(intervals, func(i, j int) bool { return intervals[i].start < intervals[j].start })
+		merged := intervals[:1]
+		for _, interval := range intervals[1:] {
+			last := &merged[len(merged)-1]
+			if interval.start <= last.end+1 {
+				if interval.end > last.end {
+					last.end = interval.end
+				}
+				continue
+			}
+			merged = append(merged, interval)
+		}
+		ret[key] = merged
+	}
+	return ret, nil
+}
+
+// gtidSetSubtractCount returns the number of transactions in GTID set a but not in b.
+func gtidSetSubtractCount(a, b string) (uint64, error) {
+	as, err := parseGtidSet(a)
+	if err != nil {
+		return 0, err
+	}
+	bs, err := parseGtidSet(b)
+	if err != nil {
+		return 0, err
+	}
+
+	var total uint64
+	for key, intervals := range as {
+		for _, interval := range intervals {
+			total += interval.end - interval.start + 1
+			for _, other := range bs[key] {
+				start, end := interval.start, interval.end
+				if other.start > start {
+					start = other.start
+				}
+				if other.end < end {
+					end = other.end
+				}
+				if start <= end {
+					total -= end - start + 1
+				}
+			}
+		}
+	}
+	return total, nil
+}
+
+// check interface
+var _ Scraper = ScrapeGtid{}
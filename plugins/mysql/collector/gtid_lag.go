@@ -0,0 +1,138 @@
+// Scrape the gap between the retrieved and executed GTID sets and the applier of the replication channels.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Name of the scraper, the metrics are in the gtid subsystem.
+	gtidLag = "gtid_lag"
+	// Queries.
+	gtidLagExecutedQuery = `SELECT @@gtid_mode, @@gtid_executed`
+	gtidLagApplierQuery  = `
+		SELECT
+		  c.CHANNEL_NAME,
+		  COUNT(w.WORKER_ID),
+		  COALESCE(SUM(w.APPLYING_TRANSACTION <> ''), 0),
+		  COALESCE(UNIX_TIMESTAMP(MAX(c.LAST_QUEUED_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP)), 0),
+		  COALESCE(UNIX_TIMESTAMP(MAX(w.LAST_APPLIED_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP)), 0)
+		FROM performance_schema.replication_connection_status c
+		JOIN performance_schema.replication_applier_status_by_worker w USING (CHANNEL_NAME)
+		GROUP BY c.CHANNEL_NAME
+		`
+)
+
+// Metric descriptors.
+var (
+	gtidRetrievedMinusExecutedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, gtid, "retrieved_minus_executed"),
+		"Number of transactions received by the replication channel but not executed yet.",
+		[]string{"channel_name"}, nil,
+	)
+	gtidApplierWorkersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, gtid, "applier_workers"),
+		"Number of applier workers of the replication channel.",
+		[]string{"channel_name"}, nil,
+	)
+	gtidApplierBusyWorkersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, gtid, "applier_busy_workers"),
+		"Number of applier workers applying a transaction.",
+		[]string{"channel_name"}, nil,
+	)
+	gtidApplierQueueLagDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, gtid, "applier_queue_lag_seconds"),
+		"Difference between the original commit timestamps of the last queued and the last applied transaction.",
+		[]string{"channel_name"}, nil,
+	)
+)
+
+// ScrapeGtidLag splits the replication lag into the transport and the apply part:
+// the transactions retrieved but not executed are waiting for the applier.
+type ScrapeGtidLag struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeGtidLag) Name() string {
+	return gtidLag
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeGtidLag) Help() string {
+	return "Collect the transactions retrieved but not executed and the applier queue of the replication channels"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeGtidLag) Version() float64 {
+	return 8.0
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeGtidLag) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var mode, executed string
+	if err := db.QueryRowContext(ctx, gtidLagExecutedQuery).Scan(&mode, &executed); err != nil {
+		return err
+	}
+	if mode != "ON" {
+		return nil
+	}
+
+	receivedRows, err := db.QueryContext(ctx, gtidReceivedQuery)
+	if err != nil {
+		return err
+	}
+	defer receivedRows.Close()
+
+	var channelName, received string
+	for receivedRows.Next() {
+		if err := receivedRows.Scan(&channelName, &received); err != nil {
+			return err
+		}
+		pending, err := gtidSetSubtractCount(received, executed)
+		if err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			gtidRetrievedMinusExecutedDesc, prometheus.GaugeValue, float64(pending), channelName,
+		)
+	}
+	if err := receivedRows.Err(); err != nil {
+		return err
+	}
+
+	applierRows, err := db.QueryContext(ctx, gtidLagApplierQuery)
+	if err != nil {
+		return err
+	}
+	defer applierRows.Close()
+
+	var workers, busy uint64
+	var queued, applied float64
+	for applierRows.Next() {
+		if err := applierRows.Scan(&channelName, &workers, &busy, &queued, &applied); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			gtidApplierWorkersDesc, prometheus.GaugeValue, float64(workers), channelName,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			gtidApplierBusyWorkersDesc, prometheus.GaugeValue, float64(busy), channelName,
+		)
+
+		// Nothing queued or applied yet, or the applier has caught up.
+		lag := 0.0
+		if queued > 0 && applied > 0 && queued > applied {
+			lag = queued - applied
+		}
+		ch <- prometheus.MustNewConstMetric(
+			gtidApplierQueueLagDesc, prometheus.GaugeValue, lag, channelName,
+		)
+	}
+	return applierRows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeGtidLag{}
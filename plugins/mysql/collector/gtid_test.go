@@ -0,0 +1,189 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestGtidSetCount(t *testing.T) {
+	tests := []struct {
+		set      string
+		expected uint64
+		fail     bool
+	}{
+		{set: "", expected: 0},
+		{set: "3E11FA47-71CA-11E1-9E33-C80AA9429562:23", expected: 1},
+		{set: "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5", expected: 5},
+		{set: "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5:11-18", expected: 13},
+		{set: "0515b3c2-f59f-11e9-881b-0620049edbec:1-15270987,\n8f782839-34f7-11e7-a774-060ac4f023ae:4-39:2387-161606", expected: 15270987 + 36 + 159220},
+		{set: "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5:domestic:1-3", expected: 8},
+		{set: "3E11FA47-71CA-11E1-9E33-C80AA9429562", fail: true},
+		{set: "3E11FA47-71CA-11E1-9E33-C80AA9429562:5-1", fail: true},
+		{set: "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-x", fail: true},
+	}
+
+	for _, test := range tests {
+		got, err := gtidSetCount(test.set)
+		if test.fail {
+			if err == nil {
+				t.Errorf("gtidSetCount(%q) expected error, got %d", test.set, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("gtidSetCount(%q) unexpected error: %s", test.set, err)
+			continue
+		}
+		if got != test.expected {
+			t.Errorf("gtidSetCount(%q) = %d, expected %d", test.set, got, test.expected)
+		}
+	}
+}
+
+func TestScrapeGtid(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(gtidQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"@@gtid_mode", "@@gtid_executed", "@@gtid_purged"}).
+			AddRow("ON", "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-100,\n8f782839-34f7-11e7-a774-060ac4f023ae:1-5", "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-10"))
+
+	mock.ExpectQuery(sanitizeQuery(gtidReceivedQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"CHANNEL_NAME", "RECEIVED_TRANSACTION_SET"}).
+			AddRow("", "3E11FA47-71CA-11E1-9E33-C80AA9429562:50-100").
+			AddRow("backup", ""))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeGtid{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, value: 105, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": ""}, value: 51, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "backup"}, value: 0, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestScrapeGtidModeOff(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(gtidQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"@@gtid_mode", "@@gtid_executed", "@@gtid_purged"}).
+			AddRow("OFF", "", ""))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeGtid{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	convey.Convey("No metrics when gtid_mode is OFF", t, func() {
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestGtidSetSubtractCount(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected uint64
+	}{
+		{a: "", b: "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5", expected: 0},
+		{a: "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-100", b: "", expected: 100},
+		{a: "3E11FA47-71CA-11E1-9E33-C80AA9429562:50-100", b: "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-90", expected: 10},
+		{a: "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-100", b: "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-10:21-30:41-100", expected: 20},
+		{a: "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-10,\n8f782839-34f7-11e7-a774-060ac4f023ae:1-5", b: "8f782839-34f7-11e7-a774-060ac4f023ae:1-5", expected: 10},
+		{a: "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5:domestic:1-3", b: "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5", expected: 3},
+	}
+
+	for _, test := range tests {
+		got, err := gtidSetSubtractCount(test.a, test.b)
+		if err != nil {
+			t.Errorf("gtidSetSubtractCount(%q, %q) unexpected error: %s", test.a, test.b, err)
+			continue
+		}
+		if got != test.expected {
+			t.Errorf("gtidSetSubtractCount(%q, %q) = %d, expected %d", test.a, test.b, got, test.expected)
+		}
+	}
+}
+
+func TestScrapeGtidLag(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(gtidLagExecutedQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"@@gtid_mode", "@@gtid_executed"}).
+			AddRow("ON", "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-90"))
+
+	mock.ExpectQuery(sanitizeQuery(gtidReceivedQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"CHANNEL_NAME", "RECEIVED_TRANSACTION_SET"}).
+			AddRow("", "3E11FA47-71CA-11E1-9E33-C80AA9429562:50-100"))
+
+	mock.ExpectQuery(sanitizeQuery(gtidLagApplierQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"CHANNEL_NAME", "workers", "busy", "queued", "applied"}).
+			AddRow("", 4, 3, "1700000012.500000", "1700000010.000000"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeGtidLag{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"channel_name": ""}, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": ""}, value: 4, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": ""}, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": ""}, value: 2.5, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
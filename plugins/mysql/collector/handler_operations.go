@@ -0,0 +1,82 @@
+// Scrape the handler and InnoDB row operation counters from `SHOW GLOBAL STATUS`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	handlerOperations = "handler_operations"
+	// Query.
+	handlerOperationsQuery = `SHOW GLOBAL STATUS WHERE Variable_name LIKE 'Handler\_%' OR Variable_name LIKE 'Innodb\_rows\_%'`
+)
+
+// Metric descriptors.
+var (
+	handlerOperationsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "handler", "operations_total"),
+		"Number of storage engine handler operations, e.g. a high read_rnd_next rate means full table scans.",
+		[]string{"handler"}, nil,
+	)
+	innodbRowOperationsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "innodb", "row_operations_total"),
+		"Number of rows operated in InnoDB tables.",
+		[]string{"operation"}, nil,
+	)
+)
+
+// ScrapeHandlerOperations collects the Handler_* and Innodb_rows_* counters.
+type ScrapeHandlerOperations struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeHandlerOperations) Name() string {
+	return handlerOperations
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeHandlerOperations) Help() string {
+	return "Collect the handler and InnoDB row operation counters from SHOW GLOBAL STATUS"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeHandlerOperations) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeHandlerOperations) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	values, err := globalStatusValues(ctx, db, handlerOperationsQuery)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch {
+		case strings.HasPrefix(key, "handler_"):
+			ch <- prometheus.MustNewConstMetric(
+				handlerOperationsDesc, prometheus.CounterValue, values[key], strings.TrimPrefix(key, "handler_"),
+			)
+		case strings.HasPrefix(key, "innodb_rows_"):
+			ch <- prometheus.MustNewConstMetric(
+				innodbRowOperationsDesc, prometheus.CounterValue, values[key], strings.TrimPrefix(key, "innodb_rows_"),
+			)
+		}
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeHandlerOperations{}
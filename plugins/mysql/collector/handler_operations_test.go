@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeHandlerOperations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"Variable_name", "Value"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("Handler_delete", "4").
+		AddRow("Handler_read_rnd_next", "12345").
+		AddRow("Handler_write", "77").
+		AddRow("Innodb_rows_read", "900").
+		AddRow("Innodb_rows_inserted", "60")
+	mock.ExpectQuery(regexp.QuoteMeta(handlerOperationsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeHandlerOperations{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"handler": "delete"}, value: 4, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"handler": "read_rnd_next"}, value: 12345, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"handler": "write"}, value: 77, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"operation": "inserted"}, value: 60, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"operation": "read"}, value: 900, metricType: dto.MetricType_COUNTER},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,210 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cprobe/cprobe/lib/logger"
+)
+
+const readOnlyQuery = `SELECT @@read_only`
+
+// The modes of the admin interface, see Options.AdminMode
+const (
+	AdminFallback = "fallback"
+	AdminAlways   = "always"
+)
+
+// The interfaces reported by mysql_exporter_connection_interface
+const (
+	interfaceRegular = "regular"
+	interfaceAdmin   = "admin"
+)
+
+// errTooManyConnections is the server error when max_connections is exhausted
+const errTooManyConnections = 1040
+
+// activeHostTTL is how long the selected host of a target is remembered after its last scrape
+const activeHostTTL = time.Hour
+
+type activeHost struct {
+	addr string
+	seen time.Time
+}
+
+// activeHosts remembers the host selected last time for every multi-host target,
+// keyed by the addresses of the target so the passwords are not kept,
+// the host is tried first on the next scrape
+var activeHosts = struct {
+	sync.Mutex
+	m map[string]activeHost
+}{m: make(map[string]activeHost)}
+
+// loadActiveHost returns the address selected last time for key and marks the entry as seen
+func loadActiveHost(key string) string {
+	activeHosts.Lock()
+	defer activeHosts.Unlock()
+	host, ok := activeHosts.m[key]
+	if !ok {
+		return ""
+	}
+	host.seen = time.Now()
+	activeHosts.m[key] = host
+	return host.addr
+}
+
+// storeActiveHost remembers addr for key, an empty addr forgets key
+func storeActiveHost(key, addr string) {
+	now := time.Now()
+	activeHosts.Lock()
+	defer activeHosts.Unlock()
+	// drop the targets which are not scraped anymore
+	for k, h := range activeHosts.m {
+		if now.Sub(h.seen) > activeHostTTL {
+			delete(activeHosts.m, k)
+		}
+	}
+	if addr == "" {
+		delete(activeHosts.m, key)
+		return
+	}
+	activeHosts.m[key] = activeHost{addr: addr, seen: now}
+}
+
+// connect opens a connection to the first available host of the target and sets e.dsn and e.connDSN.
+// The host selected last time is tried first, the others are tried in order when it fails,
+// so a failover is followed without reconfiguring.
+func (e *Exporter) connect(ctx context.Context) (*sql.DB, error) {
+	if len(e.dsns) == 1 {
+		return e.openHost(ctx, e.dsns[0], false)
+	}
+
+	addrs := make([]string, len(e.dsns))
+	for i, dsn := range e.dsns {
+		addrs[i] = dsnAddr(dsn)
+	}
+	key := strings.Join(addrs, ",")
+	lastAddr := loadActiveHost(key)
+	var lastDSN string
+	for i, addr := range addrs {
+		if lastAddr != "" && addr == lastAddr {
+			lastDSN = e.dsns[i]
+			break
+		}
+	}
+
+	var errs []string
+	var lastErr error
+	for _, dsn := range orderDSNs(e.dsns, lastDSN) {
+		db, err := e.openHost(ctx, dsn, e.opts.PrimaryDetection)
+		if err != nil {
+			if lastErr != nil {
+				errs = append(errs, lastErr.Error())
+			}
+			lastErr = err
+			continue
+		}
+		if dsn != lastDSN {
+			storeActiveHost(key, dsnAddr(dsn))
+		}
+		e.dsn = dsn
+		return db, nil
+	}
+
+	storeActiveHost(key, "")
+	// the error of the last host tried is wrapped, so errors.Is reports its kind
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no available host: %w", lastErr)
+	}
+	return nil, fmt.Errorf("no available host: %s; %w", strings.Join(errs, "; "), lastErr)
+}
+
+// openHost connects to the host of dsn through the regular or the admin interface by Options.AdminMode and sets e.connDSN,
+// the admin interface stays available when max_connections is exhausted
+func (e *Exporter) openHost(ctx context.Context, dsn string, primary bool) (*sql.DB, error) {
+	adminDSN, hasAdmin := e.adminDSNs[dsn]
+	if hasAdmin && e.opts.AdminMode == AdminAlways {
+		return e.openAndPingAs(ctx, adminDSN, primary)
+	}
+
+	db, err := e.openAndPingAs(ctx, dsn, primary)
+	if err == nil || !hasAdmin || !isMySQLError(err, errTooManyConnections) {
+		return db, err
+	}
+
+	logger.Warnf("too many connections on %s, connect through the admin interface", dsnAddr(dsn))
+	return e.openAndPingAs(ctx, adminDSN, primary)
+}
+
+// openAndPingAs is openAndPing setting e.connDSN on success
+func (e *Exporter) openAndPingAs(ctx context.Context, dsn string, primary bool) (*sql.DB, error) {
+	db, err := e.openAndPing(ctx, dsn, primary)
+	if err == nil {
+		e.connDSN = dsn
+	}
+	return db, err
+}
+
+// connectedDSN returns the DSN connected to, e.dsn if not connected yet
+func (e *Exporter) connectedDSN() string {
+	if e.connDSN == "" {
+		return e.dsn
+	}
+	return e.connDSN
+}
+
+// openAndPing opens the database of dsn and makes sure it is reachable,
+// if primary is true the host must be writable as well
+func (e *Exporter) openAndPing(ctx context.Context, dsn string, primary bool) (*sql.DB, error) {
+	addr := dsnAddr(dsn)
+	db, err := e.openDB(dsn)
+	if err != nil {
+		return nil, &ScrapeError{Kind: ErrConnect, Addr: addr, Err: err}
+	}
+
+	// By design exporter should use maximum one connection per request.
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	// Set max lifetime for a connection.
+	db.SetConnMaxLifetime(1 * time.Minute)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, pingError(addr, err)
+	}
+
+	if primary {
+		var readOnly int
+		if err := db.QueryRowContext(ctx, readOnlyQuery).Scan(&readOnly); err != nil {
+			db.Close()
+			return nil, &ScrapeError{Kind: ErrConnect, Addr: addr, Err: fmt.Errorf("cannot detect whether it is primary: %w", err)}
+		}
+		if readOnly != 0 {
+			db.Close()
+			return nil, &ScrapeError{Kind: ErrConnect, Addr: addr, Err: errors.New("read only")}
+		}
+	}
+
+	return db, nil
+}
+
+// orderDSNs moves last to the front, the others keep the configured order
+func orderDSNs(dsns []string, last string) []string {
+	ret := make([]string, 0, len(dsns))
+	for _, dsn := range dsns {
+		if dsn == last {
+			ret = append(ret, dsn)
+		}
+	}
+	for _, dsn := range dsns {
+		if dsn != last {
+			ret = append(ret, dsn)
+		}
+	}
+	return ret
+}
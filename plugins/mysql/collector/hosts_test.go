@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestOrderDSNs(t *testing.T) {
+	dsns := []string{"a", "b", "c"}
+
+	convey.Convey("Last selected host first", t, func() {
+		convey.So(orderDSNs(dsns, ""), convey.ShouldResemble, []string{"a", "b", "c"})
+		convey.So(orderDSNs(dsns, "b"), convey.ShouldResemble, []string{"b", "a", "c"})
+		convey.So(orderDSNs(dsns, "d"), convey.ShouldResemble, []string{"a", "b", "c"})
+	})
+}
+
+func TestActiveHosts(t *testing.T) {
+	convey.Convey("Selected hosts are remembered until not scraped for activeHostTTL", t, func() {
+		storeActiveHost("10.0.0.1:3306,10.0.0.2:3306", "10.0.0.2:3306")
+		convey.So(loadActiveHost("10.0.0.1:3306,10.0.0.2:3306"), convey.ShouldEqual, "10.0.0.2:3306")
+
+		activeHosts.Lock()
+		activeHosts.m["gone"] = activeHost{addr: "10.0.0.3:3306", seen: time.Now().Add(-2 * activeHostTTL)}
+		activeHosts.Unlock()
+		storeActiveHost("10.0.0.1:3306,10.0.0.2:3306", "")
+		convey.So(activeHosts.m, convey.ShouldBeEmpty)
+	})
+}
+
+func TestNewMultiHostDSN(t *testing.T) {
+	convey.Convey("Params are added to every host", t, func() {
+		var dsns []string
+		for _, addr := range []string{"10.0.0.1:3306", "10.0.0.2:3306"} {
+			cfg := mysql.NewConfig()
+			cfg.Net = "tcp"
+			cfg.Addr = addr
+			dsns = append(dsns, cfg.FormatDSN())
+		}
+
+		exporter := New(context.Background(), dsns, nil, nil, nil, Options{LockWaitTimeout: 2})
+		convey.So(exporter.dsns, convey.ShouldHaveLength, 2)
+		convey.So(exporter.getTargetFromDsn(), convey.ShouldEqual, "10.0.0.1:3306")
+		for i, dsn := range exporter.dsns {
+			parsed, err := mysql.ParseDSN(dsn)
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(parsed.Addr, convey.ShouldEqual, []string{"10.0.0.1:3306", "10.0.0.2:3306"}[i])
+			convey.So(parsed.Params["lock_wait_timeout"], convey.ShouldEqual, "2")
+		}
+	})
+}
+
+// fakeErrorServer fails every connection with the server error number before the handshake, as max_connections does
+func fakeErrorServer(t *testing.T, number uint16) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			payload := append([]byte{0xff, byte(number), byte(number >> 8)}, "fake error"...)
+			conn.Write(append([]byte{byte(len(payload)), 0, 0, 0}, payload...))
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestAdminInterface(t *testing.T) {
+	dsnOf := func(addr string) string {
+		cfg := mysql.NewConfig()
+		cfg.Net = "tcp"
+		cfg.Addr = addr
+		return cfg.FormatDSN()
+	}
+	regular := dsnOf(fakeErrorServer(t, errTooManyConnections))
+	admin := dsnOf(fakeErrorServer(t, 1045))
+
+	convey.Convey("Admin interface is connected when the regular one has too many connections", t, func() {
+		exporter := New(context.Background(), []string{regular}, nil, nil, nil, Options{AdminDSNs: []string{admin}})
+		convey.So(exporter.adminDSNs, convey.ShouldHaveLength, 1)
+
+		err := exporter.Ping(context.Background())
+		convey.So(isMySQLError(err, 1045), convey.ShouldBeTrue)
+
+		exporter = New(context.Background(), []string{regular}, nil, nil, nil, Options{})
+		err = exporter.Ping(context.Background())
+		convey.So(isMySQLError(err, errTooManyConnections), convey.ShouldBeTrue)
+	})
+
+	convey.Convey("Admin interface is always connected", t, func() {
+		exporter := New(context.Background(), []string{dsnOf(fakeErrorServer(t, 1045))}, nil, nil, nil, Options{
+			AdminDSNs: []string{dsnOf(fakeErrorServer(t, 1227))},
+			AdminMode: AdminAlways,
+		})
+		convey.So(isMySQLError(exporter.Ping(context.Background()), 1227), convey.ShouldBeTrue)
+	})
+}
@@ -0,0 +1,120 @@
+// Scrape the active transactions from `information_schema.innodb_trx`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/cprobe/cprobe/lib/logger"
+	MySQL "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	innodbTrx = "innodb_trx"
+	// Queries.
+	innodbTrxQuery = `SELECT GREATEST(TIMESTAMPDIFF(SECOND, trx_started, NOW()), 0) FROM information_schema.innodb_trx`
+)
+
+// DefaultInnodbTrxThresholds are the ages in seconds the transactions older than are counted.
+var DefaultInnodbTrxThresholds = []int{60, 600, 3600}
+
+// Metric descriptors.
+var (
+	innodbTrxActiveDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "innodb", "active_transactions"),
+		"Number of active InnoDB transactions.",
+		[]string{}, nil,
+	)
+	innodbTrxOldestDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "innodb", "oldest_transaction_seconds"),
+		"Age of the oldest active InnoDB transaction, 0 if there is none. A long running transaction blocks purge and grows the history list.",
+		[]string{}, nil,
+	)
+	innodbTrxOlderThanDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "innodb", "transactions_older_than"),
+		"Number of active InnoDB transactions older than threshold_seconds.",
+		[]string{"threshold_seconds"}, nil,
+	)
+)
+
+// ScrapeInnodbTrx collects the number and the age of the active transactions.
+type ScrapeInnodbTrx struct {
+	// Thresholds in seconds, the transactions older than each one are counted
+	Thresholds []int
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbTrx) Name() string {
+	return "info_schema." + innodbTrx
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbTrx) Help() string {
+	return "Collect the number and the age of the active transactions from information_schema.innodb_trx"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbTrx) Version() float64 {
+	return 5.5
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapeInnodbTrx) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, innodbTrxQuery)
+	if err != nil {
+		if mysqlErr, ok := err.(*MySQL.MySQLError); ok {
+			// Check for error 1227: Access denied, PROCESS is required
+			if mysqlErr.Number == 1227 {
+				logger.Warnf("cannot scrape %s, PROCESS is not granted: %s", s.Name(), err)
+				return nil
+			}
+		}
+		return err
+	}
+	defer rows.Close()
+
+	thresholds := s.Thresholds
+	if len(thresholds) == 0 {
+		thresholds = DefaultInnodbTrxThresholds
+	}
+
+	var (
+		age    uint64
+		active int
+		oldest uint64
+	)
+	olderThan := make([]int, len(thresholds))
+	for rows.Next() {
+		if err := rows.Scan(&age); err != nil {
+			return err
+		}
+		active++
+		if age > oldest {
+			oldest = age
+		}
+		for i, threshold := range thresholds {
+			if age > uint64(threshold) {
+				olderThan[i]++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(innodbTrxActiveDesc, prometheus.GaugeValue, float64(active))
+	ch <- prometheus.MustNewConstMetric(innodbTrxOldestDesc, prometheus.GaugeValue, float64(oldest))
+	for i, threshold := range thresholds {
+		ch <- prometheus.MustNewConstMetric(
+			innodbTrxOlderThanDesc, prometheus.GaugeValue, float64(olderThan[i]), strconv.Itoa(threshold),
+		)
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbTrx{}
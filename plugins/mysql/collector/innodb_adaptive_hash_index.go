@@ -0,0 +1,198 @@
+// Scrape the InnoDB adaptive hash index searches.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strconv"
+
+	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	innodbAHI = "innodb_ahi"
+	// Scraper name.
+	innodbAdaptiveHashIndex = "innodb_adaptive_hash_index"
+	// Queries.
+	innodbAHIEnabledQuery = `SELECT @@innodb_adaptive_hash_index`
+	// MariaDB and Percona Server.
+	innodbAHIStatusQuery = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Innodb_adaptive_hash_hash_searches', 'Innodb_adaptive_hash_non_hash_searches')`
+	// Only available if the adaptive_hash_index module of innodb_monitor_enable is enabled.
+	innodbAHIMetricsQuery = `SELECT NAME, COUNT FROM information_schema.INNODB_METRICS WHERE NAME IN ('adaptive_hash_searches', 'adaptive_hash_searches_btree') AND STATUS = 'enabled'`
+)
+
+// `0.00 hash searches/s, 0.00 non-hash searches/s` of the INSERT BUFFER AND ADAPTIVE HASH INDEX section.
+var innodbAHISearchesRE = regexp.MustCompile(`(\d+(?:\.\d+)?) hash searches/s, (\d+(?:\.\d+)?) non-hash searches/s`)
+
+// Metric descriptors.
+var (
+	innodbAHIEnabledDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbAHI, "enabled"),
+		"Whether the adaptive hash index is enabled, from @@innodb_adaptive_hash_index.",
+		[]string{}, nil,
+	)
+	innodbAHIHashSearchesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbAHI, "hash_searches_total"),
+		"Number of the searches satisfied by the adaptive hash index.",
+		[]string{}, nil,
+	)
+	innodbAHINonHashSearchesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbAHI, "non_hash_searches_total"),
+		"Number of the searches done with the B-tree because the adaptive hash index could not be used.",
+		[]string{}, nil,
+	)
+	innodbAHIHashSearchesRateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbAHI, "hash_searches_per_second"),
+		"Adaptive hash index searches per second averaged by SHOW ENGINE INNODB STATUS, only if the totals are not exposed.",
+		[]string{}, nil,
+	)
+	innodbAHINonHashSearchesRateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbAHI, "non_hash_searches_per_second"),
+		"B-tree searches per second averaged by SHOW ENGINE INNODB STATUS, only if the totals are not exposed.",
+		[]string{}, nil,
+	)
+	innodbAHIHitRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbAHI, "hit_ratio"),
+		"Hash searches divided by all the searches, a low ratio means the adaptive hash index costs more than it saves.",
+		[]string{}, nil,
+	)
+)
+
+// ScrapeInnodbAdaptiveHashIndex collects the adaptive hash index searches from the status variables of
+// MariaDB and Percona Server, or information_schema.INNODB_METRICS. If neither is available and ParseStatus
+// is set, the per second averages are parsed from SHOW ENGINE INNODB STATUS.
+type ScrapeInnodbAdaptiveHashIndex struct {
+	ParseStatus bool
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbAdaptiveHashIndex) Name() string {
+	return innodbAdaptiveHashIndex
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbAdaptiveHashIndex) Help() string {
+	return "Collect the InnoDB adaptive hash index searches and hit ratio"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbAdaptiveHashIndex) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapeInnodbAdaptiveHashIndex) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var enabled float64
+	if err := db.QueryRowContext(ctx, innodbAHIEnabledQuery).Scan(&enabled); err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(
+		innodbAHIEnabledDesc, prometheus.GaugeValue, enabled,
+	)
+
+	status, err := globalStatusValues(ctx, db, innodbAHIStatusQuery)
+	if err != nil {
+		return err
+	}
+	hash, hashOK := status["innodb_adaptive_hash_hash_searches"]
+	nonHash, nonHashOK := status["innodb_adaptive_hash_non_hash_searches"]
+
+	if !hashOK || !nonHashOK {
+		metrics, err := innodbAHIMetrics(ctx, db)
+		if err != nil {
+			return err
+		}
+		hash, hashOK = metrics["adaptive_hash_searches"]
+		nonHash, nonHashOK = metrics["adaptive_hash_searches_btree"]
+	}
+
+	if hashOK && nonHashOK {
+		ch <- prometheus.MustNewConstMetric(
+			innodbAHIHashSearchesDesc, prometheus.CounterValue, hash,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			innodbAHINonHashSearchesDesc, prometheus.CounterValue, nonHash,
+		)
+		sendAHIHitRatio(hash, nonHash, ch)
+		return nil
+	}
+
+	if !s.ParseStatus {
+		return nil
+	}
+
+	var typeCol, nameCol, statusCol string
+	if err := db.QueryRowContext(ctx, engineInnodbStatusQuery).Scan(&typeCol, &nameCol, &statusCol); err != nil {
+		return err
+	}
+
+	hashRate, nonHashRate, ok := parseInnodbAHISearches(statusCol)
+	if !ok {
+		logger.Warnf("no adaptive hash index searches found in SHOW ENGINE INNODB STATUS")
+		return nil
+	}
+	ch <- prometheus.MustNewConstMetric(
+		innodbAHIHashSearchesRateDesc, prometheus.GaugeValue, hashRate,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		innodbAHINonHashSearchesRateDesc, prometheus.GaugeValue, nonHashRate,
+	)
+	sendAHIHitRatio(hashRate, nonHashRate, ch)
+	return nil
+}
+
+// innodbAHIMetrics returns the enabled adaptive hash index counters of INNODB_METRICS keyed by name
+func innodbAHIMetrics(ctx context.Context, db *sql.DB) (map[string]float64, error) {
+	rows, err := db.QueryContext(ctx, innodbAHIMetricsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var name string
+	var count float64
+	ret := make(map[string]float64)
+	for rows.Next() {
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, err
+		}
+		ret[name] = count
+	}
+	return ret, rows.Err()
+}
+
+// parseInnodbAHISearches finds the hash and non-hash searches per second in the status text,
+// ok is false if the line is missing, e.g. the format of a fork is different
+func parseInnodbAHISearches(status string) (hash, nonHash float64, ok bool) {
+	match := innodbAHISearchesRE.FindStringSubmatch(status)
+	if match == nil {
+		return 0, 0, false
+	}
+
+	hash, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	nonHash, err = strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return hash, nonHash, true
+}
+
+// sendAHIHitRatio is skipped if there was no search at all
+func sendAHIHitRatio(hash, nonHash float64, ch chan<- prometheus.Metric) {
+	if hash+nonHash <= 0 {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(
+		innodbAHIHitRatioDesc, prometheus.GaugeValue, hash/(hash+nonHash),
+	)
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbAdaptiveHashIndex{}
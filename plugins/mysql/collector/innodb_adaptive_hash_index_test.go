@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeInnodbAdaptiveHashIndex(t *testing.T) {
+	convey.Convey("Status variables", t, func() {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("error opening a stub database connection: %s", err)
+		}
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(innodbAHIEnabledQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@innodb_adaptive_hash_index"}).AddRow(1))
+		mock.ExpectQuery(sanitizeQuery(innodbAHIStatusQuery)).WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).
+			AddRow("Innodb_adaptive_hash_hash_searches", "750").
+			AddRow("Innodb_adaptive_hash_non_hash_searches", "250"))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err = (ScrapeInnodbAdaptiveHashIndex{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		expected := []MetricResult{
+			{labels: labelMap{}, value: 1, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 750, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 250, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 0.75, metricType: dto.MetricType_GAUGE},
+		}
+		convey.Convey("Metrics comparison", func() {
+			for _, expect := range expected {
+				got := readMetric(<-ch)
+				convey.So(got, convey.ShouldResemble, expect)
+			}
+			_, ok := <-ch
+			convey.So(ok, convey.ShouldBeFalse)
+		})
+
+		// Ensure all SQL queries were executed
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled exceptions: %s", err)
+		}
+	})
+
+	convey.Convey("SHOW ENGINE INNODB STATUS", t, func() {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("error opening a stub database connection: %s", err)
+		}
+		defer db.Close()
+
+		status := `
+-------------------------------------
+INSERT BUFFER AND ADAPTIVE HASH INDEX
+-------------------------------------
+Ibuf: size 1, free list len 0, seg size 2, 0 merges
+Hash table size 34679, node heap has 2 buffer(s)
+120.50 hash searches/s, 40.50 non-hash searches/s
+`
+		mock.ExpectQuery(sanitizeQuery(innodbAHIEnabledQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@innodb_adaptive_hash_index"}).AddRow(1))
+		mock.ExpectQuery(sanitizeQuery(innodbAHIStatusQuery)).WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}))
+		mock.ExpectQuery(sanitizeQuery(innodbAHIMetricsQuery)).WillReturnRows(sqlmock.NewRows([]string{"NAME", "COUNT"}))
+		mock.ExpectQuery(sanitizeQuery(engineInnodbStatusQuery)).WillReturnRows(sqlmock.NewRows([]string{"Type", "Name", "Status"}).AddRow("InnoDB", "", status))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err = (ScrapeInnodbAdaptiveHashIndex{ParseStatus: true}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		expected := []MetricResult{
+			{labels: labelMap{}, value: 1, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 120.5, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 40.5, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 120.5 / 161, metricType: dto.MetricType_GAUGE},
+		}
+		convey.Convey("Metrics comparison", func() {
+			for _, expect := range expected {
+				got := readMetric(<-ch)
+				convey.So(got, convey.ShouldResemble, expect)
+			}
+			_, ok := <-ch
+			convey.So(ok, convey.ShouldBeFalse)
+		})
+
+		// Ensure all SQL queries were executed
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled exceptions: %s", err)
+		}
+	})
+}
+
+func TestParseInnodbAHISearches(t *testing.T) {
+	convey.Convey("Robust parsing", t, func() {
+		hash, nonHash, ok := parseInnodbAHISearches("0.00 hash searches/s, 0.00 non-hash searches/s")
+		convey.So(ok, convey.ShouldBeTrue)
+		convey.So(hash, convey.ShouldEqual, 0)
+		convey.So(nonHash, convey.ShouldEqual, 0)
+
+		_, _, ok = parseInnodbAHISearches("Hash table size 34679, node heap has 0 buffer(s)")
+		convey.So(ok, convey.ShouldBeFalse)
+
+		_, _, ok = parseInnodbAHISearches("")
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+}
@@ -0,0 +1,133 @@
+// Scrape the InnoDB buffer pool dump and load status.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	innodbBufferPool = "innodb_buffer_pool"
+	// Scraper name.
+	innodbBufferPoolLoad = "innodb_buffer_pool_load"
+	// Query.
+	innodbBufferPoolLoadQuery = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Innodb_buffer_pool_load_status', 'Innodb_buffer_pool_dump_status')`
+)
+
+// Matches the progress of the load, e.g. `Loaded 5121/8192 pages`.
+var bufferPoolLoadedRE = regexp.MustCompile(`(?i)loaded\s+(\d+)\s*/\s*(\d+)\s+pages`)
+
+// Metric descriptors.
+var (
+	innodbBufferPoolLoadStatusDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbBufferPool, "load_status"),
+		"Whether the buffer pool load at startup is completed, from Innodb_buffer_pool_load_status.",
+		[]string{}, nil,
+	)
+	innodbBufferPoolLoadProgressDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbBufferPool, "load_progress_percent"),
+		"Percentage of the pages loaded into the buffer pool, only reported while loading or completed.",
+		[]string{}, nil,
+	)
+	innodbBufferPoolDumpStatusDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbBufferPool, "dump_status"),
+		"Whether the last buffer pool dump is completed, from Innodb_buffer_pool_dump_status.",
+		[]string{}, nil,
+	)
+)
+
+// ScrapeInnodbBufferPoolLoad tells whether a restarted instance has warmed up its buffer pool.
+type ScrapeInnodbBufferPoolLoad struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbBufferPoolLoad) Name() string {
+	return innodbBufferPoolLoad
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbBufferPoolLoad) Help() string {
+	return "Collect the InnoDB buffer pool dump and load status"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbBufferPoolLoad) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeInnodbBufferPoolLoad) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, innodbBufferPoolLoadQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var name, status string
+	for rows.Next() {
+		if err := rows.Scan(&name, &status); err != nil {
+			return err
+		}
+
+		switch strings.ToLower(name) {
+		case "innodb_buffer_pool_load_status":
+			completed, progress, ok := parseBufferPoolLoadStatus(status)
+			if !ok {
+				logger.Warnf("unrecognized Innodb_buffer_pool_load_status: %q", status)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				innodbBufferPoolLoadStatusDesc, prometheus.GaugeValue, completed,
+			)
+			if progress >= 0 {
+				ch <- prometheus.MustNewConstMetric(
+					innodbBufferPoolLoadProgressDesc, prometheus.GaugeValue, progress,
+				)
+			}
+		case "innodb_buffer_pool_dump_status":
+			completed := 0.0
+			if strings.Contains(strings.ToLower(status), "completed") {
+				completed = 1
+			}
+			ch <- prometheus.MustNewConstMetric(
+				innodbBufferPoolDumpStatusDesc, prometheus.GaugeValue, completed,
+			)
+		}
+	}
+	return rows.Err()
+}
+
+// parseBufferPoolLoadStatus parses texts like `Buffer pool(s) load completed at 230101 12:00:00`
+// or `Loaded 5121/8192 pages`. The texts vary between versions, progress is -1 if unknown,
+// ok is false if the text is not recognized at all.
+func parseBufferPoolLoadStatus(status string) (completed, progress float64, ok bool) {
+	lower := strings.ToLower(status)
+	switch {
+	case strings.Contains(lower, "completed"):
+		return 1, 100, true
+	case strings.Contains(lower, "aborted"), strings.Contains(lower, "not started"), strings.TrimSpace(lower) == "":
+		return 0, -1, true
+	}
+
+	if match := bufferPoolLoadedRE.FindStringSubmatch(status); match != nil {
+		loaded, err1 := strconv.ParseFloat(match[1], 64)
+		total, err2 := strconv.ParseFloat(match[2], 64)
+		if err1 == nil && err2 == nil && total > 0 {
+			return 0, loaded / total * 100, true
+		}
+	}
+
+	if strings.Contains(lower, "load") {
+		return 0, -1, true
+	}
+	return 0, -1, false
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbBufferPoolLoad{}
@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseBufferPoolLoadStatus(t *testing.T) {
+	tests := []struct {
+		status    string
+		completed float64
+		progress  float64
+		ok        bool
+	}{
+		{"Buffer pool(s) load completed at 230101 12:00:00", 1, 100, true},
+		{"Loaded 2048/8192 pages", 0, 25, true},
+		{"Buffer pool(s) load aborted on request", 0, -1, true},
+		{"Buffer pool(s) load not started", 0, -1, true},
+		{"Loading buffer pool(s) from /var/lib/mysql/ib_buffer_pool", 0, -1, true},
+		{"", 0, -1, true},
+		{"something else", 0, -1, false},
+	}
+
+	for _, test := range tests {
+		completed, progress, ok := parseBufferPoolLoadStatus(test.status)
+		if completed != test.completed || progress != test.progress || ok != test.ok {
+			t.Errorf("parseBufferPoolLoadStatus(%q) = %v, %v, %v, want %v, %v, %v", test.status, completed, progress, ok, test.completed, test.progress, test.ok)
+		}
+	}
+}
+
+func TestScrapeInnodbBufferPoolLoad(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(innodbBufferPoolLoadQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"Variable_name", "Value"}).
+			AddRow("Innodb_buffer_pool_dump_status", "Dumping of buffer pool not started").
+			AddRow("Innodb_buffer_pool_load_status", "Loaded 6144/8192 pages"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeInnodbBufferPoolLoad{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 75, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
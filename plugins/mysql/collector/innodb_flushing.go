@@ -0,0 +1,98 @@
+// Scrape the InnoDB dirty pages and page flushing from `SHOW GLOBAL STATUS` and `innodb_max_dirty_pages_pct`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scraper name.
+	innodbFlushing = "innodb_flushing"
+	// Queries.
+	innodbFlushingStatusQuery   = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Innodb_buffer_pool_pages_dirty', 'Innodb_buffer_pool_pages_total', 'Innodb_buffer_pool_pages_flushed')`
+	innodbFlushingVariableQuery = `SELECT @@innodb_max_dirty_pages_pct`
+)
+
+// Metric descriptors.
+var (
+	innodbBufferPoolDirtyPagesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbBufferPool, "dirty_pages"),
+		"Number of the dirty pages in the buffer pool, from Innodb_buffer_pool_pages_dirty.",
+		[]string{}, nil,
+	)
+	innodbBufferPoolTotalPagesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbBufferPool, "total_pages"),
+		"Total number of the pages in the buffer pool, from Innodb_buffer_pool_pages_total.",
+		[]string{}, nil,
+	)
+	innodbBufferPoolDirtyPagesRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbBufferPool, "dirty_pages_ratio"),
+		"Ratio of the dirty pages to the total pages in the buffer pool. A high ratio with a low flush rate predicts a checkpoint storm.",
+		[]string{}, nil,
+	)
+	innodbBufferPoolPagesFlushedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbBufferPool, "pages_flushed_total"),
+		"Number of the pages flushed from the buffer pool, from Innodb_buffer_pool_pages_flushed.",
+		[]string{}, nil,
+	)
+	innodbMaxDirtyPagesPctDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "innodb", "max_dirty_pages_pct"),
+		"Value of innodb_max_dirty_pages_pct, the percentage of the dirty pages InnoDB flushes to stay under.",
+		[]string{}, nil,
+	)
+)
+
+// ScrapeInnodbFlushing collects the dirty page ratio and the flushed pages of the buffer pool.
+type ScrapeInnodbFlushing struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbFlushing) Name() string {
+	return innodbFlushing
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbFlushing) Help() string {
+	return "Collect the InnoDB dirty page ratio, the flushed pages and innodb_max_dirty_pages_pct"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbFlushing) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeInnodbFlushing) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	values, err := globalStatusValues(ctx, db, innodbFlushingStatusQuery)
+	if err != nil {
+		return err
+	}
+
+	dirty, hasDirty := values["innodb_buffer_pool_pages_dirty"]
+	total, hasTotal := values["innodb_buffer_pool_pages_total"]
+	if hasDirty {
+		ch <- prometheus.MustNewConstMetric(innodbBufferPoolDirtyPagesDesc, prometheus.GaugeValue, dirty)
+	}
+	if hasTotal {
+		ch <- prometheus.MustNewConstMetric(innodbBufferPoolTotalPagesDesc, prometheus.GaugeValue, total)
+	}
+	if hasDirty && hasTotal && total > 0 {
+		ch <- prometheus.MustNewConstMetric(innodbBufferPoolDirtyPagesRatioDesc, prometheus.GaugeValue, dirty/total)
+	}
+	if flushed, ok := values["innodb_buffer_pool_pages_flushed"]; ok {
+		ch <- prometheus.MustNewConstMetric(innodbBufferPoolPagesFlushedDesc, prometheus.CounterValue, flushed)
+	}
+
+	var maxDirtyPagesPct float64
+	if err := db.QueryRowContext(ctx, innodbFlushingVariableQuery).Scan(&maxDirtyPagesPct); err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(innodbMaxDirtyPagesPctDesc, prometheus.GaugeValue, maxDirtyPagesPct)
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbFlushing{}
@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeInnodbFlushing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"Variable_name", "Value"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("Innodb_buffer_pool_pages_dirty", "2048").
+		AddRow("Innodb_buffer_pool_pages_flushed", "123456").
+		AddRow("Innodb_buffer_pool_pages_total", "8192")
+	mock.ExpectQuery(regexp.QuoteMeta(innodbFlushingStatusQuery)).WillReturnRows(rows)
+	mock.ExpectQuery(sanitizeQuery(innodbFlushingVariableQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"@@innodb_max_dirty_pages_pct"}).AddRow("90.000000"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeInnodbFlushing{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, value: 2048, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 8192, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 0.25, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 123456, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{}, value: 90, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,129 @@
+// Scrape the InnoDB lock waits from `performance_schema.data_lock_waits` (8.0)
+// or `information_schema.innodb_lock_waits` (5.7 and MariaDB).
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	innodbLockWaits = "innodb_lock_waits"
+	// Default number of the blocking relationships emitted by detail.
+	defaultInnodbLockWaitsDetailLimit = 20
+	// Queries, information_schema.innodb_lock_waits is removed in 8.0.
+	innodbLockWaitsCountQuery   = `SELECT COUNT(*) FROM performance_schema.data_lock_waits`
+	innodbLockWaitsCountQuery57 = `SELECT COUNT(*) FROM information_schema.innodb_lock_waits`
+	innodbLockWaitsDetailQuery  = `
+		SELECT COALESCE(rt.PROCESSLIST_ID, w.REQUESTING_THREAD_ID),
+		       COALESCE(bt.PROCESSLIST_ID, w.BLOCKING_THREAD_ID),
+		       CONCAT(l.OBJECT_SCHEMA, '.', l.OBJECT_NAME)
+		  FROM performance_schema.data_lock_waits w
+		  JOIN performance_schema.data_locks l ON l.ENGINE_LOCK_ID = w.REQUESTING_ENGINE_LOCK_ID
+		  LEFT JOIN performance_schema.threads rt ON rt.THREAD_ID = w.REQUESTING_THREAD_ID
+		  LEFT JOIN performance_schema.threads bt ON bt.THREAD_ID = w.BLOCKING_THREAD_ID
+		  LIMIT ?
+		`
+	innodbLockWaitsDetailQuery57 = `
+		SELECT r.trx_mysql_thread_id, b.trx_mysql_thread_id, l.lock_table
+		  FROM information_schema.innodb_lock_waits w
+		  JOIN information_schema.innodb_trx r ON r.trx_id = w.requesting_trx_id
+		  JOIN information_schema.innodb_trx b ON b.trx_id = w.blocking_trx_id
+		  JOIN information_schema.innodb_locks l ON l.lock_id = w.requested_lock_id
+		  LIMIT ?
+		`
+)
+
+// Metric descriptors.
+var (
+	innodbLockWaitsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", innodbLockWaits),
+		"Number of InnoDB lock requests waiting for a lock held by another transaction.",
+		[]string{}, nil,
+	)
+	innodbLockWaitInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "innodb_lock_wait_info"),
+		"A thread waiting for an InnoDB lock held by the blocking thread, the thread ids are the processlist ids.",
+		[]string{"waiting_thread_id", "blocking_thread_id", "locked_table"}, nil,
+	)
+)
+
+// ScrapeInnodbLockWaits collects the number of InnoDB lock waits and optionally the blocking relationships.
+type ScrapeInnodbLockWaits struct {
+	// Detail emits mysql_innodb_lock_wait_info for each lock wait
+	Detail bool
+	// DetailLimit bounds the number of mysql_innodb_lock_wait_info series
+	DetailLimit int
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbLockWaits) Name() string {
+	return innodbLockWaits
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbLockWaits) Help() string {
+	return "Collect the number of InnoDB lock waits and the blocking relationships"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbLockWaits) Version() float64 {
+	return 5.5
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapeInnodbLockWaits) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	version, err := getMySQLVersion(ctx, db, versionQuery)
+	if err != nil {
+		return err
+	}
+
+	// MariaDB 10+ still has the information_schema tables.
+	countQuery, detailQuery := innodbLockWaitsCountQuery57, innodbLockWaitsDetailQuery57
+	if version >= 8.0 && version < 10 {
+		countQuery, detailQuery = innodbLockWaitsCountQuery, innodbLockWaitsDetailQuery
+	}
+
+	var waits uint64
+	if err := db.QueryRowContext(ctx, countQuery).Scan(&waits); err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(innodbLockWaitsDesc, prometheus.GaugeValue, float64(waits))
+
+	if !s.Detail || waits == 0 {
+		return nil
+	}
+
+	limit := s.DetailLimit
+	if limit <= 0 {
+		limit = defaultInnodbLockWaitsDetailLimit
+	}
+	rows, err := db.QueryContext(ctx, detailQuery, limit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		waiting, blocking uint64
+		table             sql.NullString
+	)
+	for rows.Next() {
+		if err := rows.Scan(&waiting, &blocking, &table); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			innodbLockWaitInfoDesc, prometheus.GaugeValue, 1,
+			strconv.FormatUint(waiting, 10), strconv.FormatUint(blocking, 10), table.String,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbLockWaits{}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeInnodbLockWaits(t *testing.T) {
+	convey.Convey("MySQL 8.0 with detail", t, func() {
+		db, mock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(versionQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@version"}).AddRow("8.0.35"))
+		mock.ExpectQuery(sanitizeQuery(innodbLockWaitsCountQuery)).WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(2))
+		mock.ExpectQuery(strings.ReplaceAll(sanitizeQuery(innodbLockWaitsDetailQuery), "?", `\?`)).WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"waiting", "blocking", "table"}).
+				AddRow(12, 10, "app.orders").
+				AddRow(13, 10, "app.orders"))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapeInnodbLockWaits{Detail: true, DetailLimit: 5}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		expected := []MetricResult{
+			{labels: labelMap{}, value: 2, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"waiting_thread_id": "12", "blocking_thread_id": "10", "locked_table": "app.orders"}, value: 1, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"waiting_thread_id": "13", "blocking_thread_id": "10", "locked_table": "app.orders"}, value: 1, metricType: dto.MetricType_GAUGE},
+		}
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+
+	convey.Convey("MySQL 5.7 count only", t, func() {
+		db, mock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(versionQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@version"}).AddRow("5.7.44-log"))
+		mock.ExpectQuery(sanitizeQuery(innodbLockWaitsCountQuery57)).WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(3))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapeInnodbLockWaits{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		got := readMetric(<-ch)
+		convey.So(got, convey.ShouldResemble, MetricResult{labels: labelMap{}, value: 3, metricType: dto.MetricType_GAUGE})
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+}
@@ -0,0 +1,178 @@
+// Scrape InnoDB redo log and checkpoint metrics.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	innodbRedoLog = "innodb_redo_log"
+	// Queries.
+	innodbRedoLogStatusQuery    = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Innodb_os_log_written', 'Innodb_log_waits', 'Innodb_log_writes', 'Innodb_os_log_fsyncs', 'Innodb_redo_log_current_lsn', 'Innodb_redo_log_checkpoint_lsn')`
+	innodbRedoLogVariablesQuery = `SHOW GLOBAL VARIABLES WHERE Variable_name IN ('innodb_redo_log_capacity', 'innodb_log_file_size', 'innodb_log_files_in_group')`
+	// The checkpoint age before 8.0.30, only available if the log module of innodb_monitor_enable is enabled.
+	innodbRedoLogCheckpointAgeQuery = `SELECT COUNT FROM information_schema.INNODB_METRICS WHERE NAME = 'log_lsn_checkpoint_age' AND STATUS = 'enabled'`
+	innodbRedoLogFilesTableQuery    = `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'performance_schema' AND table_name = 'innodb_redo_log_files'`
+	innodbRedoLogFilesQuery         = `SELECT COUNT(*), IFNULL(SUM(SIZE_IN_BYTES), 0), IFNULL(SUM(IS_FULL), 0) FROM performance_schema.innodb_redo_log_files`
+)
+
+// Metric descriptors.
+var (
+	innodbRedoLogWrittenDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbRedoLog, "written_bytes_total"),
+		"Number of bytes written to the redo log files, from Innodb_os_log_written.",
+		[]string{}, nil,
+	)
+	innodbRedoLogWritesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbRedoLog, "writes_total"),
+		"Number of physical writes to the redo log files, from Innodb_log_writes.",
+		[]string{}, nil,
+	)
+	innodbRedoLogFsyncsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbRedoLog, "fsyncs_total"),
+		"Number of fsync() writes done to the redo log files, from Innodb_os_log_fsyncs.",
+		[]string{}, nil,
+	)
+	innodbRedoLogWaitsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbRedoLog, "waits_total"),
+		"Number of times the log buffer was too small and a wait was required for it to be flushed, from Innodb_log_waits.",
+		[]string{}, nil,
+	)
+	innodbRedoLogCapacityDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbRedoLog, "capacity_bytes"),
+		"Total size of the redo log, @@innodb_redo_log_capacity or @@innodb_log_file_size * @@innodb_log_files_in_group.",
+		[]string{}, nil,
+	)
+	innodbRedoLogCheckpointAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbRedoLog, "checkpoint_age_bytes"),
+		"Number of bytes of redo log written since the last checkpoint.",
+		[]string{}, nil,
+	)
+	innodbRedoLogUsedRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbRedoLog, "used_ratio"),
+		"Checkpoint age divided by the redo log capacity, InnoDB flushes aggressively and stalls writes when it gets close to 1.",
+		[]string{}, nil,
+	)
+	innodbRedoLogFilesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbRedoLog, "files"),
+		"Number of redo log files in performance_schema.innodb_redo_log_files.",
+		[]string{}, nil,
+	)
+	innodbRedoLogFilesFullDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbRedoLog, "files_full"),
+		"Number of full redo log files in performance_schema.innodb_redo_log_files.",
+		[]string{}, nil,
+	)
+	innodbRedoLogFilesSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbRedoLog, "files_size_bytes"),
+		"Total size of the redo log files in performance_schema.innodb_redo_log_files.",
+		[]string{}, nil,
+	)
+)
+
+// ScrapeInnodbRedoLog collects the redo log space usage, checkpoint age and flush rate.
+type ScrapeInnodbRedoLog struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbRedoLog) Name() string {
+	return innodbRedoLog
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbRedoLog) Help() string {
+	return "Collect InnoDB redo log and checkpoint metrics"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbRedoLog) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeInnodbRedoLog) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	status, err := globalStatusValues(ctx, db, innodbRedoLogStatusQuery)
+	if err != nil {
+		return err
+	}
+
+	counters := []struct {
+		name string
+		desc *prometheus.Desc
+	}{
+		{"innodb_os_log_written", innodbRedoLogWrittenDesc},
+		{"innodb_log_writes", innodbRedoLogWritesDesc},
+		{"innodb_os_log_fsyncs", innodbRedoLogFsyncsDesc},
+		{"innodb_log_waits", innodbRedoLogWaitsDesc},
+	}
+	for _, c := range counters {
+		if v, ok := status[c.name]; ok {
+			ch <- prometheus.MustNewConstMetric(c.desc, prometheus.CounterValue, v)
+		}
+	}
+
+	variables, err := globalStatusValues(ctx, db, innodbRedoLogVariablesQuery)
+	if err != nil {
+		return err
+	}
+
+	// innodb_redo_log_capacity supersedes the others since 8.0.30.
+	capacity, ok := variables["innodb_redo_log_capacity"]
+	if !ok {
+		capacity = variables["innodb_log_file_size"] * variables["innodb_log_files_in_group"]
+	}
+	if capacity > 0 {
+		ch <- prometheus.MustNewConstMetric(innodbRedoLogCapacityDesc, prometheus.GaugeValue, capacity)
+	}
+
+	currentLSN, hasCurrent := status["innodb_redo_log_current_lsn"]
+	checkpointLSN, hasCheckpoint := status["innodb_redo_log_checkpoint_lsn"]
+	checkpointAge, hasAge := currentLSN-checkpointLSN, hasCurrent && hasCheckpoint
+	if !hasAge {
+		err := db.QueryRowContext(ctx, innodbRedoLogCheckpointAgeQuery).Scan(&checkpointAge)
+		switch {
+		case err == sql.ErrNoRows:
+		case err != nil:
+			return err
+		default:
+			hasAge = true
+		}
+	}
+	if hasAge {
+		ch <- prometheus.MustNewConstMetric(innodbRedoLogCheckpointAgeDesc, prometheus.GaugeValue, checkpointAge)
+		if capacity > 0 {
+			ch <- prometheus.MustNewConstMetric(innodbRedoLogUsedRatioDesc, prometheus.GaugeValue, checkpointAge/capacity)
+		}
+	}
+
+	// performance_schema.innodb_redo_log_files is available since 8.0.30.
+	version, err := getMySQLVersion(ctx, db, versionQuery)
+	if err != nil || version < 8.0 {
+		return nil
+	}
+
+	var tables int
+	if err := db.QueryRowContext(ctx, innodbRedoLogFilesTableQuery).Scan(&tables); err != nil {
+		return err
+	}
+	if tables == 0 {
+		return nil
+	}
+
+	var files, size, full float64
+	if err := db.QueryRowContext(ctx, innodbRedoLogFilesQuery).Scan(&files, &size, &full); err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(innodbRedoLogFilesDesc, prometheus.GaugeValue, files)
+	ch <- prometheus.MustNewConstMetric(innodbRedoLogFilesFullDesc, prometheus.GaugeValue, full)
+	ch <- prometheus.MustNewConstMetric(innodbRedoLogFilesSizeDesc, prometheus.GaugeValue, size)
+
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbRedoLog{}
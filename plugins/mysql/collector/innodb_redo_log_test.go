@@ -0,0 +1,114 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeInnodbRedoLog(t *testing.T) {
+	convey.Convey("MySQL 8.0.30+", t, func() {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("error opening a stub database connection: %s", err)
+		}
+		defer db.Close()
+
+		columns := []string{"Variable_name", "Value"}
+		mock.ExpectQuery(sanitizeQuery(innodbRedoLogStatusQuery)).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("Innodb_log_waits", "3").
+			AddRow("Innodb_log_writes", "500").
+			AddRow("Innodb_os_log_fsyncs", "400").
+			AddRow("Innodb_os_log_written", "1048576").
+			AddRow("Innodb_redo_log_checkpoint_lsn", "1000000").
+			AddRow("Innodb_redo_log_current_lsn", "26165824"))
+		mock.ExpectQuery(sanitizeQuery(innodbRedoLogVariablesQuery)).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("innodb_log_file_size", "50331648").
+			AddRow("innodb_log_files_in_group", "2").
+			AddRow("innodb_redo_log_capacity", "104857600"))
+		mock.ExpectQuery(sanitizeQuery(versionQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@version"}).AddRow("8.0.35"))
+		mock.ExpectQuery(sanitizeQuery(innodbRedoLogFilesTableQuery)).WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+		mock.ExpectQuery(sanitizeQuery(innodbRedoLogFilesQuery)).WillReturnRows(sqlmock.NewRows([]string{"files", "size", "full"}).AddRow(32, 104857600, 2))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err = (ScrapeInnodbRedoLog{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		expected := []MetricResult{
+			{labels: labelMap{}, value: 1048576, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 500, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 400, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 3, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 104857600, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 25165824, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 0.24, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 32, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 2, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 104857600, metricType: dto.MetricType_GAUGE},
+		}
+		convey.Convey("Metrics comparison", func() {
+			for _, expect := range expected {
+				got := readMetric(<-ch)
+				convey.So(got, convey.ShouldResemble, expect)
+			}
+		})
+
+		// Ensure all SQL queries were executed
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled exceptions: %s", err)
+		}
+	})
+
+	convey.Convey("MySQL 5.7", t, func() {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("error opening a stub database connection: %s", err)
+		}
+		defer db.Close()
+
+		columns := []string{"Variable_name", "Value"}
+		mock.ExpectQuery(sanitizeQuery(innodbRedoLogStatusQuery)).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("Innodb_log_waits", "0").
+			AddRow("Innodb_os_log_written", "2048"))
+		mock.ExpectQuery(sanitizeQuery(innodbRedoLogVariablesQuery)).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("innodb_log_file_size", "50331648").
+			AddRow("innodb_log_files_in_group", "2"))
+		mock.ExpectQuery(sanitizeQuery(innodbRedoLogCheckpointAgeQuery)).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}))
+		mock.ExpectQuery(sanitizeQuery(versionQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@version"}).AddRow("5.7.44-log"))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err = (ScrapeInnodbRedoLog{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		expected := []MetricResult{
+			{labels: labelMap{}, value: 2048, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 0, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 100663296, metricType: dto.MetricType_GAUGE},
+		}
+		convey.Convey("Metrics comparison", func() {
+			for _, expect := range expected {
+				got := readMetric(<-ch)
+				convey.So(got, convey.ShouldResemble, expect)
+			}
+			_, ok := <-ch
+			convey.So(ok, convey.ShouldBeFalse)
+		})
+
+		// Ensure all SQL queries were executed
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled exceptions: %s", err)
+		}
+	})
+}
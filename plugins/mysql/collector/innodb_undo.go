@@ -0,0 +1,138 @@
+// Scrape the InnoDB undo tablespaces and the purge lag.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	innodbUndo = "innodb_undo"
+	// Queries.
+	// The undo tablespaces are innodb_undo_001 and innodb_undo_002 by default, more can be created and dropped
+	// at runtime with any name by CREATE UNDO TABLESPACE, so they are listed on every scrape.
+	innodbUndoTablespacesQuery = `SELECT NAME, STATE, FILE_SIZE, ALLOCATED_SIZE FROM information_schema.INNODB_TABLESPACES WHERE SPACE_TYPE = 'Undo'`
+	innodbUndoHistoryQuery     = `SELECT COUNT FROM information_schema.INNODB_METRICS WHERE NAME = 'trx_rseg_history_len'`
+	innodbUndoMaxPurgeLagQuery = `SELECT @@innodb_max_purge_lag`
+)
+
+// Metric descriptors.
+var (
+	innodbUndoSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbUndo, "tablespace_size_bytes"),
+		"Apparent size of the undo tablespace file, from information_schema.INNODB_TABLESPACES.FILE_SIZE.",
+		[]string{"tablespace"}, nil,
+	)
+	innodbUndoAllocatedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbUndo, "tablespace_allocated_bytes"),
+		"Actual size of the undo tablespace file on disk, from information_schema.INNODB_TABLESPACES.ALLOCATED_SIZE.",
+		[]string{"tablespace"}, nil,
+	)
+	innodbUndoActiveDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbUndo, "tablespace_active"),
+		"Whether the undo tablespace is active, 0 while it is inactive or empty, e.g. being truncated.",
+		[]string{"tablespace"}, nil,
+	)
+	innodbUndoHistoryDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbUndo, "history_list_length"),
+		"Number of the undo log units not purged yet, it keeps growing while the purge lags behind.",
+		[]string{}, nil,
+	)
+	innodbUndoMaxPurgeLagDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, innodbUndo, "max_purge_lag"),
+		"The history list length from which the DML is delayed, from @@innodb_max_purge_lag, 0 means no limit.",
+		[]string{}, nil,
+	)
+)
+
+// ScrapeInnodbUndo collects the size of the undo tablespaces and the history list length, together with
+// mysql_innodb_oldest_transaction_seconds of info_schema.innodb_trx it tells which transaction blocks the purge.
+type ScrapeInnodbUndo struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeInnodbUndo) Name() string {
+	return innodbUndo
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeInnodbUndo) Help() string {
+	return "Collect the size of the InnoDB undo tablespaces and the purge lag"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeInnodbUndo) Version() float64 {
+	return 8.0
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeInnodbUndo) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	if err := scrapeInnodbUndoTablespaces(ctx, db, ch); err != nil {
+		return err
+	}
+
+	var history float64
+	err := db.QueryRowContext(ctx, innodbUndoHistoryQuery).Scan(&history)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return err
+	default:
+		ch <- prometheus.MustNewConstMetric(
+			innodbUndoHistoryDesc, prometheus.GaugeValue, history,
+		)
+	}
+
+	var maxPurgeLag float64
+	if err := db.QueryRowContext(ctx, innodbUndoMaxPurgeLagQuery).Scan(&maxPurgeLag); err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(
+		innodbUndoMaxPurgeLagDesc, prometheus.GaugeValue, maxPurgeLag,
+	)
+	return nil
+}
+
+// scrapeInnodbUndoTablespaces skips the servers without SPACE_TYPE of INNODB_TABLESPACES, i.e. before 8.0.14 or the forks
+func scrapeInnodbUndoTablespaces(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, innodbUndoTablespacesQuery)
+	if err != nil {
+		// ER_BAD_FIELD_ERROR, ER_UNKNOWN_TABLE
+		if isMySQLError(err, 1054) || isMySQLError(err, 1109) {
+			return nil
+		}
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		name, state     string
+		size, allocated float64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&name, &state, &size, &allocated); err != nil {
+			return err
+		}
+
+		active := 0.0
+		if state == "active" {
+			active = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			innodbUndoSizeDesc, prometheus.GaugeValue, size, name,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			innodbUndoAllocatedDesc, prometheus.GaugeValue, allocated, name,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			innodbUndoActiveDesc, prometheus.GaugeValue, active, name,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeInnodbUndo{}
@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	MySQL "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeInnodbUndo(t *testing.T) {
+	convey.Convey("Undo tablespaces", t, func() {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("error opening a stub database connection: %s", err)
+		}
+		defer db.Close()
+
+		columns := []string{"NAME", "STATE", "FILE_SIZE", "ALLOCATED_SIZE"}
+		mock.ExpectQuery(sanitizeQuery(innodbUndoTablespacesQuery)).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("innodb_undo_001", "active", 16777216, 16777216).
+			AddRow("undo_003", "empty", 1048576, 0))
+		mock.ExpectQuery(sanitizeQuery(innodbUndoHistoryQuery)).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(1200))
+		mock.ExpectQuery(sanitizeQuery(innodbUndoMaxPurgeLagQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@innodb_max_purge_lag"}).AddRow(0))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err = (ScrapeInnodbUndo{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		expected := []MetricResult{
+			{labels: labelMap{"tablespace": "innodb_undo_001"}, value: 16777216, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"tablespace": "innodb_undo_001"}, value: 16777216, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"tablespace": "innodb_undo_001"}, value: 1, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"tablespace": "undo_003"}, value: 1048576, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"tablespace": "undo_003"}, value: 0, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"tablespace": "undo_003"}, value: 0, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 1200, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 0, metricType: dto.MetricType_GAUGE},
+		}
+		convey.Convey("Metrics comparison", func() {
+			for _, expect := range expected {
+				got := readMetric(<-ch)
+				convey.So(got, convey.ShouldResemble, expect)
+			}
+			_, ok := <-ch
+			convey.So(ok, convey.ShouldBeFalse)
+		})
+
+		// Ensure all SQL queries were executed
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled exceptions: %s", err)
+		}
+	})
+
+	convey.Convey("No SPACE_TYPE column", t, func() {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("error opening a stub database connection: %s", err)
+		}
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(innodbUndoTablespacesQuery)).WillReturnError(&MySQL.MySQLError{Number: 1054, Message: "Unknown column 'SPACE_TYPE' in 'where clause'"})
+		mock.ExpectQuery(sanitizeQuery(innodbUndoHistoryQuery)).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(5))
+		mock.ExpectQuery(sanitizeQuery(innodbUndoMaxPurgeLagQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@innodb_max_purge_lag"}).AddRow(1000000))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err = (ScrapeInnodbUndo{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		expected := []MetricResult{
+			{labels: labelMap{}, value: 5, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 1000000, metricType: dto.MetricType_GAUGE},
+		}
+		convey.Convey("Metrics comparison", func() {
+			for _, expect := range expected {
+				got := readMetric(<-ch)
+				convey.So(got, convey.ShouldResemble, expect)
+			}
+			_, ok := <-ch
+			convey.So(ok, convey.ShouldBeFalse)
+		})
+
+		// Ensure all SQL queries were executed
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled exceptions: %s", err)
+		}
+	})
+}
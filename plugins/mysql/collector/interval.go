@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cprobe/cprobe/lib/decimal"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// IntervalScraper is implemented by the slow-moving scrapers which don't need to run
+// on every scrape, the metrics of the last run are reported again until Interval has passed.
+type IntervalScraper interface {
+	Scraper
+
+	// Interval between two runs of the scraper for a target, 0 means every scrape.
+	Interval() time.Duration
+}
+
+// Policies for the scrapes a scraper is skipped on.
+const (
+	// SkippedHold reports the metrics of the last run again
+	SkippedHold = "hold"
+	// SkippedDrop reports nothing
+	SkippedDrop = "drop"
+	// SkippedStale reports the metrics of the last run with staleness markers once, then nothing
+	SkippedStale = "stale"
+)
+
+// every scrapes are not tied to a duration, their results are dropped if the target is not scraped for this long
+const scrapeEveryResultTTL = time.Hour
+
+type intervalResult struct {
+	at       time.Time
+	seen     time.Time
+	interval time.Duration
+	skipped  int
+	metrics  []prometheus.Metric
+}
+
+// intervalResults is keyed by target, scraper name and scraper settings,
+// so the jobs scraping the same target with different settings don't share the result
+var intervalResults = struct {
+	sync.Mutex
+	m map[string]intervalResult
+}{m: make(map[string]intervalResult)}
+
+// scrapeWithInterval runs the scraper only if its interval has passed since the last successful run,
+// or every Options.ScrapeEvery scrapes, on the other scrapes the metrics of the last run are handled by
+// Options.SkippedPolicy
+func (e *Exporter) scrapeWithInterval(ctx context.Context, db *sql.DB, scraper Scraper, label string, ch chan<- prometheus.Metric) error {
+	var interval time.Duration
+	if is, ok := scraper.(IntervalScraper); ok {
+		interval = is.Interval()
+	}
+	every := e.opts.ScrapeEvery[scraper.Name()]
+	if every <= 1 && interval <= 0 {
+		return e.scrapeWithLimit(ctx, db, scraper, label, ch)
+	}
+
+	// keyed by the address, the server label is only for display and may be shared by the targets
+	key := fmt.Sprintf("%s/%s/%+v", dsnAddr(e.dsn), scraper.Name(), scraper)
+
+	intervalResults.Lock()
+	last, ok := intervalResults.m[key]
+	if ok {
+		skip := time.Since(last.at) < interval
+		if every > 1 {
+			skip = last.skipped+1 < every
+		}
+		if skip {
+			last.skipped++
+			last.seen = time.Now()
+			intervalResults.m[key] = last
+			intervalResults.Unlock()
+			e.sendSkipped(last, ch)
+			return nil
+		}
+	}
+	intervalResults.Unlock()
+
+	bufCh := make(chan prometheus.Metric)
+	metricsCh := make(chan []prometheus.Metric)
+	go func() {
+		var metrics []prometheus.Metric
+		for m := range bufCh {
+			metrics = append(metrics, m)
+			ch <- m
+		}
+		metricsCh <- metrics
+	}()
+
+	err := e.scrapeWithLimit(ctx, db, scraper, label, bufCh)
+	close(bufCh)
+	metrics := <-metricsCh
+	if err != nil {
+		return err
+	}
+
+	if every > 1 {
+		interval = scrapeEveryResultTTL
+	}
+
+	now := time.Now()
+	intervalResults.Lock()
+	defer intervalResults.Unlock()
+	// drop the results of the targets which are not scraped anymore
+	for k, r := range intervalResults.m {
+		if now.Sub(r.seen) > 2*r.interval {
+			delete(intervalResults.m, k)
+		}
+	}
+	intervalResults.m[key] = intervalResult{at: now, seen: now, interval: interval, metrics: metrics}
+
+	return nil
+}
+
+// sendSkipped reports the metrics of the last run according to Options.SkippedPolicy
+func (e *Exporter) sendSkipped(last intervalResult, ch chan<- prometheus.Metric) {
+	switch e.opts.SkippedPolicy {
+	case SkippedDrop:
+		return
+	case SkippedStale:
+		if last.skipped > 1 {
+			return
+		}
+		for _, m := range last.metrics {
+			ch <- staleMetric{m}
+		}
+	default:
+		for _, m := range last.metrics {
+			ch <- m
+		}
+	}
+}
+
+// staleMetric replaces the value of the metric with a staleness marker,
+// so the series of a skipped scraper end at once instead of fading out after 5 minutes
+type staleMetric struct {
+	prometheus.Metric
+}
+
+func (m staleMetric) Write(pb *dto.Metric) error {
+	if err := m.Metric.Write(pb); err != nil {
+		return err
+	}
+	v := decimal.StaleNaN
+	switch {
+	case pb.Gauge != nil:
+		pb.Gauge.Value = &v
+	case pb.Counter != nil:
+		pb.Counter.Value = &v
+	case pb.Untyped != nil:
+		pb.Untyped.Value = &v
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+)
+
+// defaultIsolatedMaxOpenConns is the number of dedicated connections open at the same time
+// in the Options.IsolateConnections mode if Options.MaxOpenConns is not set
+const defaultIsolatedMaxOpenConns = 4
+
+// isolatedConns hands out a dedicated connection to every scraper and custom query, so that the session
+// state set by one of them does not leak into the others and they really run concurrently.
+// At most Options.MaxOpenConns of them are open at the same time, the others wait for a free slot.
+type isolatedConns struct {
+	e   *Exporter
+	sem chan struct{}
+}
+
+// newIsolatedConns returns nil unless Options.IsolateConnections is set, the shared connection is used then
+func newIsolatedConns(e *Exporter) *isolatedConns {
+	if !e.opts.IsolateConnections {
+		return nil
+	}
+
+	size := e.opts.MaxOpenConns
+	if size <= 0 {
+		size = defaultIsolatedMaxOpenConns
+	}
+	return &isolatedConns{e: e, sem: make(chan struct{}, size)}
+}
+
+// get returns a dedicated connection to the scraped host and the func to release it,
+// or the shared db if the isolation mode is off
+func (c *isolatedConns) get(ctx context.Context, shared *sql.DB) (*sql.DB, func(), error) {
+	if c == nil {
+		return shared, func() {}, nil
+	}
+
+	select {
+	case c.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+
+	db, err := c.e.openAndPing(ctx, c.e.connectedDSN(), false)
+	if err != nil {
+		<-c.sem
+		return nil, nil, err
+	}
+
+	return db, func() {
+		db.Close()
+		<-c.sem
+	}, nil
+}
@@ -0,0 +1,45 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestIsolatedConns(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	convey.Convey("Shared connection by default", t, func() {
+		conns := newIsolatedConns(&Exporter{})
+		convey.So(conns, convey.ShouldBeNil)
+
+		got, release, err := conns.get(context.Background(), db)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(got, convey.ShouldEqual, db)
+		release()
+	})
+
+	convey.Convey("Pool size", t, func() {
+		conns := newIsolatedConns(&Exporter{opts: Options{IsolateConnections: true}})
+		convey.So(cap(conns.sem), convey.ShouldEqual, defaultIsolatedMaxOpenConns)
+
+		conns = newIsolatedConns(&Exporter{opts: Options{IsolateConnections: true, MaxOpenConns: 2}})
+		convey.So(cap(conns.sem), convey.ShouldEqual, 2)
+	})
+
+	convey.Convey("Waiting for a free slot is canceled with the scrape", t, func() {
+		conns := newIsolatedConns(&Exporter{opts: Options{IsolateConnections: true, MaxOpenConns: 1}})
+		conns.sem <- struct{}{}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, _, err := conns.get(ctx, db)
+		convey.So(err, convey.ShouldEqual, context.Canceled)
+	})
+}
@@ -0,0 +1,144 @@
+// Scrape the full table scan and join counters from `SHOW GLOBAL STATUS`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	optimizer = "optimizer"
+	// Queries. Uptime is the time base of the rates, which is reset with the counters.
+	// @@server_uuid is not available on MariaDB, the states are keyed by the host name and the port like slowQueriesStates.
+	optimizerStatusQuery = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Select_scan', 'Select_full_join', 'Select_range_check', 'Handler_read_rnd_next', 'Uptime')`
+	optimizerServerQuery = `SELECT CONCAT(@@hostname, ':', @@port)`
+	// the counters of the servers not scraped for this long are dropped
+	optimizerTTL = time.Hour
+)
+
+// optimizerCounters are the status variables of the counters in the order emitted.
+var optimizerCounters = []string{"select_scan", "select_full_join", "select_range_check", "handler_read_rnd_next"}
+
+// Metric descriptors.
+var (
+	optimizerCounterDescs = map[string]*prometheus.Desc{
+		"select_scan": prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, optimizer, "select_scan_total"),
+			"Number of the joins that did a full scan of the first table, from Select_scan.",
+			[]string{}, nil,
+		),
+		"select_full_join": prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, optimizer, "select_full_join_total"),
+			"Number of the joins that did table scans because they do not use indexes, from Select_full_join.",
+			[]string{}, nil,
+		),
+		"select_range_check": prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, optimizer, "select_range_check_total"),
+			"Number of the joins without keys that check for key usage after each row, from Select_range_check.",
+			[]string{}, nil,
+		),
+		"handler_read_rnd_next": prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, optimizer, "handler_read_rnd_next_total"),
+			"Number of the requests to read the next row in the data file, mostly by table scans, from Handler_read_rnd_next.",
+			[]string{}, nil,
+		),
+	}
+	optimizerRateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, optimizer, "rate_per_second"),
+		"Increase per second of the counter since the last scrape by the server uptime, a jump after a schema or statistics change means a query regression.",
+		[]string{"counter"}, nil,
+	)
+)
+
+type optimizerState struct {
+	uptime float64
+	values map[string]float64
+	seen   time.Time
+}
+
+// optimizerStates remembers the counters of the last scrape, keyed by the host name and the port of the server
+var optimizerStates = struct {
+	sync.Mutex
+	m map[string]optimizerState
+}{m: make(map[string]optimizerState)}
+
+// ScrapeOptimizer collects the counters of the full table scans and joins and their rates between two scrapes.
+type ScrapeOptimizer struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeOptimizer) Name() string {
+	return optimizer
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeOptimizer) Help() string {
+	return "Collect the full table scan and join counters and their rates from SHOW GLOBAL STATUS"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeOptimizer) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeOptimizer) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	values, err := globalStatusValues(ctx, db, optimizerStatusQuery)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range optimizerCounters {
+		if value, ok := values[name]; ok {
+			ch <- prometheus.MustNewConstMetric(optimizerCounterDescs[name], prometheus.CounterValue, value)
+		}
+	}
+
+	uptime, ok := values["uptime"]
+	if !ok {
+		return nil
+	}
+
+	// the counters are sent anyway, only the rates need to know which server the last scrape was of
+	var server string
+	if err := db.QueryRowContext(ctx, optimizerServerQuery).Scan(&server); err != nil {
+		logger.Warnf("cannot scrape the rates of %s, the server key is not available: %s", optimizer, err)
+		return nil
+	}
+
+	seen := time.Now()
+	optimizerStates.Lock()
+	defer optimizerStates.Unlock()
+
+	last, ok := optimizerStates.m[server]
+	optimizerStates.m[server] = optimizerState{uptime: uptime, values: values, seen: seen}
+
+	// the counters are reset if the server restarted
+	if ok && uptime > last.uptime {
+		window := uptime - last.uptime
+		for _, name := range optimizerCounters {
+			value, has := values[name]
+			lastValue, hasLast := last.values[name]
+			if !has || !hasLast || value < lastValue {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(optimizerRateDesc, prometheus.GaugeValue, (value-lastValue)/window, name)
+		}
+	}
+
+	for key, state := range optimizerStates.m {
+		if seen.Sub(state.seen) > optimizerTTL {
+			delete(optimizerStates.m, key)
+		}
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeOptimizer{}
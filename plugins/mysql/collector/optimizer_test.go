@@ -0,0 +1,87 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeOptimizer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"Variable_name", "Value"}
+	server := "db1:3306"
+	expectScrape := func(uptime, scan, fullJoin, rangeCheck, rndNext string) {
+		mock.ExpectQuery(regexp.QuoteMeta(optimizerStatusQuery)).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("Handler_read_rnd_next", rndNext).
+			AddRow("Select_full_join", fullJoin).
+			AddRow("Select_range_check", rangeCheck).
+			AddRow("Select_scan", scan).
+			AddRow("Uptime", uptime))
+		mock.ExpectQuery(regexp.QuoteMeta(optimizerServerQuery)).WillReturnRows(sqlmock.NewRows([]string{"server"}).AddRow(server))
+	}
+	// the first scrape only remembers the counters
+	expectScrape("1000", "100", "10", "0", "50000")
+	expectScrape("1010", "150", "10", "2", "60000")
+	// restarted
+	expectScrape("5", "3", "0", "0", "100")
+	// the counters are still sent if the server key cannot be read
+	mock.ExpectQuery(regexp.QuoteMeta(optimizerStatusQuery)).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("Select_scan", "4").
+		AddRow("Uptime", "15"))
+	mock.ExpectQuery(regexp.QuoteMeta(optimizerServerQuery)).WillReturnError(fmt.Errorf("unknown system variable"))
+
+	scrape := func() []MetricResult {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapeOptimizer{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		var ret []MetricResult
+		for m := range ch {
+			ret = append(ret, readMetric(m))
+		}
+		return ret
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		convey.So(scrape(), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{}, value: 100, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 10, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 0, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 50000, metricType: dto.MetricType_COUNTER},
+		})
+		convey.So(scrape(), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{}, value: 150, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 10, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 2, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 60000, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{"counter": "select_scan"}, value: 5, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"counter": "select_full_join"}, value: 0, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"counter": "select_range_check"}, value: 0.2, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"counter": "handler_read_rnd_next"}, value: 1000, metricType: dto.MetricType_GAUGE},
+		})
+		convey.So(scrape(), convey.ShouldHaveLength, 4)
+		convey.So(scrape(), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{}, value: 4, metricType: dto.MetricType_COUNTER},
+		})
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Scrape the stored programs with the highest total latency from `performance_schema.events_statements_summary_by_program`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// The table is missing on the versions or forks without the stored program instrumentation.
+	perfEventsStatementsByProgramTableQuery = `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'performance_schema' AND table_name = 'events_statements_summary_by_program'`
+	perfEventsStatementsByProgramQuery      = `
+	SELECT OBJECT_TYPE, OBJECT_SCHEMA, OBJECT_NAME, COUNT_STAR, SUM_TIMER_WAIT
+	  FROM performance_schema.events_statements_summary_by_program
+	 WHERE COUNT_STAR > 0
+	 ORDER BY SUM_TIMER_WAIT DESC
+	 LIMIT ?
+	`
+	defaultPerfEventsStatementsByProgramLimit = 20
+)
+
+// Metric descriptors.
+var (
+	perfEventsStatementsByProgramLabels = []string{"type", "schema", "name"}
+
+	perfEventsStatementsByProgramCallsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "program_calls_total"),
+		"The number of times the stored program was executed.",
+		perfEventsStatementsByProgramLabels, nil,
+	)
+	perfEventsStatementsByProgramLatencyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, performanceSchema, "program_latency_seconds_total"),
+		"The total seconds the executions of the stored program took.",
+		perfEventsStatementsByProgramLabels, nil,
+	)
+)
+
+// ScrapePerfEventsStatementsByProgram collects the calls and the latency of the stored procedures, functions,
+// triggers and events with the highest total latency, capped to Limit programs.
+type ScrapePerfEventsStatementsByProgram struct {
+	// Limit defaults to 20
+	Limit int
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfEventsStatementsByProgram) Name() string {
+	return "perf_schema.eventsstatementsbyprogram"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfEventsStatementsByProgram) Help() string {
+	return "Collect the top stored programs by total latency from performance_schema.events_statements_summary_by_program"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfEventsStatementsByProgram) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapePerfEventsStatementsByProgram) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var enabled bool
+	if err := db.QueryRowContext(ctx, perfSchemaEnabledQuery).Scan(&enabled); err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+
+	var tables int
+	if err := db.QueryRowContext(ctx, perfEventsStatementsByProgramTableQuery).Scan(&tables); err != nil {
+		return err
+	}
+	if tables == 0 {
+		return nil
+	}
+
+	limit := s.Limit
+	if limit <= 0 {
+		limit = defaultPerfEventsStatementsByProgramLimit
+	}
+
+	// Timers here are returned in picoseconds.
+	rows, err := db.QueryContext(ctx, perfEventsStatementsByProgramQuery, limit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		objectType, objectSchema, objectName string
+		calls, latency                       uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&objectType, &objectSchema, &objectName, &calls, &latency); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			perfEventsStatementsByProgramCallsDesc, prometheus.CounterValue, float64(calls),
+			objectType, objectSchema, objectName,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			perfEventsStatementsByProgramLatencyDesc, prometheus.CounterValue, float64(latency)/picoSeconds,
+			objectType, objectSchema, objectName,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapePerfEventsStatementsByProgram{}
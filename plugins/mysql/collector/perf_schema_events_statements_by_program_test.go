@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapePerfEventsStatementsByProgram(t *testing.T) {
+	convey.Convey("Top stored programs", t, func() {
+		db, mock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(perfSchemaEnabledQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"@@performance_schema"}).AddRow(1))
+		mock.ExpectQuery(sanitizeQuery(perfEventsStatementsByProgramTableQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+		mock.ExpectQuery(strings.ReplaceAll(sanitizeQuery(perfEventsStatementsByProgramQuery), "?", `\?`)).WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"OBJECT_TYPE", "OBJECT_SCHEMA", "OBJECT_NAME", "COUNT_STAR", "SUM_TIMER_WAIT"}).
+				AddRow("PROCEDURE", "app", "settle_orders", 120, 4000000000000).
+				AddRow("FUNCTION", "app", "order_total", 3000, 1500000000000))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapePerfEventsStatementsByProgram{Limit: 2}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		procedure := labelMap{"type": "PROCEDURE", "schema": "app", "name": "settle_orders"}
+		function := labelMap{"type": "FUNCTION", "schema": "app", "name": "order_total"}
+		expected := []MetricResult{
+			{labels: procedure, value: 120, metricType: dto.MetricType_COUNTER},
+			{labels: procedure, value: 4, metricType: dto.MetricType_COUNTER},
+			{labels: function, value: 3000, metricType: dto.MetricType_COUNTER},
+			{labels: function, value: 1.5, metricType: dto.MetricType_COUNTER},
+		}
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+
+	convey.Convey("Skipped if the table is not available", t, func() {
+		db, mock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(perfSchemaEnabledQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"@@performance_schema"}).AddRow(1))
+		mock.ExpectQuery(sanitizeQuery(perfEventsStatementsByProgramTableQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapePerfEventsStatementsByProgram{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+}
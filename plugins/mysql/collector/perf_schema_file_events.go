@@ -18,6 +18,8 @@ package collector
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -29,8 +31,11 @@ const perfFileEventsQuery = `
 	    COUNT_WRITE, SUM_TIMER_WRITE, SUM_NUMBER_OF_BYTES_WRITE,
 	    COUNT_MISC, SUM_TIMER_MISC
 	  FROM performance_schema.file_summary_by_event_name
+	  %s
 	`
 
+const perfSchemaEnabledQuery = `SELECT @@performance_schema`
+
 // Metric descriptors.
 var (
 	performanceSchemaFileEventsDesc = prometheus.NewDesc(
@@ -51,7 +56,12 @@ var (
 )
 
 // ScrapePerfFileEvents collects from `performance_schema.file_summary_by_event_name`.
-type ScrapePerfFileEvents struct{}
+type ScrapePerfFileEvents struct {
+	// Events limits the event names, e.g. wait/io/file/innodb/innodb_log_file, empty means all
+	Events []string
+	// TopN only collects the N events with the most wait time, 0 means no limit
+	TopN int
+}
 
 // Name of the Scraper. Should be unique.
 func (ScrapePerfFileEvents) Name() string {
@@ -69,9 +79,29 @@ func (ScrapePerfFileEvents) Version() float64 {
 }
 
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapePerfFileEvents) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+func (s ScrapePerfFileEvents) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var enabled bool
+	if err := db.QueryRowContext(ctx, perfSchemaEnabledQuery).Scan(&enabled); err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+
+	var conditions []string
+	var args []interface{}
+	if len(s.Events) > 0 {
+		conditions = append(conditions, "WHERE EVENT_NAME IN ("+strings.TrimSuffix(strings.Repeat("?,", len(s.Events)), ",")+")")
+		for _, event := range s.Events {
+			args = append(args, event)
+		}
+	}
+	if s.TopN > 0 {
+		conditions = append(conditions, fmt.Sprintf("ORDER BY SUM_TIMER_WAIT DESC LIMIT %d", s.TopN))
+	}
+
 	// Timers here are returned in picoseconds.
-	perfSchemaFileEventsRows, err := db.QueryContext(ctx, perfFileEventsQuery)
+	perfSchemaFileEventsRows, err := db.QueryContext(ctx, fmt.Sprintf(perfFileEventsQuery, strings.Join(conditions, " ")), args...)
 	if err != nil {
 		return err
 	}
@@ -125,7 +155,7 @@ func (ScrapePerfFileEvents) Scrape(ctx context.Context, db *sql.DB, ch chan<- pr
 			eventName, "misc",
 		)
 	}
-	return nil
+	return perfSchemaFileEventsRows.Err()
 }
 
 // check interface
@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapePerfFileEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(perfSchemaEnabledQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"@@performance_schema"}).AddRow(1))
+
+	columns := []string{
+		"EVENT_NAME",
+		"COUNT_READ", "SUM_TIMER_READ", "SUM_NUMBER_OF_BYTES_READ",
+		"COUNT_WRITE", "SUM_TIMER_WRITE", "SUM_NUMBER_OF_BYTES_WRITE",
+		"COUNT_MISC", "SUM_TIMER_MISC",
+	}
+	rows := sqlmock.NewRows(columns).
+		AddRow("wait/io/file/sql/binlog", 1, 2000000000000, 3, 4, 5000000000000, 6, 7, 8000000000000)
+	query := fmt.Sprintf(perfFileEventsQuery, "WHERE EVENT_NAME IN (?,?) ORDER BY SUM_TIMER_WAIT DESC LIMIT 1")
+	mock.ExpectQuery(strings.ReplaceAll(sanitizeQuery(query), "?", `\?`)).
+		WithArgs("wait/io/file/sql/binlog", "wait/io/file/sql/relaylog").
+		WillReturnRows(rows)
+
+	scraper := ScrapePerfFileEvents{
+		Events: []string{"wait/io/file/sql/binlog", "wait/io/file/sql/relaylog"},
+		TopN:   1,
+	}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = scraper.Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	event := "wait/io/file/sql/binlog"
+	expected := []MetricResult{
+		{labels: labelMap{"event_name": event, "mode": "read"}, value: 1, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": event, "mode": "read"}, value: 2, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": event, "mode": "read"}, value: 3, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": event, "mode": "write"}, value: 4, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": event, "mode": "write"}, value: 5, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": event, "mode": "write"}, value: 6, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": event, "mode": "misc"}, value: 7, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"event_name": event, "mode": "misc"}, value: 8, metricType: dto.MetricType_COUNTER},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestScrapePerfFileEventsDisabled(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(perfSchemaEnabledQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"@@performance_schema"}).AddRow(0))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePerfFileEvents{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	convey.Convey("No metrics", t, func() {
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
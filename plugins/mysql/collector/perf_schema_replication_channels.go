@@ -0,0 +1,96 @@
+// Scrape the replication channels from `performance_schema.replication_connection_status`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// The SQL thread of a channel is in replication_applier_status, CONNECTING of the IO thread is not running.
+	perfReplicationChannelsQuery = `
+		SELECT c.CHANNEL_NAME, c.SERVICE_STATE, COALESCE(a.SERVICE_STATE, 'OFF')
+		  FROM performance_schema.replication_connection_status c
+		  LEFT JOIN performance_schema.replication_applier_status a ON a.CHANNEL_NAME = c.CHANNEL_NAME
+		 ORDER BY c.CHANNEL_NAME
+		`
+)
+
+// Metric descriptors.
+var (
+	perfReplicationChannelsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "replica", "channels_total"),
+		"Number of the replication channels, 0 if the server is not a replica.",
+		[]string{}, nil,
+	)
+	perfReplicationChannelRunningDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "replica", "channel_running"),
+		"Whether the IO or SQL thread of the replication channel is running, SERVICE_STATE = ON.",
+		[]string{"channel_name", "thread"}, nil,
+	)
+)
+
+// ScrapePerfReplicationChannels collects the threads state of every replication channel,
+// so that a stopped channel among the channels of a multi-source replica is visible.
+type ScrapePerfReplicationChannels struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfReplicationChannels) Name() string {
+	return performanceSchema + ".replication_channels"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfReplicationChannels) Help() string {
+	return "Collect the number of the replication channels and whether their IO and SQL threads are running"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfReplicationChannels) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePerfReplicationChannels) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, perfReplicationChannelsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		channelName, ioState, sqlState string
+		channels                       int
+	)
+	for rows.Next() {
+		if err := rows.Scan(&channelName, &ioState, &sqlState); err != nil {
+			return err
+		}
+		channels++
+		ch <- prometheus.MustNewConstMetric(
+			perfReplicationChannelRunningDesc, prometheus.GaugeValue, serviceStateRunning(ioState), channelName, "io",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			perfReplicationChannelRunningDesc, prometheus.GaugeValue, serviceStateRunning(sqlState), channelName, "sql",
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(perfReplicationChannelsDesc, prometheus.GaugeValue, float64(channels))
+	return nil
+}
+
+// serviceStateRunning is 1 for ON, 0 for OFF and CONNECTING
+func serviceStateRunning(state string) float64 {
+	if state == "ON" {
+		return 1
+	}
+	return 0
+}
+
+// check interface
+var _ Scraper = ScrapePerfReplicationChannels{}
@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapePerfReplicationChannels(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"CHANNEL_NAME", "SERVICE_STATE", "SERVICE_STATE"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("analytics", "ON", "ON").
+		AddRow("orders", "CONNECTING", "OFF")
+	mock.ExpectQuery(sanitizeQuery(perfReplicationChannelsQuery)).WillReturnRows(rows)
+	mock.ExpectQuery(sanitizeQuery(perfReplicationChannelsQuery)).WillReturnRows(sqlmock.NewRows(columns))
+
+	scrape := func() []MetricResult {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapePerfReplicationChannels{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		var metrics []MetricResult
+		for m := range ch {
+			metrics = append(metrics, readMetric(m))
+		}
+		return metrics
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		convey.So(scrape(), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{"channel_name": "analytics", "thread": "io"}, value: 1, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"channel_name": "analytics", "thread": "sql"}, value: 1, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"channel_name": "orders", "thread": "io"}, value: 0, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"channel_name": "orders", "thread": "sql"}, value: 0, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 2, metricType: dto.MetricType_GAUGE},
+		})
+
+		// not a replica
+		convey.So(scrape(), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{}, value: 0, metricType: dto.MetricType_GAUGE},
+		})
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,137 @@
+// Scrape `performance_schema.threads`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	perfThreads = "threads"
+	// The idle connections, the replication dump threads and the daemon threads are not running anything.
+	perfThreadsQuery = `
+		SELECT
+		    COALESCE(PROCESSLIST_USER, '') AS user,
+		    COALESCE(PROCESSLIST_STATE, '') AS state,
+		    COUNT(*) AS threads,
+		    COALESCE(MAX(PROCESSLIST_TIME), 0) AS max_time
+		  FROM performance_schema.threads
+		 WHERE TYPE = 'FOREGROUND'
+		   AND PROCESSLIST_COMMAND NOT IN ('Sleep', 'Daemon', 'Binlog Dump', 'Binlog Dump GTID')
+		   AND PROCESSLIST_ID != CONNECTION_ID()
+		 GROUP BY PROCESSLIST_USER, PROCESSLIST_STATE
+		`
+)
+
+// Metric descriptors.
+var (
+	perfThreadsByStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, perfThreads, "by_state"),
+		"The number of the running foreground threads by state, from performance_schema.threads.",
+		[]string{"state"}, nil,
+	)
+	perfThreadsByStateUserDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, perfThreads, "by_state"),
+		"The number of the running foreground threads by state and user, from performance_schema.threads.",
+		[]string{"state", "mysql_user"}, nil,
+	)
+	perfThreadsOldestRunningDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, perfThreads, "oldest_running_seconds"),
+		"The time in seconds the longest running foreground thread has been in its current state.",
+		[]string{}, nil,
+	)
+)
+
+// ScrapePerfThreads collects the running threads by state from performance_schema.threads,
+// unlike information_schema.processlist it does not take the global mutex on 8.0.
+type ScrapePerfThreads struct {
+	// ByUser adds the mysql_user label, the cardinality grows with the number of users
+	ByUser bool
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfThreads) Name() string {
+	return performanceSchema + ".threads"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfThreads) Help() string {
+	return "Collect the running threads by state and the oldest running thread from performance_schema.threads"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfThreads) Version() float64 {
+	return 8.0
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapePerfThreads) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, perfThreadsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type key struct {
+		state, user string
+	}
+
+	var (
+		user, state      string
+		threads, maxTime float64
+		oldest           float64
+	)
+	counts := make(map[key]float64)
+	for rows.Next() {
+		if err := rows.Scan(&user, &state, &threads, &maxTime); err != nil {
+			return err
+		}
+		k := key{state: sanitizeState(state)}
+		if s.ByUser {
+			k.user = user
+		}
+		counts[k] += threads
+		if maxTime > oldest {
+			oldest = maxTime
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	keys := make([]key, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].state != keys[j].state {
+			return keys[i].state < keys[j].state
+		}
+		return keys[i].user < keys[j].user
+	})
+
+	for _, k := range keys {
+		if s.ByUser {
+			ch <- prometheus.MustNewConstMetric(
+				perfThreadsByStateUserDesc, prometheus.GaugeValue, counts[k], k.state, k.user,
+			)
+		} else {
+			ch <- prometheus.MustNewConstMetric(
+				perfThreadsByStateDesc, prometheus.GaugeValue, counts[k], k.state,
+			)
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		perfThreadsOldestRunningDesc, prometheus.GaugeValue, oldest,
+	)
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapePerfThreads{}
@@ -0,0 +1,79 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapePerfThreads(t *testing.T) {
+	columns := []string{"user", "state", "threads", "max_time"}
+	newRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).
+			AddRow("app", "Sending data", 3, 12).
+			AddRow("report", "Sending data", 1, 350).
+			AddRow("app", "", 2, 0)
+	}
+
+	tests := []struct {
+		name     string
+		scraper  ScrapePerfThreads
+		expected []MetricResult
+	}{
+		{
+			name:    "By state",
+			scraper: ScrapePerfThreads{},
+			expected: []MetricResult{
+				{labels: labelMap{"state": "sending_data"}, value: 4, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{"state": "unknown"}, value: 2, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{}, value: 350, metricType: dto.MetricType_GAUGE},
+			},
+		},
+		{
+			name:    "By state and user",
+			scraper: ScrapePerfThreads{ByUser: true},
+			expected: []MetricResult{
+				{labels: labelMap{"state": "sending_data", "mysql_user": "app"}, value: 3, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{"state": "sending_data", "mysql_user": "report"}, value: 1, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{"state": "unknown", "mysql_user": "app"}, value: 2, metricType: dto.MetricType_GAUGE},
+				{labels: labelMap{}, value: 350, metricType: dto.MetricType_GAUGE},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		convey.Convey(test.name, t, func() {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("error opening a stub database connection: %s", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery(sanitizeQuery(perfThreadsQuery)).WillReturnRows(newRows())
+
+			ch := make(chan prometheus.Metric)
+			go func() {
+				if err = test.scraper.Scrape(context.Background(), db, ch); err != nil {
+					t.Errorf("error calling function on test: %s", err)
+				}
+				close(ch)
+			}()
+
+			for _, expect := range test.expected {
+				got := readMetric(<-ch)
+				convey.So(got, convey.ShouldResemble, expect)
+			}
+			_, ok := <-ch
+			convey.So(ok, convey.ShouldBeFalse)
+
+			// Ensure all SQL queries were executed
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled exceptions: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Scrape the top wait events from `performance_schema.events_waits_summary_global_by_event_name`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	perfWait = "perf"
+	// The idle waits of the sessions are not the waits to tune.
+	perfTopWaitsQuery = `
+	SELECT EVENT_NAME, SUM_TIMER_WAIT
+	  FROM performance_schema.events_waits_summary_global_by_event_name
+	 WHERE EVENT_NAME <> 'idle' AND SUM_TIMER_WAIT > 0
+	 ORDER BY SUM_TIMER_WAIT DESC
+	 LIMIT ?
+	`
+	defaultPerfTopWaitsLimit = 20
+)
+
+// Metric descriptors.
+var (
+	perfWaitSecondsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, perfWait, "wait_seconds_total"),
+		"The total seconds waited of the wait events with the highest total wait time.",
+		[]string{"event"}, nil,
+	)
+)
+
+// ScrapePerfTopWaits collects the wait events with the highest total wait time, capped to Limit events.
+type ScrapePerfTopWaits struct {
+	// Limit defaults to 20
+	Limit int
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePerfTopWaits) Name() string {
+	return "perf_schema.top_waits"
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePerfTopWaits) Help() string {
+	return "Collect the top wait events by total wait time from performance_schema.events_waits_summary_global_by_event_name"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePerfTopWaits) Version() float64 {
+	return 5.5
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapePerfTopWaits) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var enabled bool
+	if err := db.QueryRowContext(ctx, perfSchemaEnabledQuery).Scan(&enabled); err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+
+	limit := s.Limit
+	if limit <= 0 {
+		limit = defaultPerfTopWaitsLimit
+	}
+
+	// Timers here are returned in picoseconds.
+	rows, err := db.QueryContext(ctx, perfTopWaitsQuery, limit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		eventName string
+		wait      uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&eventName, &wait); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			perfWaitSecondsDesc, prometheus.CounterValue, float64(wait)/picoSeconds, eventName,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapePerfTopWaits{}
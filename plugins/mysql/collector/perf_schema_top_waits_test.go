@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapePerfTopWaits(t *testing.T) {
+	convey.Convey("Top wait events", t, func() {
+		db, mock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(perfSchemaEnabledQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"@@performance_schema"}).AddRow(1))
+		mock.ExpectQuery(strings.ReplaceAll(sanitizeQuery(perfTopWaitsQuery), "?", `\?`)).WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"EVENT_NAME", "SUM_TIMER_WAIT"}).
+				AddRow("wait/io/file/innodb/innodb_data_file", 3000000000000).
+				AddRow("wait/synch/mutex/innodb/buf_pool_mutex", 500000000000))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapePerfTopWaits{Limit: 2}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		expected := []MetricResult{
+			{labels: labelMap{"event": "wait/io/file/innodb/innodb_data_file"}, value: 3, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{"event": "wait/synch/mutex/innodb/buf_pool_mutex"}, value: 0.5, metricType: dto.MetricType_COUNTER},
+		}
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+
+	convey.Convey("Skipped if performance_schema is disabled", t, func() {
+		db, mock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(perfSchemaEnabledQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"@@performance_schema"}).AddRow(0))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapePerfTopWaits{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+}
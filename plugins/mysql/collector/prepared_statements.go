@@ -0,0 +1,127 @@
+// Scrape the prepared statement counters from `SHOW GLOBAL STATUS` and the cache sizes from `SHOW GLOBAL VARIABLES`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	preparedStatements = "prepared_statements"
+	// Queries.
+	preparedStatementsStatusQuery    = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Com_stmt_prepare', 'Com_stmt_execute', 'Com_stmt_close', 'Com_stmt_reprepare', 'Prepared_stmt_count')`
+	preparedStatementsVariablesQuery = `SHOW GLOBAL VARIABLES WHERE Variable_name IN ('max_prepared_stmt_count', 'stored_program_cache')`
+)
+
+// Metric descriptors.
+var (
+	preparedStatementsCounterDescs = map[string]*prometheus.Desc{
+		"com_stmt_prepare": prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, preparedStatements, "prepare_total"),
+			"Number of the statements prepared.",
+			[]string{}, nil,
+		),
+		"com_stmt_execute": prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, preparedStatements, "execute_total"),
+			"Number of the prepared statements executed.",
+			[]string{}, nil,
+		),
+		"com_stmt_close": prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, preparedStatements, "close_total"),
+			"Number of the prepared statements closed.",
+			[]string{}, nil,
+		),
+		"com_stmt_reprepare": prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, preparedStatements, "reprepare_total"),
+			"Number of the prepared statements prepared again automatically after the metadata of the tables or views changed.",
+			[]string{}, nil,
+		),
+	}
+	preparedStatementsCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, preparedStatements, "count"),
+		"Number of the prepared statements currently open.",
+		[]string{}, nil,
+	)
+	preparedStatementsMaxCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, preparedStatements, "max_count"),
+		"Value of max_prepared_stmt_count, the statements failed to be prepared when reached.",
+		[]string{}, nil,
+	)
+	preparedStatementsRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, preparedStatements, "prepare_execute_ratio"),
+		"Ratio of the statements prepared to the ones executed since the server started, 0 if there is none executed. Close to 1 means the statements are prepared again for every execution instead of being reused.",
+		[]string{}, nil,
+	)
+	storedProgramCacheSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "stored_program_cache", "size"),
+		"Value of stored_program_cache, the soft upper limit of the stored routines cached per connection.",
+		[]string{}, nil,
+	)
+)
+
+// ScrapePreparedStatements collects the churn of the prepared statements and the ratio of the prepared to the executed ones.
+type ScrapePreparedStatements struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapePreparedStatements) Name() string {
+	return preparedStatements
+}
+
+// Help describes the role of the Scraper.
+func (ScrapePreparedStatements) Help() string {
+	return "Collect the prepared statement counters, the prepare/execute ratio and the statement and stored program cache sizes"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapePreparedStatements) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapePreparedStatements) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	status, err := globalStatusValues(ctx, db, preparedStatementsStatusQuery)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{"com_stmt_prepare", "com_stmt_execute", "com_stmt_close", "com_stmt_reprepare"} {
+		if value, ok := status[name]; ok {
+			ch <- prometheus.MustNewConstMetric(preparedStatementsCounterDescs[name], prometheus.CounterValue, value)
+		}
+	}
+
+	if count, ok := status["prepared_stmt_count"]; ok {
+		ch <- prometheus.MustNewConstMetric(preparedStatementsCountDesc, prometheus.GaugeValue, count)
+	}
+
+	prepare, hasPrepare := status["com_stmt_prepare"]
+	execute, hasExecute := status["com_stmt_execute"]
+	if hasPrepare && hasExecute {
+		ratio := 0.0
+		if execute > 0 {
+			ratio = prepare / execute
+		}
+		ch <- prometheus.MustNewConstMetric(preparedStatementsRatioDesc, prometheus.GaugeValue, ratio)
+	}
+
+	variables, err := globalStatusValues(ctx, db, preparedStatementsVariablesQuery)
+	if err != nil {
+		return err
+	}
+
+	if value, ok := variables["max_prepared_stmt_count"]; ok {
+		ch <- prometheus.MustNewConstMetric(preparedStatementsMaxCountDesc, prometheus.GaugeValue, value)
+	}
+	// stored_program_cache is available since 5.5
+	if value, ok := variables["stored_program_cache"]; ok {
+		ch <- prometheus.MustNewConstMetric(storedProgramCacheSizeDesc, prometheus.GaugeValue, value)
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapePreparedStatements{}
@@ -0,0 +1,64 @@
+package collector
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapePreparedStatements(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"Variable_name", "Value"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("Com_stmt_close", "180").
+		AddRow("Com_stmt_execute", "400").
+		AddRow("Com_stmt_prepare", "200").
+		AddRow("Com_stmt_reprepare", "3").
+		AddRow("Prepared_stmt_count", "20")
+	mock.ExpectQuery(regexp.QuoteMeta(preparedStatementsStatusQuery)).WillReturnRows(rows)
+
+	rows = sqlmock.NewRows(columns).
+		AddRow("max_prepared_stmt_count", "16382").
+		AddRow("stored_program_cache", "256")
+	mock.ExpectQuery(regexp.QuoteMeta(preparedStatementsVariablesQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapePreparedStatements{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, value: 200, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{}, value: 400, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{}, value: 180, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{}, value: 3, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{}, value: 20, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 0.5, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 16382, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 256, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+)
+
+// queryNameKey is the context key of the name of the scraper or custom query the statements are run for
+type queryNameKey struct{}
+
+// withQueryName sets the name in the comments of the statements run with ctx if Options.QueryComment is set
+func withQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameKey{}, name)
+}
+
+// commentSanitizer keeps the comment from ending early or adding placeholders, so that interpolateParams
+// and the prepared statements count the same placeholders as without the comment
+var commentSanitizer = strings.NewReplacer("*/", "* /", "?", "_", "\n", " ", "\r", " ")
+
+// commentConnector prepends `/* cprobe target=... query=... */` to every statement, so that the monitoring queries
+// are told from the others in the processlist and the slow log
+type commentConnector struct {
+	driver.Connector
+	target string
+}
+
+func (c *commentConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &commentConn{Conn: conn, target: c.target}, nil
+}
+
+// commentConn comments the statements and forwards the optional interfaces of the driver connection
+type commentConn struct {
+	driver.Conn
+	target string
+}
+
+func (c *commentConn) comment(ctx context.Context, query string) string {
+	var sb strings.Builder
+	sb.WriteString("/* cprobe target=")
+	sb.WriteString(commentSanitizer.Replace(c.target))
+	if name, ok := ctx.Value(queryNameKey{}).(string); ok && name != "" {
+		sb.WriteString(" query=")
+		sb.WriteString(commentSanitizer.Replace(name))
+	}
+	sb.WriteString(" */ ")
+	sb.WriteString(query)
+	return sb.String()
+}
+
+func (c *commentConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(c.comment(context.Background(), query))
+}
+
+func (c *commentConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, c.comment(ctx, query))
+	}
+	return c.Conn.Prepare(c.comment(ctx, query))
+}
+
+func (c *commentConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return queryer.QueryContext(ctx, c.comment(ctx, query), args)
+}
+
+func (c *commentConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return execer.ExecContext(ctx, c.comment(ctx, query), args)
+}
+
+func (c *commentConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck
+}
+
+func (c *commentConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *commentConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *commentConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *commentConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
@@ -0,0 +1,39 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestCommentConnector(t *testing.T) {
+	convey.Convey("Statements are commented with the target and the query name", t, func() {
+		mockDB, mock, err := sqlmock.NewWithDSN("comment_ok", sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		convey.So(err, convey.ShouldBeNil)
+		defer mockDB.Close()
+
+		mock.ExpectQuery("/* cprobe target=db1:3306 */ SELECT @@version").
+			WillReturnRows(sqlmock.NewRows([]string{"@@version"}).AddRow("8.0.36"))
+		mock.ExpectQuery("/* cprobe target=db1:3306 query=biz_* /orders_ */ SELECT count(*) FROM orders WHERE shop = ?").
+			WithArgs("a").
+			WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(3))
+
+		db := sql.OpenDB(&commentConnector{Connector: dsnConnector{dsn: "comment_ok", drv: mockDB.Driver()}, target: "db1:3306"})
+		defer db.Close()
+
+		var version string
+		convey.So(db.QueryRowContext(context.Background(), "SELECT @@version").Scan(&version), convey.ShouldBeNil)
+		convey.So(version, convey.ShouldEqual, "8.0.36")
+
+		// the comment can neither be closed nor add a placeholder by the name
+		var count int
+		ctx := withQueryName(context.Background(), "biz_*/orders?")
+		convey.So(db.QueryRowContext(ctx, "SELECT count(*) FROM orders WHERE shop = ?", "a").Scan(&count), convey.ShouldBeNil)
+		convey.So(count, convey.ShouldEqual, 3)
+
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+}
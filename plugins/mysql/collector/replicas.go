@@ -0,0 +1,116 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+
+	"github.com/cprobe/cprobe/lib/logger"
+)
+
+// Replica is a read replica the custom queries with on_replica are spread across.
+type Replica struct {
+	// Address is reported as the server label of the results
+	Address string
+	DSN     string
+	// Weight of the replica in the weighted round-robin, defaults to 1
+	Weight int
+}
+
+// replicaBalancers keeps the current weights of the smooth weighted round-robin across scrapes,
+// keyed by the addresses of the replicas of the target
+var replicaBalancers = struct {
+	sync.Mutex
+	m map[string]map[string]int
+}{m: make(map[string]map[string]int)}
+
+// pickReplica selects a replica not in failed by the smooth weighted round-robin of nginx,
+// the replicas are selected in proportion to their weights and interleaved
+func pickReplica(replicas []Replica, failed map[string]bool) (Replica, bool) {
+	keys := make([]string, len(replicas))
+	for i, r := range replicas {
+		keys[i] = r.Address
+	}
+	key := strings.Join(keys, ",")
+
+	replicaBalancers.Lock()
+	defer replicaBalancers.Unlock()
+
+	current, has := replicaBalancers.m[key]
+	if !has {
+		current = make(map[string]int)
+		replicaBalancers.m[key] = current
+	}
+
+	best, total := -1, 0
+	for i, r := range replicas {
+		if failed[r.Address] {
+			continue
+		}
+		weight := r.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		current[r.Address] += weight
+		total += weight
+		if best < 0 || current[r.Address] > current[replicas[best].Address] {
+			best = i
+		}
+	}
+	if best < 0 {
+		return Replica{}, false
+	}
+	current[replicas[best].Address] -= total
+	return replicas[best], true
+}
+
+// replicaPool opens the replicas lazily during a scrape, a replica failed to connect
+// is skipped by the rest of the queries of the scrape
+type replicaPool struct {
+	e      *Exporter
+	mu     sync.Mutex
+	dbs    map[string]*sql.DB
+	failed map[string]bool
+}
+
+func newReplicaPool(e *Exporter) *replicaPool {
+	return &replicaPool{
+		e:      e,
+		dbs:    make(map[string]*sql.DB),
+		failed: make(map[string]bool),
+	}
+}
+
+// get returns the connection of the next available replica, ok is false if all the replicas failed
+func (p *replicaPool) get(ctx context.Context) (db *sql.DB, address string, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		replica, ok := pickReplica(p.e.opts.Replicas, p.failed)
+		if !ok {
+			return nil, "", false
+		}
+		if db, has := p.dbs[replica.Address]; has {
+			return db, replica.Address, true
+		}
+
+		db, err := p.e.openAndPing(ctx, replica.DSN, false)
+		if err != nil {
+			logger.Warnf("skip replica %s for custom queries, target: %s, error: %s", replica.Address, p.e.getTargetFromDsn(), err)
+			p.failed[replica.Address] = true
+			continue
+		}
+		p.dbs[replica.Address] = db
+		return db, replica.Address, true
+	}
+}
+
+func (p *replicaPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, db := range p.dbs {
+		db.Close()
+	}
+}
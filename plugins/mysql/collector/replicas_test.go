@@ -0,0 +1,35 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestPickReplica(t *testing.T) {
+	convey.Convey("Weighted and interleaved", t, func() {
+		replicas := []Replica{{Address: "a:3306", Weight: 2}, {Address: "b:3306", Weight: 1}, {Address: "c:3306"}}
+
+		var picked []string
+		for i := 0; i < 8; i++ {
+			r, ok := pickReplica(replicas, nil)
+			convey.So(ok, convey.ShouldBeTrue)
+			picked = append(picked, r.Address)
+		}
+		convey.So(picked, convey.ShouldResemble, []string{"a:3306", "b:3306", "c:3306", "a:3306", "a:3306", "b:3306", "c:3306", "a:3306"})
+	})
+
+	convey.Convey("Failed replicas are skipped", t, func() {
+		replicas := []Replica{{Address: "d:3306", Weight: 5}, {Address: "e:3306", Weight: 1}}
+		failed := map[string]bool{"d:3306": true}
+		for i := 0; i < 3; i++ {
+			r, ok := pickReplica(replicas, failed)
+			convey.So(ok, convey.ShouldBeTrue)
+			convey.So(r.Address, convey.ShouldEqual, "e:3306")
+		}
+
+		failed["e:3306"] = true
+		_, ok := pickReplica(replicas, failed)
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+}
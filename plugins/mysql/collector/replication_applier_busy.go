@@ -0,0 +1,162 @@
+// Scrape the busy ratio of the replication applier workers from `performance_schema.replication_applier_status_by_worker`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scraper name.
+	replicationApplierBusy = "replication_applier_busy"
+	// Query. UNIX_TIMESTAMP of the zero timestamp, i.e. nothing applied yet, is 0.
+	replicationApplierBusyQuery = `
+		SELECT
+		    CHANNEL_NAME,
+		    WORKER_ID,
+		    APPLYING_TRANSACTION != '' AS applying,
+		    UNIX_TIMESTAMP(APPLYING_TRANSACTION_START_APPLY_TIMESTAMP) AS applying_start,
+		    UNIX_TIMESTAMP(LAST_APPLIED_TRANSACTION_END_APPLY_TIMESTAMP) AS last_applied_end,
+		    UNIX_TIMESTAMP(NOW(6)) AS now,
+		    @@server_uuid AS server_uuid
+		  FROM performance_schema.replication_applier_status_by_worker
+		`
+	// the state of the servers not scraped for this long is dropped
+	replicationApplierBusyTTL = time.Hour
+)
+
+// Metric descriptors.
+var (
+	replicationApplierBusyRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "replica", "applier_busy_ratio"),
+		"Estimated ratio of the time the applier workers of the channel were applying since the last scrape, close to 1 means the replica is apply-bound.",
+		[]string{"channel_name"}, nil,
+	)
+)
+
+type applierBusyKey struct {
+	server, channel string
+}
+
+type applierBusyState struct {
+	at   float64
+	seen time.Time
+}
+
+// applierBusyStates remembers the server time of the last scrape of every channel, keyed by @@server_uuid
+// instead of the target, so the jobs scraping the same server through a proxy share it
+var applierBusyStates = struct {
+	sync.Mutex
+	m map[applierBusyKey]applierBusyState
+}{m: make(map[applierBusyKey]applierBusyState)}
+
+// ScrapeReplicationApplierBusy estimates the busy ratio of the applier workers between two scrapes. The workers
+// only expose the timestamps of the transaction being applied and the last one applied, so the idle time of
+// a worker in the window is the gap since it finished the last transaction, or between that one and the one being
+// applied. The earlier gaps in the window are not seen, the ratio is an upper bound, which is safe to alert on.
+type ScrapeReplicationApplierBusy struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeReplicationApplierBusy) Name() string {
+	return replicationApplierBusy
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeReplicationApplierBusy) Help() string {
+	return "Collect the busy ratio of the replication applier workers"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeReplicationApplierBusy) Version() float64 {
+	return 8.0
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeReplicationApplierBusy) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, replicationApplierBusyQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type worker struct {
+		applying                      bool
+		applyingStart, lastAppliedEnd float64
+	}
+
+	var (
+		channel, server string
+		workerID        uint64
+		w               worker
+		now             float64
+	)
+	workers := make(map[string][]worker)
+	for rows.Next() {
+		if err := rows.Scan(&channel, &workerID, &w.applying, &w.applyingStart, &w.lastAppliedEnd, &now, &server); err != nil {
+			return err
+		}
+		workers[channel] = append(workers[channel], w)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	channels := make([]string, 0, len(workers))
+	for channel := range workers {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+
+	seen := time.Now()
+	applierBusyStates.Lock()
+	defer applierBusyStates.Unlock()
+
+	for _, channel := range channels {
+		key := applierBusyKey{server: server, channel: channel}
+		last, ok := applierBusyStates.m[key]
+		applierBusyStates.m[key] = applierBusyState{at: now, seen: seen}
+		if !ok || now <= last.at {
+			continue
+		}
+
+		window := now - last.at
+		var busy float64
+		for _, w := range workers[channel] {
+			idleFrom := w.lastAppliedEnd
+			if idleFrom < last.at {
+				idleFrom = last.at
+			}
+			idleTo := now
+			if w.applying {
+				idleTo = w.applyingStart
+			}
+			idle := 0.0
+			if idleTo > idleFrom {
+				idle = idleTo - idleFrom
+			}
+			if idle < window {
+				busy += window - idle
+			}
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			replicationApplierBusyRatioDesc, prometheus.GaugeValue, busy/(window*float64(len(workers[channel]))), channel,
+		)
+	}
+
+	for key, state := range applierBusyStates.m {
+		if seen.Sub(state.seen) > replicationApplierBusyTTL {
+			delete(applierBusyStates.m, key)
+		}
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeReplicationApplierBusy{}
@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeReplicationApplierBusy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"CHANNEL_NAME", "WORKER_ID", "applying", "applying_start", "last_applied_end", "now", "server_uuid"}
+	uuid := "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	// the first scrape only remembers the time
+	mock.ExpectQuery(sanitizeQuery(replicationApplierBusyQuery)).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("", 1, 0, 0, 0, 1000, uuid).
+		AddRow("", 2, 0, 0, 0, 1000, uuid))
+	// worker 1 has been applying since before the window, worker 2 became idle in the middle of the window
+	mock.ExpectQuery(sanitizeQuery(replicationApplierBusyQuery)).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("", 1, 1, 990, 985, 1010, uuid).
+		AddRow("", 2, 0, 0, 1005, 1010, uuid))
+
+	scrape := func() []MetricResult {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapeReplicationApplierBusy{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		var ret []MetricResult
+		for m := range ch {
+			ret = append(ret, readMetric(m))
+		}
+		return ret
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		convey.So(scrape(), convey.ShouldBeEmpty)
+		convey.So(scrape(), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{"channel_name": ""}, value: 0.75, metricType: dto.MetricType_GAUGE},
+		})
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,137 @@
+// Scrape the replication filters from `performance_schema.replication_applier_filters` or `SHOW SLAVE STATUS`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	MySQL "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scraper name, the metrics are in the replication subsystem.
+	replicationFilters = "replication_filters"
+	// MySQL 8.0+, the table also has the filters configured globally.
+	replicationFiltersQuery = `SELECT CHANNEL_NAME, FILTER_NAME, FILTER_RULE FROM performance_schema.replication_applier_filters`
+)
+
+// The filter columns of SHOW SLAVE STATUS, for MySQL 5.7 and MariaDB.
+var replicationFilterColumns = []string{
+	"Replicate_Do_DB",
+	"Replicate_Ignore_DB",
+	"Replicate_Do_Table",
+	"Replicate_Ignore_Table",
+	"Replicate_Wild_Do_Table",
+	"Replicate_Wild_Ignore_Table",
+	"Replicate_Rewrite_DB",
+}
+
+// Metric descriptors.
+var (
+	replicationFilterDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, replication, "filter_info"),
+		"A replication filter configured on the channel, always 1.",
+		[]string{"channel_name", "filter_name", "filter_rule"}, nil,
+	)
+)
+
+// ScrapeReplicationFilters exposes the replication filters so the changes after maintenance are visible.
+type ScrapeReplicationFilters struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeReplicationFilters) Name() string {
+	return replicationFilters
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeReplicationFilters) Help() string {
+	return "Collect the replication filters of the replication channels"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeReplicationFilters) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeReplicationFilters) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, replicationFiltersQuery)
+	if err != nil {
+		var mysqlErr *MySQL.MySQLError
+		// Error 1146: table does not exist before 8.0
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1146 {
+			return scrapeReplicationFiltersFromStatus(ctx, db, ch)
+		}
+		return err
+	}
+	defer rows.Close()
+
+	var channelName, filterName, filterRule string
+	for rows.Next() {
+		if err := rows.Scan(&channelName, &filterName, &filterRule); err != nil {
+			return err
+		}
+		if filterRule == "" {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			replicationFilterDesc, prometheus.GaugeValue, 1, channelName, strings.ToUpper(filterName), filterRule,
+		)
+	}
+	return rows.Err()
+}
+
+// scrapeReplicationFiltersFromStatus reads the filter columns of each replication channel.
+func scrapeReplicationFiltersFromStatus(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	for _, query := range replicationSourceQueries {
+		if rows, err = db.QueryContext(ctx, query); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		scanArgs := make([]interface{}, len(cols))
+		for i := range scanArgs {
+			scanArgs[i] = &sql.RawBytes{}
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+
+		channelName := columnValue(scanArgs, cols, "Channel_Name") // MySQL & Percona
+		if channelName == "" {
+			channelName = columnValue(scanArgs, cols, "Connection_name") // MariaDB
+		}
+
+		for _, col := range replicationFilterColumns {
+			rule := columnValue(scanArgs, cols, col)
+			if rule == "" {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				replicationFilterDesc, prometheus.GaugeValue, 1, channelName, strings.ToUpper(col), rule,
+			)
+		}
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeReplicationFilters{}
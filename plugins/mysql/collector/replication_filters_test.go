@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	MySQL "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeReplicationFilters(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(replicationFiltersQuery)).WillReturnRows(
+		sqlmock.NewRows([]string{"CHANNEL_NAME", "FILTER_NAME", "FILTER_RULE"}).
+			AddRow("", "REPLICATE_IGNORE_DB", "tmp,scratch").
+			AddRow("analytics", "REPLICATE_WILD_DO_TABLE", "sales.%").
+			AddRow("analytics", "REPLICATE_DO_DB", ""))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeReplicationFilters{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"channel_name": "", "filter_name": "REPLICATE_IGNORE_DB", "filter_rule": "tmp,scratch"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "analytics", "filter_name": "REPLICATE_WILD_DO_TABLE", "filter_rule": "sales.%"}, value: 1, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestScrapeReplicationFiltersFromStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(replicationFiltersQuery)).WillReturnError(&MySQL.MySQLError{Number: 1146, Message: "Table 'performance_schema.replication_applier_filters' doesn't exist"})
+	mock.ExpectQuery(sanitizeQuery("SHOW ALL SLAVES STATUS")).WillReturnRows(
+		sqlmock.NewRows([]string{"Connection_name", "Master_Host", "Replicate_Do_DB", "Replicate_Ignore_Table"}).
+			AddRow("", "10.0.0.1", "", "app.sessions"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeReplicationFilters{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	convey.Convey("Metrics comparison", t, func() {
+		got := readMetric(<-ch)
+		convey.So(got, convey.ShouldResemble, MetricResult{labels: labelMap{"channel_name": "", "filter_name": "REPLICATE_IGNORE_TABLE", "filter_rule": "app.sessions"}, value: 1, metricType: dto.MetricType_GAUGE})
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Scrape the configured replication heartbeat period and the received heartbeats.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scraper name, the metrics are in the replication subsystem.
+	replicationHeartbeat = "replication_heartbeat"
+	// MySQL 5.7+, one row per replication channel.
+	replicationHeartbeatQuery = `
+		SELECT c.CHANNEL_NAME
+		     , c.HEARTBEAT_INTERVAL
+		     , s.COUNT_RECEIVED_HEARTBEATS
+		     , COALESCE(UNIX_TIMESTAMP(s.LAST_HEARTBEAT_TIMESTAMP), 0)
+		  FROM performance_schema.replication_connection_configuration c
+		  JOIN performance_schema.replication_connection_status s USING (CHANNEL_NAME)
+		`
+	// MySQL 5.6 and MariaDB.
+	replicationHeartbeatStatusQuery = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Slave_running', 'Slave_heartbeat_period', 'Slave_received_heartbeats')`
+)
+
+// Metric descriptors.
+var (
+	replicationHeartbeatPeriodDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, replication, "heartbeat_period_seconds"),
+		"The configured heartbeat period of the replication channel, MASTER_HEARTBEAT_PERIOD.",
+		[]string{"channel_name"}, nil,
+	)
+	replicationReceivedHeartbeatsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, replication, "received_heartbeats_total"),
+		"The number of heartbeats received by the replication channel, it stops increasing if the heartbeats are stalled.",
+		[]string{"channel_name"}, nil,
+	)
+	replicationLastHeartbeatDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, replication, "last_heartbeat_timestamp_seconds"),
+		"The time the last heartbeat of the replication channel was received.",
+		[]string{"channel_name"}, nil,
+	)
+)
+
+// ScrapeReplicationHeartbeat tells whether the heartbeats arrive at the configured period,
+// a stalled heartbeat shows a network issue even when Seconds_Behind_Master looks fine.
+type ScrapeReplicationHeartbeat struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeReplicationHeartbeat) Name() string {
+	return replicationHeartbeat
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeReplicationHeartbeat) Help() string {
+	return "Collect the heartbeat period and the received heartbeats of the replication channels"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeReplicationHeartbeat) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeReplicationHeartbeat) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, replicationHeartbeatQuery)
+	if err != nil {
+		// Error 1146: table does not exist
+		if isMySQLError(err, 1146) {
+			return scrapeReplicationHeartbeatFromStatus(ctx, db, ch)
+		}
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		channelName   string
+		period        float64
+		heartbeats    uint64
+		lastHeartbeat float64
+	)
+	// No rows if the instance is not a replica
+	for rows.Next() {
+		if err := rows.Scan(&channelName, &period, &heartbeats, &lastHeartbeat); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(
+			replicationHeartbeatPeriodDesc, prometheus.GaugeValue, period, channelName,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			replicationReceivedHeartbeatsDesc, prometheus.CounterValue, float64(heartbeats), channelName,
+		)
+		if lastHeartbeat > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				replicationLastHeartbeatDesc, prometheus.GaugeValue, lastHeartbeat, channelName,
+			)
+		}
+	}
+	return rows.Err()
+}
+
+// scrapeReplicationHeartbeatFromStatus reads the status variables of the default channel.
+func scrapeReplicationHeartbeatFromStatus(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	values, err := globalStatusValues(ctx, db, replicationHeartbeatStatusQuery)
+	if err != nil {
+		return err
+	}
+	if values["slave_running"] != 1 {
+		return nil
+	}
+
+	if period, ok := values["slave_heartbeat_period"]; ok {
+		ch <- prometheus.MustNewConstMetric(
+			replicationHeartbeatPeriodDesc, prometheus.GaugeValue, period, "",
+		)
+	}
+	if heartbeats, ok := values["slave_received_heartbeats"]; ok {
+		ch <- prometheus.MustNewConstMetric(
+			replicationReceivedHeartbeatsDesc, prometheus.CounterValue, heartbeats, "",
+		)
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeReplicationHeartbeat{}
@@ -0,0 +1,81 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	MySQL "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeReplicationHeartbeat(t *testing.T) {
+	convey.Convey("From performance_schema", t, func() {
+		db, mock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(replicationHeartbeatQuery)).WillReturnRows(
+			sqlmock.NewRows([]string{"CHANNEL_NAME", "HEARTBEAT_INTERVAL", "COUNT_RECEIVED_HEARTBEATS", "LAST_HEARTBEAT_TIMESTAMP"}).
+				AddRow("", "30.000", 120, "1700000000.000000").
+				AddRow("analytics", "5.000", 0, "0"))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapeReplicationHeartbeat{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		expected := []MetricResult{
+			{labels: labelMap{"channel_name": ""}, value: 30, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"channel_name": ""}, value: 120, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{"channel_name": ""}, value: 1700000000, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"channel_name": "analytics"}, value: 5, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"channel_name": "analytics"}, value: 0, metricType: dto.MetricType_COUNTER},
+		}
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+
+	convey.Convey("From status variables", t, func() {
+		db, mock, err := sqlmock.New()
+		convey.So(err, convey.ShouldBeNil)
+		defer db.Close()
+
+		mock.ExpectQuery(sanitizeQuery(replicationHeartbeatQuery)).WillReturnError(&MySQL.MySQLError{Number: 1146, Message: "Table doesn't exist"})
+		mock.ExpectQuery(sanitizeQuery(replicationHeartbeatStatusQuery)).WillReturnRows(
+			sqlmock.NewRows([]string{"Variable_name", "Value"}).
+				AddRow("Slave_heartbeat_period", "1.000").
+				AddRow("Slave_received_heartbeats", "42").
+				AddRow("Slave_running", "ON"))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapeReplicationHeartbeat{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		expected := []MetricResult{
+			{labels: labelMap{"channel_name": ""}, value: 1, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"channel_name": ""}, value: 42, metricType: dto.MetricType_COUNTER},
+		}
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+}
@@ -0,0 +1,123 @@
+// Scrape the last errors of the replication threads from `SHOW SLAVE STATUS`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scraper name.
+	replicationLastError = "replication_last_error"
+)
+
+// DefaultReplicationErrorMessageLength is the number of characters the error messages are truncated to.
+const DefaultReplicationErrorMessageLength = 256
+
+// Metric descriptors.
+var (
+	replicationLastErrorDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "replica", "last_error"),
+		"The number of the last error of the IO or SQL thread of the replication channel, 0 if there is none, with the error message truncated.",
+		[]string{"channel_name", "thread", "error_number", "error_message"}, nil,
+	)
+)
+
+// ScrapeReplicationLastError exposes the last IO and SQL errors, so the alerts of broken replication tell the reason.
+type ScrapeReplicationLastError struct {
+	// MessageLength truncates the error messages, defaults to DefaultReplicationErrorMessageLength
+	MessageLength int
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeReplicationLastError) Name() string {
+	return replicationLastError
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeReplicationLastError) Help() string {
+	return "Collect the last IO and SQL errors of the replication channels"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeReplicationLastError) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapeReplicationLastError) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	for _, query := range replicationSourceQueries {
+		if rows, err = db.QueryContext(ctx, query); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	length := s.MessageLength
+	if length <= 0 {
+		length = DefaultReplicationErrorMessageLength
+	}
+
+	for rows.Next() {
+		scanArgs := make([]interface{}, len(cols))
+		for i := range scanArgs {
+			scanArgs[i] = &sql.RawBytes{}
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+
+		// Not a replica, e.g. after RESET SLAVE
+		if columnValue(scanArgs, cols, "Source_Host") == "" && columnValue(scanArgs, cols, "Master_Host") == "" {
+			continue
+		}
+
+		channelName := columnValue(scanArgs, cols, "Channel_Name") // MySQL & Percona
+		if channelName == "" {
+			channelName = columnValue(scanArgs, cols, "Connection_name") // MariaDB
+		}
+
+		for _, thread := range []string{"io", "sql"} {
+			prefix := "Last_" + strings.ToUpper(thread) + "_"
+			errno, _ := strconv.ParseFloat(columnValue(scanArgs, cols, prefix+"Errno"), 64)
+			message := ""
+			if errno != 0 {
+				message = truncateMessage(columnValue(scanArgs, cols, prefix+"Error"), length)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				replicationLastErrorDesc, prometheus.GaugeValue, errno,
+				channelName, thread, strconv.FormatFloat(errno, 'f', -1, 64), message,
+			)
+		}
+	}
+	return rows.Err()
+}
+
+// truncateMessage cuts the message to at most length characters without breaking a multi-byte character
+func truncateMessage(message string, length int) string {
+	runes := []rune(message)
+	if len(runes) <= length {
+		return message
+	}
+	return string(runes[:length]) + "..."
+}
+
+// check interface
+var _ Scraper = ScrapeReplicationLastError{}
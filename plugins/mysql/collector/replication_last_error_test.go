@@ -0,0 +1,55 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeReplicationLastError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	sqlError := "Coordinator stopped because there were error(s) in the worker(s). " + strings.Repeat("x", 100)
+	mock.ExpectQuery(sanitizeQuery("SHOW ALL SLAVES STATUS")).WillReturnRows(
+		sqlmock.NewRows([]string{"Connection_name", "Master_Host", "Last_IO_Errno", "Last_IO_Error", "Last_SQL_Errno", "Last_SQL_Error"}).
+			AddRow("", "10.0.0.1", "0", "", "1062", sqlError).
+			AddRow("analytics", "10.0.0.2", "2003", "error connecting to master", "0", "").
+			AddRow("stopped", "", "0", "", "0", ""))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeReplicationLastError{MessageLength: 20}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"channel_name": "", "thread": "io", "error_number": "0", "error_message": ""}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "", "thread": "sql", "error_number": "1062", "error_message": "Coordinator stopped ..."}, value: 1062, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "analytics", "thread": "io", "error_number": "2003", "error_message": "error connecting to ..."}, value: 2003, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "analytics", "thread": "sql", "error_number": "0", "error_message": ""}, value: 0, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
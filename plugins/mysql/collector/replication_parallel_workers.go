@@ -0,0 +1,185 @@
+// Scrape the configured and the active replication applier workers from `performance_schema.replication_applier_status_by_worker`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scraper name.
+	replicationParallelWorkers = "replication_parallel_workers"
+	// Queries. The end of the last applied transaction changes with every transaction of the worker since 8.0,
+	// 5.7 only has the last seen GTID, which does not change without GTIDs.
+	replicationParallelWorkersQuery = `
+		SELECT
+		    CHANNEL_NAME,
+		    WORKER_ID,
+		    APPLYING_TRANSACTION != '' AS applying,
+		    CAST(LAST_APPLIED_TRANSACTION_END_APPLY_TIMESTAMP AS CHAR) AS last_applied,
+		    @@server_uuid AS server_uuid,
+		    @@slave_parallel_workers AS parallel_workers
+		  FROM performance_schema.replication_applier_status_by_worker
+		`
+	replicationParallelWorkers57Query = `
+		SELECT
+		    CHANNEL_NAME,
+		    WORKER_ID,
+		    0 AS applying,
+		    LAST_SEEN_TRANSACTION AS last_applied,
+		    @@server_uuid AS server_uuid,
+		    @@slave_parallel_workers AS parallel_workers
+		  FROM performance_schema.replication_applier_status_by_worker
+		`
+	// the workers of the servers not scraped for this long are dropped
+	replicationParallelWorkersTTL = time.Hour
+)
+
+// Metric descriptors.
+var (
+	replicationParallelWorkersConfiguredDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "replica", "parallel_workers_configured"),
+		"Value of slave_parallel_workers, only reported by the replicas.",
+		[]string{}, nil,
+	)
+	replicationParallelWorkersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "replica", "parallel_workers"),
+		"Number of the applier workers of the channel.",
+		[]string{"channel_name"}, nil,
+	)
+	replicationParallelWorkersActiveDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "replica", "parallel_workers_active"),
+		"Number of the applier workers of the channel that applied a transaction since the last scrape or are applying one. Far below the configured workers means the parallelism is not helping.",
+		[]string{"channel_name"}, nil,
+	)
+)
+
+type parallelWorkerKey struct {
+	server, channel string
+	worker          uint64
+}
+
+type parallelWorkerState struct {
+	lastApplied string
+	seen        time.Time
+}
+
+// parallelWorkerStates remembers the last applied transaction of every worker, keyed by @@server_uuid
+// like applierBusyStates
+var parallelWorkerStates = struct {
+	sync.Mutex
+	m map[parallelWorkerKey]parallelWorkerState
+}{m: make(map[parallelWorkerKey]parallelWorkerState)}
+
+// ScrapeReplicationParallelWorkers compares the configured applier workers with the ones used between two scrapes.
+type ScrapeReplicationParallelWorkers struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeReplicationParallelWorkers) Name() string {
+	return replicationParallelWorkers
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeReplicationParallelWorkers) Help() string {
+	return "Collect the configured replication applier workers and the ones applying transactions"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeReplicationParallelWorkers) Version() float64 {
+	return 5.7
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeReplicationParallelWorkers) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, replicationParallelWorkersQuery)
+	// ER_BAD_FIELD_ERROR before 8.0
+	if isMySQLError(err, 1054) {
+		rows, err = db.QueryContext(ctx, replicationParallelWorkers57Query)
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type worker struct {
+		id          uint64
+		applying    bool
+		lastApplied sql.NullString
+	}
+
+	var (
+		channel, server string
+		w               worker
+		configured      float64
+	)
+	workers := make(map[string][]worker)
+	for rows.Next() {
+		if err := rows.Scan(&channel, &w.id, &w.applying, &w.lastApplied, &server, &configured); err != nil {
+			return err
+		}
+		workers[channel] = append(workers[channel], w)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// not a replica
+	if len(workers) == 0 {
+		return nil
+	}
+
+	channels := make([]string, 0, len(workers))
+	for channel := range workers {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+
+	seen := time.Now()
+	parallelWorkerStates.Lock()
+	defer parallelWorkerStates.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(replicationParallelWorkersConfiguredDesc, prometheus.GaugeValue, configured)
+	for _, channel := range channels {
+		ch <- prometheus.MustNewConstMetric(
+			replicationParallelWorkersDesc, prometheus.GaugeValue, float64(len(workers[channel])), channel,
+		)
+
+		// the workers are only active since the last scrape if there is one
+		var active, known int
+		for _, w := range workers[channel] {
+			key := parallelWorkerKey{server: server, channel: channel, worker: w.id}
+			last, ok := parallelWorkerStates.m[key]
+			parallelWorkerStates.m[key] = parallelWorkerState{lastApplied: w.lastApplied.String, seen: seen}
+			if !ok {
+				continue
+			}
+			known++
+			if w.applying || last.lastApplied != w.lastApplied.String {
+				active++
+			}
+		}
+		if known == 0 {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			replicationParallelWorkersActiveDesc, prometheus.GaugeValue, float64(active), channel,
+		)
+	}
+
+	for key, state := range parallelWorkerStates.m {
+		if seen.Sub(state.seen) > replicationParallelWorkersTTL {
+			delete(parallelWorkerStates.m, key)
+		}
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeReplicationParallelWorkers{}
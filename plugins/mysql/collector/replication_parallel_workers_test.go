@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	MySQL "github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeReplicationParallelWorkers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"CHANNEL_NAME", "WORKER_ID", "applying", "last_applied", "server_uuid", "parallel_workers"}
+	uuid := "5f2b8e6a-71ca-11e1-9e33-c80aa9429562"
+	// the first scrape only remembers the workers
+	mock.ExpectQuery(sanitizeQuery(replicationParallelWorkersQuery)).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("", 1, 0, "2024-01-01 00:00:01.000000", uuid, 4).
+		AddRow("", 2, 0, "2024-01-01 00:00:01.000000", uuid, 4).
+		AddRow("", 3, 0, "0000-00-00 00:00:00.000000", uuid, 4).
+		AddRow("", 4, 0, "0000-00-00 00:00:00.000000", uuid, 4))
+	// worker 1 applied another transaction, worker 2 is applying one, the others are idle
+	mock.ExpectQuery(sanitizeQuery(replicationParallelWorkersQuery)).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("", 1, 0, "2024-01-01 00:00:09.000000", uuid, 4).
+		AddRow("", 2, 1, "2024-01-01 00:00:01.000000", uuid, 4).
+		AddRow("", 3, 0, "0000-00-00 00:00:00.000000", uuid, 4).
+		AddRow("", 4, 0, "0000-00-00 00:00:00.000000", uuid, 4))
+	// 5.7 has no LAST_APPLIED_TRANSACTION_END_APPLY_TIMESTAMP
+	mock.ExpectQuery(sanitizeQuery(replicationParallelWorkersQuery)).WillReturnError(&MySQL.MySQLError{Number: 1054, Message: "Unknown column 'APPLYING_TRANSACTION' in 'field list'"})
+	mock.ExpectQuery(sanitizeQuery(replicationParallelWorkers57Query)).WillReturnRows(sqlmock.NewRows(columns))
+
+	scrape := func() []MetricResult {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := (ScrapeReplicationParallelWorkers{}).Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		var ret []MetricResult
+		for m := range ch {
+			ret = append(ret, readMetric(m))
+		}
+		return ret
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		convey.So(scrape(), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{}, value: 4, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"channel_name": ""}, value: 4, metricType: dto.MetricType_GAUGE},
+		})
+		convey.So(scrape(), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{}, value: 4, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"channel_name": ""}, value: 4, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{"channel_name": ""}, value: 2, metricType: dto.MetricType_GAUGE},
+		})
+		// not a replica
+		convey.So(scrape(), convey.ShouldBeEmpty)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
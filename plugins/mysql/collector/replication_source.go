@@ -0,0 +1,111 @@
+// Scrape the source of the replication channels from `SHOW SLAVE STATUS`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	replication = "replication"
+	// Scraper name.
+	replicationSource = "replication_source"
+	// Queries.
+	serverIDQuery = `SELECT @@server_id`
+)
+
+// Tried in order: MariaDB, MySQL 8.0.22+ (Source_* columns) and the older MySQL (Master_* columns).
+var replicationSourceQueries = [3]string{"SHOW ALL SLAVES STATUS", "SHOW REPLICA STATUS", "SHOW SLAVE STATUS"}
+
+// Metric descriptors.
+var (
+	replicationSourceDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, replication, "source"),
+		"The source the replication channel replicates from, always 1.",
+		[]string{"channel_name", "server_id", "source_host", "source_port", "source_server_id"}, nil,
+	)
+)
+
+// ScrapeReplicationSource reports who the instance replicates from, for reconstructing the topology across the targets.
+type ScrapeReplicationSource struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeReplicationSource) Name() string {
+	return replicationSource
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeReplicationSource) Help() string {
+	return "Collect the source host and port of the replication channels"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeReplicationSource) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeReplicationSource) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var serverID string
+	if err := db.QueryRowContext(ctx, serverIDQuery).Scan(&serverID); err != nil {
+		return err
+	}
+
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	for _, query := range replicationSourceQueries {
+		if rows, err = db.QueryContext(ctx, query); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		scanArgs := make([]interface{}, len(cols))
+		for i := range scanArgs {
+			scanArgs[i] = &sql.RawBytes{}
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+
+		value := func(names ...string) string {
+			for _, name := range names {
+				if v := columnValue(scanArgs, cols, name); v != "" {
+					return v
+				}
+			}
+			return ""
+		}
+
+		// Not a replica, e.g. after RESET SLAVE
+		host := value("Source_Host", "Master_Host")
+		if host == "" {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			replicationSourceDesc, prometheus.GaugeValue, 1,
+			value("Channel_Name", "Connection_name"), serverID, host,
+			value("Source_Port", "Master_Port"), value("Source_Server_Id", "Master_Server_Id"),
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeReplicationSource{}
@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeReplicationSource(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(serverIDQuery)).WillReturnRows(sqlmock.NewRows([]string{"@@server_id"}).AddRow("3"))
+	mock.ExpectQuery(sanitizeQuery("SHOW ALL SLAVES STATUS")).WillReturnError(fmt.Errorf("ERROR 1064"))
+	mock.ExpectQuery(sanitizeQuery("SHOW REPLICA STATUS")).WillReturnRows(
+		sqlmock.NewRows([]string{"Source_Host", "Source_Port", "Source_Server_Id", "Channel_Name"}).
+			AddRow("10.0.0.1", "3306", "1", "").
+			AddRow("10.0.0.2", "3307", "2", "analytics").
+			AddRow("", "3306", "0", "stopped"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeReplicationSource{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"channel_name": "", "server_id": "3", "source_host": "10.0.0.1", "source_port": "3306", "source_server_id": "1"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"channel_name": "analytics", "server_id": "3", "source_host": "10.0.0.2", "source_port": "3307", "source_server_id": "2"}, value: 1, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,117 @@
+// Scrape the SSL settings of the replication channels from `SHOW SLAVE STATUS`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scraper name.
+	replicationSSL = "replication_ssl"
+)
+
+// Metric descriptors.
+var (
+	replicationSSLEnabledDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "replica", "source_ssl_enabled"),
+		"Whether the connection of the replication channel to its source is configured to use SSL, Master_SSL_Allowed = Yes.",
+		[]string{"channel_name"}, nil,
+	)
+	replicationSSLVerifyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "replica", "source_ssl_verify_server_cert"),
+		"Whether the replication channel verifies the certificate of its source, Master_SSL_Verify_Server_Cert = Yes.",
+		[]string{"channel_name"}, nil,
+	)
+)
+
+// ScrapeReplicationSSL reports whether the replication channels are encrypted,
+// so that a channel falling back to plaintext after CHANGE MASTER TO is noticed.
+type ScrapeReplicationSSL struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeReplicationSSL) Name() string {
+	return replicationSSL
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeReplicationSSL) Help() string {
+	return "Collect whether the replication channels connect to their source with SSL"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeReplicationSSL) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeReplicationSSL) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	for _, query := range replicationSourceQueries {
+		if rows, err = db.QueryContext(ctx, query); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		scanArgs := make([]interface{}, len(cols))
+		for i := range scanArgs {
+			scanArgs[i] = &sql.RawBytes{}
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+
+		value := func(names ...string) string {
+			for _, name := range names {
+				if v := columnValue(scanArgs, cols, name); v != "" {
+					return v
+				}
+			}
+			return ""
+		}
+
+		// Not a replica, e.g. after RESET SLAVE
+		if value("Source_Host", "Master_Host") == "" {
+			continue
+		}
+
+		channelName := value("Channel_Name", "Connection_name")
+		ch <- prometheus.MustNewConstMetric(
+			replicationSSLEnabledDesc, prometheus.GaugeValue,
+			yesValue(value("Source_SSL_Allowed", "Master_SSL_Allowed")), channelName,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			replicationSSLVerifyDesc, prometheus.GaugeValue,
+			yesValue(value("Source_SSL_Verify_Server_Cert", "Master_SSL_Verify_Server_Cert")), channelName,
+		)
+	}
+	return rows.Err()
+}
+
+// yesValue is 1 for Yes, 0 for the others, e.g. No or Ignored when SSL is allowed but not supported by the replica
+func yesValue(v string) float64 {
+	if strings.EqualFold(v, "Yes") {
+		return 1
+	}
+	return 0
+}
+
+// check interface
+var _ Scraper = ScrapeReplicationSSL{}
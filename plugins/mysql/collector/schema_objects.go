@@ -0,0 +1,117 @@
+// Scrape the number of stored routines, triggers and events per schema from `information_schema`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	schemaObjects = "schema_objects"
+	// Queries.
+	schemaObjectsQuery = `
+		SELECT ROUTINE_SCHEMA, LOWER(ROUTINE_TYPE), COUNT(*)
+		  FROM information_schema.routines
+		  GROUP BY ROUTINE_SCHEMA, ROUTINE_TYPE
+		UNION ALL
+		SELECT TRIGGER_SCHEMA, 'trigger', COUNT(*)
+		  FROM information_schema.triggers
+		  GROUP BY TRIGGER_SCHEMA
+		UNION ALL
+		SELECT EVENT_SCHEMA, 'event', COUNT(*)
+		  FROM information_schema.events
+		  GROUP BY EVENT_SCHEMA
+		`
+)
+
+// Metric descriptors.
+var (
+	schemaObjectCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "schema", "object_count"),
+		"Number of stored procedures, functions, triggers and events per schema.",
+		[]string{"schema", "object_type"}, nil,
+	)
+)
+
+// ScrapeSchemaObjects collects the number of stored routines, triggers and events per schema.
+type ScrapeSchemaObjects struct {
+	// Include only collects the schemas matching the regexp, empty means all
+	Include string
+	// Exclude skips the schemas matching the regexp
+	Exclude string
+	// ScrapeInterval runs the scraper less often than the job, the objects rarely change
+	ScrapeInterval time.Duration
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeSchemaObjects) Name() string {
+	return schemaObjects
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeSchemaObjects) Help() string {
+	return "Collect the number of stored routines, triggers and events per schema from information_schema"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeSchemaObjects) Version() float64 {
+	return 5.1
+}
+
+// Interval between two runs of the scraper for a target.
+func (s ScrapeSchemaObjects) Interval() time.Duration {
+	return s.ScrapeInterval
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapeSchemaObjects) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var include, exclude *regexp.Regexp
+	var err error
+	if s.Include != "" {
+		if include, err = regexp.Compile(s.Include); err != nil {
+			return fmt.Errorf("invalid include regexp %q: %w", s.Include, err)
+		}
+	}
+	if s.Exclude != "" {
+		if exclude, err = regexp.Compile(s.Exclude); err != nil {
+			return fmt.Errorf("invalid exclude regexp %q: %w", s.Exclude, err)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, schemaObjectsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		schema, objectType string
+		count              uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&schema, &objectType, &count); err != nil {
+			return err
+		}
+		if include != nil && !include.MatchString(schema) {
+			continue
+		}
+		if exclude != nil && exclude.MatchString(schema) {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			schemaObjectCountDesc, prometheus.GaugeValue, float64(count), schema, objectType,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeSchemaObjects{}
+var _ IntervalScraper = ScrapeSchemaObjects{}
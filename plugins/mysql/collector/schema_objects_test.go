@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeSchemaObjects(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"ROUTINE_SCHEMA", "LOWER(ROUTINE_TYPE)", "COUNT(*)"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", "procedure", 3).
+		AddRow("app", "function", 1).
+		AddRow("sys", "procedure", 26).
+		AddRow("app_tmp", "trigger", 2).
+		AddRow("app", "event", 1)
+	mock.ExpectQuery(sanitizeQuery(schemaObjectsQuery)).WillReturnRows(rows)
+
+	scraper := ScrapeSchemaObjects{Include: "^app", Exclude: "_tmp$"}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = scraper.Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"schema": "app", "object_type": "procedure"}, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "app", "object_type": "function"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "app", "object_type": "event"}, value: 1, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,129 @@
+// Scrape `Rpl_semi_sync_%` from `SHOW GLOBAL STATUS`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	semiSync = "rpl_semi_sync"
+	// Query.
+	semiSyncQuery = `SHOW GLOBAL STATUS LIKE 'Rpl_semi_sync_%'`
+)
+
+// Metric descriptors.
+var (
+	semiSyncMasterStatusDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, semiSync, "master_status"),
+		"Whether semisynchronous replication is currently operational on the source (1 = ON, 0 = OFF).",
+		[]string{}, nil,
+	)
+	semiSyncSlaveStatusDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, semiSync, "slave_status"),
+		"Whether semisynchronous replication is currently operational on the replica (1 = ON, 0 = OFF).",
+		[]string{}, nil,
+	)
+	semiSyncMasterClientsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, semiSync, "master_clients"),
+		"Number of semisynchronous replicas.",
+		[]string{}, nil,
+	)
+	semiSyncMasterYesTxDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, semiSync, "master_yes_tx_total"),
+		"Number of commits that were acknowledged successfully by a replica.",
+		[]string{}, nil,
+	)
+	semiSyncMasterNoTxDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, semiSync, "master_no_tx_total"),
+		"Number of commits that were not acknowledged successfully by a replica.",
+		[]string{}, nil,
+	)
+	semiSyncMasterTxWaitsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, semiSync, "master_tx_waits_total"),
+		"Total number of times the source waited for transactions.",
+		[]string{}, nil,
+	)
+	semiSyncMasterTxWaitSecondsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, semiSync, "master_tx_wait_seconds_total"),
+		"Total time the source spent waiting for transactions.",
+		[]string{}, nil,
+	)
+	semiSyncMasterTxAvgWaitSecondsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, semiSync, "master_tx_avg_wait_seconds"),
+		"Average time the source waited for each transaction.",
+		[]string{}, nil,
+	)
+)
+
+// semiSyncMetrics maps the status variables (without the Rpl_semi_sync_ prefix) to metrics,
+// the divisor converts microseconds to seconds.
+var semiSyncMetrics = []struct {
+	name      string
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	divisor   float64
+}{
+	{"master_status", semiSyncMasterStatusDesc, prometheus.GaugeValue, 1},
+	{"slave_status", semiSyncSlaveStatusDesc, prometheus.GaugeValue, 1},
+	{"master_clients", semiSyncMasterClientsDesc, prometheus.GaugeValue, 1},
+	{"master_yes_tx", semiSyncMasterYesTxDesc, prometheus.CounterValue, 1},
+	{"master_no_tx", semiSyncMasterNoTxDesc, prometheus.CounterValue, 1},
+	{"master_tx_waits", semiSyncMasterTxWaitsDesc, prometheus.CounterValue, 1},
+	{"master_tx_wait_time", semiSyncMasterTxWaitSecondsDesc, prometheus.CounterValue, 1e6},
+	{"master_tx_avg_wait_time", semiSyncMasterTxAvgWaitSecondsDesc, prometheus.GaugeValue, 1e6},
+}
+
+// ScrapeSemiSync collects the semisynchronous replication status.
+type ScrapeSemiSync struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeSemiSync) Name() string {
+	return semiSync
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeSemiSync) Help() string {
+	return "Collect semisynchronous replication status from SHOW GLOBAL STATUS"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeSemiSync) Version() float64 {
+	return 5.5
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeSemiSync) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	// no rows if the semisync plugins are not loaded
+	values, err := globalStatusValues(ctx, db, semiSyncQuery)
+	if err != nil {
+		return err
+	}
+
+	normalized := make(map[string]float64, len(values))
+	for key, value := range values {
+		key = strings.TrimPrefix(key, "rpl_semi_sync_")
+		// MySQL 8.0.26+ semisync plugins use source/replica instead of master/slave
+		if strings.HasPrefix(key, "source_") {
+			key = "master_" + strings.TrimPrefix(key, "source_")
+		} else if strings.HasPrefix(key, "replica_") {
+			key = "slave_" + strings.TrimPrefix(key, "replica_")
+		}
+		normalized[key] = value
+	}
+
+	for _, m := range semiSyncMetrics {
+		if v, ok := normalized[m.name]; ok {
+			ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, v/m.divisor)
+		}
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeSemiSync{}
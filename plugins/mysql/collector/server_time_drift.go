@@ -0,0 +1,70 @@
+// Scrape the drift of the server clock from `SELECT NOW(6)`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scraper name.
+	serverTimeDrift = "server_time_drift"
+	// Query. NOW(6) is the start of the statement, the fractional seconds are available since 5.6.4.
+	serverTimeDriftQuery = `SELECT UNIX_TIMESTAMP(NOW(6))`
+)
+
+// Metric descriptors.
+var (
+	serverTimeDriftDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "server", "time_drift_seconds"),
+		"Time of the server minus the local time at the middle of the round trip of the query, positive if the server clock is ahead.",
+		[]string{}, nil,
+	)
+	serverTimeDriftRoundTripDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "server", "time_drift_round_trip_seconds"),
+		"Round trip of the query measuring the drift, the drift is accurate to half of it.",
+		[]string{}, nil,
+	)
+)
+
+// ScrapeServerTimeDrift compares the clock of the server with the local one, the skew corrupts the lag measured
+// by the heartbeat tables and the correlation with the other metrics.
+type ScrapeServerTimeDrift struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeServerTimeDrift) Name() string {
+	return serverTimeDrift
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeServerTimeDrift) Help() string {
+	return "Collect the drift of the server clock from the local one"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeServerTimeDrift) Version() float64 {
+	return 5.6
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeServerTimeDrift) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var serverTime float64
+	start := time.Now()
+	if err := db.QueryRowContext(ctx, serverTimeDriftQuery).Scan(&serverTime); err != nil {
+		return err
+	}
+	roundTrip := time.Since(start)
+	local := start.Add(roundTrip / 2)
+
+	drift := serverTime - float64(local.UnixNano())/1e9
+	ch <- prometheus.MustNewConstMetric(serverTimeDriftDesc, prometheus.GaugeValue, drift)
+	ch <- prometheus.MustNewConstMetric(serverTimeDriftRoundTripDesc, prometheus.GaugeValue, roundTrip.Seconds())
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeServerTimeDrift{}
@@ -0,0 +1,49 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeServerTimeDrift(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	// the server clock is 5 seconds ahead
+	serverTime := fmt.Sprintf("%.6f", float64(time.Now().Add(5*time.Second).UnixNano())/1e9)
+	mock.ExpectQuery(sanitizeQuery(serverTimeDriftQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"UNIX_TIMESTAMP(NOW(6))"}).AddRow(serverTime))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeServerTimeDrift{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	convey.Convey("Metrics comparison", t, func() {
+		drift := readMetric(<-ch)
+		convey.So(drift.metricType, convey.ShouldEqual, dto.MetricType_GAUGE)
+		convey.So(drift.value, convey.ShouldAlmostEqual, 5, 0.5)
+
+		roundTrip := readMetric(<-ch)
+		convey.So(roundTrip.metricType, convey.ShouldEqual, dto.MetricType_GAUGE)
+		convey.So(roundTrip.value, convey.ShouldBeBetween, 0, 0.5)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,157 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// openDB opens the database, if session statements or an init sql file are configured they are
+// executed every time the pool establishes a new connection, if Options.Credentials is set the
+// user and password are got from it on every new connection, if Options.QueryComment is set the
+// statements are commented with the target and the name of the scraper or custom query
+func (e *Exporter) openDB(dsn string) (*sql.DB, error) {
+	if len(e.opts.SessionStatements) == 0 && e.opts.InitSQLFile == "" && e.opts.Credentials == nil && !e.opts.QueryComment {
+		return sql.Open("mysql", dsn)
+	}
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	var connector driver.Connector
+	if e.opts.Credentials != nil {
+		connector = &credentialsConnector{cfg: cfg, provider: e.opts.Credentials}
+	} else if connector, err = mysql.NewConnector(cfg); err != nil {
+		return nil, err
+	}
+
+	if len(e.opts.SessionStatements) > 0 || e.opts.InitSQLFile != "" {
+		connector = &sessionConnector{Connector: connector, statements: e.opts.SessionStatements, initSQLFile: e.opts.InitSQLFile}
+	}
+
+	if e.opts.QueryComment {
+		target := e.opts.ServerLabel
+		if target == "" {
+			target = dsnAddr(dsn)
+		}
+		connector = &commentConnector{Connector: connector, target: target}
+	}
+	return sql.OpenDB(connector), nil
+}
+
+// sessionConnector runs the statements and then the ones of initSQLFile right after connecting,
+// an error fails the connection so that the scrape fails at ping with the failed statement.
+// initSQLFile is read on every new connection, so the edits take effect without a restart.
+type sessionConnector struct {
+	driver.Connector
+	statements  []string
+	initSQLFile string
+}
+
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("driver connection does not support executing session statements")
+	}
+
+	for _, statement := range c.statements {
+		if _, err := execer.ExecContext(ctx, statement, nil); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to execute session statement %q: %w", statement, err)
+		}
+	}
+
+	if c.initSQLFile == "" {
+		return conn, nil
+	}
+
+	content, err := os.ReadFile(c.initSQLFile)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read init_sql_file: %w", err)
+	}
+
+	for _, statement := range splitSQLStatements(string(content)) {
+		if _, err := execer.ExecContext(ctx, statement, nil); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to execute statement %q of init_sql_file %s: %w", statement, c.initSQLFile, err)
+		}
+	}
+
+	return conn, nil
+}
+
+// splitSQLStatements splits the content by the semicolons outside of the quotes and comments,
+// the comments and the blank statements are dropped
+func splitSQLStatements(content string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quote      byte
+	)
+
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			current.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(content) {
+				i++
+				current.WriteByte(content[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteByte(c)
+		case c == '#' || isDashComment(content[i:]):
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case c == '/' && strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end + 3
+			}
+			current.WriteByte(' ')
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return statements
+}
+
+// isDashComment reports whether s starts with a `-- ` comment, the dashes should be followed by a whitespace
+func isDashComment(s string) bool {
+	if !strings.HasPrefix(s, "--") {
+		return false
+	}
+	return len(s) == 2 || s[2] == ' ' || s[2] == '\t' || s[2] == '\n' || s[2] == '\r'
+}
@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+type dsnConnector struct {
+	dsn string
+	drv driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.drv.Open(c.dsn) }
+func (c dsnConnector) Driver() driver.Driver                      { return c.drv }
+
+func TestSessionConnector(t *testing.T) {
+	statements := []string{
+		"SET SESSION transaction_isolation='READ-COMMITTED'",
+		"SET RESOURCE GROUP monitoring",
+	}
+
+	convey.Convey("Session statements are executed on connect", t, func() {
+		mockDB, mock, err := sqlmock.NewWithDSN("session_ok")
+		convey.So(err, convey.ShouldBeNil)
+		defer mockDB.Close()
+
+		mock.ExpectExec("SET SESSION transaction_isolation='READ-COMMITTED'").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SET RESOURCE GROUP monitoring").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPing()
+
+		db := sql.OpenDB(&sessionConnector{Connector: dsnConnector{dsn: "session_ok", drv: mockDB.Driver()}, statements: statements})
+		defer db.Close()
+
+		convey.So(db.PingContext(context.Background()), convey.ShouldBeNil)
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+	})
+
+	convey.Convey("Failed statement fails the connection", t, func() {
+		mockDB, mock, err := sqlmock.NewWithDSN("session_fail")
+		convey.So(err, convey.ShouldBeNil)
+		defer mockDB.Close()
+
+		mock.ExpectExec("SET SESSION transaction_isolation='READ-COMMITTED'").WillReturnError(errors.New("unknown variable"))
+
+		db := sql.OpenDB(&sessionConnector{Connector: dsnConnector{dsn: "session_fail", drv: mockDB.Driver()}, statements: statements})
+		defer db.Close()
+
+		err = db.PingContext(context.Background())
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(err.Error(), convey.ShouldContainSubstring, "failed to execute session statement")
+	})
+}
+
+func TestSplitSQLStatements(t *testing.T) {
+	content := `-- monitoring session
+SET SESSION transaction_isolation='READ-COMMITTED';
+SET @note = 'a;b -- not a comment';  # trailing comment
+/* block; comment */ SET SESSION max_execution_time = 5000
+;
+SET @x = "it\"s;"`
+
+	convey.Convey("Statements are split outside quotes and comments", t, func() {
+		convey.So(splitSQLStatements(content), convey.ShouldResemble, []string{
+			"SET SESSION transaction_isolation='READ-COMMITTED'",
+			"SET @note = 'a;b -- not a comment'",
+			"SET SESSION max_execution_time = 5000",
+			`SET @x = "it\"s;"`,
+		})
+		convey.So(splitSQLStatements("\n-- nothing\n;;"), convey.ShouldBeEmpty)
+	})
+}
+
+func TestSessionConnectorInitSQLFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "init.sql")
+
+	convey.Convey("The file is read on every new connection", t, func() {
+		mockDB, mock, err := sqlmock.NewWithDSN("init_file_ok")
+		convey.So(err, convey.ShouldBeNil)
+		defer mockDB.Close()
+
+		convey.So(os.WriteFile(path, []byte("SET @a = 1;\nSET @b = 2;\n"), 0644), convey.ShouldBeNil)
+		mock.ExpectExec("SET RESOURCE GROUP monitoring").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SET @a = 1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SET @b = 2").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectPing()
+
+		connector := &sessionConnector{
+			Connector:   dsnConnector{dsn: "init_file_ok", drv: mockDB.Driver()},
+			statements:  []string{"SET RESOURCE GROUP monitoring"},
+			initSQLFile: path,
+		}
+		db := sql.OpenDB(connector)
+		convey.So(db.PingContext(context.Background()), convey.ShouldBeNil)
+		convey.So(mock.ExpectationsWereMet(), convey.ShouldBeNil)
+		db.Close()
+	})
+
+	convey.Convey("Failed statement of the file fails the connection", t, func() {
+		mockDB, mock, err := sqlmock.NewWithDSN("init_file_fail")
+		convey.So(err, convey.ShouldBeNil)
+		defer mockDB.Close()
+
+		convey.So(os.WriteFile(path, []byte("SET @c = 3;"), 0644), convey.ShouldBeNil)
+		mock.ExpectExec("SET @c = 3").WillReturnError(errors.New("denied"))
+
+		db := sql.OpenDB(&sessionConnector{Connector: dsnConnector{dsn: "init_file_fail", drv: mockDB.Driver()}, initSQLFile: path})
+		defer db.Close()
+
+		err = db.PingContext(context.Background())
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(err.Error(), convey.ShouldContainSubstring, "of init_sql_file")
+	})
+}
@@ -0,0 +1,113 @@
+// Scrape the p50, p95 and max of `Seconds_Behind_Master` over the last scrapes for ScrapeSlaveStatus.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Query, @@server_uuid is not available on MariaDB, the windows are keyed by the host name and the port instead.
+	slaveLagServerQuery = `SELECT CONCAT(@@hostname, ':', @@port)`
+	// The windows of the servers or channels not scraped within the TTL are forgotten.
+	slaveLagWindowTTL = time.Hour
+)
+
+// Metric descriptors.
+var (
+	slaveLagLabels = []string{"master_host", "master_uuid", "channel_name", "connection_name"}
+
+	slaveLagP50Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, slaveStatus, "lag_p50_seconds"),
+		"Median of Seconds_Behind_Master over the last scrapes of the lag window.",
+		slaveLagLabels, nil,
+	)
+	slaveLagP95Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, slaveStatus, "lag_p95_seconds"),
+		"95th percentile of Seconds_Behind_Master over the last scrapes of the lag window.",
+		slaveLagLabels, nil,
+	)
+	slaveLagMaxDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, slaveStatus, "lag_max_seconds"),
+		"Max of Seconds_Behind_Master over the last scrapes of the lag window.",
+		slaveLagLabels, nil,
+	)
+)
+
+// slaveLag is the Seconds_Behind_Master of a channel with the values of slaveLagLabels
+type slaveLag struct {
+	labels []string
+	value  float64
+}
+
+type slaveLagKey struct {
+	server, channel, connection string
+}
+
+type slaveLagWindow struct {
+	values []float64
+	seen   time.Time
+}
+
+// slaveLagWindows remembers the last lag samples of every channel, keyed by the server like applierBusyStates
+var slaveLagWindows = struct {
+	sync.Mutex
+	m map[slaveLagKey]*slaveLagWindow
+}{m: make(map[slaveLagKey]*slaveLagWindow)}
+
+// scrapeSlaveLagWindow appends the lags to the windows of at most size samples and emits the percentiles of them,
+// a transient spike moves the max only while a sustained lag moves the p50 too
+func scrapeSlaveLagWindow(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric, size int, lags []slaveLag) error {
+	var server string
+	if err := db.QueryRowContext(ctx, slaveLagServerQuery).Scan(&server); err != nil {
+		return err
+	}
+	seen := time.Now()
+
+	slaveLagWindows.Lock()
+	defer slaveLagWindows.Unlock()
+
+	for _, lag := range lags {
+		key := slaveLagKey{server: server, channel: lag.labels[2], connection: lag.labels[3]}
+		w, ok := slaveLagWindows.m[key]
+		if !ok {
+			w = &slaveLagWindow{}
+			slaveLagWindows.m[key] = w
+		}
+		w.values = append(w.values, lag.value)
+		if len(w.values) > size {
+			w.values = w.values[len(w.values)-size:]
+		}
+		w.seen = seen
+
+		sorted := append([]float64(nil), w.values...)
+		sort.Float64s(sorted)
+
+		ch <- prometheus.MustNewConstMetric(slaveLagP50Desc, prometheus.GaugeValue, nearestRank(sorted, 0.5), lag.labels...)
+		ch <- prometheus.MustNewConstMetric(slaveLagP95Desc, prometheus.GaugeValue, nearestRank(sorted, 0.95), lag.labels...)
+		ch <- prometheus.MustNewConstMetric(slaveLagMaxDesc, prometheus.GaugeValue, sorted[len(sorted)-1], lag.labels...)
+	}
+
+	for key, w := range slaveLagWindows.m {
+		if seen.Sub(w.seen) > slaveLagWindowTTL {
+			delete(slaveLagWindows.m, key)
+		}
+	}
+	return nil
+}
+
+// nearestRank returns the q quantile of the sorted values by the nearest-rank method, which is always one of the values
+func nearestRank(sorted []float64, q float64) float64 {
+	rank := int(math.Ceil(q * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -50,7 +51,10 @@ func columnValue(scanArgs []interface{}, slaveCols []string, colName string) str
 }
 
 // ScrapeSlaveStatus collects from `SHOW SLAVE STATUS`.
-type ScrapeSlaveStatus struct{}
+type ScrapeSlaveStatus struct {
+	// LagWindow is the number of the last lag samples of every channel the p50, p95 and max are emitted over, 0 disables it
+	LagWindow int
+}
 
 // Name of the Scraper. Should be unique.
 func (ScrapeSlaveStatus) Name() string {
@@ -68,7 +72,7 @@ func (ScrapeSlaveStatus) Version() float64 {
 }
 
 // Scrape collects data from database connection and sends it over channel as prometheus metric.
-func (ScrapeSlaveStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+func (s ScrapeSlaveStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
 	var (
 		slaveStatusRows *sql.Rows
 		err             error
@@ -98,6 +102,7 @@ func (ScrapeSlaveStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<- prome
 		return err
 	}
 
+	var lags []slaveLag
 	for slaveStatusRows.Next() {
 		// As the number of columns varies with mysqld versions,
 		// and sql.Scan requires []interface{}, we need to create a
@@ -116,6 +121,16 @@ func (ScrapeSlaveStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<- prome
 		channelName := columnValue(scanArgs, slaveCols, "Channel_Name")       // MySQL & Percona
 		connectionName := columnValue(scanArgs, slaveCols, "Connection_name") // MariaDB
 
+		if s.LagWindow > 0 {
+			// NULL while the SQL thread is not running
+			if lag, err := strconv.ParseFloat(columnValue(scanArgs, slaveCols, "Seconds_Behind_Master"), 64); err == nil {
+				lags = append(lags, slaveLag{
+					labels: []string{masterHost, masterUUID, channelName, connectionName},
+					value:  lag,
+				})
+			}
+		}
+
 		for i, col := range slaveCols {
 			if value, ok := parseStatus(*scanArgs[i].(*sql.RawBytes)); ok { // Silently skip unparsable values.
 				ch <- prometheus.MustNewConstMetric(
@@ -132,6 +147,13 @@ func (ScrapeSlaveStatus) Scrape(ctx context.Context, db *sql.DB, ch chan<- prome
 			}
 		}
 	}
+	if err := slaveStatusRows.Err(); err != nil {
+		return err
+	}
+
+	if len(lags) > 0 {
+		return scrapeSlaveLagWindow(ctx, db, ch, s.LagWindow, lags)
+	}
 	return nil
 }
 
@@ -61,3 +61,50 @@ func TestScrapeSlaveStatus(t *testing.T) {
 		t.Errorf("there were unfulfilled exceptions: %s", err)
 	}
 }
+
+func TestScrapeSlaveStatusLagWindow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	labels := labelMap{"channel_name": "", "connection_name": "", "master_host": "127.0.0.1", "master_uuid": ""}
+	scraper := ScrapeSlaveStatus{LagWindow: 3}
+
+	var got []MetricResult
+	for _, lag := range []string{"2", "10", "4", "3"} {
+		rows := sqlmock.NewRows([]string{"Master_Host", "Seconds_Behind_Master"}).AddRow("127.0.0.1", lag)
+		mock.ExpectQuery(sanitizeQuery("SHOW SLAVE STATUS")).WillReturnRows(rows)
+		mock.ExpectQuery(sanitizeQuery(slaveLagServerQuery)).WillReturnRows(sqlmock.NewRows([]string{"server"}).AddRow("db1:3306"))
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := scraper.Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		got = got[:0]
+		for m := range ch {
+			got = append(got, readMetric(m))
+		}
+	}
+
+	// the window of the last scrape is 10, 4 and 3
+	expected := []MetricResult{
+		{labels: labels, value: 3, metricType: dto.MetricType_UNTYPED},
+		{labels: labels, value: 4, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 10, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 10, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		convey.So(got, convey.ShouldResemble, expected)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
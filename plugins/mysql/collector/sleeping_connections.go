@@ -0,0 +1,154 @@
+// Scrape the sleeping connections from `information_schema.processlist`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	sleepingConnections = "sleeping_connections"
+	// Queries.
+	sleepingConnectionsQuery = `SELECT TIME FROM information_schema.processlist WHERE COMMAND = 'Sleep' AND ID != CONNECTION_ID()`
+	showGrantsQuery          = `SHOW GRANTS`
+)
+
+// DefaultSleepingConnectionsThresholds are the idle times in seconds the sleeping connections older than are counted.
+var DefaultSleepingConnectionsThresholds = []int{60, 600, 3600}
+
+// globalGrantRE matches the privileges granted on *.*
+var globalGrantRE = regexp.MustCompile(`(?i)^GRANT\s+(.+?)\s+ON\s+\*\.\*`)
+
+// Metric descriptors.
+var (
+	sleepingConnectionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", sleepingConnections),
+		"Number of the connections in the Sleep command, i.e. idle and holding a connection slot.",
+		[]string{}, nil,
+	)
+	sleepingConnectionsOlderThanDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sleepingConnections, "older_than"),
+		"Number of the sleeping connections idle for longer than threshold_seconds, a growing count suggests a connection pool leak.",
+		[]string{"threshold_seconds"}, nil,
+	)
+	sleepingConnectionsOldestDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, sleepingConnections, "oldest_seconds"),
+		"Idle time of the longest sleeping connection, 0 if there is none.",
+		[]string{}, nil,
+	)
+)
+
+// ScrapeSleepingConnections collects the number and the idle time of the sleeping connections,
+// counted by threshold instead of per connection to keep the cardinality low.
+type ScrapeSleepingConnections struct {
+	// Thresholds in seconds, the connections idle for longer than each one are counted
+	Thresholds []int
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeSleepingConnections) Name() string {
+	return informationSchema + "." + sleepingConnections
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeSleepingConnections) Help() string {
+	return "Collect the number and the idle time of the sleeping connections from information_schema.processlist"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeSleepingConnections) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapeSleepingConnections) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	// processlist only shows the connections of the current user without PROCESS, the counts would be misleading
+	if granted, err := hasGlobalPrivilege(ctx, db, "PROCESS"); err == nil && !granted {
+		logger.Warnf("cannot scrape %s, PROCESS is not granted", s.Name())
+		return nil
+	}
+
+	rows, err := db.QueryContext(ctx, sleepingConnectionsQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	thresholds := s.Thresholds
+	if len(thresholds) == 0 {
+		thresholds = DefaultSleepingConnectionsThresholds
+	}
+
+	var (
+		idle     uint64
+		sleeping int
+		oldest   uint64
+	)
+	olderThan := make([]int, len(thresholds))
+	for rows.Next() {
+		if err := rows.Scan(&idle); err != nil {
+			return err
+		}
+		sleeping++
+		if idle > oldest {
+			oldest = idle
+		}
+		for i, threshold := range thresholds {
+			if idle > uint64(threshold) {
+				olderThan[i]++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(sleepingConnectionsDesc, prometheus.GaugeValue, float64(sleeping))
+	ch <- prometheus.MustNewConstMetric(sleepingConnectionsOldestDesc, prometheus.GaugeValue, float64(oldest))
+	for i, threshold := range thresholds {
+		ch <- prometheus.MustNewConstMetric(
+			sleepingConnectionsOlderThanDesc, prometheus.GaugeValue, float64(olderThan[i]), strconv.Itoa(threshold),
+		)
+	}
+	return nil
+}
+
+// hasGlobalPrivilege checks the privilege is granted on *.* to the current user by SHOW GRANTS,
+// the privileges granted by the roles not activated are not seen
+func hasGlobalPrivilege(ctx context.Context, db *sql.DB, privilege string) (bool, error) {
+	rows, err := db.QueryContext(ctx, showGrantsQuery)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	var grant string
+	granted := false
+	for rows.Next() {
+		if err := rows.Scan(&grant); err != nil {
+			return false, err
+		}
+		match := globalGrantRE.FindStringSubmatch(grant)
+		if match == nil {
+			continue
+		}
+		for _, p := range strings.Split(match[1], ",") {
+			p = strings.ToUpper(strings.TrimSpace(p))
+			if p == privilege || p == "ALL" || p == "ALL PRIVILEGES" {
+				granted = true
+			}
+		}
+	}
+	return granted, rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeSleepingConnections{}
@@ -0,0 +1,82 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeSleepingConnections(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(showGrantsQuery)).WillReturnRows(sqlmock.NewRows([]string{"grants"}).
+		AddRow("GRANT PROCESS, REPLICATION CLIENT ON *.* TO `exporter`@`%`").
+		AddRow("GRANT SELECT ON `performance_schema`.* TO `exporter`@`%`"))
+	rows := sqlmock.NewRows([]string{"time"}).
+		AddRow(5).
+		AddRow(900).
+		AddRow(4000)
+	mock.ExpectQuery(sanitizeQuery(sleepingConnectionsQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeSleepingConnections{Thresholds: []int{60, 3600}}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 4000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"threshold_seconds": "60"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"threshold_seconds": "3600"}, value: 1, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestScrapeSleepingConnectionsWithoutProcess(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(sanitizeQuery(showGrantsQuery)).WillReturnRows(sqlmock.NewRows([]string{"grants"}).
+		AddRow("GRANT USAGE ON *.* TO `exporter`@`%`"))
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeSleepingConnections{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	convey.Convey("Nothing is emitted", t, func() {
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
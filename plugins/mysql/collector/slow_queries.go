@@ -0,0 +1,130 @@
+// Scrape `Slow_queries` from `SHOW GLOBAL STATUS` with its rate and the slow query log settings.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	slowQueries = "slow_queries"
+	// Queries, @@server_uuid is not available on MariaDB, the states are keyed by the host name and the port like slaveLagWindows.
+	slowQueriesStatusQuery    = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Slow_queries', 'Uptime')`
+	slowQueriesVariablesQuery = `SELECT CONCAT(@@hostname, ':', @@port), @@long_query_time, @@slow_query_log`
+	// the counters of the servers not scraped for this long are dropped
+	slowQueriesTTL = time.Hour
+)
+
+// Metric descriptors.
+var (
+	slowQueriesTotalDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, slowQueries, "total"),
+		"Number of the queries that took more than @@long_query_time, counted whether the slow query log is enabled or not.",
+		[]string{}, nil,
+	)
+	slowQueriesRateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, slowQueries, "rate_per_second"),
+		"Increase per second of Slow_queries since the last scrape by the server uptime.",
+		[]string{}, nil,
+	)
+	slowQueriesLongQueryTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, slowQueries, "long_query_time_seconds"),
+		"The value of @@long_query_time.",
+		[]string{}, nil,
+	)
+	slowQueriesLogEnabledDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, slowQueries, "log_enabled"),
+		"Whether the slow query log is enabled, from @@slow_query_log.",
+		[]string{}, nil,
+	)
+)
+
+type slowQueriesState struct {
+	uptime, value float64
+	seen          time.Time
+}
+
+// slowQueriesStates remembers Slow_queries of the last scrape of every server
+var slowQueriesStates = struct {
+	sync.Mutex
+	m map[string]slowQueriesState
+}{m: make(map[string]slowQueriesState)}
+
+// ScrapeSlowQueries collects the number of the slow queries and its rate between two scrapes.
+type ScrapeSlowQueries struct {
+	// Variables emits @@long_query_time and @@slow_query_log as well
+	Variables bool
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeSlowQueries) Name() string {
+	return slowQueries
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeSlowQueries) Help() string {
+	return "Collect the number and the rate of the slow queries from SHOW GLOBAL STATUS"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeSlowQueries) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapeSlowQueries) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	values, err := globalStatusValues(ctx, db, slowQueriesStatusQuery)
+	if err != nil {
+		return err
+	}
+
+	var (
+		server                    string
+		longQueryTime, slowLogged float64
+	)
+	if err := db.QueryRowContext(ctx, slowQueriesVariablesQuery).Scan(&server, &longQueryTime, &slowLogged); err != nil {
+		return err
+	}
+
+	value, ok := values["slow_queries"]
+	if ok {
+		ch <- prometheus.MustNewConstMetric(slowQueriesTotalDesc, prometheus.CounterValue, value)
+	}
+	if s.Variables {
+		ch <- prometheus.MustNewConstMetric(slowQueriesLongQueryTimeDesc, prometheus.GaugeValue, longQueryTime)
+		ch <- prometheus.MustNewConstMetric(slowQueriesLogEnabledDesc, prometheus.GaugeValue, slowLogged)
+	}
+
+	uptime, hasUptime := values["uptime"]
+	if !ok || !hasUptime {
+		return nil
+	}
+
+	seen := time.Now()
+	slowQueriesStates.Lock()
+	defer slowQueriesStates.Unlock()
+
+	last, ok := slowQueriesStates.m[server]
+	slowQueriesStates.m[server] = slowQueriesState{uptime: uptime, value: value, seen: seen}
+
+	// the counter is reset if the server restarted
+	if ok && uptime > last.uptime && value >= last.value {
+		ch <- prometheus.MustNewConstMetric(slowQueriesRateDesc, prometheus.GaugeValue, (value-last.value)/(uptime-last.uptime))
+	}
+
+	for key, state := range slowQueriesStates.m {
+		if seen.Sub(state.seen) > slowQueriesTTL {
+			delete(slowQueriesStates.m, key)
+		}
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeSlowQueries{}
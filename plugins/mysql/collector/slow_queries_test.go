@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeSlowQueries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"Variable_name", "Value"}
+	expectScrape := func(uptime, slow string) {
+		mock.ExpectQuery(regexp.QuoteMeta(slowQueriesStatusQuery)).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("Slow_queries", slow).
+			AddRow("Uptime", uptime))
+		mock.ExpectQuery(sanitizeQuery(slowQueriesVariablesQuery)).WillReturnRows(
+			sqlmock.NewRows([]string{"server", "@@long_query_time", "@@slow_query_log"}).AddRow("db1:3306", 0.5, 1))
+	}
+	// the first scrape only remembers the counter
+	expectScrape("1000", "40")
+	expectScrape("1010", "45")
+	// restarted
+	expectScrape("5", "1")
+
+	scrape := func(scraper ScrapeSlowQueries) []MetricResult {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := scraper.Scrape(context.Background(), db, ch); err != nil {
+				t.Errorf("error calling function on test: %s", err)
+			}
+			close(ch)
+		}()
+
+		var ret []MetricResult
+		for m := range ch {
+			ret = append(ret, readMetric(m))
+		}
+		return ret
+	}
+
+	convey.Convey("Metrics comparison", t, func() {
+		convey.So(scrape(ScrapeSlowQueries{Variables: true}), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{}, value: 40, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 0.5, metricType: dto.MetricType_GAUGE},
+			{labels: labelMap{}, value: 1, metricType: dto.MetricType_GAUGE},
+		})
+		convey.So(scrape(ScrapeSlowQueries{}), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{}, value: 45, metricType: dto.MetricType_COUNTER},
+			{labels: labelMap{}, value: 0.5, metricType: dto.MetricType_GAUGE},
+		})
+		convey.So(scrape(ScrapeSlowQueries{}), convey.ShouldResemble, []MetricResult{
+			{labels: labelMap{}, value: 1, metricType: dto.MetricType_COUNTER},
+		})
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
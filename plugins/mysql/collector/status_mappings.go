@@ -0,0 +1,142 @@
+// Scrape the `SHOW GLOBAL STATUS` variables mapped by the configuration.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	statusMappings = "status_mappings"
+)
+
+// StatusMapping maps the status variables matching Pattern to the metric mysql_<Name>,
+// the label values can refer to the capture groups of Pattern, e.g.
+// pattern: 'com_(.*)' name: 'commands_total' type: 'counter' labels: { command = '$1' }
+type StatusMapping struct {
+	// Pattern is a regexp matching the whole lower-cased variable name
+	Pattern string            `toml:"pattern"`
+	Name    string            `toml:"name"`
+	Type    string            `toml:"type"`
+	Help    string            `toml:"help"`
+	Labels  map[string]string `toml:"labels"`
+
+	re *regexp.Regexp
+}
+
+// Compile checks the mapping and compiles the pattern.
+func (m *StatusMapping) Compile() error {
+	if m.Name == "" {
+		return fmt.Errorf("name of status mapping %q is blank", m.Pattern)
+	}
+
+	switch m.Type {
+	case "", "gauge", "counter", "untyped":
+	default:
+		return fmt.Errorf("invalid type %q of status mapping %s", m.Type, m.Name)
+	}
+
+	re, err := regexp.Compile("^(?i:" + m.Pattern + ")$")
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q of status mapping %s: %w", m.Pattern, m.Name, err)
+	}
+	m.re = re
+	return nil
+}
+
+func (m *StatusMapping) valueType() prometheus.ValueType {
+	switch m.Type {
+	case "gauge":
+		return prometheus.GaugeValue
+	case "counter":
+		return prometheus.CounterValue
+	}
+	return prometheus.UntypedValue
+}
+
+// ScrapeStatusMappings collects the status variables mapped by the configuration.
+type ScrapeStatusMappings struct {
+	// Mappings are matched in order, a variable is emitted by the first matching mapping
+	Mappings []StatusMapping
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeStatusMappings) Name() string {
+	return statusMappings
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeStatusMappings) Help() string {
+	return "Collect the SHOW GLOBAL STATUS variables mapped by the configuration"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeStatusMappings) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapeStatusMappings) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	for i := range s.Mappings {
+		if s.Mappings[i].re == nil {
+			if err := s.Mappings[i].Compile(); err != nil {
+				return err
+			}
+		}
+	}
+
+	values, err := globalStatusValues(ctx, db, globalStatusQuery)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for i := range s.Mappings {
+			m := &s.Mappings[i]
+			match := m.re.FindStringSubmatchIndex(key)
+			if match == nil {
+				continue
+			}
+
+			labelNames := make([]string, 0, len(m.Labels))
+			for name := range m.Labels {
+				labelNames = append(labelNames, name)
+			}
+			sort.Strings(labelNames)
+
+			labelValues := make([]string, 0, len(labelNames))
+			for _, name := range labelNames {
+				labelValues = append(labelValues, string(m.re.ExpandString(nil, m.Labels[name], key, match)))
+			}
+
+			help := m.Help
+			if help == "" {
+				help = "Mapped from SHOW GLOBAL STATUS."
+			}
+			desc := prometheus.NewDesc(prometheus.BuildFQName(namespace, "", m.Name), help, labelNames, nil)
+			metric, err := prometheus.NewConstMetric(desc, m.valueType(), values[key], labelValues...)
+			if err != nil {
+				return fmt.Errorf("invalid status mapping %s of %s: %w", m.Name, key, err)
+			}
+			ch <- metric
+			break
+		}
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeStatusMappings{}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeStatusMappings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"Variable_name", "Value"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("Com_select", "10").
+		AddRow("Com_insert", "5").
+		AddRow("Innodb_redo_log_enabled", "ON").
+		AddRow("Uptime", "3600").
+		AddRow("Threads_running", "2")
+	mock.ExpectQuery(sanitizeQuery(globalStatusQuery)).WillReturnRows(rows)
+
+	scraper := ScrapeStatusMappings{Mappings: []StatusMapping{
+		{Pattern: "Com_(select|insert)", Name: "commands_total", Type: "counter", Labels: map[string]string{"command": "$1"}},
+		{Pattern: "innodb_redo_log_enabled", Name: "innodb_redo_log_enabled", Type: "gauge"},
+		{Pattern: "uptime", Name: "uptime_seconds_total", Type: "counter"},
+		// Uptime is already mapped by the mapping above
+		{Pattern: ".*", Name: "status", Labels: map[string]string{"name": "$0"}},
+	}}
+	for i := range scraper.Mappings {
+		if err := scraper.Mappings[i].Compile(); err != nil {
+			t.Fatalf("failed to compile mapping: %s", err)
+		}
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = scraper.Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"command": "insert"}, value: 5, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{"command": "select"}, value: 10, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"name": "threads_running"}, value: 2, metricType: dto.MetricType_UNTYPED},
+		{labels: labelMap{}, value: 3600, metricType: dto.MetricType_COUNTER},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestStatusMappingCompile(t *testing.T) {
+	for _, m := range []StatusMapping{
+		{Pattern: "com_.*"},
+		{Pattern: "com_.*", Name: "commands_total", Type: "histogram"},
+		{Pattern: "com_(", Name: "commands_total"},
+	} {
+		if err := m.Compile(); err == nil {
+			t.Errorf("expected error for mapping: %+v", m)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Scrape the free space of the tables from `information_schema.tables` to find the tables worth an OPTIMIZE.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scraper name.
+	tableFragmentation = "table_fragmentation"
+	// Query, DATA_FREE is meaningless for the other engines such as MEMORY and ARCHIVE.
+	tableFragmentationQuery = `
+		SELECT TABLE_SCHEMA, TABLE_NAME, DATA_FREE, DATA_LENGTH + INDEX_LENGTH
+		  FROM information_schema.tables
+		 WHERE TABLE_TYPE = 'BASE TABLE'
+		   AND ENGINE IN ('InnoDB', 'MyISAM', 'Aria')
+		   AND DATA_FREE >= ?
+		`
+)
+
+// Metric descriptors.
+var (
+	tableDataFreeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "table", "data_free_bytes"),
+		"Allocated but unused bytes of the table, from information_schema.tables.DATA_FREE.",
+		[]string{"schema", "table"}, nil,
+	)
+	tableFragmentationRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "table", "fragmentation_ratio"),
+		"DATA_FREE divided by the total of DATA_LENGTH, INDEX_LENGTH and DATA_FREE of the table.",
+		[]string{"schema", "table"}, nil,
+	)
+)
+
+// ScrapeTableFragmentation collects the free space of the tables, only the tables with at least MinDataFree bytes free are reported.
+type ScrapeTableFragmentation struct {
+	// Include only collects the schemas matching the regexp, empty means all
+	Include string
+	// Exclude skips the schemas matching the regexp
+	Exclude string
+	// MinDataFree bounds the cardinality, the tables with less free bytes are not worth rebuilding
+	MinDataFree uint64
+	// ScrapeInterval runs the scraper less often than the job, the query is slow on large instances
+	ScrapeInterval time.Duration
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeTableFragmentation) Name() string {
+	return tableFragmentation
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeTableFragmentation) Help() string {
+	return "Collect the free space and the fragmentation ratio of the tables from information_schema"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeTableFragmentation) Version() float64 {
+	return 5.1
+}
+
+// Interval between two runs of the scraper for a target.
+func (s ScrapeTableFragmentation) Interval() time.Duration {
+	return s.ScrapeInterval
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapeTableFragmentation) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var include, exclude *regexp.Regexp
+	var err error
+	if s.Include != "" {
+		if include, err = regexp.Compile(s.Include); err != nil {
+			return fmt.Errorf("invalid include regexp %q: %w", s.Include, err)
+		}
+	}
+	if s.Exclude != "" {
+		if exclude, err = regexp.Compile(s.Exclude); err != nil {
+			return fmt.Errorf("invalid exclude regexp %q: %w", s.Exclude, err)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, tableFragmentationQuery, s.MinDataFree)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		schema, table string
+		free, used    uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&schema, &table, &free, &used); err != nil {
+			return err
+		}
+		if include != nil && !include.MatchString(schema) {
+			continue
+		}
+		if exclude != nil && exclude.MatchString(schema) {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			tableDataFreeDesc, prometheus.GaugeValue, float64(free), schema, table,
+		)
+		ratio := 0.0
+		if total := free + used; total > 0 {
+			ratio = float64(free) / float64(total)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			tableFragmentationRatioDesc, prometheus.GaugeValue, ratio, schema, table,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeTableFragmentation{}
+var _ IntervalScraper = ScrapeTableFragmentation{}
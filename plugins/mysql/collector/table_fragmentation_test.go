@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeTableFragmentation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"TABLE_SCHEMA", "TABLE_NAME", "DATA_FREE", "DATA_LENGTH + INDEX_LENGTH"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", "orders", 300, 700).
+		AddRow("app", "empty", 0, 0).
+		AddRow("mysql", "user", 100, 100)
+	mock.ExpectQuery(strings.NewReplacer("?", `\?`, "+", `\+`).Replace(sanitizeQuery(tableFragmentationQuery))).WithArgs(uint64(1024)).WillReturnRows(rows)
+
+	scraper := ScrapeTableFragmentation{Exclude: "^mysql$", MinDataFree: 1024}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = scraper.Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"schema": "app", "table": "orders"}, value: 300, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "app", "table": "orders"}, value: 0.3, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "app", "table": "empty"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "app", "table": "empty"}, value: 0, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,81 @@
+// Scrape the table lock counters from `SHOW GLOBAL STATUS`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	tableLocks = "table_locks"
+	// Query.
+	tableLocksQuery = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Table_locks_immediate', 'Table_locks_waited')`
+)
+
+// Metric descriptors.
+var (
+	tableLocksImmediateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, tableLocks, "immediate_total"),
+		"Number of the table lock requests granted immediately.",
+		[]string{}, nil,
+	)
+	tableLocksWaitedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, tableLocks, "waited_total"),
+		"Number of the table lock requests that had to wait.",
+		[]string{}, nil,
+	)
+	tableLocksWaitedRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, tableLocks, "waited_ratio"),
+		"Ratio of the table lock requests that had to wait since the server started, 0 if there is none. Table level locks are taken by MyISAM, MEMORY and MERGE tables, not InnoDB.",
+		[]string{}, nil,
+	)
+)
+
+// ScrapeTableLocks collects the Table_locks_* counters and the ratio of the waited ones.
+type ScrapeTableLocks struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeTableLocks) Name() string {
+	return tableLocks
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeTableLocks) Help() string {
+	return "Collect the table lock counters and the contention ratio from SHOW GLOBAL STATUS"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeTableLocks) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeTableLocks) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	values, err := globalStatusValues(ctx, db, tableLocksQuery)
+	if err != nil {
+		return err
+	}
+
+	immediate, hasImmediate := values["table_locks_immediate"]
+	waited, hasWaited := values["table_locks_waited"]
+	if !hasImmediate || !hasWaited {
+		return nil
+	}
+
+	ratio := 0.0
+	if total := immediate + waited; total > 0 {
+		ratio = waited / total
+	}
+
+	ch <- prometheus.MustNewConstMetric(tableLocksImmediateDesc, prometheus.CounterValue, immediate)
+	ch <- prometheus.MustNewConstMetric(tableLocksWaitedDesc, prometheus.CounterValue, waited)
+	ch <- prometheus.MustNewConstMetric(tableLocksWaitedRatioDesc, prometheus.GaugeValue, ratio)
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeTableLocks{}
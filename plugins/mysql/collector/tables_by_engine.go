@@ -0,0 +1,136 @@
+// Scrape the number of tables and the estimated rows per storage engine from `information_schema.tables`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Scraper name.
+	tablesByEngine = "tables_by_engine"
+	// Query, grouped by schema as well so that the schemas are filtered before summing by engine.
+	tablesByEngineQuery = `
+		SELECT TABLE_SCHEMA, IFNULL(ENGINE, ''), COUNT(*), IFNULL(SUM(TABLE_ROWS), 0)
+		  FROM information_schema.tables
+		 WHERE TABLE_TYPE = 'BASE TABLE'
+		 GROUP BY TABLE_SCHEMA, ENGINE
+		`
+)
+
+// Metric descriptors.
+var (
+	tablesByEngineDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", tablesByEngine),
+		"Number of base tables per storage engine.",
+		[]string{"engine"}, nil,
+	)
+	tableRowsByEngineDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "table_rows_by_engine"),
+		"Sum of the estimated rows of the base tables per storage engine, from information_schema.tables.TABLE_ROWS.",
+		[]string{"engine"}, nil,
+	)
+)
+
+// ScrapeTablesByEngine collects the number of tables and the estimated rows per storage engine,
+// e.g. to follow the migration from MyISAM to InnoDB.
+type ScrapeTablesByEngine struct {
+	// Include only collects the schemas matching the regexp, empty means all
+	Include string
+	// Exclude skips the schemas matching the regexp
+	Exclude string
+	// ScrapeInterval runs the scraper less often than the job, the query is slow on large instances
+	ScrapeInterval time.Duration
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeTablesByEngine) Name() string {
+	return tablesByEngine
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeTablesByEngine) Help() string {
+	return "Collect the number of tables and the estimated rows per storage engine from information_schema"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeTablesByEngine) Version() float64 {
+	return 5.1
+}
+
+// Interval between two runs of the scraper for a target.
+func (s ScrapeTablesByEngine) Interval() time.Duration {
+	return s.ScrapeInterval
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapeTablesByEngine) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var include, exclude *regexp.Regexp
+	var err error
+	if s.Include != "" {
+		if include, err = regexp.Compile(s.Include); err != nil {
+			return fmt.Errorf("invalid include regexp %q: %w", s.Include, err)
+		}
+	}
+	if s.Exclude != "" {
+		if exclude, err = regexp.Compile(s.Exclude); err != nil {
+			return fmt.Errorf("invalid exclude regexp %q: %w", s.Exclude, err)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, tablesByEngineQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		schema, engine   string
+		tables, rowCount uint64
+	)
+	tablesOf := make(map[string]uint64)
+	rowsOf := make(map[string]uint64)
+	for rows.Next() {
+		if err := rows.Scan(&schema, &engine, &tables, &rowCount); err != nil {
+			return err
+		}
+		if include != nil && !include.MatchString(schema) {
+			continue
+		}
+		if exclude != nil && exclude.MatchString(schema) {
+			continue
+		}
+		tablesOf[engine] += tables
+		rowsOf[engine] += rowCount
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	engines := make([]string, 0, len(tablesOf))
+	for engine := range tablesOf {
+		engines = append(engines, engine)
+	}
+	sort.Strings(engines)
+
+	for _, engine := range engines {
+		ch <- prometheus.MustNewConstMetric(
+			tablesByEngineDesc, prometheus.GaugeValue, float64(tablesOf[engine]), engine,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			tableRowsByEngineDesc, prometheus.GaugeValue, float64(rowsOf[engine]), engine,
+		)
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeTablesByEngine{}
+var _ IntervalScraper = ScrapeTablesByEngine{}
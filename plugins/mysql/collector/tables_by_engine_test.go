@@ -0,0 +1,57 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeTablesByEngine(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"TABLE_SCHEMA", "IFNULL(ENGINE, '')", "COUNT(*)", "IFNULL(SUM(TABLE_ROWS), 0)"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", "InnoDB", 10, 5000).
+		AddRow("app", "MyISAM", 2, 300).
+		AddRow("app_archive", "InnoDB", 3, 1000).
+		AddRow("mysql", "InnoDB", 30, 7000).
+		AddRow("mysql", "CSV", 2, 0)
+	mock.ExpectQuery(sanitizeQuery(tablesByEngineQuery)).WillReturnRows(rows)
+
+	scraper := ScrapeTablesByEngine{Exclude: "^mysql$"}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = scraper.Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"engine": "InnoDB"}, value: 13, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"engine": "InnoDB"}, value: 6000, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"engine": "MyISAM"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"engine": "MyISAM"}, value: 300, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Scrape the number of tables without a primary key per schema from `information_schema`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	tablesWithoutPrimaryKey = "tables_without_primary_key"
+	// Query, the schemas without offenders are reported as 0 so that new offenders can be alerted on.
+	tablesWithoutPrimaryKeyQuery = `
+		SELECT t.TABLE_SCHEMA, SUM(c.CONSTRAINT_NAME IS NULL)
+		  FROM information_schema.tables t
+		  LEFT JOIN information_schema.table_constraints c
+		    ON c.TABLE_SCHEMA = t.TABLE_SCHEMA
+		   AND c.TABLE_NAME = t.TABLE_NAME
+		   AND c.CONSTRAINT_TYPE = 'PRIMARY KEY'
+		  WHERE t.TABLE_TYPE = 'BASE TABLE'
+		  GROUP BY t.TABLE_SCHEMA
+		`
+)
+
+// Metric descriptors.
+var (
+	tablesWithoutPrimaryKeyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", tablesWithoutPrimaryKey),
+		"Number of base tables without a primary key per schema, they slow down row-based replication.",
+		[]string{"schema"}, nil,
+	)
+)
+
+// ScrapeTablesWithoutPrimaryKey collects the number of tables without a primary key per schema.
+type ScrapeTablesWithoutPrimaryKey struct {
+	// Include only collects the schemas matching the regexp, empty means all
+	Include string
+	// Exclude skips the schemas matching the regexp
+	Exclude string
+	// ScrapeInterval runs the scraper less often than the job, the query is slow on large instances
+	ScrapeInterval time.Duration
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeTablesWithoutPrimaryKey) Name() string {
+	return tablesWithoutPrimaryKey
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeTablesWithoutPrimaryKey) Help() string {
+	return "Collect the number of tables without a primary key per schema from information_schema"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeTablesWithoutPrimaryKey) Version() float64 {
+	return 5.1
+}
+
+// Interval between two runs of the scraper for a target.
+func (s ScrapeTablesWithoutPrimaryKey) Interval() time.Duration {
+	return s.ScrapeInterval
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapeTablesWithoutPrimaryKey) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var include, exclude *regexp.Regexp
+	var err error
+	if s.Include != "" {
+		if include, err = regexp.Compile(s.Include); err != nil {
+			return fmt.Errorf("invalid include regexp %q: %w", s.Include, err)
+		}
+	}
+	if s.Exclude != "" {
+		if exclude, err = regexp.Compile(s.Exclude); err != nil {
+			return fmt.Errorf("invalid exclude regexp %q: %w", s.Exclude, err)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, tablesWithoutPrimaryKeyQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		schema string
+		count  uint64
+	)
+	for rows.Next() {
+		if err := rows.Scan(&schema, &count); err != nil {
+			return err
+		}
+		if include != nil && !include.MatchString(schema) {
+			continue
+		}
+		if exclude != nil && exclude.MatchString(schema) {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			tablesWithoutPrimaryKeyDesc, prometheus.GaugeValue, float64(count), schema,
+		)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeTablesWithoutPrimaryKey{}
+var _ IntervalScraper = ScrapeTablesWithoutPrimaryKey{}
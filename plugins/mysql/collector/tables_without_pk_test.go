@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeTablesWithoutPrimaryKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"TABLE_SCHEMA", "SUM(c.CONSTRAINT_NAME IS NULL)"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("app", 2).
+		AddRow("app_archive", 0).
+		AddRow("mysql", 5).
+		AddRow("app_tmp", 7)
+	mock.ExpectQuery(sanitizeQuery(tablesWithoutPrimaryKeyQuery)).WillReturnRows(rows)
+
+	scraper := ScrapeTablesWithoutPrimaryKey{Exclude: "^mysql$|_tmp$"}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = scraper.Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"schema": "app"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"schema": "app_archive"}, value: 0, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+		_, ok := <-ch
+		convey.So(ok, convey.ShouldBeFalse)
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
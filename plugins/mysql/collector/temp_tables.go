@@ -0,0 +1,106 @@
+// Scrape temp table and sort activity from `SHOW GLOBAL STATUS`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	tempTables = "temp_tables"
+	// Queries.
+	tempTablesQuery = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Created_tmp_tables', 'Created_tmp_disk_tables', 'Sort_merge_passes')`
+	// internal_tmp_mem_storage_engine only exists since 8.0, returns no rows on older versions.
+	tempTablesEngineQuery = `SHOW GLOBAL VARIABLES LIKE 'internal_tmp_mem_storage_engine'`
+)
+
+// Metric descriptors.
+var (
+	tempTablesCreatedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, tempTables, "created_total"),
+		"Number of internal temporary tables created while executing statements.",
+		[]string{}, nil,
+	)
+	tempTablesCreatedDiskDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, tempTables, "created_disk_total"),
+		"Number of internal on-disk temporary tables created while executing statements.",
+		[]string{}, nil,
+	)
+	tempTablesDiskRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, tempTables, "disk_ratio"),
+		"Ratio of Created_tmp_disk_tables to Created_tmp_tables since the server started.",
+		[]string{}, nil,
+	)
+	tempTablesMemStorageEngineDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, tempTables, "mem_storage_engine_info"),
+		"The storage engine for in-memory internal temporary tables (MySQL 8.0+), TempTable overflows are not counted in Created_tmp_disk_tables.",
+		[]string{"engine"}, nil,
+	)
+	sortMergePassesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sort", "merge_passes_total"),
+		"Number of merge passes that the sort algorithm has had to do.",
+		[]string{}, nil,
+	)
+)
+
+// ScrapeTempTables collects temp table and sort activity.
+type ScrapeTempTables struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeTempTables) Name() string {
+	return tempTables
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeTempTables) Help() string {
+	return "Collect temp table and sort activity from SHOW GLOBAL STATUS"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeTempTables) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeTempTables) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	values, err := globalStatusValues(ctx, db, tempTablesQuery)
+	if err != nil {
+		return err
+	}
+
+	created, hasCreated := values["created_tmp_tables"]
+	if hasCreated {
+		ch <- prometheus.MustNewConstMetric(tempTablesCreatedDesc, prometheus.CounterValue, created)
+	}
+	if v, ok := values["created_tmp_disk_tables"]; ok {
+		ch <- prometheus.MustNewConstMetric(tempTablesCreatedDiskDesc, prometheus.CounterValue, v)
+		if created > 0 {
+			ch <- prometheus.MustNewConstMetric(tempTablesDiskRatioDesc, prometheus.GaugeValue, v/created)
+		}
+	}
+	if v, ok := values["sort_merge_passes"]; ok {
+		ch <- prometheus.MustNewConstMetric(sortMergePassesDesc, prometheus.CounterValue, v)
+	}
+
+	rows, err := db.QueryContext(ctx, tempTablesEngineQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var name, engine string
+	for rows.Next() {
+		if err := rows.Scan(&name, &engine); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(tempTablesMemStorageEngineDesc, prometheus.GaugeValue, 1, engine)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeTempTables{}
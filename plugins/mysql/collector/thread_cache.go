@@ -0,0 +1,103 @@
+// Scrape prepared statement and thread cache efficiency from `SHOW GLOBAL STATUS`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	threadCache = "thread_cache"
+	// Queries.
+	threadCacheQuery          = `SHOW GLOBAL STATUS WHERE Variable_name IN ('Prepared_stmt_count', 'Threads_cached', 'Threads_created', 'Connections')`
+	threadCacheVariablesQuery = `SELECT @@thread_cache_size, @@max_prepared_stmt_count`
+)
+
+// Metric descriptors.
+var (
+	threadCacheThreadsCachedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, threadCache, "threads_cached"),
+		"Number of threads in the thread cache.",
+		[]string{}, nil,
+	)
+	threadCacheThreadsCreatedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, threadCache, "threads_created_total"),
+		"Number of threads created to handle connections, a high value relative to connections means the thread cache is too small.",
+		[]string{}, nil,
+	)
+	threadCacheHitRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, threadCache, "hit_ratio"),
+		"Ratio of connections served by a cached thread, 1 - Threads_created / Connections since the server started.",
+		[]string{}, nil,
+	)
+	threadCacheSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, threadCache, "size"),
+		"The value of @@thread_cache_size.",
+		[]string{}, nil,
+	)
+	preparedStmtCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "prepared_stmt", "count"),
+		"Current number of prepared statements.",
+		[]string{}, nil,
+	)
+	preparedStmtMaxCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "prepared_stmt", "max_count"),
+		"The value of @@max_prepared_stmt_count.",
+		[]string{}, nil,
+	)
+)
+
+// ScrapeThreadCache collects prepared statement and thread cache efficiency.
+type ScrapeThreadCache struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeThreadCache) Name() string {
+	return threadCache
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeThreadCache) Help() string {
+	return "Collect prepared statement and thread cache efficiency from SHOW GLOBAL STATUS"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeThreadCache) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeThreadCache) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	values, err := globalStatusValues(ctx, db, threadCacheQuery)
+	if err != nil {
+		return err
+	}
+
+	if v, ok := values["threads_cached"]; ok {
+		ch <- prometheus.MustNewConstMetric(threadCacheThreadsCachedDesc, prometheus.GaugeValue, v)
+	}
+	if v, ok := values["threads_created"]; ok {
+		ch <- prometheus.MustNewConstMetric(threadCacheThreadsCreatedDesc, prometheus.CounterValue, v)
+		if connections := values["connections"]; connections > 0 {
+			ch <- prometheus.MustNewConstMetric(threadCacheHitRatioDesc, prometheus.GaugeValue, 1-v/connections)
+		}
+	}
+	if v, ok := values["prepared_stmt_count"]; ok {
+		ch <- prometheus.MustNewConstMetric(preparedStmtCountDesc, prometheus.GaugeValue, v)
+	}
+
+	var cacheSize, maxPreparedStmtCount float64
+	if err := db.QueryRowContext(ctx, threadCacheVariablesQuery).Scan(&cacheSize, &maxPreparedStmtCount); err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(threadCacheSizeDesc, prometheus.GaugeValue, cacheSize)
+	ch <- prometheus.MustNewConstMetric(preparedStmtMaxCountDesc, prometheus.GaugeValue, maxPreparedStmtCount)
+
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeThreadCache{}
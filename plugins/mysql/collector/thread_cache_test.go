@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeThreadCache(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"Variable_name", "Value"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("Connections", "200").
+		AddRow("Prepared_stmt_count", "12").
+		AddRow("Threads_cached", "8").
+		AddRow("Threads_created", "50")
+	mock.ExpectQuery(sanitizeQuery(threadCacheQuery)).WillReturnRows(rows)
+
+	rows = sqlmock.NewRows([]string{"@@thread_cache_size", "@@max_prepared_stmt_count"}).
+		AddRow(9, 16382)
+	mock.ExpectQuery(sanitizeQuery(threadCacheVariablesQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeThreadCache{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, value: 8, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 50, metricType: dto.MetricType_COUNTER},
+		{labels: labelMap{}, value: 0.75, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 12, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 9, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 16382, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
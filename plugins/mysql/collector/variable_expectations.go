@@ -0,0 +1,175 @@
+// Scrape whether the `SHOW GLOBAL VARIABLES` variables match the expected values.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	variableExpectations = "variable_expectations"
+	// Query, the placeholders are filled with the variable names.
+	variableExpectationsQuery = `SHOW GLOBAL VARIABLES WHERE Variable_name IN (%s)`
+)
+
+// Metric descriptors.
+var (
+	variableMatchesExpectedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "variable", "matches_expected"),
+		"Whether the global variable matches the expected value, 1 if matches.",
+		[]string{"variable"}, nil,
+	)
+	variableValueDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "variable", "value"),
+		"The numeric value of the global variable with an expected value.",
+		[]string{"variable"}, nil,
+	)
+)
+
+// ExpectedVariable is the expected value of a global variable, e.g.
+// variable: 'max_connections' operator: '>=' value: '1000'
+type ExpectedVariable struct {
+	Variable string `toml:"variable"`
+	Value    string `toml:"value"`
+	// Operator is one of ==, !=, >, >=, <, <=, the default is ==.
+	// Both values are compared as numbers if they are numeric, ON/OFF are 1/0, otherwise as case-insensitive strings.
+	Operator string `toml:"operator"`
+}
+
+// Validate checks the operator and the value.
+func (e ExpectedVariable) Validate() error {
+	if e.Variable == "" {
+		return fmt.Errorf("variable of expected value %q is blank", e.Value)
+	}
+
+	switch e.Operator {
+	case "", "==", "!=":
+	case ">", ">=", "<", "<=":
+		if _, ok := parseStatus(sql.RawBytes(e.Value)); !ok {
+			return fmt.Errorf("value %q of variable %s should be numeric for operator %s", e.Value, e.Variable, e.Operator)
+		}
+	default:
+		return fmt.Errorf("invalid operator %q of variable %s", e.Operator, e.Variable)
+	}
+	return nil
+}
+
+// Matches reports whether the actual value matches the expected one.
+func (e ExpectedVariable) Matches(actual string) bool {
+	expectedNum, expectedIsNum := parseStatus(sql.RawBytes(e.Value))
+	actualNum, actualIsNum := parseStatus(sql.RawBytes(actual))
+
+	if expectedIsNum && actualIsNum {
+		switch e.Operator {
+		case "!=":
+			return actualNum != expectedNum
+		case ">":
+			return actualNum > expectedNum
+		case ">=":
+			return actualNum >= expectedNum
+		case "<":
+			return actualNum < expectedNum
+		case "<=":
+			return actualNum <= expectedNum
+		}
+		return actualNum == expectedNum
+	}
+
+	equal := normalizeVariableValue(actual) == normalizeVariableValue(e.Value)
+	switch e.Operator {
+	case "", "==":
+		return equal
+	case "!=":
+		return !equal
+	}
+	// numeric operator on a non-numeric value
+	return false
+}
+
+// normalizeVariableValue lower-cases the value and sorts the items of a list such as sql_mode.
+func normalizeVariableValue(v string) string {
+	items := strings.Split(strings.ToLower(strings.TrimSpace(v)), ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// ScrapeVariableExpectations collects whether the global variables match the expected values.
+type ScrapeVariableExpectations struct {
+	Expected []ExpectedVariable
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeVariableExpectations) Name() string {
+	return variableExpectations
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeVariableExpectations) Help() string {
+	return "Collect whether the global variables match the expected values"
+}
+
+// Version of MySQL from which scraper is available.
+func (ScrapeVariableExpectations) Version() float64 {
+	return 5.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapeVariableExpectations) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	if len(s.Expected) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(s.Expected))
+	for _, e := range s.Expected {
+		args = append(args, e.Variable)
+	}
+	query := fmt.Sprintf(variableExpectationsQuery, strings.TrimSuffix(strings.Repeat("?,", len(args)), ","))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var key, val string
+	values := make(map[string]string)
+	for rows.Next() {
+		if err := rows.Scan(&key, &val); err != nil {
+			return err
+		}
+		values[strings.ToLower(key)] = val
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range s.Expected {
+		name := strings.ToLower(e.Variable)
+		actual, ok := values[name]
+
+		// a missing variable never matches
+		matches := 0.0
+		if ok && e.Matches(actual) {
+			matches = 1
+		}
+		ch <- prometheus.MustNewConstMetric(variableMatchesExpectedDesc, prometheus.GaugeValue, matches, name)
+
+		if v, isNum := parseStatus(sql.RawBytes(actual)); ok && isNum {
+			ch <- prometheus.MustNewConstMetric(variableValueDesc, prometheus.GaugeValue, v, name)
+		}
+	}
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeVariableExpectations{}
@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeVariableExpectations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	scraper := ScrapeVariableExpectations{Expected: []ExpectedVariable{
+		{Variable: "innodb_flush_log_at_trx_commit", Value: "1"},
+		{Variable: "read_only", Value: "OFF"},
+		{Variable: "max_connections", Value: "1000", Operator: ">="},
+		{Variable: "sql_mode", Value: "STRICT_TRANS_TABLES,ONLY_FULL_GROUP_BY"},
+		{Variable: "innodb_dedicated_server", Value: "ON"},
+	}}
+
+	columns := []string{"Variable_name", "Value"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("innodb_flush_log_at_trx_commit", "2").
+		AddRow("max_connections", "1500").
+		AddRow("read_only", "OFF").
+		AddRow("sql_mode", "ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES")
+	query := fmt.Sprintf(variableExpectationsQuery, "?,?,?,?,?")
+	mock.ExpectQuery(strings.ReplaceAll(sanitizeQuery(query), "?", `\?`)).
+		WithArgs("innodb_flush_log_at_trx_commit", "read_only", "max_connections", "sql_mode", "innodb_dedicated_server").
+		WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = scraper.Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{"variable": "innodb_flush_log_at_trx_commit"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"variable": "innodb_flush_log_at_trx_commit"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"variable": "read_only"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"variable": "read_only"}, value: 0, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"variable": "max_connections"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"variable": "max_connections"}, value: 1500, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"variable": "sql_mode"}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"variable": "innodb_dedicated_server"}, value: 0, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestExpectedVariableValidate(t *testing.T) {
+	tests := []struct {
+		expected ExpectedVariable
+		valid    bool
+	}{
+		{ExpectedVariable{Variable: "read_only", Value: "ON"}, true},
+		{ExpectedVariable{Variable: "max_connections", Value: "100", Operator: "<"}, true},
+		{ExpectedVariable{Variable: "sql_mode", Value: "ANSI", Operator: ">"}, false},
+		{ExpectedVariable{Variable: "read_only", Value: "ON", Operator: "=~"}, false},
+		{ExpectedVariable{Value: "ON"}, false},
+	}
+
+	for _, test := range tests {
+		err := test.expected.Validate()
+		if (err == nil) != test.valid {
+			t.Errorf("Validate(%+v) = %v, want valid: %v", test.expected, err, test.valid)
+		}
+	}
+}
@@ -7,7 +7,11 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/cprobe/cprobe/lib/logger"
@@ -19,16 +23,153 @@ import (
 )
 
 type Global struct {
-	User                  string   `toml:"user"`
-	Password              string   `toml:"password"`
-	SslCa                 string   `toml:"ssl_ca"`
-	SslCert               string   `toml:"ssl_cert"`
-	SslKey                string   `toml:"ssl_key"`
-	TlsInsecureSkipVerify bool     `toml:"ssl_skip_verfication"`
-	Tls                   string   `toml:"tls"`
-	ScraperEnabled        []string `toml:"scraper_enabled"`
-	LockWaitTimeout       int      `toml:"lock_wait_timeout"`
-	LogSlowFilter         bool     `toml:"log_slow_filter"`
+	User                    string   `toml:"user"`
+	Password                string   `toml:"password"`
+	SslCa                   string   `toml:"ssl_ca"`
+	SslCert                 string   `toml:"ssl_cert"`
+	SslKey                  string   `toml:"ssl_key"`
+	UseSystemCertPool       bool     `toml:"use_system_cert_pool"`
+	TlsInsecureSkipVerify   bool     `toml:"ssl_skip_verfication"`
+	Tls                     string   `toml:"tls"`
+	ScraperEnabled          []string `toml:"scraper_enabled"`
+	LockWaitTimeout         int      `toml:"lock_wait_timeout"`
+	LogSlowFilter           bool     `toml:"log_slow_filter"`
+	SeriesLimitPerCollector int      `toml:"series_limit_per_collector"`
+	SessionStatements       []string `toml:"session_statements"`
+	ReadOnly                bool     `toml:"read_only"`
+	VersionQuery            string   `toml:"version_query"`
+	PrimaryDetection        bool     `toml:"primary_detection"`
+	// DSNParams are appended to the DSN, e.g. maxAllowedPacket, only the parameters recognized by the driver are allowed
+	DSNParams map[string]string `toml:"dsn_params"`
+	// ScrapeEvery runs the expensive collectors every N scrapes, keyed by the collector name, e.g. 'info_schema.tables' = 10
+	ScrapeEvery map[string]int `toml:"scrape_every"`
+	// ScrapeEveryPolicy is for the skipped scrapes of the collectors: hold, drop or stale
+	ScrapeEveryPolicy string `toml:"scrape_every_policy"`
+	// CustomQueryReplicas are the read replicas the queries with on_replica are spread across by weighted round-robin
+	CustomQueryReplicas []CustomQueryReplica `toml:"custom_query_replicas"`
+	// InitSQLFile holds the statements executed on every new connection after session_statements, relative to the config dir
+	InitSQLFile string `toml:"init_sql_file"`
+	// CustomQueryNames is sanitize (default) to replace the invalid characters of the metric and label names
+	// of the custom queries at scrape time, or reject to refuse the config with an invalid name
+	CustomQueryNames string `toml:"custom_query_names"`
+	// IsolateConnections runs every collector and custom query on a dedicated connection
+	IsolateConnections bool `toml:"isolate_connections"`
+	// MaxOpenConns is the size of the connection pool of a target, or the dedicated connections open at the same time
+	MaxOpenConns int `toml:"max_open_conns"`
+	// AdminPort is the admin_port of MySQL 8.0.14+ on the hosts of the target, the admin interface is not connected if 0
+	AdminPort int `toml:"admin_port"`
+	// AdminUser and AdminPassword connect to the admin interface, default to user and password, SERVICE_CONNECTION_ADMIN is required
+	AdminUser     string `toml:"admin_user"`
+	AdminPassword string `toml:"admin_password"`
+	// AdminMode is fallback to connect to the admin interface when the regular one has too many connections, or always
+	AdminMode string `toml:"admin_mode"`
+	// ReportSkippedCollectors reports mysql_exporter_collector_skipped{collector,reason} for the collectors enabled but skipped
+	ReportSkippedCollectors bool `toml:"report_skipped_collectors"`
+	// QueryComment prepends /* cprobe target=... query=... */ to every statement to identify them in the processlist
+	QueryComment bool `toml:"query_comment"`
+	// Vault provides the user and password instead of the options above, they are not part of the DSN
+	Vault *Vault `toml:"vault"`
+	// Resolver resolves the hostnames of the targets instead of the system resolver
+	Resolver *Resolver `toml:"resolver"`
+}
+
+type CustomQueryReplica struct {
+	Address string `toml:"address"`
+	// Weight defaults to 1
+	Weight int `toml:"weight"`
+}
+
+// injectedDSNParams are set by the other options, they cannot be set by dsn_params
+var injectedDSNParams = map[string]string{
+	"tls":               "tls, ssl_ca, ssl_cert, ssl_key and ssl_skip_verfication",
+	"lock_wait_timeout": "lock_wait_timeout",
+	"log_slow_filter":   "log_slow_filter",
+}
+
+// validateDSNParams checks every dsn_params by a mysql.ParseDSN round-trip, the driver treats the unknown
+// parameters as system variables, they are refused here and should be set by session_statements instead
+func (g Global) validateDSNParams() error {
+	for key, value := range g.DSNParams {
+		if option, has := injectedDSNParams[key]; has {
+			return fmt.Errorf("dsn_params %s conflicts with the injected parameter, use %s instead", key, option)
+		}
+		if key == "" || strings.ContainsAny(key, "&=?") || strings.Contains(value, "&") {
+			return fmt.Errorf("invalid dsn_params %q, the key and value should not contain '&'", key)
+		}
+
+		cfg, err := mysql.ParseDSN("/?" + key + "=" + value)
+		if err != nil {
+			return fmt.Errorf("invalid dsn_params %s: %s", key, err)
+		}
+		if _, unknown := cfg.Params[key]; unknown {
+			return fmt.Errorf("dsn_params %s is not a parameter of the driver, use session_statements to set system variables", key)
+		}
+	}
+	return nil
+}
+
+// validateScrapeEvery checks the counts and the policy, the unknown collector names are ignored
+// since the same rule_head.toml is usually shared by the jobs enabling different collectors
+func (g Global) validateScrapeEvery() error {
+	switch g.ScrapeEveryPolicy {
+	case "", collector.SkippedHold, collector.SkippedDrop, collector.SkippedStale:
+	default:
+		return fmt.Errorf("invalid scrape_every_policy %q, should be hold, drop or stale", g.ScrapeEveryPolicy)
+	}
+
+	for name, every := range g.ScrapeEvery {
+		if every < 1 {
+			return fmt.Errorf("invalid scrape_every %s = %d, should be at least 1", name, every)
+		}
+	}
+	return nil
+}
+
+// validateCustomQueryReplicas checks the addresses and weights of custom_query_replicas
+func (g Global) validateCustomQueryReplicas() error {
+	seen := make(map[string]bool, len(g.CustomQueryReplicas))
+	for _, r := range g.CustomQueryReplicas {
+		if strings.TrimSpace(r.Address) == "" {
+			return fmt.Errorf("blank address in custom_query_replicas")
+		}
+		if seen[r.Address] {
+			return fmt.Errorf("duplicate address %s in custom_query_replicas", r.Address)
+		}
+		seen[r.Address] = true
+		if r.Weight < 0 {
+			return fmt.Errorf("invalid weight %d of custom_query_replicas %s, should not be negative", r.Weight, r.Address)
+		}
+	}
+	return nil
+}
+
+// dsnWithParams appends the dsn_params to dsn in a stable order
+func (g Global) dsnWithParams(dsn string) (string, error) {
+	if len(g.DSNParams) == 0 {
+		return dsn, nil
+	}
+
+	keys := make([]string, 0, len(g.DSNParams))
+	for key := range g.DSNParams {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(dsn)
+	for i, key := range keys {
+		if i == 0 && !strings.Contains(dsn, "?") {
+			sb.WriteByte('?')
+		} else {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(key + "=" + g.DSNParams[key])
+	}
+
+	if _, err := mysql.ParseDSN(sb.String()); err != nil {
+		return "", fmt.Errorf("invalid dsn_params: %s", err)
+	}
+	return sb.String(), nil
 }
 
 func (g Global) FormDSN(target string) (string, error) {
@@ -37,59 +178,107 @@ func (g Global) FormDSN(target string) (string, error) {
 	}
 
 	config := mysql.NewConfig()
-	config.User = g.User
-	config.Passwd = g.Password
+	if g.Vault == nil {
+		config.User = g.User
+		config.Passwd = g.Password
+	}
 	config.Net = "tcp"
 	if prefix := "unix://"; strings.HasPrefix(target, prefix) {
 		config.Net = "unix"
 		config.Addr = target[len(prefix):]
+	} else if prefix := "pipe://"; strings.HasPrefix(target, prefix) {
+		if !namedPipeSupported {
+			return "", fmt.Errorf("named pipe target %s is only supported on windows", target)
+		}
+		config.Net = namedPipeNet
+		config.Addr = normalizePipeName(target[len(prefix):])
 	} else {
 		if _, _, err := net.SplitHostPort(target); err != nil {
 			return "", fmt.Errorf("failed to parse target: %s", err)
 		}
 		config.Addr = target
+		if g.Resolver != nil {
+			config.Net = g.Resolver.netName()
+		}
 	}
 
 	if g.TlsInsecureSkipVerify {
 		config.TLSConfig = "skip-verify"
 	} else {
 		config.TLSConfig = g.Tls
-		if g.SslCa != "" {
-			if err := g.CustomizeTLS(); err != nil {
+		if g.SslCa != "" || g.UseSystemCertPool {
+			name, err := g.CustomizeTLS()
+			if err != nil {
 				err = fmt.Errorf("failed to register a custom TLS configuration for mysql dsn: %w", err)
 				return "", err
 			}
-			config.TLSConfig = "custom"
+			config.TLSConfig = name
 		}
 	}
 
-	return config.FormatDSN(), nil
+	return g.dsnWithParams(config.FormatDSN())
 }
 
-func (g Global) CustomizeTLS() error {
+// sessionStatements returns the configured session statements, with the
+// session set to read-only first if read_only is enabled.
+func (g Global) sessionStatements() []string {
+	if !g.ReadOnly {
+		return g.SessionStatements
+	}
+	return append([]string{"SET SESSION TRANSACTION READ ONLY"}, g.SessionStatements...)
+}
+
+// credentials returns the provider of the user and password if vault is configured
+func (g Global) credentials() collector.CredentialsProvider {
+	if g.Vault == nil {
+		return nil
+	}
+	return getVaultProvider(g.Vault)
+}
+
+// initSQLFile resolves init_sql_file relative to the config dir
+func (c *Config) initSQLFile() string {
+	path := c.Global.InitSQLFile
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.BaseDir, path)
+}
+
+// tlsConfig verifies the server certs by ssl_ca, merged into the system cert pool if use_system_cert_pool is set,
+// e.g. the certs of the cloud providers chaining to the public roots
+func (g Global) tlsConfig() (*tls.Config, error) {
 	var tlsCfg tls.Config
 	caBundle := x509.NewCertPool()
-	pemCA, err := os.ReadFile(g.SslCa)
-	if err != nil {
-		return err
+	if g.UseSystemCertPool {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the system cert pool: %w", err)
+		}
+		caBundle = pool
 	}
-	if ok := caBundle.AppendCertsFromPEM(pemCA); ok {
-		tlsCfg.RootCAs = caBundle
-	} else {
-		return fmt.Errorf("failed parse pem-encoded CA certificates from %s", g.SslCa)
+	if g.SslCa != "" {
+		pemCA, err := os.ReadFile(g.SslCa)
+		if err != nil {
+			return nil, err
+		}
+		if ok := caBundle.AppendCertsFromPEM(pemCA); !ok {
+			return nil, fmt.Errorf("failed parse pem-encoded CA certificates from %s", g.SslCa)
+		}
 	}
+	tlsCfg.RootCAs = caBundle
 	if g.SslCert != "" && g.SslKey != "" {
 		certPairs := make([]tls.Certificate, 0, 1)
 		keypair, err := tls.LoadX509KeyPair(g.SslCert, g.SslKey)
 		if err != nil {
-			return fmt.Errorf("failed to parse pem-encoded SSL cert %s or SSL key %s: %w",
+			return nil, fmt.Errorf("failed to parse pem-encoded SSL cert %s or SSL key %s: %w",
 				g.SslCert, g.SslKey, err)
 		}
 		certPairs = append(certPairs, keypair)
 		tlsCfg.Certificates = certPairs
 	}
 	tlsCfg.InsecureSkipVerify = g.TlsInsecureSkipVerify
-	return mysql.RegisterTLSConfig("custom", &tlsCfg)
+	return &tlsCfg, nil
 }
 
 type Config struct {
@@ -104,7 +293,8 @@ type Config struct {
 		Enabled bool `toml:"enabled"`
 	} `toml:"collect_global_variables"`
 	CollectSlaveStatus struct {
-		Enabled bool `toml:"enabled"`
+		Enabled   bool `toml:"enabled"`
+		LagWindow int  `toml:"lag_window"`
 	} `toml:"collect_slave_status"`
 	CollectInfoSchemaInnodbCmp struct {
 		Enabled bool `toml:"enabled"`
@@ -178,7 +368,9 @@ type Config struct {
 		Enabled bool `toml:"enabled"`
 	} `toml:"collect_perf_schema_eventswaits"`
 	CollectPerfSchemaFileEvents struct {
-		Enabled bool `toml:"enabled"`
+		Enabled bool     `toml:"enabled"`
+		Events  []string `toml:"events"`
+		TopN    int      `toml:"top_n"`
 	} `toml:"collect_perf_schema_file_events"`
 	CollectPerfSchemaFileInstances struct {
 		Enabled      bool   `toml:"enabled"`
@@ -216,6 +408,161 @@ type Config struct {
 	CollectSlaveHosts struct {
 		Enabled bool `toml:"enabled"`
 	} `toml:"collect_slave_hosts"`
+	CollectGtid struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_gtid"`
+	CollectConnectionErrors struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_connection_errors"`
+	CollectTempTables struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_temp_tables"`
+	CollectSemiSync struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_semi_sync"`
+	CollectThreadCache struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_thread_cache"`
+	CollectSchemaObjects struct {
+		Enabled  bool          `toml:"enabled"`
+		Include  string        `toml:"include"`
+		Exclude  string        `toml:"exclude"`
+		Interval time.Duration `toml:"interval"`
+	} `toml:"collect_schema_objects"`
+	CollectAccountConnections struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_account_connections"`
+	CollectInfoSchemaInnodbTrx struct {
+		Enabled    bool  `toml:"enabled"`
+		Thresholds []int `toml:"thresholds"`
+	} `toml:"collect_info_schema_innodb_trx"`
+	CollectStatusMappings struct {
+		Enabled  bool                      `toml:"enabled"`
+		Mappings []collector.StatusMapping `toml:"mappings"`
+	} `toml:"collect_status_mappings"`
+	CollectInnodbRedoLog struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_innodb_redo_log"`
+	CollectVariableExpectations struct {
+		Enabled  bool                         `toml:"enabled"`
+		Expected []collector.ExpectedVariable `toml:"expected"`
+	} `toml:"collect_variable_expectations"`
+	CollectXProtocol struct {
+		Enabled               bool          `toml:"enabled"`
+		Port                  int           `toml:"port"`
+		Timeout               time.Duration `toml:"timeout"`
+		TLS                   bool          `toml:"tls"`
+		TLSInsecureSkipVerify bool          `toml:"tls_insecure_skip_verify"`
+	} `toml:"collect_xprotocol"`
+	CollectHandlerOperations struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_handler_operations"`
+	CollectTablesWithoutPrimaryKey struct {
+		Enabled  bool          `toml:"enabled"`
+		Include  string        `toml:"include"`
+		Exclude  string        `toml:"exclude"`
+		Interval time.Duration `toml:"interval"`
+	} `toml:"collect_tables_without_primary_key"`
+	CollectInnodbLockWaits struct {
+		Enabled     bool `toml:"enabled"`
+		Detail      bool `toml:"detail"`
+		DetailLimit int  `toml:"detail_limit"`
+	} `toml:"collect_innodb_lock_waits"`
+	CollectAccountExpiry struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_account_expiry"`
+	CollectGtidLag struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_gtid_lag"`
+	CollectReplicationSource struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_replication_source"`
+	CollectInnodbBufferPoolLoad struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_innodb_buffer_pool_load"`
+	CollectReplicationFilters struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_replication_filters"`
+	CollectAurora struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_aurora"`
+	CollectPerfTopWaits struct {
+		Enabled bool `toml:"enabled"`
+		Limit   int  `toml:"limit"`
+	} `toml:"collect_perf_top_waits"`
+	CollectReplicationHeartbeat struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_replication_heartbeat"`
+	CollectTableFragmentation struct {
+		Enabled          bool          `toml:"enabled"`
+		Include          string        `toml:"include"`
+		Exclude          string        `toml:"exclude"`
+		MinDataFreeBytes uint64        `toml:"min_data_free_bytes"`
+		Interval         time.Duration `toml:"interval"`
+	} `toml:"collect_table_fragmentation"`
+	CollectInnodbAdaptiveHashIndex struct {
+		Enabled     bool `toml:"enabled"`
+		ParseStatus bool `toml:"parse_status"`
+	} `toml:"collect_innodb_adaptive_hash_index"`
+	CollectPerfThreads struct {
+		Enabled bool `toml:"enabled"`
+		ByUser  bool `toml:"by_user"`
+	} `toml:"collect_perf_threads"`
+	CollectInnodbUndo struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_innodb_undo"`
+	CollectReplicationApplierBusy struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_replication_applier_busy"`
+	CollectSleepingConnections struct {
+		Enabled    bool  `toml:"enabled"`
+		Thresholds []int `toml:"thresholds"`
+	} `toml:"collect_sleeping_connections"`
+	CollectTableLocks struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_table_locks"`
+	CollectReplicationSSL struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_replication_ssl"`
+	CollectReplicationLastError struct {
+		Enabled       bool `toml:"enabled"`
+		MessageLength int  `toml:"message_length"`
+	} `toml:"collect_replication_last_error"`
+	CollectPerfReplicationChannels struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_perf_replication_channels"`
+	CollectPreparedStatements struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_prepared_statements"`
+	CollectInnodbFlushing struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_innodb_flushing"`
+	CollectReplicationParallelWorkers struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_replication_parallel_workers"`
+	CollectOptimizer struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_optimizer"`
+	CollectServerTimeDrift struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_server_time_drift"`
+	CollectTablesByEngine struct {
+		Enabled  bool          `toml:"enabled"`
+		Include  string        `toml:"include"`
+		Exclude  string        `toml:"exclude"`
+		Interval time.Duration `toml:"interval"`
+	} `toml:"collect_tables_by_engine"`
+	CollectConnectionSaturation struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_connection_saturation"`
+	CollectSlowQueries struct {
+		Enabled   bool `toml:"enabled"`
+		Variables bool `toml:"variables"`
+	} `toml:"collect_slow_queries"`
+	CollectPerfEventsStatementsByProgram struct {
+		Enabled bool `toml:"enabled"`
+		Limit   int  `toml:"limit"`
+	} `toml:"collect_perf_events_statements_by_program"`
 }
 
 func (c *Config) EnabledScrapers() (ret []collector.Scraper) {
@@ -228,7 +575,9 @@ func (c *Config) EnabledScrapers() (ret []collector.Scraper) {
 	}
 
 	if c.CollectSlaveStatus.Enabled {
-		ret = append(ret, collector.ScrapeSlaveStatus{})
+		ret = append(ret, collector.ScrapeSlaveStatus{
+			LagWindow: c.CollectSlaveStatus.LagWindow,
+		})
 	}
 
 	if c.CollectInfoSchemaInnodbCmp.Enabled {
@@ -328,7 +677,10 @@ func (c *Config) EnabledScrapers() (ret []collector.Scraper) {
 	}
 
 	if c.CollectPerfSchemaFileEvents.Enabled {
-		ret = append(ret, collector.ScrapePerfFileEvents{})
+		ret = append(ret, collector.ScrapePerfFileEvents{
+			Events: c.CollectPerfSchemaFileEvents.Events,
+			TopN:   c.CollectPerfSchemaFileEvents.TopN,
+		})
 	}
 
 	if c.CollectPerfSchemaFileInstances.Enabled {
@@ -380,6 +732,204 @@ func (c *Config) EnabledScrapers() (ret []collector.Scraper) {
 		ret = append(ret, collector.ScrapeSlaveHosts{})
 	}
 
+	if c.CollectGtid.Enabled {
+		ret = append(ret, collector.ScrapeGtid{})
+	}
+
+	if c.CollectConnectionErrors.Enabled {
+		ret = append(ret, collector.ScrapeConnectionErrors{})
+	}
+
+	if c.CollectTempTables.Enabled {
+		ret = append(ret, collector.ScrapeTempTables{})
+	}
+
+	if c.CollectSemiSync.Enabled {
+		ret = append(ret, collector.ScrapeSemiSync{})
+	}
+
+	if c.CollectThreadCache.Enabled {
+		ret = append(ret, collector.ScrapeThreadCache{})
+	}
+
+	if c.CollectSchemaObjects.Enabled {
+		ret = append(ret, collector.ScrapeSchemaObjects{
+			Include:        c.CollectSchemaObjects.Include,
+			Exclude:        c.CollectSchemaObjects.Exclude,
+			ScrapeInterval: c.CollectSchemaObjects.Interval,
+		})
+	}
+
+	if c.CollectAccountConnections.Enabled {
+		ret = append(ret, collector.ScrapeAccountConnections{})
+	}
+
+	if c.CollectInfoSchemaInnodbTrx.Enabled {
+		ret = append(ret, collector.ScrapeInnodbTrx{
+			Thresholds: c.CollectInfoSchemaInnodbTrx.Thresholds,
+		})
+	}
+
+	if c.CollectStatusMappings.Enabled {
+		ret = append(ret, collector.ScrapeStatusMappings{
+			Mappings: c.CollectStatusMappings.Mappings,
+		})
+	}
+
+	if c.CollectInnodbRedoLog.Enabled {
+		ret = append(ret, collector.ScrapeInnodbRedoLog{})
+	}
+
+	if c.CollectVariableExpectations.Enabled {
+		ret = append(ret, collector.ScrapeVariableExpectations{
+			Expected: c.CollectVariableExpectations.Expected,
+		})
+	}
+
+	if c.CollectHandlerOperations.Enabled {
+		ret = append(ret, collector.ScrapeHandlerOperations{})
+	}
+
+	if c.CollectTablesWithoutPrimaryKey.Enabled {
+		ret = append(ret, collector.ScrapeTablesWithoutPrimaryKey{
+			Include:        c.CollectTablesWithoutPrimaryKey.Include,
+			Exclude:        c.CollectTablesWithoutPrimaryKey.Exclude,
+			ScrapeInterval: c.CollectTablesWithoutPrimaryKey.Interval,
+		})
+	}
+
+	if c.CollectInnodbLockWaits.Enabled {
+		ret = append(ret, collector.ScrapeInnodbLockWaits{
+			Detail:      c.CollectInnodbLockWaits.Detail,
+			DetailLimit: c.CollectInnodbLockWaits.DetailLimit,
+		})
+	}
+
+	if c.CollectAccountExpiry.Enabled {
+		ret = append(ret, collector.ScrapeAccountExpiry{})
+	}
+
+	if c.CollectGtidLag.Enabled {
+		ret = append(ret, collector.ScrapeGtidLag{})
+	}
+
+	if c.CollectReplicationSource.Enabled {
+		ret = append(ret, collector.ScrapeReplicationSource{})
+	}
+
+	if c.CollectInnodbBufferPoolLoad.Enabled {
+		ret = append(ret, collector.ScrapeInnodbBufferPoolLoad{})
+	}
+
+	if c.CollectReplicationFilters.Enabled {
+		ret = append(ret, collector.ScrapeReplicationFilters{})
+	}
+
+	if c.CollectAurora.Enabled {
+		ret = append(ret, collector.ScrapeAurora{})
+	}
+
+	if c.CollectPerfTopWaits.Enabled {
+		ret = append(ret, collector.ScrapePerfTopWaits{Limit: c.CollectPerfTopWaits.Limit})
+	}
+
+	if c.CollectReplicationHeartbeat.Enabled {
+		ret = append(ret, collector.ScrapeReplicationHeartbeat{})
+	}
+
+	if c.CollectTableFragmentation.Enabled {
+		ret = append(ret, collector.ScrapeTableFragmentation{
+			Include:        c.CollectTableFragmentation.Include,
+			Exclude:        c.CollectTableFragmentation.Exclude,
+			MinDataFree:    c.CollectTableFragmentation.MinDataFreeBytes,
+			ScrapeInterval: c.CollectTableFragmentation.Interval,
+		})
+	}
+
+	if c.CollectInnodbAdaptiveHashIndex.Enabled {
+		ret = append(ret, collector.ScrapeInnodbAdaptiveHashIndex{
+			ParseStatus: c.CollectInnodbAdaptiveHashIndex.ParseStatus,
+		})
+	}
+
+	if c.CollectPerfThreads.Enabled {
+		ret = append(ret, collector.ScrapePerfThreads{ByUser: c.CollectPerfThreads.ByUser})
+	}
+
+	if c.CollectInnodbUndo.Enabled {
+		ret = append(ret, collector.ScrapeInnodbUndo{})
+	}
+
+	if c.CollectReplicationApplierBusy.Enabled {
+		ret = append(ret, collector.ScrapeReplicationApplierBusy{})
+	}
+
+	if c.CollectSleepingConnections.Enabled {
+		ret = append(ret, collector.ScrapeSleepingConnections{
+			Thresholds: c.CollectSleepingConnections.Thresholds,
+		})
+	}
+
+	if c.CollectTableLocks.Enabled {
+		ret = append(ret, collector.ScrapeTableLocks{})
+	}
+
+	if c.CollectReplicationSSL.Enabled {
+		ret = append(ret, collector.ScrapeReplicationSSL{})
+	}
+
+	if c.CollectReplicationLastError.Enabled {
+		ret = append(ret, collector.ScrapeReplicationLastError{
+			MessageLength: c.CollectReplicationLastError.MessageLength,
+		})
+	}
+
+	if c.CollectPerfReplicationChannels.Enabled {
+		ret = append(ret, collector.ScrapePerfReplicationChannels{})
+	}
+
+	if c.CollectPreparedStatements.Enabled {
+		ret = append(ret, collector.ScrapePreparedStatements{})
+	}
+
+	if c.CollectInnodbFlushing.Enabled {
+		ret = append(ret, collector.ScrapeInnodbFlushing{})
+	}
+
+	if c.CollectReplicationParallelWorkers.Enabled {
+		ret = append(ret, collector.ScrapeReplicationParallelWorkers{})
+	}
+
+	if c.CollectOptimizer.Enabled {
+		ret = append(ret, collector.ScrapeOptimizer{})
+	}
+
+	if c.CollectServerTimeDrift.Enabled {
+		ret = append(ret, collector.ScrapeServerTimeDrift{})
+	}
+
+	if c.CollectTablesByEngine.Enabled {
+		ret = append(ret, collector.ScrapeTablesByEngine{
+			Include:        c.CollectTablesByEngine.Include,
+			Exclude:        c.CollectTablesByEngine.Exclude,
+			ScrapeInterval: c.CollectTablesByEngine.Interval,
+		})
+	}
+
+	if c.CollectConnectionSaturation.Enabled {
+		ret = append(ret, collector.ScrapeConnectionSaturation{})
+	}
+
+	if c.CollectSlowQueries.Enabled {
+		ret = append(ret, collector.ScrapeSlowQueries{
+			Variables: c.CollectSlowQueries.Variables,
+		})
+	}
+
+	if c.CollectPerfEventsStatementsByProgram.Enabled {
+		ret = append(ret, collector.ScrapePerfEventsStatementsByProgram{Limit: c.CollectPerfEventsStatementsByProgram.Limit})
+	}
+
 	return
 }
 
@@ -400,9 +950,184 @@ func (*MySQL) ParseConfig(baseDir string, bs []byte) (any, error) {
 
 	c.BaseDir = baseDir
 
+	for _, q := range c.Queries {
+		if err := q.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range c.CollectStatusMappings.Mappings {
+		if err := c.CollectStatusMappings.Mappings[i].Compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	c.xprotocolDefaults()
+
+	for _, e := range c.CollectVariableExpectations.Expected {
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Global != nil {
+		if err := c.Global.validateDSNParams(); err != nil {
+			return nil, err
+		}
+
+		if err := c.Global.validateScrapeEvery(); err != nil {
+			return nil, err
+		}
+
+		if err := c.Global.validateCustomQueryReplicas(); err != nil {
+			return nil, err
+		}
+
+		if c.Global.Vault != nil {
+			if err := c.Global.Vault.validate(baseDir); err != nil {
+				return nil, err
+			}
+		}
+
+		if c.Global.Resolver != nil {
+			if err := c.Global.Resolver.validate(); err != nil {
+				return nil, err
+			}
+		}
+
+		if c.Global.AdminPort < 0 || c.Global.AdminPort > 65535 {
+			return nil, fmt.Errorf("invalid admin_port %d", c.Global.AdminPort)
+		}
+
+		switch c.Global.AdminMode {
+		case "", collector.AdminFallback, collector.AdminAlways:
+		default:
+			return nil, fmt.Errorf("invalid admin_mode %q, should be fallback or always", c.Global.AdminMode)
+		}
+
+		if c.Global.MaxOpenConns < 0 {
+			return nil, fmt.Errorf("invalid max_open_conns %d, should not be negative", c.Global.MaxOpenConns)
+		}
+
+		switch c.Global.CustomQueryNames {
+		case "", "sanitize":
+		case "reject":
+			for _, q := range c.Queries {
+				if err := q.ValidateNames(); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid custom_query_names %q, should be sanitize or reject", c.Global.CustomQueryNames)
+		}
+
+		for _, statement := range c.Global.SessionStatements {
+			if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(statement)), "SET ") {
+				return nil, fmt.Errorf("session statement must be a SET statement: %s", statement)
+			}
+		}
+
+		if c.Global.ReadOnly {
+			var writes []string
+			for _, q := range c.Queries {
+				if !collector.IsReadOnlyQuery(q.Request) {
+					writes = append(writes, q.Mesurement)
+				}
+			}
+			if c.Global.VersionQuery != "" && !collector.IsReadOnlyQuery(c.Global.VersionQuery) {
+				writes = append(writes, "version_query")
+			}
+			if len(writes) > 0 {
+				return nil, fmt.Errorf("read_only is enabled, but these queries are not SELECT or SHOW statements, or write files or take locks: %s", strings.Join(writes, ", "))
+			}
+		}
+	}
+
 	return &c, nil
 }
 
+// ValidateTarget forms the DSN of each host of the target without connecting
+func (*MySQL) ValidateTarget(address string, c any) error {
+	cfg := c.(*Config)
+	for _, host := range splitHosts(address) {
+		if host == "" {
+			return fmt.Errorf("blank host in target %q", address)
+		}
+		if _, err := cfg.Global.FormDSN(host); err != nil {
+			return fmt.Errorf("failed to form dsn for %s: %s", host, err)
+		}
+	}
+	return nil
+}
+
+// ProbeTarget connects to the first available host of the target without scraping
+func (*MySQL) ProbeTarget(ctx context.Context, address string, c any) error {
+	cfg := c.(*Config)
+	dsns, err := cfg.Global.formDSNs(splitHosts(address))
+	if err != nil {
+		return fmt.Errorf("failed to form dsn for %s: %s", address, err)
+	}
+
+	adminDSNs, err := cfg.Global.formAdminDSNs(splitHosts(address))
+	if err != nil {
+		return fmt.Errorf("failed to form admin dsn for %s: %s", address, err)
+	}
+
+	return collector.New(ctx, dsns, nil, nil, nil, collector.Options{
+		LockWaitTimeout:   cfg.Global.LockWaitTimeout,
+		LogSlowFilter:     cfg.Global.LogSlowFilter,
+		SessionStatements: cfg.Global.sessionStatements(),
+		InitSQLFile:       cfg.initSQLFile(),
+		PrimaryDetection:  cfg.Global.PrimaryDetection,
+		Credentials:       cfg.Global.credentials(),
+		AdminDSNs:         adminDSNs,
+		AdminMode:         cfg.Global.AdminMode,
+	}).Ping(ctx)
+}
+
+// formAdminDSNs forms the DSNs of the admin interface of the hosts, blank for the unix socket and named pipe hosts
+func (g *Global) formAdminDSNs(hosts []string) ([]string, error) {
+	if g.AdminPort == 0 {
+		return nil, nil
+	}
+
+	admin := *g
+	if g.AdminUser != "" {
+		admin.User, admin.Password = g.AdminUser, g.AdminPassword
+	}
+
+	dsns := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if strings.HasPrefix(host, "unix://") || strings.HasPrefix(host, "pipe://") {
+			dsns = append(dsns, "")
+			continue
+		}
+
+		h, _, err := net.SplitHostPort(host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse target: %s", err)
+		}
+		dsn, err := admin.FormDSN(net.JoinHostPort(h, strconv.Itoa(g.AdminPort)))
+		if err != nil {
+			return nil, err
+		}
+		dsns = append(dsns, dsn)
+	}
+	return dsns, nil
+}
+
+func (g *Global) formDSNs(hosts []string) ([]string, error) {
+	dsns := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		dsn, err := g.FormDSN(host)
+		if err != nil {
+			return nil, err
+		}
+		dsns = append(dsns, dsn)
+	}
+	return dsns, nil
+}
+
 // mysqld_exporter 原来的很多参数都是通过命令行传的，在 cprobe 的场景下，需要改造
 // cprobe 是并发抓取很多个数据库实例的监控数据，不同的数据库实例其抓取参数可能不同
 // 如果直接修改 collector pkg 下面的变量，就会有并发使用变量的问题
@@ -410,13 +1135,52 @@ func (*MySQL) ParseConfig(baseDir string, bs []byte) (any, error) {
 func (*MySQL) Scrape(ctx context.Context, address string, c any, ss *types.Samples) error {
 	// 这个方法中如果要对配置 c 变量做修改，一定要 clone 一份之后再修改，因为并发的多个 target 共享了一个 c 变量
 	cfg := c.(*Config)
-	dsn, err := cfg.Global.FormDSN(address)
+
+	// target 的 __server_label__ 标签，代替 address 作为 instance 标签和日志里的 target
+	serverLabel := plugins.ServerLabel(ctx)
+
+	// address 可以是逗号分隔的多个 host，比如高可用集群的多个节点，按顺序选择第一个可用的 host 抓取
+	hosts := splitHosts(address)
+	dsns, err := cfg.Global.formDSNs(hosts)
 	if err != nil {
 		return fmt.Errorf("failed to form dsn for %s: %s", address, err)
 	}
 
+	adminDSNs, err := cfg.Global.formAdminDSNs(hosts)
+	if err != nil {
+		return fmt.Errorf("failed to form admin dsn for %s: %s", address, err)
+	}
+
+	var replicas []collector.Replica
+	for _, r := range cfg.Global.CustomQueryReplicas {
+		dsn, err := cfg.Global.FormDSN(r.Address)
+		if err != nil {
+			return fmt.Errorf("failed to form dsn for replica %s: %s", r.Address, err)
+		}
+		replicas = append(replicas, collector.Replica{Address: r.Address, DSN: dsn, Weight: r.Weight})
+	}
+
 	scrapers := cfg.EnabledScrapers()
-	exporter := collector.New(ctx, dsn, scrapers, ss, cfg.Queries, cfg.Global.LockWaitTimeout, cfg.Global.LogSlowFilter)
+	exporter := collector.New(ctx, dsns, scrapers, ss, cfg.Queries, collector.Options{
+		LockWaitTimeout:    cfg.Global.LockWaitTimeout,
+		LogSlowFilter:      cfg.Global.LogSlowFilter,
+		SeriesLimit:        cfg.Global.SeriesLimitPerCollector,
+		SessionStatements:  cfg.Global.sessionStatements(),
+		InitSQLFile:        cfg.initSQLFile(),
+		VersionQuery:       cfg.Global.VersionQuery,
+		PrimaryDetection:   cfg.Global.PrimaryDetection,
+		ScrapeEvery:        cfg.Global.ScrapeEvery,
+		SkippedPolicy:      cfg.Global.ScrapeEveryPolicy,
+		Replicas:           replicas,
+		IsolateConnections: cfg.Global.IsolateConnections,
+		MaxOpenConns:       cfg.Global.MaxOpenConns,
+		ServerLabel:        serverLabel,
+		Credentials:        cfg.Global.credentials(),
+		AdminDSNs:          adminDSNs,
+		AdminMode:          cfg.Global.AdminMode,
+		QueryComment:       cfg.Global.QueryComment,
+		ReportSkipped:      cfg.Global.ReportSkippedCollectors,
+	})
 
 	ch := make(chan prometheus.Metric)
 	errCh := make(chan error, 1)
@@ -432,5 +1196,18 @@ func (*MySQL) Scrape(ctx context.Context, address string, c any, ss *types.Sampl
 		}
 	}
 
+	if cfg.CollectXProtocol.Enabled {
+		cfg.scrapeXProtocol(ctx, hosts, ss)
+	}
+
+	// the instance label of the metrics is the address of the target by default
+	if serverLabel != "" {
+		ms := ss.PopBackAll()
+		for _, m := range ms {
+			m.AddTag("instance", serverLabel)
+		}
+		ss.PushFrontN(ms)
+	}
+
 	return <-errCh
 }
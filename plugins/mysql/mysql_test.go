@@ -0,0 +1,229 @@
+package mysql
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDSNParams(t *testing.T) {
+	g := Global{User: "root", Password: "pass", DSNParams: map[string]string{
+		"maxAllowedPacket":  "16777216",
+		"interpolateParams": "true",
+	}}
+	if err := g.validateDSNParams(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dsn, err := g.FormDSN("127.0.0.1:3306")
+	if err != nil {
+		t.Fatalf("failed to form dsn: %s", err)
+	}
+	if !strings.HasSuffix(dsn, "?interpolateParams=true&maxAllowedPacket=16777216") {
+		t.Errorf("unexpected dsn: %s", dsn)
+	}
+
+	for _, params := range []map[string]string{
+		{"tls": "true"},
+		{"lock_wait_timeout": "5"},
+		{"sql_mode": "ANSI"},
+		{"maxAllowedPacket": "abc"},
+		{"clientFoundRows": "maybe"},
+		{"timeout": "1s&tls=false"},
+	} {
+		if err := (Global{DSNParams: params}).validateDSNParams(); err == nil {
+			t.Errorf("expected error for dsn_params: %v", params)
+		}
+	}
+}
+
+func TestFormAdminDSNs(t *testing.T) {
+	g := Global{User: "root", Password: "pass", AdminPort: 33062, AdminUser: "admin", AdminPassword: "secret"}
+	dsns, err := g.formAdminDSNs([]string{"10.0.0.1:3306", "unix:///tmp/mysql.sock"})
+	if err != nil {
+		t.Fatalf("failed to form admin dsns: %s", err)
+	}
+	if len(dsns) != 2 || dsns[0] != "admin:secret@tcp(10.0.0.1:33062)/" || dsns[1] != "" {
+		t.Errorf("unexpected admin dsns: %v", dsns)
+	}
+
+	if dsns, _ := (&Global{}).formAdminDSNs([]string{"10.0.0.1:3306"}); dsns != nil {
+		t.Errorf("unexpected admin dsns without admin_port: %v", dsns)
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cprobe test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create cert: %s", err)
+	}
+	ca, _ := x509.ParseCertificate(der)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write ca: %s", err)
+	}
+
+	system, err := x509.SystemCertPool()
+	if err != nil {
+		t.Skipf("system cert pool is not available: %s", err)
+	}
+
+	verifies := func(cfg *Global) bool {
+		tlsCfg, err := cfg.tlsConfig()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		_, err = ca.Verify(x509.VerifyOptions{Roots: tlsCfg.RootCAs})
+		return err == nil
+	}
+
+	// ssl_ca only
+	if !verifies(&Global{SslCa: caFile}) {
+		t.Errorf("expected the cert verified by ssl_ca")
+	}
+
+	// the system cert pool only
+	g := Global{UseSystemCertPool: true}
+	tlsCfg, err := g.tlsConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !tlsCfg.RootCAs.Equal(system) {
+		t.Errorf("expected the system cert pool")
+	}
+	if verifies(&g) {
+		t.Errorf("expected the cert not verified by the system cert pool")
+	}
+
+	// both merged
+	g.SslCa = caFile
+	if !verifies(&g) {
+		t.Errorf("expected the cert verified by the merged pool")
+	}
+	if tlsCfg, _ = g.tlsConfig(); tlsCfg.RootCAs.Equal(system) {
+		t.Errorf("expected ssl_ca merged into the system cert pool")
+	}
+
+	// the system cert pool is not changed by the merge
+	if tlsCfg, _ = (&Global{UseSystemCertPool: true}).tlsConfig(); !tlsCfg.RootCAs.Equal(system) {
+		t.Errorf("expected the system cert pool unchanged")
+	}
+
+	dsn, err := (Global{UseSystemCertPool: true}).FormDSN("127.0.0.1:3306")
+	if err != nil {
+		t.Fatalf("failed to form dsn: %s", err)
+	}
+	if !strings.Contains(dsn, "tls=custom") {
+		t.Errorf("unexpected dsn: %s", dsn)
+	}
+}
+
+func TestCustomizeTLSReload(t *testing.T) {
+	writeCA := func(path, name string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %s", err)
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+		if err != nil {
+			t.Fatalf("failed to create cert: %s", err)
+		}
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+			t.Fatalf("failed to write ca: %s", err)
+		}
+	}
+
+	dir := t.TempDir()
+	caFile, otherFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "other.pem")
+	writeCA(caFile, "cprobe test ca")
+	writeCA(otherFile, "cprobe other ca")
+
+	g, other := Global{SslCa: caFile}, Global{SslCa: otherFile}
+	name, err := g.CustomizeTLS()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	otherName, err := other.CustomizeTLS()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if name == otherName {
+		t.Fatalf("expected the cert files registered under different names, got %s", name)
+	}
+
+	stamps := func(g Global) []tlsFileStamp {
+		tlsConfigsLock.Lock()
+		defer tlsConfigsLock.Unlock()
+		for _, reg := range tlsConfigs {
+			if len(reg.stamps) > 0 && reg.stamps[0].path == g.SslCa {
+				return reg.stamps
+			}
+		}
+		t.Fatalf("no tls config registered for %s", g.SslCa)
+		return nil
+	}
+	loaded, otherLoaded := stamps(g), stamps(other)
+
+	// the rotated ca is loaded under the same name, the other cert files are untouched
+	writeCA(caFile, "cprobe rotated test ca with a longer name")
+	if err := os.Chtimes(caFile, time.Now(), time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("failed to touch ca: %s", err)
+	}
+	if reloaded, err := g.CustomizeTLS(); err != nil || reloaded != name {
+		t.Fatalf("expected %s reloaded, got %s: %v", name, reloaded, err)
+	}
+	if equalTLSFileStamps(stamps(g), loaded) {
+		t.Errorf("expected the rotated ca loaded")
+	}
+	if !equalTLSFileStamps(stamps(other), otherLoaded) {
+		t.Errorf("expected the other ca untouched")
+	}
+
+	// a broken rotation keeps the certs loaded last time
+	loaded = stamps(g)
+	if err := os.WriteFile(caFile, []byte("half written"), 0600); err != nil {
+		t.Fatalf("failed to write ca: %s", err)
+	}
+	if reloaded, err := g.CustomizeTLS(); err != nil || reloaded != name {
+		t.Fatalf("expected %s kept, got %s: %v", name, reloaded, err)
+	}
+	if !equalTLSFileStamps(stamps(g), loaded) {
+		t.Errorf("expected the certs loaded last time kept")
+	}
+
+	// a broken file fails the first registration
+	if _, err := (Global{SslCa: filepath.Join(dir, "missing.pem")}).CustomizeTLS(); err == nil {
+		t.Errorf("expected error for the missing ca")
+	}
+}
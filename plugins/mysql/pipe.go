@@ -0,0 +1,17 @@
+package mysql
+
+import "strings"
+
+// namedPipeNet is the network name registered to go-sql-driver/mysql for
+// Windows named pipe connections, the DSN looks like user:pass@pipe(\\.\pipe\MySQL)/
+const namedPipeNet = "pipe"
+
+// normalizePipeName accepts both the short pipe name (MySQL) and the full
+// path (\\.\pipe\MySQL or //./pipe/MySQL), returns the full path with backslashes
+func normalizePipeName(name string) string {
+	name = strings.ReplaceAll(name, "/", `\`)
+	if strings.HasPrefix(name, `\\`) {
+		return name
+	}
+	return `\\.\pipe\` + name
+}
@@ -0,0 +1,7 @@
+//go:build !windows
+// +build !windows
+
+package mysql
+
+// named pipe is a Windows only transport
+const namedPipeSupported = false
@@ -0,0 +1,21 @@
+package mysql
+
+import "testing"
+
+func TestNormalizePipeName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"MySQL", `\\.\pipe\MySQL`},
+		{`\\.\pipe\MySQL`, `\\.\pipe\MySQL`},
+		{"//./pipe/MySQL80", `\\.\pipe\MySQL80`},
+		{`\\db01\pipe\MySQL`, `\\db01\pipe\MySQL`},
+	}
+
+	for _, test := range tests {
+		if got := normalizePipeName(test.input); got != test.expected {
+			t.Errorf("normalizePipeName(%q) = %q, expected %q", test.input, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+//go:build windows
+// +build windows
+
+package mysql
+
+import (
+	"context"
+	"net"
+	"os"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const namedPipeSupported = true
+
+func init() {
+	mysql.RegisterDialContext(namedPipeNet, dialPipe)
+}
+
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return namedPipeNet }
+func (a pipeAddr) String() string  { return string(a) }
+
+// pipeConn wraps the opened pipe file as a net.Conn
+type pipeConn struct {
+	*os.File
+	addr pipeAddr
+}
+
+func (c *pipeConn) LocalAddr() net.Addr  { return c.addr }
+func (c *pipeConn) RemoteAddr() net.Addr { return c.addr }
+
+func dialPipe(ctx context.Context, addr string) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(addr, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pipeConn{File: f, addr: pipeAddr(addr)}, nil
+}
@@ -0,0 +1,115 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/go-sql-driver/mysql"
+)
+
+const defaultResolverTimeout = 2 * time.Second
+
+// Resolver resolves the hostnames of the targets by the DNS servers instead of the system resolver,
+// e.g. in split-horizon DNS environments
+type Resolver struct {
+	// Servers are host:port, the port defaults to 53, the queries are spread across them
+	Servers []string      `toml:"servers"`
+	Timeout time.Duration `toml:"timeout"`
+}
+
+func (r *Resolver) validate() error {
+	if len(r.Servers) == 0 {
+		return fmt.Errorf("resolver servers is required")
+	}
+
+	for i, server := range r.Servers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+		}
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			return fmt.Errorf("invalid resolver server %q: %s", r.Servers[i], err)
+		}
+		r.Servers[i] = server
+	}
+
+	if r.Timeout <= 0 {
+		r.Timeout = defaultResolverTimeout
+	}
+
+	return nil
+}
+
+// the dial funcs are registered to the driver once for every resolver config, since the config is parsed on every scrape
+var (
+	resolverNetsLock sync.Mutex
+	resolverNets     = make(map[string]string)
+)
+
+// netName returns the network name of the DSN dialing by the resolver, registered to the driver the first time
+func (r *Resolver) netName() string {
+	key := strings.Join(r.Servers, ",") + "|" + r.Timeout.String()
+
+	resolverNetsLock.Lock()
+	defer resolverNetsLock.Unlock()
+
+	if name, has := resolverNets[key]; has {
+		return name
+	}
+
+	name := fmt.Sprintf("tcp_resolver_%d", len(resolverNets)+1)
+	mysql.RegisterDialContext(name, newResolverDial(append([]string(nil), r.Servers...), r.Timeout))
+	resolverNets[key] = name
+	return name
+}
+
+// resolvedAddrs remembers the address every hostname is resolved to, the changes are logged
+var resolvedAddrs sync.Map
+
+func newResolverDial(servers []string, timeout time.Duration) mysql.DialContextFunc {
+	var next uint32
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: timeout}
+			server := servers[int(atomic.AddUint32(&next, 1)-1)%len(servers)]
+			return d.DialContext(ctx, network, server)
+		},
+	}
+	dialer := &net.Dialer{KeepAlive: 30 * time.Second}
+
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		if net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, "tcp", addr)
+		}
+
+		ips, err := resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s by %s: %w", host, strings.Join(servers, ","), err)
+		}
+
+		for _, ip := range ips {
+			var conn net.Conn
+			resolved := net.JoinHostPort(ip.IP.String(), port)
+			if conn, err = dialer.DialContext(ctx, "tcp", resolved); err != nil {
+				continue
+			}
+			if last, loaded := resolvedAddrs.Load(addr); !loaded || last != resolved {
+				resolvedAddrs.Store(addr, resolved)
+				logger.Infof("mysql target %s resolved to %s by %s", addr, resolved, strings.Join(servers, ","))
+			}
+			return conn, nil
+		}
+		return nil, err
+	}
+}
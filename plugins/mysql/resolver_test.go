@@ -0,0 +1,155 @@
+package mysql
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveTestDNS answers the A queries of db.cprobe.test with 127.0.0.1, the AAAA queries with nothing,
+// and the other names with NXDOMAIN
+func serveTestDNS(t *testing.T) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < 12 {
+				continue
+			}
+
+			// the question is the labels ending with 0, then the type and class
+			end := 12
+			var labels []string
+			for end < n && buf[end] != 0 {
+				l := int(buf[end])
+				if end+1+l > n {
+					break
+				}
+				labels = append(labels, string(buf[end+1:end+1+l]))
+				end += 1 + l
+			}
+			end += 5
+			if end > n {
+				continue
+			}
+			question := buf[12:end]
+			qtype := binary.BigEndian.Uint16(buf[end-4:])
+			name := strings.ToLower(strings.Join(labels, "."))
+
+			resp := make([]byte, 12, 64)
+			copy(resp, buf[:2])
+			flags, answers := uint16(0x8180), uint16(0)
+			if name != "db.cprobe.test" {
+				flags |= 3
+			} else if qtype == 1 {
+				answers = 1
+			}
+			binary.BigEndian.PutUint16(resp[2:], flags)
+			binary.BigEndian.PutUint16(resp[4:], 1)
+			binary.BigEndian.PutUint16(resp[6:], answers)
+			resp = append(resp, question...)
+			if answers > 0 {
+				resp = append(resp, 0xc0, 0x0c, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 127, 0, 0, 1)
+			}
+			pc.WriteTo(resp, addr)
+		}
+	}()
+
+	return pc.LocalAddr().String()
+}
+
+func TestResolverDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	r := &Resolver{Servers: []string{serveTestDNS(t)}, Timeout: time.Second}
+	if err := r.validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	dial := newResolverDial(r.Servers, r.Timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addr := net.JoinHostPort("db.cprobe.test", port)
+	conn, err := dial(ctx, addr)
+	if err != nil {
+		t.Fatalf("failed to dial %s: %s", addr, err)
+	}
+	conn.Close()
+
+	if resolved, _ := resolvedAddrs.Load(addr); resolved != ln.Addr().String() {
+		t.Errorf("unexpected resolved address: %v", resolved)
+	}
+
+	// the ip literals are not resolved
+	if conn, err = dial(ctx, ln.Addr().String()); err != nil {
+		t.Errorf("failed to dial %s: %s", ln.Addr(), err)
+	} else {
+		conn.Close()
+	}
+
+	if _, err = dial(ctx, net.JoinHostPort("other.cprobe.test", port)); err == nil || !strings.Contains(err.Error(), "failed to resolve") {
+		t.Errorf("expected the resolve error, got %v", err)
+	}
+}
+
+func TestResolverConfig(t *testing.T) {
+	r := &Resolver{Servers: []string{"10.0.0.53", "[fd00::53]", "10.0.1.53:5353"}}
+	if err := r.validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if strings.Join(r.Servers, ",") != "10.0.0.53:53,[fd00::53]:53,10.0.1.53:5353" || r.Timeout != defaultResolverTimeout {
+		t.Errorf("unexpected resolver: %v %s", r.Servers, r.Timeout)
+	}
+
+	if err := (&Resolver{}).validate(); err == nil {
+		t.Errorf("expected error for blank servers")
+	}
+
+	// the same config is registered once
+	same := &Resolver{Servers: append([]string(nil), r.Servers...), Timeout: r.Timeout}
+	if r.netName() != same.netName() {
+		t.Errorf("expected the same network for the same config")
+	}
+	if r.netName() == (&Resolver{Servers: []string{"10.0.2.53:53"}, Timeout: time.Second}).netName() {
+		t.Errorf("expected another network for another config")
+	}
+
+	g := Global{Resolver: r}
+	dsn, err := g.FormDSN("db.cprobe.test:3306")
+	if err != nil {
+		t.Fatalf("failed to form dsn: %s", err)
+	}
+	if !strings.Contains(dsn, r.netName()+"(db.cprobe.test:3306)") {
+		t.Errorf("unexpected dsn: %s", dsn)
+	}
+	if dsn, _ = g.FormDSN("unix:///tmp/mysql.sock"); !strings.HasPrefix(dsn, "unix(") {
+		t.Errorf("unexpected dsn: %s", dsn)
+	}
+}
@@ -0,0 +1,114 @@
+package mysql
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/go-sql-driver/mysql"
+)
+
+// tlsRegistration is the name a set of cert files is registered to the driver with,
+// and the stamps of the files when they were loaded
+type tlsRegistration struct {
+	name   string
+	stamps []tlsFileStamp
+}
+
+type tlsFileStamp struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// the tls configs are registered to the driver once for every set of cert files, since the config is parsed on every
+// scrape, and registered again under the same name when one of the files changes, so that the connections of the next
+// scrape use the rotated certs while the targets of the other cert files are untouched
+var (
+	tlsConfigsLock sync.Mutex
+	tlsConfigs     = make(map[string]*tlsRegistration)
+)
+
+// CustomizeTLS returns the tls name of the DSN verifying by ssl_ca and the system cert pool, with the client cert of
+// ssl_cert and ssl_key. The files are loaded again when their modification time or size changes, if the reload
+// fails, e.g. the cert is rotated but not the key yet, the certs loaded last time are kept until the next scrape.
+func (g Global) CustomizeTLS() (string, error) {
+	key := strings.Join([]string{g.SslCa, g.SslCert, g.SslKey,
+		strconv.FormatBool(g.UseSystemCertPool), strconv.FormatBool(g.TlsInsecureSkipVerify)}, "|")
+
+	stamps, err := g.tlsFileStamps()
+
+	tlsConfigsLock.Lock()
+	defer tlsConfigsLock.Unlock()
+
+	reg, has := tlsConfigs[key]
+	if err == nil && has && equalTLSFileStamps(reg.stamps, stamps) {
+		return reg.name, nil
+	}
+
+	name := fmt.Sprintf("custom_%d", len(tlsConfigs)+1)
+	if has {
+		name = reg.name
+	}
+	if err == nil {
+		err = g.registerTLS(name)
+	}
+	if err != nil {
+		if !has {
+			return "", err
+		}
+		logger.Warnf("failed to reload mysql tls config %s, keep the certs loaded last time: %s", name, err)
+		return name, nil
+	}
+
+	if has {
+		logger.Infof("mysql tls config %s reloaded, the cert files changed", name)
+	}
+	tlsConfigs[key] = &tlsRegistration{name: name, stamps: stamps}
+	return name, nil
+}
+
+func (g Global) registerTLS(name string) error {
+	tlsCfg, err := g.tlsConfig()
+	if err != nil {
+		return err
+	}
+	return mysql.RegisterTLSConfig(name, tlsCfg)
+}
+
+// tlsFileStamps stats the files tlsConfig loads
+func (g Global) tlsFileStamps() ([]tlsFileStamp, error) {
+	var paths []string
+	if g.SslCa != "" {
+		paths = append(paths, g.SslCa)
+	}
+	if g.SslCert != "" && g.SslKey != "" {
+		paths = append(paths, g.SslCert, g.SslKey)
+	}
+
+	stamps := make([]tlsFileStamp, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		stamps = append(stamps, tlsFileStamp{path: path, modTime: info.ModTime(), size: info.Size()})
+	}
+	return stamps, nil
+}
+
+func equalTLSFileStamps(a, b []tlsFileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].path != b[i].path || !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,339 @@
+package mysql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cprobe/cprobe/lib/clienttls"
+	"github.com/cprobe/cprobe/lib/logger"
+)
+
+const (
+	defaultVaultRenewBefore     = 5 * time.Minute
+	defaultVaultRefreshInterval = 5 * time.Minute
+	defaultVaultTimeout         = 5 * time.Second
+)
+
+// Vault gets the user and password of the connections from a HashiCorp Vault secret instead of the user and password options,
+// e.g. a dynamic secret of the database secrets engine renewed before it expires, or a static secret of the kv engine
+type Vault struct {
+	Address string `toml:"address"`
+	// Token falls back to the content of TokenFile, then the VAULT_TOKEN environment variable
+	Token     string `toml:"token"`
+	TokenFile string `toml:"token_file"`
+	Namespace string `toml:"namespace"`
+	// Path is read by GET /v1/<path>, e.g. database/creds/cprobe or secret/data/mysql
+	Path          string `toml:"path"`
+	UserField     string `toml:"user_field"`
+	PasswordField string `toml:"password_field"`
+	// RenewBefore is how long before the lease expires it is renewed, at most half of the lease
+	RenewBefore time.Duration `toml:"renew_before"`
+	// RefreshInterval is how often the secrets without a lease are read again
+	RefreshInterval time.Duration `toml:"refresh_interval"`
+	Timeout         time.Duration `toml:"timeout"`
+
+	clienttls.ClientConfig
+}
+
+func (v *Vault) validate(baseDir string) error {
+	if v.Address == "" {
+		return fmt.Errorf("vault address is required")
+	}
+	v.Address = strings.TrimRight(v.Address, "/")
+
+	v.Path = strings.Trim(v.Path, "/")
+	if v.Path == "" {
+		return fmt.Errorf("vault path is required")
+	}
+
+	if v.TokenFile != "" && !filepath.IsAbs(v.TokenFile) {
+		v.TokenFile = filepath.Join(baseDir, v.TokenFile)
+	}
+
+	if v.UserField == "" {
+		v.UserField = "username"
+	}
+
+	if v.PasswordField == "" {
+		v.PasswordField = "password"
+	}
+
+	if v.RenewBefore <= 0 {
+		v.RenewBefore = defaultVaultRenewBefore
+	}
+
+	if v.RefreshInterval <= 0 {
+		v.RefreshInterval = defaultVaultRefreshInterval
+	}
+
+	if v.Timeout <= 0 {
+		v.Timeout = defaultVaultTimeout
+	}
+
+	return nil
+}
+
+// token is read on every request, so that a token file rotated by the vault agent is picked up
+func (v *Vault) token() (string, error) {
+	if v.Token != "" {
+		return v.Token, nil
+	}
+
+	if v.TokenFile != "" {
+		bs, err := os.ReadFile(v.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read vault token_file: %s", err)
+		}
+		return strings.TrimSpace(string(bs)), nil
+	}
+
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	return "", fmt.Errorf("vault token is required, set token, token_file or VAULT_TOKEN")
+}
+
+func (v *Vault) client() (*http.Client, error) {
+	client := &http.Client{Timeout: v.Timeout}
+	if strings.HasPrefix(v.Address, "https") {
+		tlsConfig, err := v.ClientConfig.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		client.Transport = &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig, IdleConnTimeout: 90 * time.Second}
+	}
+	return client, nil
+}
+
+// the providers are shared by the targets and kept across the scrapes, so that the lease is reused until it is renewed
+var (
+	vaultProvidersLock sync.Mutex
+	vaultProviders     = make(map[string]*vaultProvider)
+)
+
+// getVaultProvider returns the provider of the address and path, the options are updated by the latest config
+func getVaultProvider(v *Vault) *vaultProvider {
+	key := v.Address + "/" + v.Path
+
+	vaultProvidersLock.Lock()
+	defer vaultProvidersLock.Unlock()
+
+	p, has := vaultProviders[key]
+	if !has {
+		p = &vaultProvider{key: key}
+		vaultProviders[key] = p
+	}
+
+	p.mu.Lock()
+	p.vault = v
+	p.mu.Unlock()
+
+	return p
+}
+
+// vaultProvider implements collector.CredentialsProvider, the credentials are never logged
+type vaultProvider struct {
+	key string
+
+	mu            sync.Mutex
+	vault         *Vault
+	user          string
+	password      string
+	leaseID       string
+	renewable     bool
+	leaseDuration time.Duration
+	expires       time.Time
+	fetched       time.Time
+
+	// client is kept across the requests of the provider, so that the reads and the renewals reuse the connections
+	client    *http.Client
+	clientKey string
+}
+
+type vaultSecret struct {
+	LeaseID       string         `json:"lease_id"`
+	Renewable     bool           `json:"renewable"`
+	LeaseDuration int64          `json:"lease_duration"`
+	Data          map[string]any `json:"data"`
+}
+
+func (p *vaultProvider) Credentials(ctx context.Context) (string, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.user != "" && !p.due(now) {
+		return p.user, p.password, nil
+	}
+
+	err := p.refresh(ctx, now)
+	if err == nil {
+		return p.user, p.password, nil
+	}
+
+	// vault is unavailable, the last lease still works until it expires
+	if p.user != "" && (p.expires.IsZero() || now.Before(p.expires)) {
+		logger.Warnf("failed to refresh the mysql credentials from vault %s, reuse the last ones: %s", p.key, err)
+		return p.user, p.password, nil
+	}
+
+	return "", "", fmt.Errorf("failed to get the mysql credentials from vault %s: %s", p.key, err)
+}
+
+// due reports whether the lease should be renewed or the secret read again
+func (p *vaultProvider) due(now time.Time) bool {
+	if p.expires.IsZero() {
+		return !now.Before(p.fetched.Add(p.vault.RefreshInterval))
+	}
+
+	renewBefore := p.vault.RenewBefore
+	if half := p.leaseDuration / 2; renewBefore > half {
+		renewBefore = half
+	}
+	return !now.Before(p.expires.Add(-renewBefore))
+}
+
+// refresh renews the lease if possible, the secret is read again if the lease is not renewable,
+// the renewal fails or the lease cannot be extended any more, e.g. reaching the max ttl
+func (p *vaultProvider) refresh(ctx context.Context, now time.Time) error {
+	if p.leaseID != "" && p.renewable {
+		secret, err := p.renew(ctx)
+		if err == nil {
+			duration := time.Duration(secret.LeaseDuration) * time.Second
+			p.renewable = secret.Renewable
+			p.expires = now.Add(duration)
+			if duration > p.vault.RenewBefore {
+				p.leaseDuration = duration
+				return nil
+			}
+		} else {
+			logger.Warnf("failed to renew the lease of vault %s, read the secret again: %s", p.key, err)
+		}
+	}
+
+	secret, err := p.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, _ := secret.Data[p.vault.UserField].(string)
+	password, _ := secret.Data[p.vault.PasswordField].(string)
+	if user == "" {
+		return fmt.Errorf("field %s not found in the secret", p.vault.UserField)
+	}
+
+	p.user, p.password = user, password
+	p.leaseID, p.renewable = secret.LeaseID, secret.Renewable
+	p.leaseDuration = time.Duration(secret.LeaseDuration) * time.Second
+	p.fetched = now
+	p.expires = time.Time{}
+	if p.leaseID != "" && p.leaseDuration > 0 {
+		p.expires = now.Add(p.leaseDuration)
+	}
+	return nil
+}
+
+func (p *vaultProvider) read(ctx context.Context) (*vaultSecret, error) {
+	secret, err := p.do(ctx, http.MethodGet, "/v1/"+p.vault.Path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// kv version 2 nests the secret in data.data
+	if data, ok := secret.Data["data"].(map[string]any); ok {
+		if _, ok := secret.Data["metadata"]; ok {
+			secret.Data = data
+		}
+	}
+	return secret, nil
+}
+
+func (p *vaultProvider) renew(ctx context.Context) (*vaultSecret, error) {
+	body, err := json.Marshal(map[string]any{
+		"lease_id":  p.leaseID,
+		"increment": int64(p.leaseDuration / time.Second),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p.do(ctx, http.MethodPut, "/v1/sys/leases/renew", body)
+}
+
+// httpClient returns the client of the provider, built again only when the address, the timeout or the tls options
+// change, the idle connections of the replaced client are closed. Called with p.mu held.
+func (p *vaultProvider) httpClient() (*http.Client, error) {
+	key := fmt.Sprintf("%s|%s|%+v", p.vault.Address, p.vault.Timeout, p.vault.ClientConfig)
+	if p.client != nil && p.clientKey == key {
+		return p.client, nil
+	}
+
+	client, err := p.vault.client()
+	if err != nil {
+		return nil, err
+	}
+	if p.client != nil {
+		p.client.CloseIdleConnections()
+	}
+	p.client, p.clientKey = client, key
+	return client, nil
+}
+
+func (p *vaultProvider) do(ctx context.Context, method, path string, body []byte) (*vaultSecret, error) {
+	token, err := p.vault.token()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := p.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.vault.Address+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if p.vault.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.vault.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// the body of an error only has the messages, it is safe to be logged
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(bs, &errResp)
+		return nil, fmt.Errorf("%s %s: status code %d: %s", method, path, resp.StatusCode, strings.Join(errResp.Errors, "; "))
+	}
+
+	var secret vaultSecret
+	if err := json.Unmarshal(bs, &secret); err != nil {
+		return nil, fmt.Errorf("failed to decode the response of %s: %s", path, err)
+	}
+	return &secret, nil
+}
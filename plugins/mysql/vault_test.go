@@ -0,0 +1,126 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestVault(t *testing.T, address, path string) *Vault {
+	v := &Vault{Address: address, Token: "s.test", Path: path}
+	if err := v.validate(""); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return v
+}
+
+func TestVaultKV(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.test" || r.URL.Path != "/v1/secret/data/mysql" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"username":"monitor","password":"pa55"},"metadata":{"version":3}}}`))
+	}))
+	defer srv.Close()
+
+	p := &vaultProvider{key: "kv", vault: newTestVault(t, srv.URL, "/secret/data/mysql/")}
+	user, password, err := p.Credentials(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if user != "monitor" || password != "pa55" {
+		t.Errorf("unexpected credentials: %s", user)
+	}
+
+	p = &vaultProvider{key: "denied", vault: newTestVault(t, srv.URL, "secret/data/other")}
+	if _, _, err := p.Credentials(context.Background()); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("expected the error of vault, got %v", err)
+	}
+}
+
+func TestVaultLease(t *testing.T) {
+	var reads, renews atomic.Int32
+	var down atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/database/creds/cprobe":
+			n := reads.Add(1)
+			json.NewEncoder(w).Encode(map[string]any{
+				"lease_id":       "database/creds/cprobe/" + string(rune('a'+n)),
+				"renewable":      true,
+				"lease_duration": 3600,
+				"data":           map[string]any{"username": "v-cprobe-" + string(rune('a'+n)), "password": "secret"},
+			})
+		case "/v1/sys/leases/renew":
+			renews.Add(1)
+			w.Write([]byte(`{"lease_id":"database/creds/cprobe/b","renewable":true,"lease_duration":3600}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	p := &vaultProvider{key: "lease", vault: newTestVault(t, srv.URL, "database/creds/cprobe")}
+	user, _, err := p.Credentials(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if user != "v-cprobe-b" {
+		t.Errorf("unexpected user: %s", user)
+	}
+
+	// cached within the lease
+	p.Credentials(context.Background())
+	if reads.Load() != 1 || renews.Load() != 0 {
+		t.Errorf("expected the cached lease, got %d reads and %d renews", reads.Load(), renews.Load())
+	}
+
+	// renewed before it expires, by the client of the read
+	client := p.client
+	p.expires = time.Now().Add(time.Minute)
+	if user, _, _ = p.Credentials(context.Background()); user != "v-cprobe-b" || reads.Load() != 1 || renews.Load() != 1 {
+		t.Errorf("expected the lease renewed, got %s with %d reads and %d renews", user, reads.Load(), renews.Load())
+	}
+	if p.client != client {
+		t.Errorf("expected the client of the read reused by the renewal")
+	}
+
+	// the last lease is reused while vault is down
+	down.Store(true)
+	p.expires = time.Now().Add(time.Minute)
+	if user, _, err = p.Credentials(context.Background()); err != nil || user != "v-cprobe-b" {
+		t.Errorf("expected the last lease reused, got %s: %v", user, err)
+	}
+
+	// but not after it expires
+	p.expires = time.Now().Add(-time.Second)
+	if _, _, err = p.Credentials(context.Background()); err == nil {
+		t.Errorf("expected error for the expired lease")
+	}
+}
+
+func TestVaultDSN(t *testing.T) {
+	g := Global{User: "root", Password: "pass", Vault: &Vault{}}
+	dsn, err := g.FormDSN("127.0.0.1:3306")
+	if err != nil {
+		t.Fatalf("failed to form dsn: %s", err)
+	}
+	if strings.Contains(dsn, "root") || strings.Contains(dsn, "pass") {
+		t.Errorf("unexpected credentials in dsn: %s", dsn)
+	}
+
+	if err := (&Vault{Address: "http://127.0.0.1:8200"}).validate(""); err == nil {
+		t.Errorf("expected error for blank path")
+	}
+}
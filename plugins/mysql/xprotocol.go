@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cprobe/cprobe/plugins/blackbox/prober"
+	"github.com/cprobe/cprobe/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeXProtocol 通过 blackbox 的 tcp prober 检测 X Protocol 端口是否可用，并不会真正走 X Protocol 抓取数据
+func (c *Config) scrapeXProtocol(ctx context.Context, hosts []string, ss *types.Samples) {
+	module := prober.Module{
+		Prober:  "tcp",
+		Timeout: c.CollectXProtocol.Timeout,
+		TCP: prober.TCPProbe{
+			IPProtocol:         "ip4",
+			IPProtocolFallback: true,
+			TLS:                c.CollectXProtocol.TLS,
+		},
+	}
+	module.TCP.TLSConfig.InsecureSkipVerify = c.CollectXProtocol.TLSInsecureSkipVerify
+
+	for _, host := range hosts {
+		// unix socket 和 named pipe 没有 host，无法检测
+		if strings.HasPrefix(host, "unix://") || strings.HasPrefix(host, "pipe://") {
+			continue
+		}
+		h, _, err := net.SplitHostPort(host)
+		if err != nil {
+			continue
+		}
+		target := net.JoinHostPort(h, strconv.Itoa(c.CollectXProtocol.Port))
+
+		probeCtx, cancel := context.WithTimeout(ctx, module.Timeout)
+		up := 0.0
+		if prober.ProbeTCP(probeCtx, target, module, prometheus.NewRegistry()) {
+			up = 1
+		}
+		cancel()
+
+		var tags map[string]string
+		if len(hosts) > 1 {
+			tags = map[string]string{"host": h}
+		}
+		ss.AddMetric("mysql_xprotocol", map[string]interface{}{"up": up}, tags)
+	}
+}
+
+func (c *Config) xprotocolDefaults() {
+	if c.CollectXProtocol.Port == 0 {
+		c.CollectXProtocol.Port = 33060
+	}
+	if c.CollectXProtocol.Timeout == 0 {
+		c.CollectXProtocol.Timeout = 3 * time.Second
+	}
+}
+
+func splitHosts(address string) []string {
+	hosts := strings.Split(address, ",")
+	for i := range hosts {
+		hosts[i] = strings.TrimSpace(hosts[i])
+	}
+	return hosts
+}
@@ -0,0 +1,58 @@
+package mysql
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cprobe/cprobe/types"
+)
+
+func TestScrapeXProtocol(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+
+	var c Config
+	c.CollectXProtocol.Timeout = time.Second
+	c.xprotocolDefaults()
+	if c.CollectXProtocol.Port != 33060 {
+		t.Fatalf("unexpected default port: %d", c.CollectXProtocol.Port)
+	}
+
+	if c.CollectXProtocol.Port, err = strconv.Atoi(port); err != nil {
+		t.Fatalf("failed to parse port: %s", err)
+	}
+	ss := types.NewSamples()
+	c.scrapeXProtocol(context.Background(), []string{"127.0.0.1:3306", "unix:///tmp/mysql.sock"}, ss)
+
+	ms := ss.PopBackAll()
+	if len(ms) != 1 {
+		t.Fatalf("expected 1 metric, got %d", len(ms))
+	}
+	if ms[0].Name() != "mysql_xprotocol" || ms[0].Fields()["up"] != 1.0 || len(ms[0].Tags()) != 1 {
+		t.Errorf("unexpected metric: %s %v %v", ms[0].Name(), ms[0].Fields(), ms[0].Tags())
+	}
+
+	l.Close()
+	c.scrapeXProtocol(context.Background(), []string{"127.0.0.1:3306"}, ss)
+	ms = ss.PopBackAll()
+	if len(ms) != 1 || ms[0].Fields()["up"] != 0.0 || len(ms[0].Tags()) != 0 {
+		t.Errorf("expected mysql_xprotocol_up 0 without labels, got %v", ms)
+	}
+}
@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Exporter namespace.
+	namespace = "pg"
+	// Math constant for milliseconds to seconds.
+	milliSeconds = 1e3
+)
+
+func newDesc(subsystem, name, help string, labels []string) *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, name),
+		help, labels, nil,
+	)
+}
+
+// nullString returns the string or "" if NULL.
+func nullString(s sql.NullString) string {
+	if s.Valid {
+		return s.String
+	}
+	return ""
+}
+
+// timeToUnix returns the unix timestamp in seconds or 0 if NULL.
+func timeToUnix(t sql.NullTime) float64 {
+	if !t.Valid {
+		return 0
+	}
+	return float64(t.Time.UnixNano()) / float64(time.Second)
+}
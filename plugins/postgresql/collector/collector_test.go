@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+type labelMap map[string]string
+
+type MetricResult struct {
+	labels     labelMap
+	value      float64
+	metricType dto.MetricType
+}
+
+func readMetric(m prometheus.Metric) MetricResult {
+	pb := &dto.Metric{}
+	m.Write(pb)
+	labels := make(labelMap, len(pb.Label))
+	for _, v := range pb.Label {
+		labels[v.GetName()] = v.GetValue()
+	}
+	if pb.Gauge != nil {
+		return MetricResult{labels: labels, value: pb.GetGauge().GetValue(), metricType: dto.MetricType_GAUGE}
+	}
+	if pb.Counter != nil {
+		return MetricResult{labels: labels, value: pb.GetCounter().GetValue(), metricType: dto.MetricType_COUNTER}
+	}
+	if pb.Untyped != nil {
+		return MetricResult{labels: labels, value: pb.GetUntyped().GetValue(), metricType: dto.MetricType_UNTYPED}
+	}
+	panic("Unsupported metric type")
+}
+
+func sanitizeQuery(q string) string {
+	q = strings.Join(strings.Fields(q), " ")
+	q = strings.Replace(q, "(", "\\(", -1)
+	q = strings.Replace(q, ")", "\\)", -1)
+	q = strings.Replace(q, "*", "\\*", -1)
+	return q
+}
@@ -0,0 +1,169 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/cprobe/cprobe/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metric name parts.
+const (
+	// Subsystem(s).
+	exporter = "exporter"
+)
+
+// SQL queries and parameters.
+const (
+	versionQuery = `SHOW server_version`
+)
+
+var (
+	versionRE = regexp.MustCompile(`^\d+(\.\d+)?`)
+)
+
+// metric definition
+var (
+	pgScrapeCollectorSuccess = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, exporter, "collector_success"),
+		"Whether a collector succeeded.",
+		[]string{"collector"},
+		nil,
+	)
+	pgScrapeDurationSeconds = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, exporter, "collector_duration_seconds"),
+		"Collector time duration.",
+		[]string{"collector"}, nil,
+	)
+	pgVersionDetected = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "version_detected"),
+		"Whether the PostgreSQL version was detected, if not all the collectors are run.",
+		nil, nil,
+	)
+)
+
+// Exporter collects PostgreSQL metrics.
+type Exporter struct {
+	ctx      context.Context
+	dsn      string
+	scrapers []Scraper
+	ss       *types.Samples
+	queries  []CustomQuery
+}
+
+// New returns a new PostgreSQL exporter for the provided DSN.
+func New(ctx context.Context, dsn string, scrapers []Scraper, ss *types.Samples, queries []CustomQuery) *Exporter {
+	return &Exporter{
+		ctx:      ctx,
+		dsn:      dsn,
+		scrapers: scrapers,
+		ss:       ss,
+		queries:  queries,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pgScrapeDurationSeconds
+	ch <- pgScrapeCollectorSuccess
+	ch <- pgVersionDetected
+}
+
+// Collect implements prometheus.Collector.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) error {
+	return e.scrape(e.ctx, ch)
+}
+
+// scrape collects metrics from the target.
+func (e *Exporter) scrape(ctx context.Context, ch chan<- prometheus.Metric) error {
+	scrapeTime := time.Now()
+	db, err := sql.Open("postgres", e.dsn)
+	if err != nil {
+		return fmt.Errorf("cannot opening connection to database: %s, error: %s", e.getTargetFromDsn(), err)
+	}
+
+	defer db.Close()
+
+	// By design exporter should use maximum one connection per request.
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	// Set max lifetime for a connection.
+	db.SetConnMaxLifetime(1 * time.Minute)
+
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("cannot ping postgresql %s, error: %s", e.getTargetFromDsn(), err)
+	}
+
+	ch <- prometheus.MustNewConstMetric(pgScrapeDurationSeconds, prometheus.GaugeValue, time.Since(scrapeTime).Seconds(), "connection")
+
+	version, err := getPostgreSQLVersion(ctx, db)
+	versionDetected := 1.0
+	if err != nil {
+		logger.Warnf("cannot detect postgresql version, all the collectors will be run, target: %s, error: %s", e.getTargetFromDsn(), err)
+		versionDetected = 0.0
+	}
+	ch <- prometheus.MustNewConstMetric(pgVersionDetected, prometheus.GaugeValue, versionDetected)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for _, scraper := range e.scrapers {
+		if version < scraper.Version() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(scraper Scraper) {
+			defer wg.Done()
+			label := "collect." + scraper.Name()
+			scrapeTime := time.Now()
+			collectorSuccess := 1.0
+			if err := scraper.Scrape(ctx, db, ch); err != nil {
+				logger.Errorf("cannot scrape: %s, target: %s, error: %s", scraper.Name(), e.getTargetFromDsn(), err)
+				collectorSuccess = 0.0
+			}
+			ch <- prometheus.MustNewConstMetric(pgScrapeCollectorSuccess, prometheus.GaugeValue, collectorSuccess, label)
+			ch <- prometheus.MustNewConstMetric(pgScrapeDurationSeconds, prometheus.GaugeValue, time.Since(scrapeTime).Seconds(), label)
+		}(scraper)
+	}
+
+	// 添加自定义采集的逻辑
+	e.collectCustomQueries(ctx, db, e.ss, e.queries)
+
+	return nil
+}
+
+// getTargetFromDsn returns the host:port or the unix socket directory, without the password.
+func (e *Exporter) getTargetFromDsn() string {
+	u, err := url.Parse(e.dsn)
+	if err != nil {
+		logger.Errorf("Error parsing DSN: %s", err)
+		return ""
+	}
+	if u.Host == "" {
+		return u.Query().Get("host")
+	}
+	return u.Host
+}
+
+// getPostgreSQLVersion returns the version like 9.6 or 16.2, if we can't match/parse the version,
+// it returns some big value that matches all versions with the error.
+func getPostgreSQLVersion(ctx context.Context, db *sql.DB) (float64, error) {
+	var versionStr string
+	if err := db.QueryRowContext(ctx, versionQuery).Scan(&versionStr); err != nil {
+		return 999, err
+	}
+
+	versionNum, err := strconv.ParseFloat(versionRE.FindString(versionStr), 64)
+	if err != nil || versionNum == 0 {
+		return 999, fmt.Errorf("cannot parse version string: %q", versionStr)
+	}
+	return versionNum, nil
+}
@@ -0,0 +1,75 @@
+// Scrape `pg_locks`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	locks = "locks"
+	// Query, every lock mode of every database is reported, 0 if there is no such lock.
+	locksQuery = `
+		SELECT pg_database.datname, tmp.mode, COALESCE(count, 0) AS count
+		FROM (
+		  VALUES ('accesssharelock'), ('rowsharelock'), ('rowexclusivelock'), ('shareupdateexclusivelock'),
+		    ('sharelock'), ('sharerowexclusivelock'), ('exclusivelock'), ('accessexclusivelock'), ('sireadlock')
+		) AS tmp(mode)
+		CROSS JOIN pg_database
+		LEFT JOIN (
+		  SELECT database, lower(mode) AS mode, count(*) AS count
+		  FROM pg_locks WHERE database IS NOT NULL
+		  GROUP BY database, lower(mode)
+		) AS tmp2 ON tmp.mode = tmp2.mode AND pg_database.oid = tmp2.database
+		ORDER BY 1, 2
+		`
+)
+
+// Metric descriptors.
+var (
+	locksCountDesc = newDesc(locks, "count", "Number of locks by database and lock mode.", []string{"datname", "mode"})
+)
+
+// ScrapeLocks collects from `pg_locks`.
+type ScrapeLocks struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeLocks) Name() string {
+	return locks
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeLocks) Help() string {
+	return "Collect the number of locks from pg_locks"
+}
+
+// Version of PostgreSQL from which scraper is available.
+func (ScrapeLocks) Version() float64 {
+	return 9.1
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeLocks) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, locksQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var datname, mode string
+	var count float64
+	for rows.Next() {
+		if err := rows.Scan(&datname, &mode, &count); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(locksCountDesc, prometheus.GaugeValue, count, datname, mode)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeLocks{}
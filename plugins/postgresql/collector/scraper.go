@@ -0,0 +1,25 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Scraper is minimal interface that let's you add new prometheus metrics to the postgresql plugin.
+type Scraper interface {
+	// Name of the Scraper. Should be unique.
+	Name() string
+
+	// Help describes the role of the Scraper.
+	// Example: "Collect from pg_stat_database"
+	Help() string
+
+	// Version of PostgreSQL from which scraper is available.
+	Version() float64
+
+	// Scrape collects data from database connection and sends it over channel as prometheus metric.
+	Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error
+}
@@ -0,0 +1,125 @@
+// Scrape `pg_stat_activity`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	statActivity = "stat_activity"
+	// Queries.
+	statActivityMaxConnectionsQuery = `SELECT setting::float FROM pg_settings WHERE name = 'max_connections'`
+	// Without superuser or pg_read_all_stats, the state of the other users' connections is NULL.
+	statActivityVisibilityQuery = `SELECT rolsuper OR pg_has_role('pg_read_all_stats', 'USAGE') FROM pg_roles WHERE rolname = current_user`
+	statActivityQuery           = `SELECT %scount(*) FROM pg_stat_activity WHERE backend_type = 'client backend'%s`
+)
+
+// StatActivityDimensions are the columns pg_connections can be grouped by.
+var StatActivityDimensions = []string{"datname", "state", "usename", "application_name"}
+
+// DefaultStatActivityGroupBy is used if group_by is not configured.
+var DefaultStatActivityGroupBy = []string{"datname", "state", "usename"}
+
+// Metric descriptors.
+var (
+	statActivityMaxConnectionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "settings", "max_connections"),
+		"Maximum number of concurrent connections to the database server.",
+		nil, nil,
+	)
+	statActivityLimitedVisibilityDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "connections", "limited_visibility"),
+		"Whether the monitoring user can only see the state of its own connections (1 = limited), grant pg_monitor to fix it.",
+		nil, nil,
+	)
+)
+
+// ScrapeStatActivity collects the number of connections from `pg_stat_activity`.
+type ScrapeStatActivity struct {
+	// GroupBy is the subset of StatActivityDimensions pg_connections is grouped by
+	GroupBy []string
+}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeStatActivity) Name() string {
+	return statActivity
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeStatActivity) Help() string {
+	return "Collect the number of connections from pg_stat_activity"
+}
+
+// Version of PostgreSQL from which scraper is available.
+func (ScrapeStatActivity) Version() float64 {
+	return 10
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (s ScrapeStatActivity) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var maxConnections float64
+	if err := db.QueryRowContext(ctx, statActivityMaxConnectionsQuery).Scan(&maxConnections); err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(statActivityMaxConnectionsDesc, prometheus.GaugeValue, maxConnections)
+
+	var fullVisibility bool
+	if err := db.QueryRowContext(ctx, statActivityVisibilityQuery).Scan(&fullVisibility); err != nil {
+		return err
+	}
+	limitedVisibility := 0.0
+	if !fullVisibility {
+		limitedVisibility = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(statActivityLimitedVisibilityDesc, prometheus.GaugeValue, limitedVisibility)
+
+	desc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "connections"),
+		"Number of client connections by the configured dimensions.",
+		s.GroupBy, nil,
+	)
+
+	rows, err := db.QueryContext(ctx, statActivityGroupQuery(s.GroupBy))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	labels := make([]string, len(s.GroupBy))
+	var count float64
+	dest := make([]interface{}, 0, len(labels)+1)
+	for i := range labels {
+		dest = append(dest, &labels[i])
+	}
+	dest = append(dest, &count)
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, count, labels...)
+	}
+	return rows.Err()
+}
+
+func statActivityGroupQuery(groupBy []string) string {
+	if len(groupBy) == 0 {
+		return fmt.Sprintf(statActivityQuery, "", "")
+	}
+
+	columns := make([]string, len(groupBy))
+	for i, dimension := range groupBy {
+		columns[i] = fmt.Sprintf("COALESCE(%s::text, '')", dimension)
+	}
+	return fmt.Sprintf(statActivityQuery, strings.Join(columns, ", ")+", ", " GROUP BY "+strings.Join(columns, ", "))
+}
+
+// check interface
+var _ Scraper = ScrapeStatActivity{}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeStatActivity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	groupBy := []string{"datname", "state"}
+	mock.ExpectQuery(sanitizeQuery(statActivityMaxConnectionsQuery)).WillReturnRows(sqlmock.NewRows([]string{"setting"}).AddRow(100))
+	mock.ExpectQuery(sanitizeQuery(statActivityVisibilityQuery)).WillReturnRows(sqlmock.NewRows([]string{"visible"}).AddRow(false))
+
+	rows := sqlmock.NewRows([]string{"datname", "state", "count"}).
+		AddRow("app", "active", 3).
+		AddRow("app", "idle in transaction", 2).
+		AddRow("app", "", 5)
+	mock.ExpectQuery(sanitizeQuery(statActivityGroupQuery(groupBy))).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeStatActivity{GroupBy: groupBy}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	expected := []MetricResult{
+		{labels: labelMap{}, value: 100, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{}, value: 1, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"datname": "app", "state": "active"}, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"datname": "app", "state": "idle in transaction"}, value: 2, metricType: dto.MetricType_GAUGE},
+		{labels: labelMap{"datname": "app", "state": ""}, value: 5, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
+
+func TestStatActivityGroupQuery(t *testing.T) {
+	convey.Convey("Group query", t, func() {
+		convey.So(statActivityGroupQuery(nil), convey.ShouldEqual,
+			`SELECT count(*) FROM pg_stat_activity WHERE backend_type = 'client backend'`)
+		convey.So(statActivityGroupQuery([]string{"datname", "usename"}), convey.ShouldEqual,
+			`SELECT COALESCE(datname::text, ''), COALESCE(usename::text, ''), count(*) FROM pg_stat_activity WHERE backend_type = 'client backend' GROUP BY COALESCE(datname::text, ''), COALESCE(usename::text, '')`)
+	})
+}
@@ -0,0 +1,104 @@
+// Scrape `pg_stat_bgwriter` and `pg_stat_checkpointer`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	statBgwriter = "stat_bgwriter"
+	// Queries.
+	statBgwriterVersionQuery = `SHOW server_version_num`
+	statBgwriterQuery        = `
+		SELECT checkpoints_timed, checkpoints_req, checkpoint_write_time, checkpoint_sync_time,
+		  buffers_checkpoint, buffers_clean, maxwritten_clean, buffers_backend, buffers_backend_fsync,
+		  buffers_alloc, stats_reset
+		FROM pg_stat_bgwriter
+		`
+	// PostgreSQL 17 moved the checkpoint and backend columns out of pg_stat_bgwriter.
+	statBgwriter17Query = `
+		SELECT c.num_timed, c.num_requested, c.write_time, c.sync_time,
+		  c.buffers_written, b.buffers_clean, b.maxwritten_clean, NULL, NULL,
+		  b.buffers_alloc, b.stats_reset
+		FROM pg_stat_bgwriter b, pg_stat_checkpointer c
+		`
+)
+
+// Metric descriptors, in the order of the query columns.
+var statBgwriterMetrics = []struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	divisor   float64
+}{
+	{newDesc(statBgwriter, "checkpoints_timed_total", "Number of scheduled checkpoints that have been performed.", nil), prometheus.CounterValue, 1},
+	{newDesc(statBgwriter, "checkpoints_req_total", "Number of requested checkpoints that have been performed.", nil), prometheus.CounterValue, 1},
+	{newDesc(statBgwriter, "checkpoint_write_time_seconds_total", "Time spent writing files to disk during checkpoints.", nil), prometheus.CounterValue, milliSeconds},
+	{newDesc(statBgwriter, "checkpoint_sync_time_seconds_total", "Time spent synchronizing files to disk during checkpoints.", nil), prometheus.CounterValue, milliSeconds},
+	{newDesc(statBgwriter, "buffers_checkpoint_total", "Number of buffers written during checkpoints.", nil), prometheus.CounterValue, 1},
+	{newDesc(statBgwriter, "buffers_clean_total", "Number of buffers written by the background writer.", nil), prometheus.CounterValue, 1},
+	{newDesc(statBgwriter, "maxwritten_clean_total", "Number of times the background writer stopped a cleaning scan because it had written too many buffers.", nil), prometheus.CounterValue, 1},
+	{newDesc(statBgwriter, "buffers_backend_total", "Number of buffers written directly by a backend, not available since PostgreSQL 17.", nil), prometheus.CounterValue, 1},
+	{newDesc(statBgwriter, "buffers_backend_fsync_total", "Number of times a backend had to execute its own fsync call, not available since PostgreSQL 17.", nil), prometheus.CounterValue, 1},
+	{newDesc(statBgwriter, "buffers_alloc_total", "Number of buffers allocated.", nil), prometheus.CounterValue, 1},
+}
+
+var statBgwriterStatsResetDesc = newDesc(statBgwriter, "stats_reset", "Time at which these statistics were last reset.", nil)
+
+// ScrapeStatBgwriter collects from `pg_stat_bgwriter`.
+type ScrapeStatBgwriter struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeStatBgwriter) Name() string {
+	return statBgwriter
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeStatBgwriter) Help() string {
+	return "Collect from pg_stat_bgwriter"
+}
+
+// Version of PostgreSQL from which scraper is available.
+func (ScrapeStatBgwriter) Version() float64 {
+	return 9.2
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeStatBgwriter) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	var versionNum int
+	if err := db.QueryRowContext(ctx, statBgwriterVersionQuery).Scan(&versionNum); err != nil {
+		return err
+	}
+
+	query := statBgwriterQuery
+	if versionNum >= 170000 {
+		query = statBgwriter17Query
+	}
+
+	var statsReset sql.NullTime
+	values := make([]sql.NullFloat64, len(statBgwriterMetrics))
+	dest := make([]interface{}, 0, len(values)+1)
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+	dest = append(dest, &statsReset)
+
+	if err := db.QueryRowContext(ctx, query).Scan(dest...); err != nil {
+		return err
+	}
+
+	for i, m := range statBgwriterMetrics {
+		if values[i].Valid {
+			ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, values[i].Float64/m.divisor)
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(statBgwriterStatsResetDesc, prometheus.GaugeValue, timeToUnix(statsReset))
+	return nil
+}
+
+// check interface
+var _ Scraper = ScrapeStatBgwriter{}
@@ -0,0 +1,107 @@
+// Scrape `pg_stat_database`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	statDatabase = "stat_database"
+	// Query.
+	statDatabaseQuery = `
+		SELECT datid, datname, numbackends, xact_commit, xact_rollback,
+		  blks_read, blks_hit, tup_returned, tup_fetched, tup_inserted, tup_updated, tup_deleted,
+		  conflicts, temp_files, temp_bytes, deadlocks, blk_read_time, blk_write_time, stats_reset
+		FROM pg_stat_database
+		`
+)
+
+var statDatabaseLabels = []string{"datid", "datname"}
+
+// Metric descriptors, in the order of the query columns.
+var statDatabaseMetrics = []struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	divisor   float64
+}{
+	{newDesc(statDatabase, "numbackends", "Number of backends currently connected to this database.", statDatabaseLabels), prometheus.GaugeValue, 1},
+	{newDesc(statDatabase, "xact_commit", "Number of transactions in this database that have been committed.", statDatabaseLabels), prometheus.CounterValue, 1},
+	{newDesc(statDatabase, "xact_rollback", "Number of transactions in this database that have been rolled back.", statDatabaseLabels), prometheus.CounterValue, 1},
+	{newDesc(statDatabase, "blks_read", "Number of disk blocks read in this database.", statDatabaseLabels), prometheus.CounterValue, 1},
+	{newDesc(statDatabase, "blks_hit", "Number of times disk blocks were found already in the buffer cache.", statDatabaseLabels), prometheus.CounterValue, 1},
+	{newDesc(statDatabase, "tup_returned", "Number of rows returned by queries in this database.", statDatabaseLabels), prometheus.CounterValue, 1},
+	{newDesc(statDatabase, "tup_fetched", "Number of rows fetched by queries in this database.", statDatabaseLabels), prometheus.CounterValue, 1},
+	{newDesc(statDatabase, "tup_inserted", "Number of rows inserted by queries in this database.", statDatabaseLabels), prometheus.CounterValue, 1},
+	{newDesc(statDatabase, "tup_updated", "Number of rows updated by queries in this database.", statDatabaseLabels), prometheus.CounterValue, 1},
+	{newDesc(statDatabase, "tup_deleted", "Number of rows deleted by queries in this database.", statDatabaseLabels), prometheus.CounterValue, 1},
+	{newDesc(statDatabase, "conflicts", "Number of queries canceled due to conflicts with recovery in this database.", statDatabaseLabels), prometheus.CounterValue, 1},
+	{newDesc(statDatabase, "temp_files", "Number of temporary files created by queries in this database.", statDatabaseLabels), prometheus.CounterValue, 1},
+	{newDesc(statDatabase, "temp_bytes", "Total amount of data written to temporary files by queries in this database.", statDatabaseLabels), prometheus.CounterValue, 1},
+	{newDesc(statDatabase, "deadlocks", "Number of deadlocks detected in this database.", statDatabaseLabels), prometheus.CounterValue, 1},
+	{newDesc(statDatabase, "blk_read_time_seconds", "Time spent reading data file blocks by backends in this database.", statDatabaseLabels), prometheus.CounterValue, milliSeconds},
+	{newDesc(statDatabase, "blk_write_time_seconds", "Time spent writing data file blocks by backends in this database.", statDatabaseLabels), prometheus.CounterValue, milliSeconds},
+}
+
+var statDatabaseStatsResetDesc = newDesc(statDatabase, "stats_reset", "Time at which these statistics were last reset.", statDatabaseLabels)
+
+// ScrapeStatDatabase collects from `pg_stat_database`.
+type ScrapeStatDatabase struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeStatDatabase) Name() string {
+	return statDatabase
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeStatDatabase) Help() string {
+	return "Collect from pg_stat_database"
+}
+
+// Version of PostgreSQL from which scraper is available.
+func (ScrapeStatDatabase) Version() float64 {
+	return 9.2
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeStatDatabase) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, statDatabaseQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var datid, datname sql.NullString
+	var statsReset sql.NullTime
+	values := make([]sql.NullFloat64, len(statDatabaseMetrics))
+	dest := []interface{}{&datid, &datname}
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+	dest = append(dest, &statsReset)
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		// the row of the shared objects since PostgreSQL 12
+		if !datname.Valid {
+			continue
+		}
+
+		for i, m := range statDatabaseMetrics {
+			if values[i].Valid {
+				ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, values[i].Float64/m.divisor, datid.String, datname.String)
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(statDatabaseStatsResetDesc, prometheus.GaugeValue, timeToUnix(statsReset), datid.String, datname.String)
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeStatDatabase{}
@@ -0,0 +1,69 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeStatDatabase(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"datid", "datname", "numbackends", "xact_commit", "xact_rollback",
+		"blks_read", "blks_hit", "tup_returned", "tup_fetched", "tup_inserted", "tup_updated", "tup_deleted",
+		"conflicts", "temp_files", "temp_bytes", "deadlocks", "blk_read_time", "blk_write_time", "stats_reset"}
+	statsReset := time.Unix(1700000000, 0)
+	rows := sqlmock.NewRows(columns).
+		AddRow("0", nil, 0, 0, 0, 10, 20, 0, 0, 0, 0, 0, nil, nil, nil, nil, 0, 0, nil).
+		AddRow("16384", "app", 3, 100, 2, 10, 990, 500, 400, 30, 20, 10, 0, 1, 8192, 1, 1500, 500, statsReset)
+	mock.ExpectQuery(sanitizeQuery(statDatabaseQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeStatDatabase{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	labels := labelMap{"datid": "16384", "datname": "app"}
+	expected := []MetricResult{
+		{labels: labels, value: 3, metricType: dto.MetricType_GAUGE},
+		{labels: labels, value: 100, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 2, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 10, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 990, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 500, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 400, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 30, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 20, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 10, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 0, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 1, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 8192, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 1, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 1.5, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 0.5, metricType: dto.MetricType_COUNTER},
+		{labels: labels, value: 1700000000, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
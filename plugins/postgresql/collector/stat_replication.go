@@ -0,0 +1,85 @@
+// Scrape `pg_stat_replication`.
+
+package collector
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Subsystem.
+	statReplication = "stat_replication"
+	// Query, pg_current_wal_lsn() can not be executed during recovery.
+	statReplicationQuery = `
+		SELECT application_name, client_addr, state, sync_state,
+		  CASE WHEN pg_is_in_recovery() THEN NULL ELSE pg_wal_lsn_diff(pg_current_wal_lsn(), replay_lsn) END AS replay_lag_bytes,
+		  EXTRACT(EPOCH FROM write_lag) AS write_lag,
+		  EXTRACT(EPOCH FROM flush_lag) AS flush_lag,
+		  EXTRACT(EPOCH FROM replay_lag) AS replay_lag
+		FROM pg_stat_replication
+		`
+)
+
+var statReplicationLabels = []string{"application_name", "client_addr", "state", "sync_state"}
+
+// Metric descriptors, in the order of the query columns.
+var statReplicationMetrics = []*prometheus.Desc{
+	newDesc(statReplication, "replay_lag_bytes", "Lag in bytes between the current WAL position of the primary and the replayed position of the standby.", statReplicationLabels),
+	newDesc(statReplication, "write_lag_seconds", "Time elapsed between flushing recent WAL locally and receiving notification that the standby has written it.", statReplicationLabels),
+	newDesc(statReplication, "flush_lag_seconds", "Time elapsed between flushing recent WAL locally and receiving notification that the standby has written and flushed it.", statReplicationLabels),
+	newDesc(statReplication, "replay_lag_seconds", "Time elapsed between flushing recent WAL locally and receiving notification that the standby has written, flushed and applied it.", statReplicationLabels),
+}
+
+// ScrapeStatReplication collects from `pg_stat_replication`.
+type ScrapeStatReplication struct{}
+
+// Name of the Scraper. Should be unique.
+func (ScrapeStatReplication) Name() string {
+	return statReplication
+}
+
+// Help describes the role of the Scraper.
+func (ScrapeStatReplication) Help() string {
+	return "Collect from pg_stat_replication"
+}
+
+// Version of PostgreSQL from which scraper is available.
+func (ScrapeStatReplication) Version() float64 {
+	return 10
+}
+
+// Scrape collects data from database connection and sends it over channel as prometheus metric.
+func (ScrapeStatReplication) Scrape(ctx context.Context, db *sql.DB, ch chan<- prometheus.Metric) error {
+	rows, err := db.QueryContext(ctx, statReplicationQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var applicationName, clientAddr, state, syncState sql.NullString
+	values := make([]sql.NullFloat64, len(statReplicationMetrics))
+	dest := []interface{}{&applicationName, &clientAddr, &state, &syncState}
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+
+		labels := []string{nullString(applicationName), nullString(clientAddr), nullString(state), nullString(syncState)}
+		for i, desc := range statReplicationMetrics {
+			if values[i].Valid {
+				ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, values[i].Float64, labels...)
+			}
+		}
+	}
+	return rows.Err()
+}
+
+// check interface
+var _ Scraper = ScrapeStatReplication{}
@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestScrapeStatReplication(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening a stub database connection: %s", err)
+	}
+	defer db.Close()
+
+	columns := []string{"application_name", "client_addr", "state", "sync_state", "replay_lag_bytes", "write_lag", "flush_lag", "replay_lag"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("standby1", "10.0.0.2", "streaming", "async", 4096, 0.001, 0.002, 0.003).
+		AddRow("standby2", nil, "catchup", "async", 0, nil, nil, nil)
+	mock.ExpectQuery(sanitizeQuery(statReplicationQuery)).WillReturnRows(rows)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		if err = (ScrapeStatReplication{}).Scrape(context.Background(), db, ch); err != nil {
+			t.Errorf("error calling function on test: %s", err)
+		}
+		close(ch)
+	}()
+
+	standby1 := labelMap{"application_name": "standby1", "client_addr": "10.0.0.2", "state": "streaming", "sync_state": "async"}
+	standby2 := labelMap{"application_name": "standby2", "client_addr": "", "state": "catchup", "sync_state": "async"}
+	expected := []MetricResult{
+		{labels: standby1, value: 4096, metricType: dto.MetricType_GAUGE},
+		{labels: standby1, value: 0.001, metricType: dto.MetricType_GAUGE},
+		{labels: standby1, value: 0.002, metricType: dto.MetricType_GAUGE},
+		{labels: standby1, value: 0.003, metricType: dto.MetricType_GAUGE},
+		{labels: standby2, value: 0, metricType: dto.MetricType_GAUGE},
+	}
+	convey.Convey("Metrics comparison", t, func() {
+		for _, expect := range expected {
+			got := readMetric(<-ch)
+			convey.So(got, convey.ShouldResemble, expect)
+		}
+	})
+
+	// Ensure all SQL queries were executed
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled exceptions: %s", err)
+	}
+}
@@ -0,0 +1,197 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/cprobe/cprobe/plugins"
+	"github.com/cprobe/cprobe/plugins/postgresql/collector"
+	"github.com/cprobe/cprobe/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Global struct {
+	User        string `toml:"user"`
+	Password    string `toml:"password"`
+	Database    string `toml:"database"`
+	SslMode     string `toml:"sslmode"`
+	SslRootCert string `toml:"sslrootcert"`
+	SslCert     string `toml:"sslcert"`
+	SslKey      string `toml:"sslkey"`
+}
+
+// FormDSN returns a lib/pq url, target is host:port or unix:///var/run/postgresql:5432
+func (g Global) FormDSN(target string) (string, error) {
+	if target == "" {
+		logger.Fatalf("BUG: postgresql target is blank")
+	}
+
+	database := g.Database
+	if database == "" {
+		database = "postgres"
+	}
+
+	sslMode := g.SslMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	u := &url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(g.User, g.Password),
+		Path:   "/" + database,
+	}
+
+	params := url.Values{}
+	params.Set("sslmode", sslMode)
+	if g.SslRootCert != "" {
+		params.Set("sslrootcert", g.SslRootCert)
+	}
+	if g.SslCert != "" {
+		params.Set("sslcert", g.SslCert)
+	}
+	if g.SslKey != "" {
+		params.Set("sslkey", g.SslKey)
+	}
+
+	if prefix := "unix://"; strings.HasPrefix(target, prefix) {
+		// the socket directory, the port is part of the socket file name
+		dir := target[len(prefix):]
+		if idx := strings.LastIndex(dir, ":"); idx > 0 {
+			params.Set("port", dir[idx+1:])
+			dir = dir[:idx]
+		}
+		params.Set("host", dir)
+	} else {
+		if _, _, err := net.SplitHostPort(target); err != nil {
+			return "", fmt.Errorf("failed to parse target: %s", err)
+		}
+		u.Host = target
+	}
+
+	u.RawQuery = params.Encode()
+	return u.String(), nil
+}
+
+type Config struct {
+	BaseDir string                  `toml:"-"`
+	Global  *Global                 `toml:"global"`
+	Queries []collector.CustomQuery `toml:"queries"`
+
+	CollectStatDatabase struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_stat_database"`
+	CollectStatBgwriter struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_stat_bgwriter"`
+	CollectStatReplication struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_stat_replication"`
+	CollectLocks struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_locks"`
+	CollectStatActivity struct {
+		Enabled bool     `toml:"enabled"`
+		GroupBy []string `toml:"group_by"`
+	} `toml:"collect_stat_activity"`
+}
+
+func (c *Config) EnabledScrapers() (ret []collector.Scraper) {
+	if c.CollectStatDatabase.Enabled {
+		ret = append(ret, collector.ScrapeStatDatabase{})
+	}
+
+	if c.CollectStatBgwriter.Enabled {
+		ret = append(ret, collector.ScrapeStatBgwriter{})
+	}
+
+	if c.CollectStatReplication.Enabled {
+		ret = append(ret, collector.ScrapeStatReplication{})
+	}
+
+	if c.CollectLocks.Enabled {
+		ret = append(ret, collector.ScrapeLocks{})
+	}
+
+	if c.CollectStatActivity.Enabled {
+		ret = append(ret, collector.ScrapeStatActivity{
+			GroupBy: c.CollectStatActivity.GroupBy,
+		})
+	}
+
+	return
+}
+
+type PostgreSQL struct {
+	// 这个数据结构中未来如果有变量，千万要小心并发使用变量的问题
+}
+
+func init() {
+	plugins.RegisterPlugin(types.PluginPostgreSQL, &PostgreSQL{})
+}
+
+func (*PostgreSQL) ParseConfig(baseDir string, bs []byte) (any, error) {
+	var c Config
+	err := toml.Unmarshal(bs, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.BaseDir = baseDir
+
+	if c.Global == nil {
+		c.Global = &Global{}
+	}
+
+	if c.CollectStatActivity.GroupBy == nil {
+		c.CollectStatActivity.GroupBy = collector.DefaultStatActivityGroupBy
+	}
+	for _, dimension := range c.CollectStatActivity.GroupBy {
+		valid := false
+		for _, d := range collector.StatActivityDimensions {
+			if dimension == d {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid collect_stat_activity.group_by %q, must be one of %v", dimension, collector.StatActivityDimensions)
+		}
+	}
+
+	return &c, nil
+}
+
+// 和 mysql 插件一样，每个 target 抓取时实例化 collector.Scraper 对象，避免并发使用变量的问题
+func (*PostgreSQL) Scrape(ctx context.Context, address string, c any, ss *types.Samples) error {
+	// 这个方法中如果要对配置 c 变量做修改，一定要 clone 一份之后再修改，因为并发的多个 target 共享了一个 c 变量
+	cfg := c.(*Config)
+	dsn, err := cfg.Global.FormDSN(address)
+	if err != nil {
+		return fmt.Errorf("failed to form dsn for %s: %s", address, err)
+	}
+
+	scrapers := cfg.EnabledScrapers()
+	exporter := collector.New(ctx, dsn, scrapers, ss, cfg.Queries)
+
+	ch := make(chan prometheus.Metric)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- exporter.Collect(ch)
+		close(ch)
+		close(errCh)
+	}()
+
+	for m := range ch {
+		if err := ss.AddPromMetric(m); err != nil {
+			logger.Warnf("failed to transform prometheus metric: %s", err)
+		}
+	}
+
+	return <-errCh
+}
@@ -0,0 +1,93 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/cprobe/cprobe/lib/clienttls"
+)
+
+type Config struct {
+	BaseDir string `toml:"-"`
+	Global  Global `toml:"global"`
+
+	CollectNodes struct {
+		Enabled bool `toml:"enabled"`
+	} `toml:"collect_nodes"`
+
+	CollectQueues struct {
+		Enabled bool `toml:"enabled"`
+		// Vhosts to collect, empty means all
+		Vhosts []string `toml:"vhosts"`
+		// Include is passed to the management API as the name filter, Exclude is applied locally
+		Include  string `toml:"include"`
+		Exclude  string `toml:"exclude"`
+		PageSize int    `toml:"page_size"`
+
+		excludeRE *regexp.Regexp
+	} `toml:"collect_queues"`
+}
+
+type Global struct {
+	BasicAuthUser        string `toml:"basic_auth_user"`
+	BasicAuthPass        string `toml:"basic_auth_pass"`
+	ConnectTimeoutMillis int64  `toml:"connect_timeout_millis"`
+	RequestTimeoutMillis int64  `toml:"request_timeout_millis"`
+
+	clienttls.ClientConfig
+}
+
+func (cfg *Config) initDefault() error {
+	if cfg.Global.ConnectTimeoutMillis <= 0 {
+		cfg.Global.ConnectTimeoutMillis = 500
+	}
+
+	if cfg.Global.RequestTimeoutMillis <= 0 {
+		cfg.Global.RequestTimeoutMillis = 5000
+	}
+
+	if cfg.CollectQueues.PageSize <= 0 {
+		cfg.CollectQueues.PageSize = 500
+	}
+
+	if cfg.CollectQueues.Include != "" {
+		if _, err := regexp.Compile(cfg.CollectQueues.Include); err != nil {
+			return fmt.Errorf("invalid collect_queues.include: %s", err)
+		}
+	}
+
+	if cfg.CollectQueues.Exclude != "" {
+		re, err := regexp.Compile(cfg.CollectQueues.Exclude)
+		if err != nil {
+			return fmt.Errorf("invalid collect_queues.exclude: %s", err)
+		}
+		cfg.CollectQueues.excludeRE = re
+	}
+
+	return nil
+}
+
+func (cfg *Config) newClient() (*http.Client, error) {
+	dialer := &net.Dialer{
+		Timeout: time.Duration(cfg.Global.ConnectTimeoutMillis) * time.Millisecond,
+	}
+
+	tlsConfig, err := cfg.Global.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	trans := &http.Transport{
+		DialContext:       dialer.DialContext,
+		TLSClientConfig:   tlsConfig,
+		DisableKeepAlives: true,
+	}
+
+	return &http.Client{
+		Transport: trans,
+		Timeout:   time.Duration(cfg.Global.RequestTimeoutMillis) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,264 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cprobe/cprobe/plugins"
+	"github.com/cprobe/cprobe/types"
+	"github.com/pkg/errors"
+)
+
+// queueColumns limits the fields of /api/queues to reduce the payload
+const queueColumns = "name,vhost,state,messages,messages_ready,messages_unacknowledged,consumers,memory,message_stats"
+
+var nodeFields = []string{
+	"mem_used", "mem_limit", "disk_free", "disk_free_limit", "fd_used", "fd_total",
+	"sockets_used", "sockets_total", "proc_used", "proc_total", "uptime",
+}
+
+var nodeFlags = []string{"running", "mem_alarm", "disk_free_alarm"}
+
+var queueFields = []string{"messages", "messages_ready", "messages_unacknowledged", "consumers", "memory"}
+
+type RabbitMQ struct {
+}
+
+func init() {
+	plugins.RegisterPlugin(types.PluginRabbitMQ, &RabbitMQ{})
+}
+
+func (*RabbitMQ) ParseConfig(baseDir string, bs []byte) (any, error) {
+	var c Config
+	err := toml.Unmarshal(bs, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.BaseDir = baseDir
+	if err := c.initDefault(); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Scrape target is the base url of the management plugin, e.g. http://127.0.0.1:15672
+func (*RabbitMQ) Scrape(ctx context.Context, target string, c any, ss *types.Samples) error {
+	cfg := c.(*Config)
+
+	cli, err := cfg.newClient()
+	if err != nil {
+		return errors.WithMessagef(err, "new client failed, target: %s", target)
+	}
+
+	s := &scraper{cfg: cfg, cli: cli, base: strings.TrimSuffix(target, "/")}
+
+	if err := s.scrapeOverview(ctx, ss); err != nil {
+		return err
+	}
+
+	if cfg.CollectNodes.Enabled {
+		if err := s.scrapeNodes(ctx, ss); err != nil {
+			return err
+		}
+	}
+
+	if cfg.CollectQueues.Enabled {
+		if err := s.scrapeQueues(ctx, ss); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type scraper struct {
+	cfg  *Config
+	cli  *http.Client
+	base string
+}
+
+func (s *scraper) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	u := s.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return errors.WithMessagef(err, "new request failed, url: %s", u)
+	}
+
+	if s.cfg.Global.BasicAuthUser != "" {
+		req.SetBasicAuth(s.cfg.Global.BasicAuthUser, s.cfg.Global.BasicAuthPass)
+	}
+
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		return errors.WithMessagef(err, "request failed, url: %s", u)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status code %d, url: %s, body: %s", resp.StatusCode, u, body)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return errors.WithMessagef(err, "decode response failed, url: %s", u)
+	}
+	return nil
+}
+
+type overview struct {
+	RabbitMQVersion string                 `json:"rabbitmq_version"`
+	ErlangVersion   string                 `json:"erlang_version"`
+	ClusterName     string                 `json:"cluster_name"`
+	ObjectTotals    map[string]interface{} `json:"object_totals"`
+	QueueTotals     map[string]interface{} `json:"queue_totals"`
+	MessageStats    map[string]interface{} `json:"message_stats"`
+}
+
+func (s *scraper) scrapeOverview(ctx context.Context, ss *types.Samples) error {
+	var o overview
+	if err := s.get(ctx, "/api/overview", nil, &o); err != nil {
+		return err
+	}
+
+	ss.AddMetric(types.PluginRabbitMQ, map[string]interface{}{"info": 1.0}, map[string]string{
+		"rabbitmq_version": o.RabbitMQVersion,
+		"erlang_version":   o.ErlangVersion,
+		"cluster_name":     o.ClusterName,
+	})
+
+	ss.AddMetric(types.PluginRabbitMQ+"_overview", flattenStats("", o.ObjectTotals))
+	ss.AddMetric(types.PluginRabbitMQ+"_overview", flattenStats("", o.QueueTotals))
+	ss.AddMetric(types.PluginRabbitMQ+"_overview", flattenStats("message_stats_", o.MessageStats))
+	return nil
+}
+
+func (s *scraper) scrapeNodes(ctx context.Context, ss *types.Samples) error {
+	var nodes []map[string]interface{}
+	if err := s.get(ctx, "/api/nodes", nil, &nodes); err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		fields := make(map[string]interface{})
+		for _, name := range nodeFields {
+			if v, ok := node[name].(float64); ok {
+				fields[name] = v
+			}
+		}
+		for _, name := range nodeFlags {
+			if v, ok := node[name].(bool); ok {
+				fields[name] = boolValue(v)
+			}
+		}
+
+		nodeName, _ := node["name"].(string)
+		ss.AddMetric(types.PluginRabbitMQ+"_node", fields, map[string]string{"node": nodeName})
+	}
+	return nil
+}
+
+type queuesPage struct {
+	Items     []map[string]interface{} `json:"items"`
+	PageCount int                      `json:"page_count"`
+}
+
+func (s *scraper) scrapeQueues(ctx context.Context, ss *types.Samples) error {
+	paths := []string{"/api/queues"}
+	if len(s.cfg.CollectQueues.Vhosts) > 0 {
+		paths = paths[:0]
+		for _, vhost := range s.cfg.CollectQueues.Vhosts {
+			paths = append(paths, "/api/queues/"+url.PathEscape(vhost))
+		}
+	}
+
+	for _, path := range paths {
+		for page := 1; ; page++ {
+			query := url.Values{}
+			query.Set("page", strconv.Itoa(page))
+			query.Set("page_size", strconv.Itoa(s.cfg.CollectQueues.PageSize))
+			query.Set("columns", queueColumns)
+			if s.cfg.CollectQueues.Include != "" {
+				query.Set("name", s.cfg.CollectQueues.Include)
+				query.Set("use_regex", "true")
+			}
+
+			var p queuesPage
+			if err := s.get(ctx, path, query, &p); err != nil {
+				return err
+			}
+
+			for _, queue := range p.Items {
+				s.addQueue(queue, ss)
+			}
+
+			if page >= p.PageCount {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (s *scraper) addQueue(queue map[string]interface{}, ss *types.Samples) {
+	name, _ := queue["name"].(string)
+	if s.cfg.CollectQueues.excludeRE != nil && s.cfg.CollectQueues.excludeRE.MatchString(name) {
+		return
+	}
+	vhost, _ := queue["vhost"].(string)
+
+	fields := make(map[string]interface{})
+	for _, field := range queueFields {
+		if v, ok := queue[field].(float64); ok {
+			fields[field] = v
+		}
+	}
+	if stats, ok := queue["message_stats"].(map[string]interface{}); ok {
+		for k, v := range flattenStats("message_stats_", stats) {
+			fields[k] = v
+		}
+	}
+	if state, ok := queue["state"].(string); ok {
+		fields["running"] = boolValue(state == "running")
+	}
+
+	ss.AddMetric(types.PluginRabbitMQ+"_queue", fields, map[string]string{"vhost": vhost, "queue": name})
+}
+
+// flattenStats keeps the numeric stats, and the rate of `<name>_details` as `<name>_rate`
+func flattenStats(prefix string, stats map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for k, v := range stats {
+		switch v := v.(type) {
+		case float64:
+			fields[prefix+k] = v
+		case map[string]interface{}:
+			if !strings.HasSuffix(k, "_details") {
+				continue
+			}
+			if rate, ok := v["rate"].(float64); ok {
+				fields[prefix+strings.TrimSuffix(k, "_details")+"_rate"] = rate
+			}
+		}
+	}
+	return fields
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
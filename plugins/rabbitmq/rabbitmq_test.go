@@ -0,0 +1,104 @@
+package rabbitmq
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cprobe/cprobe/types"
+)
+
+func TestScrape(t *testing.T) {
+	var queuePages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "monitor" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.EscapedPath() {
+		case "/api/overview":
+			w.Write([]byte(`{"rabbitmq_version":"3.12.0","cluster_name":"rabbit@a","object_totals":{"queues":3,"consumers":2},
+"queue_totals":{"messages":7,"messages_details":{"rate":0.5}},"message_stats":{"publish":100,"publish_details":{"rate":2.5}}}`))
+		case "/api/nodes":
+			w.Write([]byte(`[{"name":"rabbit@a","running":true,"mem_used":1024,"mem_limit":4096,"mem_alarm":false,"disk_free_alarm":true}]`))
+		case "/api/queues/%2F":
+			if r.URL.Query().Get("name") != "^orders" || r.URL.Query().Get("use_regex") != "true" {
+				t.Errorf("unexpected query: %s", r.URL.RawQuery)
+			}
+			page := r.URL.Query().Get("page")
+			queuePages = append(queuePages, page)
+			if page == "1" {
+				w.Write([]byte(`{"page_count":2,"items":[{"name":"orders","vhost":"/","state":"running","messages":5,"consumers":1,"message_stats":{"ack":9,"ack_details":{"rate":1}}}]}`))
+			} else {
+				w.Write([]byte(`{"page_count":2,"items":[{"name":"orders.tmp","vhost":"/","messages":2}]}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c, err := (&RabbitMQ{}).ParseConfig("", []byte(`
+[global]
+basic_auth_user = 'monitor'
+basic_auth_pass = 'secret'
+
+[collect_nodes]
+enabled = true
+
+[collect_queues]
+enabled = true
+vhosts = ['/']
+include = '^orders'
+exclude = '\.tmp$'
+page_size = 1
+`))
+	if err != nil {
+		t.Fatalf("failed to parse config: %s", err)
+	}
+
+	ss := types.NewSamples()
+	if err := (&RabbitMQ{}).Scrape(context.Background(), srv.URL+"/", c, ss); err != nil {
+		t.Fatalf("failed to scrape: %s", err)
+	}
+
+	got := make(map[string]float64)
+	for _, m := range ss.PopBackAll() {
+		for field, value := range m.Fields() {
+			name := m.Name() + "_" + field
+			if queue, ok := m.Tags()["queue"]; ok {
+				name += "{" + queue + "}"
+			}
+			got[name] = value.(float64)
+		}
+	}
+
+	want := map[string]float64{
+		"rabbitmq_info":                                 1,
+		"rabbitmq_overview_queues":                      3,
+		"rabbitmq_overview_messages":                    7,
+		"rabbitmq_overview_messages_rate":               0.5,
+		"rabbitmq_overview_message_stats_publish":       100,
+		"rabbitmq_overview_message_stats_publish_rate":  2.5,
+		"rabbitmq_node_mem_used":                        1024,
+		"rabbitmq_node_running":                         1,
+		"rabbitmq_node_disk_free_alarm":                 1,
+		"rabbitmq_queue_messages{orders}":               5,
+		"rabbitmq_queue_consumers{orders}":              1,
+		"rabbitmq_queue_running{orders}":                1,
+		"rabbitmq_queue_message_stats_ack_rate{orders}": 1,
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %v, want %v", name, got[name], value)
+		}
+	}
+	if _, ok := got["rabbitmq_queue_messages{orders.tmp}"]; ok {
+		t.Errorf("excluded queue should be skipped")
+	}
+	if len(queuePages) != 2 {
+		t.Errorf("expected 2 pages of queues, got %v", queuePages)
+	}
+}
@@ -0,0 +1,279 @@
+package snmp
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/cprobe/cprobe/plugins"
+	"github.com/cprobe/cprobe/types"
+	"github.com/gosnmp/gosnmp"
+)
+
+const defaultPort = 161
+
+type Global struct {
+	// Version is 2c or 3
+	Version   string        `toml:"version"`
+	Community string        `toml:"community"`
+	Timeout   time.Duration `toml:"timeout"`
+	Retries   int           `toml:"retries"`
+
+	// SNMP v3
+	Username      string `toml:"username"`
+	SecurityLevel string `toml:"security_level"`
+	AuthProtocol  string `toml:"auth_protocol"`
+	AuthPassword  string `toml:"auth_password"`
+	PrivProtocol  string `toml:"priv_protocol"`
+	PrivPassword  string `toml:"priv_password"`
+	ContextName   string `toml:"context_name"`
+}
+
+// Metric maps an oid to a metric, the oid of a walk metric is a table column,
+// every row is a series with the oid suffix as the index label.
+// Type is gauge, counter or info, an info metric is 1 with the string value as the value label, e.g. sysDescr
+type Metric struct {
+	Name       string        `toml:"name"`
+	Oid        string        `toml:"oid"`
+	Type       string        `toml:"type"`
+	Walk       bool          `toml:"walk"`
+	IndexLabel string        `toml:"index_label"`
+	Timeout    time.Duration `toml:"timeout"`
+}
+
+type Config struct {
+	BaseDir string   `toml:"-"`
+	Global  *Global  `toml:"global"`
+	Metrics []Metric `toml:"metrics"`
+}
+
+type SNMP struct {
+}
+
+func init() {
+	plugins.RegisterPlugin(types.PluginSNMP, &SNMP{})
+}
+
+func (*SNMP) ParseConfig(baseDir string, bs []byte) (any, error) {
+	var c Config
+	err := toml.Unmarshal(bs, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.BaseDir = baseDir
+
+	if c.Global == nil {
+		c.Global = &Global{}
+	}
+	if c.Global.Version == "" {
+		c.Global.Version = "2c"
+	}
+	if c.Global.Version != "2c" && c.Global.Version != "3" {
+		return nil, fmt.Errorf("unsupported snmp version: %s, must be 2c or 3", c.Global.Version)
+	}
+	if c.Global.Timeout == 0 {
+		c.Global.Timeout = 5 * time.Second
+	}
+
+	for i := range c.Metrics {
+		m := &c.Metrics[i]
+		if m.Name == "" || m.Oid == "" {
+			return nil, fmt.Errorf("snmp metric name and oid must be set: %+v", *m)
+		}
+		m.Oid = "." + strings.TrimPrefix(m.Oid, ".")
+		switch m.Type {
+		case "":
+			m.Type = "gauge"
+		case "gauge", "info":
+		case "counter":
+			if !strings.HasSuffix(m.Name, "_total") {
+				m.Name += "_total"
+			}
+		default:
+			return nil, fmt.Errorf("unsupported type of snmp metric %s: %s, must be gauge, counter or info", m.Name, m.Type)
+		}
+		if m.IndexLabel == "" {
+			m.IndexLabel = "index"
+		}
+	}
+
+	return &c, nil
+}
+
+// newClient returns a client for target, target is host or host:port, the port defaults to 161
+func (g Global) newClient(target string) (*gosnmp.GoSNMP, error) {
+	host, port := target, uint16(defaultPort)
+	if h, p, err := net.SplitHostPort(target); err == nil {
+		n, err := strconv.ParseUint(p, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port of target %s: %s", target, err)
+		}
+		host, port = h, uint16(n)
+	}
+
+	client := &gosnmp.GoSNMP{
+		Target:    host,
+		Port:      port,
+		Community: g.Community,
+		Timeout:   g.Timeout,
+		Retries:   g.Retries,
+		MaxOids:   gosnmp.MaxOids,
+	}
+
+	if g.Version == "2c" {
+		client.Version = gosnmp.Version2c
+		return client, nil
+	}
+
+	client.Version = gosnmp.Version3
+	client.SecurityModel = gosnmp.UserSecurityModel
+	client.ContextName = g.ContextName
+
+	usm := &gosnmp.UsmSecurityParameters{
+		UserName:                 g.Username,
+		AuthenticationPassphrase: g.AuthPassword,
+		PrivacyPassphrase:        g.PrivPassword,
+	}
+
+	switch strings.ToLower(g.SecurityLevel) {
+	case "", "noauthnopriv":
+		client.MsgFlags = gosnmp.NoAuthNoPriv
+	case "authnopriv":
+		client.MsgFlags = gosnmp.AuthNoPriv
+	case "authpriv":
+		client.MsgFlags = gosnmp.AuthPriv
+	default:
+		return nil, fmt.Errorf("unsupported security_level: %s", g.SecurityLevel)
+	}
+
+	switch strings.ToUpper(g.AuthProtocol) {
+	case "":
+		usm.AuthenticationProtocol = gosnmp.NoAuth
+	case "MD5":
+		usm.AuthenticationProtocol = gosnmp.MD5
+	case "SHA":
+		usm.AuthenticationProtocol = gosnmp.SHA
+	case "SHA224":
+		usm.AuthenticationProtocol = gosnmp.SHA224
+	case "SHA256":
+		usm.AuthenticationProtocol = gosnmp.SHA256
+	case "SHA384":
+		usm.AuthenticationProtocol = gosnmp.SHA384
+	case "SHA512":
+		usm.AuthenticationProtocol = gosnmp.SHA512
+	default:
+		return nil, fmt.Errorf("unsupported auth_protocol: %s", g.AuthProtocol)
+	}
+
+	switch strings.ToUpper(g.PrivProtocol) {
+	case "":
+		usm.PrivacyProtocol = gosnmp.NoPriv
+	case "DES":
+		usm.PrivacyProtocol = gosnmp.DES
+	case "AES":
+		usm.PrivacyProtocol = gosnmp.AES
+	case "AES192":
+		usm.PrivacyProtocol = gosnmp.AES192
+	case "AES256":
+		usm.PrivacyProtocol = gosnmp.AES256
+	default:
+		return nil, fmt.Errorf("unsupported priv_protocol: %s", g.PrivProtocol)
+	}
+
+	client.SecurityParameters = usm
+	return client, nil
+}
+
+func (*SNMP) Scrape(ctx context.Context, address string, c any, ss *types.Samples) error {
+	cfg := c.(*Config)
+	client, err := cfg.Global.newClient(address)
+	if err != nil {
+		return err
+	}
+	client.Context = ctx
+
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("cannot connect to snmp agent %s: %s", address, err)
+	}
+	defer client.Conn.Close()
+
+	// 给每个指标都打上设备的标签，device 是 target 中的 host 部分
+	device := client.Target
+
+	var failed []string
+	for _, m := range cfg.Metrics {
+		client.Timeout = cfg.Global.Timeout
+		if m.Timeout > 0 {
+			client.Timeout = m.Timeout
+		}
+
+		if err := scrapeMetric(client, m, device, ss); err != nil {
+			logger.Warnf("failed to get snmp oid %s(%s) of %s: %s", m.Name, m.Oid, address, err)
+			failed = append(failed, m.Name)
+		}
+	}
+
+	if len(failed) == len(cfg.Metrics) && len(failed) > 0 {
+		return fmt.Errorf("failed to get all the snmp oids of %s", address)
+	}
+	return nil
+}
+
+func scrapeMetric(client *gosnmp.GoSNMP, m Metric, device string, ss *types.Samples) error {
+	if !m.Walk {
+		result, err := client.Get([]string{m.Oid})
+		if err != nil {
+			return err
+		}
+		for _, pdu := range result.Variables {
+			addPDU(m, pdu, map[string]string{"device": device}, ss)
+		}
+		return nil
+	}
+
+	return client.BulkWalk(m.Oid, func(pdu gosnmp.SnmpPDU) error {
+		index := strings.TrimPrefix(strings.TrimPrefix(pdu.Name, m.Oid), ".")
+		addPDU(m, pdu, map[string]string{"device": device, m.IndexLabel: index}, ss)
+		return nil
+	})
+}
+
+func addPDU(m Metric, pdu gosnmp.SnmpPDU, tags map[string]string, ss *types.Samples) {
+	if m.Type == "info" {
+		if pdu.Type != gosnmp.OctetString {
+			return
+		}
+		tags["value"] = string(pdu.Value.([]byte))
+		ss.AddMetric(types.PluginSNMP, map[string]interface{}{m.Name: 1.0}, tags)
+		return
+	}
+
+	if v, ok := pduValue(pdu); ok {
+		ss.AddMetric(types.PluginSNMP, map[string]interface{}{m.Name: v}, tags)
+	}
+}
+
+// pduValue converts the numeric pdus and the octet strings of a number, ok is false otherwise
+func pduValue(pdu gosnmp.SnmpPDU) (float64, bool) {
+	switch pdu.Type {
+	case gosnmp.Integer, gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Counter64, gosnmp.Uinteger32:
+		f, _ := new(big.Float).SetInt(gosnmp.ToBigInt(pdu.Value)).Float64()
+		return f, true
+	case gosnmp.OpaqueFloat:
+		return float64(pdu.Value.(float32)), true
+	case gosnmp.OpaqueDouble:
+		return pdu.Value.(float64), true
+	case gosnmp.OctetString:
+		f, err := strconv.ParseFloat(strings.TrimSpace(string(pdu.Value.([]byte))), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,114 @@
+package snmp
+
+import (
+	"testing"
+
+	"github.com/cprobe/cprobe/types"
+	"github.com/gosnmp/gosnmp"
+)
+
+func TestParseConfig(t *testing.T) {
+	bs := []byte(`
+[global]
+community = 'public'
+
+[[metrics]]
+name = 'if_in_octets'
+oid = '1.3.6.1.2.1.31.1.1.1.6'
+type = 'counter'
+walk = true
+index_label = 'ifIndex'
+
+[[metrics]]
+name = 'sys_uptime'
+oid = '.1.3.6.1.2.1.1.3.0'
+timeout = '2s'
+`)
+	c, err := (&SNMP{}).ParseConfig("", bs)
+	if err != nil {
+		t.Fatalf("failed to parse config: %s", err)
+	}
+
+	cfg := c.(*Config)
+	if cfg.Global.Version != "2c" || cfg.Global.Timeout == 0 {
+		t.Errorf("unexpected global defaults: %+v", cfg.Global)
+	}
+	if m := cfg.Metrics[0]; m.Name != "if_in_octets_total" || m.Oid != ".1.3.6.1.2.1.31.1.1.1.6" || m.IndexLabel != "ifIndex" {
+		t.Errorf("unexpected counter metric: %+v", m)
+	}
+	if m := cfg.Metrics[1]; m.Type != "gauge" || m.IndexLabel != "index" || m.Timeout.Seconds() != 2 {
+		t.Errorf("unexpected gauge metric: %+v", m)
+	}
+
+	for _, bad := range []string{
+		"[global]\nversion = '1'",
+		"[[metrics]]\nname = 'x'",
+		"[[metrics]]\nname = 'x'\noid = '1.3'\ntype = 'histogram'",
+	} {
+		if _, err := (&SNMP{}).ParseConfig("", []byte(bad)); err == nil {
+			t.Errorf("expected error for config: %q", bad)
+		}
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	g := Global{
+		Version:       "3",
+		Username:      "monitor",
+		SecurityLevel: "authPriv",
+		AuthProtocol:  "sha256",
+		AuthPassword:  "authpass",
+		PrivProtocol:  "aes",
+		PrivPassword:  "privpass",
+	}
+
+	client, err := g.newClient("10.0.0.1:1161")
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+	if client.Target != "10.0.0.1" || client.Port != 1161 || client.Version != gosnmp.Version3 || client.MsgFlags != gosnmp.AuthPriv {
+		t.Errorf("unexpected client: %+v", client)
+	}
+	usm := client.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	if usm.AuthenticationProtocol != gosnmp.SHA256 || usm.PrivacyProtocol != gosnmp.AES {
+		t.Errorf("unexpected usm: %+v", usm)
+	}
+
+	client, err = Global{Version: "2c", Community: "public"}.newClient("switch01")
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+	if client.Target != "switch01" || client.Port != defaultPort {
+		t.Errorf("unexpected client: %+v", client)
+	}
+
+	if _, err := (Global{Version: "3", PrivProtocol: "3des"}).newClient("switch01"); err == nil {
+		t.Errorf("expected error for unsupported priv_protocol")
+	}
+}
+
+func TestAddPDU(t *testing.T) {
+	ss := types.NewSamples()
+	addPDU(Metric{Name: "if_in_octets_total", Type: "counter"},
+		gosnmp.SnmpPDU{Type: gosnmp.Counter64, Value: uint64(1) << 40}, map[string]string{"device": "switch01", "ifIndex": "3"}, ss)
+	addPDU(Metric{Name: "temperature", Type: "gauge"},
+		gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("36.5")}, map[string]string{"device": "switch01"}, ss)
+	addPDU(Metric{Name: "sys_descr", Type: "info"},
+		gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("Cisco IOS")}, map[string]string{"device": "switch01"}, ss)
+	addPDU(Metric{Name: "not_a_number", Type: "gauge"},
+		gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("n/a")}, map[string]string{"device": "switch01"}, ss)
+
+	ms := ss.PopBackAll()
+	if len(ms) != 3 {
+		t.Fatalf("unexpected number of samples: %d", len(ms))
+	}
+	if v := ms[0].Fields()["if_in_octets_total"]; v != float64(uint64(1)<<40) {
+		t.Errorf("unexpected counter value: %v", v)
+	}
+	if v := ms[1].Fields()["temperature"]; v != 36.5 {
+		t.Errorf("unexpected gauge value: %v", v)
+	}
+	if ms[2].Tags()["value"] != "Cisco IOS" || ms[2].Name() != "snmp" {
+		t.Errorf("unexpected info sample: %s %v", ms[2].Name(), ms[2].Tags())
+	}
+}
@@ -0,0 +1,164 @@
+package probe
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/cprobe/cprobe/plugins"
+	"github.com/cprobe/cprobe/writer"
+)
+
+// CheckConfig loads all the main*.yaml under configDirectory and validates the jobs without scraping,
+// the rule files are parsed by the plugins and the static and file_sd targets are validated if the plugin
+// supports it, the remote service discovery is not queried. The summary is printed to w.
+func CheckConfig(configDirectory string, w io.Writer) error {
+	if err := checkCacheResultsOnFailure(); err != nil {
+		return err
+	}
+
+	pluginDirs, err := listPlugins(configDirectory)
+	if err != nil {
+		return err
+	}
+
+	if len(pluginDirs) == 0 {
+		return fmt.Errorf("no plugin dirs found under %s", configDirectory)
+	}
+
+	var jobs, targets, errs int
+	report := func(format string, a ...any) {
+		errs++
+		fmt.Fprintf(w, "  ERROR: "+format+"\n", a...)
+	}
+
+	for _, pluginDir := range pluginDirs {
+		entryYamlFilePaths, err := filepath.Glob(filepath.Join(configDirectory, pluginDir, "main*.yaml"))
+		if err != nil {
+			return fmt.Errorf("cannot glob main*.yaml under %s: %s", pluginDir, err)
+		}
+
+		for _, entryYamlFilePath := range entryYamlFilePaths {
+			fmt.Fprintf(w, "%s\n", entryYamlFilePath)
+
+			plugin, has := plugins.GetPlugin(pluginDir)
+			if _, supported := Jobs[pluginDir]; !has || !supported {
+				report("unsupported plugin %s", pluginDir)
+				continue
+			}
+
+			cfg, err := loadConfig(entryYamlFilePath)
+			if err != nil {
+				report("%s", err)
+				continue
+			}
+
+			for _, sc := range cfg.ScrapeConfigs {
+				if sc == nil {
+					report("a scrape_config is skipped, see the error logged above")
+					continue
+				}
+
+				jobs++
+				n, jobErrs := checkJob(w, NewJobGoroutine(pluginDir, sc), plugin)
+				targets += n
+				for _, err := range jobErrs {
+					report("job(%s) %s", sc.JobName, err)
+				}
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d jobs, %d targets checked, %d errors\n", jobs, targets, errs)
+	if errs > 0 {
+		return fmt.Errorf("%d errors found", errs)
+	}
+	return nil
+}
+
+// checkJob returns the number of the local targets checked and the errors found
+func checkJob(w io.Writer, j *JobGoroutine, plugin plugins.Plugin) (int, []error) {
+	sc := j.scrapeConfig
+	targets, errs := resolveLocalTargets(j, plugin)
+
+	validator, _ := plugin.(plugins.TargetValidator)
+
+	for _, target := range targets {
+		if target.err != nil {
+			errs = append(errs, fmt.Errorf("target(%s) %s", target.address, target.err))
+			continue
+		}
+		if validator == nil || target.config == nil {
+			continue
+		}
+		if err := validator.ValidateTarget(target.address, target.config); err != nil {
+			errs = append(errs, fmt.Errorf("target(%s) %s", target.address, err))
+		}
+	}
+
+	sds := remoteSDConfigs(sc)
+	fmt.Fprintf(w, "  job(%s): %d rule files, %d local targets, %d remote sd configs not resolved\n", sc.JobName, len(sc.ScrapeRuleFiles), len(targets), sds)
+
+	return len(targets), errs
+}
+
+// localTarget is a static or file_sd target with the plugin config parsed from its rule files,
+// config is nil if the rule files of the job are invalid
+type localTarget struct {
+	address string
+	config  any
+	err     error
+}
+
+// resolveLocalTargets parses the rule files of the job and the local targets, the errors of the job are returned,
+// the errors of a target with its own rule files are set to the target
+func resolveLocalTargets(j *JobGoroutine, plugin plugins.Plugin) ([]localTarget, []error) {
+	var errs []error
+	sc := j.scrapeConfig
+	baseDir := sc.ConfigRef.BaseDir
+
+	var config any
+	tomlBytes, err := j.readRuleFiles(sc.ScrapeRuleFiles)
+	if err == nil {
+		config, err = plugin.ParseConfig(baseDir, tomlBytes)
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	targets, targetErrs := j.getLocalTargets()
+	errs = append(errs, targetErrs...)
+
+	var ret []localTarget
+	for _, target := range targets {
+		pt := j.parseTarget(sc.JobName, target)
+		if pt == nil {
+			continue
+		}
+
+		lt := localTarget{address: pt.Get("__address__"), config: config}
+		if err := writer.CheckWriters(parseRuleFilesLabel(pt.Get(writersLabel))); err != nil {
+			lt.err = err
+			ret = append(ret, lt)
+			continue
+		}
+		if ruleFiles := parseRuleFilesLabel(pt.Get(ruleFilesLabel)); len(ruleFiles) > 0 {
+			targetTomlBytes, err := j.readRuleFiles(ruleFiles)
+			if err == nil {
+				lt.config, err = plugin.ParseConfig(baseDir, targetTomlBytes)
+			}
+			if err != nil {
+				lt.config, lt.err = nil, err
+			}
+		}
+		ret = append(ret, lt)
+	}
+	return ret, errs
+}
+
+func remoteSDConfigs(sc *ScrapeConfig) int {
+	return len(sc.AzureSDConfigs) + len(sc.ConsulSDConfigs) + len(sc.DigitaloceanSDConfigs) + len(sc.DNSSDConfigs) +
+		len(sc.DockerSDConfigs) + len(sc.DockerSwarmSDConfigs) + len(sc.EC2SDConfigs) + len(sc.EurekaSDConfigs) +
+		len(sc.GCESDConfigs) + len(sc.HTTPSDConfigs) + len(sc.KubernetesSDConfigs) + len(sc.OpenStackSDConfigs) +
+		len(sc.YandexCloudSDConfigs)
+}
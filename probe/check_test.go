@@ -0,0 +1,52 @@
+package probe
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckConfig(t *testing.T) {
+	dir := t.TempDir()
+	pluginDir := filepath.Join(dir, "mysql")
+	if err := os.Mkdir(pluginDir, 0755); err != nil {
+		t.Fatalf("cannot create plugin dir: %s", err)
+	}
+
+	files := map[string]string{
+		"main.yaml": `
+scrape_configs:
+- job_name: 'mysql'
+  static_configs:
+  - targets: ['127.0.0.1:3306', 'badtarget']
+  scrape_rule_files: ['rule.toml']
+- job_name: 'missing'
+  scrape_rule_files: ['rule_missing.toml']
+`,
+		"rule.toml": "[global]\nuser = 'root'\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(pluginDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("cannot write %s: %s", name, err)
+		}
+	}
+
+	var out bytes.Buffer
+	err := CheckConfig(dir, &out)
+	if err == nil {
+		t.Fatalf("expected errors, got output:\n%s", out.String())
+	}
+
+	for _, want := range []string{
+		"job(mysql): 1 rule files, 2 local targets",
+		"job(mysql) target(badtarget)",
+		"job(missing) read rule file(rule_missing.toml)",
+		"2 jobs, 2 targets checked, 2 errors",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, out.String())
+		}
+	}
+}
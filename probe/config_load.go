@@ -12,6 +12,7 @@ import (
 	"github.com/cprobe/cprobe/lib/logger"
 	"github.com/cprobe/cprobe/lib/promrelabel"
 	"github.com/cprobe/cprobe/lib/promutils"
+	"github.com/cprobe/cprobe/writer"
 	"gopkg.in/yaml.v2"
 )
 
@@ -51,6 +52,10 @@ func (cfg *Config) parseData(data []byte, path string) error {
 		return fmt.Errorf("cannot parse global metric_relabel_configs: %w", err)
 	}
 
+	if err = writer.CheckWriters(cfg.Global.Writers); err != nil {
+		return fmt.Errorf("invalid global writers: %w", err)
+	}
+
 	// Load cfg.ScrapeConfigFiles into c.ScrapeConfigs
 	scs := mustLoadScrapeConfigFiles(cfg.BaseDir, cfg.ScrapeConfigFiles)
 	cfg.ScrapeConfigFiles = nil
@@ -96,6 +101,30 @@ func (cfg *Config) parseData(data []byte, path string) error {
 			continue
 		}
 
+		if err = parseSampleTransforms(sc.SampleTransforms); err != nil {
+			logger.Errorf("skipping `scrape_config` for job_name=%s because of parse sample_transforms error: %s", sc.JobName, err)
+			cfg.ScrapeConfigs[i] = nil
+			continue
+		}
+
+		if err = parseSampleFilters(sc.SampleFilters); err != nil {
+			logger.Errorf("skipping `scrape_config` for job_name=%s because of parse sample_filters error: %s", sc.JobName, err)
+			cfg.ScrapeConfigs[i] = nil
+			continue
+		}
+
+		if err = parseLabelMasks(sc.LabelMasks); err != nil {
+			logger.Errorf("skipping `scrape_config` for job_name=%s because of parse label_masks error: %s", sc.JobName, err)
+			cfg.ScrapeConfigs[i] = nil
+			continue
+		}
+
+		if err = writer.CheckWriters(sc.Writers); err != nil {
+			logger.Errorf("skipping `scrape_config` for job_name=%s because of invalid writers: %s", sc.JobName, err)
+			cfg.ScrapeConfigs[i] = nil
+			continue
+		}
+
 		scrapeConcurrency := sc.ScrapeConcurrency
 		if scrapeConcurrency <= 0 {
 			scrapeConcurrency = cfg.Global.ScrapeConcurrency
@@ -111,6 +140,11 @@ func (cfg *Config) parseData(data []byte, path string) error {
 				scrapeInterval = defaultScrapeInterval
 			}
 		}
+
+		initialDelay := sc.InitialDelay.Duration()
+		if initialDelay <= 0 {
+			initialDelay = cfg.Global.InitialDelay.Duration()
+		}
 		// scrapeTimeout := sc.ScrapeTimeout.Duration()
 		// if scrapeTimeout <= 0 {
 		// 	scrapeTimeout = cfg.Global.ScrapeTimeout.Duration()
@@ -127,6 +161,7 @@ func (cfg *Config) parseData(data []byte, path string) error {
 
 		sc.ScrapeConcurrency = scrapeConcurrency
 		sc.ScrapeInterval = promutils.NewDuration(scrapeInterval)
+		sc.InitialDelay = promutils.NewDuration(initialDelay)
 		// sc.ScrapeTimeout = promutils.NewDuration(scrapeTimeout)
 
 		sc.ConfigRef = cfg
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cprobe/cprobe/discovery/azure"
+	"github.com/cprobe/cprobe/discovery/consul"
 	"github.com/cprobe/cprobe/discovery/digitalocean"
 	"github.com/cprobe/cprobe/discovery/dns"
 	"github.com/cprobe/cprobe/discovery/docker"
@@ -13,6 +14,7 @@ import (
 	"github.com/cprobe/cprobe/discovery/eureka"
 	"github.com/cprobe/cprobe/discovery/gce"
 	"github.com/cprobe/cprobe/discovery/http"
+	"github.com/cprobe/cprobe/discovery/kubernetes"
 	"github.com/cprobe/cprobe/discovery/openstack"
 	"github.com/cprobe/cprobe/discovery/yandexcloud"
 	"github.com/cprobe/cprobe/lib/envtemplate"
@@ -42,8 +44,13 @@ type Config struct {
 type GlobalConfig struct {
 	ScrapeConcurrency int                 `yaml:"scrape_concurrency,omitempty"` // 不能一次性启动太多 target 的抓取，比如 icmp 的抓取，一次性启动太多，会导致 icmp 的抓取超时
 	ScrapeInterval    *promutils.Duration `yaml:"scrape_interval,omitempty"`
+	InitialDelay      *promutils.Duration `yaml:"initial_delay,omitempty"` // 首次抓取前等待的时间，避免 cprobe 重启后所有插件同时开始抓取
 	// ScrapeTimeout     *promutils.Duration `yaml:"scrape_timeout,omitempty"`
 	ExternalLabels *promutils.Labels `yaml:"external_labels,omitempty"`
+	// 插件的数据发给 writer.yaml 中哪些 writer，按 name 引用，不配置就发给所有 writer
+	Writers []string `yaml:"writers,omitempty"`
+	// 所有插件所有 job 同时在抓取的 target 数量上限，超出的排队等待，各个 main*.yaml 中配置的取最大值，不配置表示不限制
+	MaxConcurrentScrapes int `yaml:"max_concurrent_scrapes,omitempty"`
 
 	MetricRelabelConfigs       []promrelabel.RelabelConfig `yaml:"metric_relabel_configs,omitempty"`
 	ParsedMetricRelabelConfigs *promrelabel.ParsedConfigs  `yaml:"-"`
@@ -58,6 +65,7 @@ type ScrapeConfig struct {
 	JobName           string              `yaml:"job_name"`
 	ScrapeConcurrency int                 `yaml:"scrape_concurrency,omitempty"`
 	ScrapeInterval    *promutils.Duration `yaml:"scrape_interval,omitempty"`
+	InitialDelay      *promutils.Duration `yaml:"initial_delay,omitempty"`
 	// ScrapeTimeout     *promutils.Duration `yaml:"scrape_timeout,omitempty"`
 
 	// 抓取数据的逻辑大变，已经不止是 HTTP /metrics 数据的抓取，可能是抓取的 SNMP、也可能抓的 MySQL
@@ -84,9 +92,22 @@ type ScrapeConfig struct {
 	ParsedRelabelConfigs       *promrelabel.ParsedConfigs `yaml:"-"`
 	ParsedMetricRelabelConfigs *promrelabel.ParsedConfigs `yaml:"-"`
 
+	// 在 metric_relabel_configs 之前对样本值做变换，比如单位换算
+	SampleTransforms []*SampleTransform `yaml:"sample_transforms,omitempty"`
+
+	// 在 sample_transforms 之后按指标名过滤样本，比如丢弃总是为 0 的 counter，减少数据量
+	SampleFilters []*SampleFilter `yaml:"sample_filters,omitempty"`
+
+	// 对插件采集到的敏感标签值做脱敏，hash 或者替换成固定的字符串
+	LabelMasks []*LabelMask `yaml:"label_masks,omitempty"`
+
+	// 本 job 的数据发给哪些 writer，覆盖 global 中的 writers，target 还可以通过 __writers__ 标签单独指定
+	Writers []string `yaml:"writers,omitempty"`
+
 	// SampleLimit          int                         `yaml:"sample_limit,omitempty"`
 
 	AzureSDConfigs        []azure.SDConfig        `yaml:"azure_sd_configs,omitempty"`
+	ConsulSDConfigs       []consul.SDConfig       `yaml:"consul_sd_configs,omitempty"`
 	DigitaloceanSDConfigs []digitalocean.SDConfig `yaml:"digitalocean_sd_configs,omitempty"`
 	DNSSDConfigs          []dns.SDConfig          `yaml:"dns_sd_configs,omitempty"`
 	DockerSDConfigs       []docker.SDConfig       `yaml:"docker_sd_configs,omitempty"`
@@ -96,6 +117,7 @@ type ScrapeConfig struct {
 	FileSDConfigs         []FileSDConfig          `yaml:"file_sd_configs,omitempty"`
 	GCESDConfigs          []gce.SDConfig          `yaml:"gce_sd_configs,omitempty"`
 	HTTPSDConfigs         []http.SDConfig         `yaml:"http_sd_configs,omitempty"`
+	KubernetesSDConfigs   []kubernetes.SDConfig   `yaml:"kubernetes_sd_configs,omitempty"`
 	OpenStackSDConfigs    []openstack.SDConfig    `yaml:"openstack_sd_configs,omitempty"`
 	StaticConfigs         []StaticConfig          `yaml:"static_configs,omitempty"`
 	YandexCloudSDConfigs  []yandexcloud.SDConfig  `yaml:"yandexcloud_sd_configs,omitempty"`
@@ -0,0 +1,76 @@
+package probe
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/cprobe/cprobe/types/metric"
+)
+
+const defaultMaskReplacement = "***"
+
+// LabelMask replaces the values of the sensitive labels collected by the plugins before output,
+// e.g. labels: ['user', 'client_host'] action: 'hash' salt: 'change-me'
+type LabelMask struct {
+	Labels []string `yaml:"labels"`
+	// hash: salted HMAC-SHA256 of the value, the same value always maps to the same hash so the series stay continuous
+	// mask: replace the value with Replacement
+	Action      string `yaml:"action,omitempty"`
+	Salt        string `yaml:"salt,omitempty"`
+	Replacement string `yaml:"replacement,omitempty"`
+}
+
+func (lm *LabelMask) parse() error {
+	if len(lm.Labels) == 0 {
+		return fmt.Errorf("labels is empty")
+	}
+
+	switch lm.Action {
+	case "", "hash":
+		lm.Action = "hash"
+		if lm.Salt == "" {
+			return fmt.Errorf("salt is required by action hash")
+		}
+	case "mask":
+		if lm.Replacement == "" {
+			lm.Replacement = defaultMaskReplacement
+		}
+	default:
+		return fmt.Errorf("unknown action %q, should be hash or mask", lm.Action)
+	}
+	return nil
+}
+
+func parseLabelMasks(lms []*LabelMask) error {
+	for i := range lms {
+		if err := lms[i].parse(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (lm *LabelMask) apply(value string) string {
+	if lm.Action == "mask" {
+		return lm.Replacement
+	}
+	mac := hmac.New(sha256.New, []byte(lm.Salt))
+	mac.Write([]byte(value))
+	// 64 bits are enough to keep the series distinct
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// applyLabelMasks rewrites the tags of the collected samples in place, the target labels are not touched
+func applyLabelMasks(lms []*LabelMask, ms []metric.Metric) {
+	for _, m := range ms {
+		for _, lm := range lms {
+			for _, label := range lm.Labels {
+				if value, has := m.GetTag(label); has {
+					m.AddTag(label, lm.apply(value))
+				}
+			}
+		}
+	}
+}
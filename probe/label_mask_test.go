@@ -0,0 +1,64 @@
+package probe
+
+import (
+	"testing"
+
+	"github.com/cprobe/cprobe/types/metric"
+)
+
+func TestLabelMaskParse(t *testing.T) {
+	tests := []struct {
+		mask  LabelMask
+		valid bool
+	}{
+		{LabelMask{Labels: []string{"user"}, Salt: "s"}, true},
+		{LabelMask{Labels: []string{"user"}}, false},
+		{LabelMask{Labels: []string{"user"}, Action: "mask"}, true},
+		{LabelMask{Labels: []string{"user"}, Action: "drop"}, false},
+		{LabelMask{Action: "mask"}, false},
+	}
+
+	for _, test := range tests {
+		err := test.mask.parse()
+		if (err == nil) != test.valid {
+			t.Errorf("parse(%+v) = %v, want valid: %v", test.mask, err, test.valid)
+		}
+	}
+}
+
+func TestApplyLabelMasks(t *testing.T) {
+	lms := []*LabelMask{
+		{Labels: []string{"user"}, Salt: "salt"},
+		{Labels: []string{"host"}, Action: "mask"},
+	}
+	if err := parseLabelMasks(lms); err != nil {
+		t.Fatalf("parseLabelMasks() unexpected error: %s", err)
+	}
+
+	newMetric := func(user string) metric.Metric {
+		return metric.New("mysql_user", map[string]string{"user": user, "host": "10.0.0.1", "db": "app"},
+			map[string]interface{}{"connections": 1.0}, 0)
+	}
+	tag := func(m metric.Metric, key string) string {
+		v, _ := m.GetTag(key)
+		return v
+	}
+	m1, m2, m3 := newMetric("alice"), newMetric("alice"), newMetric("bob")
+	applyLabelMasks(lms, []metric.Metric{m1, m2, m3})
+
+	if tag(m1, "user") == "alice" || len(tag(m1, "user")) != 16 {
+		t.Errorf("user is not hashed: %q", tag(m1, "user"))
+	}
+	if tag(m1, "user") != tag(m2, "user") {
+		t.Errorf("hash is not deterministic: %q != %q", tag(m1, "user"), tag(m2, "user"))
+	}
+	if tag(m1, "user") == tag(m3, "user") {
+		t.Errorf("different values map to the same hash: %q", tag(m1, "user"))
+	}
+	if tag(m1, "host") != defaultMaskReplacement {
+		t.Errorf("host is not masked: %q", tag(m1, "host"))
+	}
+	if tag(m1, "db") != "app" {
+		t.Errorf("db should not be touched: %q", tag(m1, "db"))
+	}
+}
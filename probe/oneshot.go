@@ -0,0 +1,54 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cprobe/cprobe/lib/logger"
+)
+
+// Oneshot scrapes every target of every job exactly once, concurrently by job and within ctx, without the scheduler loop.
+// The samples are sent to the writers like the scheduled scrapes, the caller flushes the writers before exiting.
+// It fails if the ratio of the failed targets is greater than maxFailedRatio, a job whose rule files or plugin
+// are broken counts as one failed target.
+func Oneshot(ctx context.Context, configDirectory string, maxFailedRatio float64) error {
+	jobs, err := readFiles(configDirectory)
+	if err != nil {
+		return err
+	}
+
+	setMaxConcurrentScrapes(maxConcurrentScrapesOf(jobs))
+
+	var (
+		lock          sync.Mutex
+		wg            sync.WaitGroup
+		total, failed int
+	)
+
+	for _, pluginJobs := range jobs {
+		for _, j := range pluginJobs {
+			wg.Add(1)
+			go func(j *JobGoroutine) {
+				defer wg.Done()
+				t, f := j.run(ctx)
+
+				lock.Lock()
+				defer lock.Unlock()
+				total += t
+				failed += f
+			}(j)
+		}
+	}
+
+	wg.Wait()
+
+	logger.Infof("oneshot: %d targets scraped, %d failed", total, failed)
+	if total == 0 {
+		return fmt.Errorf("no targets found under %s", configDirectory)
+	}
+	if float64(failed)/float64(total) > maxFailedRatio {
+		return fmt.Errorf("%d of %d targets failed, more than the ratio %g allowed", failed, total, maxFailedRatio)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package probe
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOneshot(t *testing.T) {
+	dir := t.TempDir()
+	pluginDir := filepath.Join(dir, "mysql")
+	if err := os.Mkdir(pluginDir, 0755); err != nil {
+		t.Fatalf("cannot create plugin dir: %s", err)
+	}
+
+	files := map[string]string{
+		"main.yaml": `
+scrape_configs:
+- job_name: 'mysql'
+  static_configs:
+  - targets: ['127.0.0.1:1']
+  scrape_rule_files: ['rule.toml']
+`,
+		"rule.toml": "[global]\nuser = 'root'\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(pluginDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("cannot write %s: %s", name, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := Oneshot(ctx, dir, 0); err == nil {
+		t.Fatalf("expected the unreachable target to fail")
+	}
+	if err := Oneshot(ctx, dir, 1); err != nil {
+		t.Fatalf("expected the failure to be allowed by the ratio, got %s", err)
+	}
+	if err := Oneshot(ctx, t.TempDir(), 1); err == nil {
+		t.Fatalf("expected an error without any target")
+	}
+}
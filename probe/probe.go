@@ -10,6 +10,7 @@ import (
 
 	"github.com/cprobe/cprobe/lib/fileutil"
 	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/cprobe/cprobe/plugins"
 	"github.com/pkg/errors"
 )
 
@@ -43,6 +44,10 @@ func listPlugins(configDirectory string) ([]string, error) {
 
 // Start starts the probe goroutines.
 func Start(ctx context.Context, configDirectory string) error {
+	if err := checkCacheResultsOnFailure(); err != nil {
+		return err
+	}
+
 	pluginDirs, err := listPlugins(configDirectory)
 	if err != nil {
 		return err
@@ -53,15 +58,26 @@ func Start(ctx context.Context, configDirectory string) error {
 	}
 
 	for i := 0; i < len(pluginDirs); i++ {
-		if err := startPlugin(ctx, configDirectory, pluginDirs[i]); err != nil {
+		if err := startPlugin(configDirectory, pluginDirs[i]); err != nil {
 			return errors.Wrapf(err, "cannot start plugin %s", pluginDirs[i])
 		}
 	}
 
+	// 全局的抓取并发上限要在所有配置都加载之后才知道，所以 job 统一在这里启动
+	setMaxConcurrentScrapes(maxConcurrentScrapesOf(Jobs))
+
+	for _, pluginJobs := range Jobs {
+		for _, jobGoroutine := range pluginJobs {
+			// 启动 goroutine，稍微 sleep 一下，避免所有 goroutine 同时启动
+			time.Sleep(time.Millisecond * 10)
+			go jobGoroutine.Start(ctx)
+		}
+	}
+
 	return nil
 }
 
-func startPlugin(ctx context.Context, configDirectory, pluginDir string) error {
+func startPlugin(configDirectory, pluginDir string) error {
 	pluginDirPath := filepath.Join(configDirectory, pluginDir)
 	entryYamlFilePaths, err := filepath.Glob(filepath.Join(pluginDirPath, "main*.yaml"))
 	if err != nil {
@@ -73,7 +89,7 @@ func startPlugin(ctx context.Context, configDirectory, pluginDir string) error {
 	}
 
 	for i := 0; i < len(entryYamlFilePaths); i++ {
-		if err = startEntry(ctx, pluginDir, entryYamlFilePaths[i]); err != nil {
+		if err = startEntry(pluginDir, entryYamlFilePaths[i]); err != nil {
 			return errors.Wrapf(err, "cannot start entry %s", entryYamlFilePaths[i])
 		}
 	}
@@ -83,7 +99,7 @@ func startPlugin(ctx context.Context, configDirectory, pluginDir string) error {
 
 var PluginCfgs = make(map[string][]*Config)
 
-func startEntry(ctx context.Context, pluginName, entryYamlFilePath string) error {
+func startEntry(pluginName, entryYamlFilePath string) error {
 	cfg, err := loadConfig(entryYamlFilePath)
 	if err != nil {
 		return err
@@ -95,18 +111,69 @@ func startEntry(ctx context.Context, pluginName, entryYamlFilePath string) error
 		return fmt.Errorf("unsupported plugin %s", pluginName)
 	}
 
+	plugin, has := plugins.GetPlugin(pluginName)
+	if !has {
+		return fmt.Errorf("unsupported plugin %s", pluginName)
+	}
+
+	// 启动之前先检查所有 job 的 rule 文件，有问题就拒绝启动，否则每次抓取都会报错，target 连 up=0 都没有
+	for i := range cfg.ScrapeConfigs {
+		if cfg.ScrapeConfigs[i] == nil {
+			continue
+		}
+
+		if err := checkRuleFiles(NewJobGoroutine(pluginName, cfg.ScrapeConfigs[i]), plugin); err != nil {
+			return errors.Wrapf(err, "job(%s)", cfg.ScrapeConfigs[i].JobName)
+		}
+	}
+
 	for i := range cfg.ScrapeConfigs {
 		if cfg.ScrapeConfigs[i] == nil {
 			continue
 		}
 
 		jobID := JobID{YamlFile: entryYamlFilePath, JobName: cfg.ScrapeConfigs[i].JobName}
-		jobGoroutine := NewJobGoroutine(pluginName, cfg.ScrapeConfigs[i])
-		pluginJobs[jobID] = jobGoroutine
+		pluginJobs[jobID] = NewJobGoroutine(pluginName, cfg.ScrapeConfigs[i])
+	}
+
+	return nil
+}
+
+// checkRuleFiles 解析 job 的 scrape_rule_files，以及 static 和 file_sd 的 target 通过 __scrape_rule_files__ 指定的 rule 文件，
+// 比如 mysql 插件开启 read_only 之后，不是只读的自定义 SQL 在这里就会报错。远程服务发现的 target 只能在抓取时检查
+func checkRuleFiles(j *JobGoroutine, plugin plugins.Plugin) error {
+	sc := j.scrapeConfig
+	baseDir := sc.ConfigRef.BaseDir
+
+	parse := func(ruleFiles []string) error {
+		tomlBytes, err := j.readRuleFiles(ruleFiles)
+		if err != nil {
+			return err
+		}
+		if _, err = plugin.ParseConfig(baseDir, tomlBytes); err != nil {
+			return fmt.Errorf("parse rule files(%s) error: %s", strings.Join(ruleFiles, ","), err)
+		}
+		return nil
+	}
+
+	if len(sc.ScrapeRuleFiles) > 0 {
+		if err := parse(sc.ScrapeRuleFiles); err != nil {
+			return err
+		}
+	}
 
-		// 启动 goroutine，稍微 sleep 一下，避免所有 goroutine 同时启动
-		time.Sleep(time.Millisecond * 10)
-		go jobGoroutine.Start(ctx)
+	// file_sd 文件的错误在抓取时也会跳过并打日志，这里不作为启动失败的原因
+	targets, _ := j.getLocalTargets()
+	for _, target := range targets {
+		pt := j.parseTarget(sc.JobName, target)
+		if pt == nil {
+			continue
+		}
+		if ruleFiles := parseRuleFilesLabel(pt.Get(ruleFilesLabel)); len(ruleFiles) > 0 {
+			if err := parse(ruleFiles); err != nil {
+				return fmt.Errorf("target(%s) %s", pt.Get("__address__"), err)
+			}
+		}
 	}
 
 	return nil
@@ -114,12 +181,18 @@ func startEntry(ctx context.Context, pluginName, entryYamlFilePath string) error
 
 // Reload 读取磁盘配置文件，与内存中的配置文件进行比较，增删 JobGoroutine
 func Reload(ctx context.Context, configDirectory string) {
+	// rule 文件的读取结果缓存了 5s，reload 时要读最新的内容来检查
+	c.Flush()
+
 	newJobs, err := readFiles(configDirectory)
 	if err != nil {
 		logger.Errorf("cannot read files: %s", err)
 		return
 	}
 
+	// 新的配置可能修改了 max_concurrent_scrapes
+	setMaxConcurrentScrapes(maxConcurrentScrapesOf(newJobs))
+
 	// 遍历内存中的老 Jobs，如果磁盘上的新 Jobs 中没有，就删除
 	for pluginName, jobs := range Jobs {
 		newPluginJobs := newJobs[pluginName]
@@ -136,9 +209,23 @@ func Reload(ctx context.Context, configDirectory string) {
 	// 遍历磁盘中的新 Jobs，如果内存中老 Jobs 没有，就新增，有就更新
 	for pluginName, jobs := range newJobs {
 		oldPluginJobs := Jobs[pluginName]
+		plugin, _ := plugins.GetPlugin(pluginName)
 
 		for jobID, jobGoroutine := range jobs {
 			oldJobGoroutine, has := oldPluginJobs[jobID]
+
+			// 和启动时一样检查 rule 文件，有问题的新 job 不启动，已有的 job 继续用老的配置
+			if plugin != nil {
+				if err := checkRuleFiles(jobGoroutine, plugin); err != nil {
+					if has {
+						logger.Errorf("job(%s) keeps the previous config, the new rule files are invalid: %s", jobID.JobName, err)
+					} else {
+						logger.Errorf("job(%s) is not started, the rule files are invalid: %s", jobID.JobName, err)
+					}
+					continue
+				}
+			}
+
 			if !has {
 				oldPluginJobs[jobID] = jobGoroutine
 
@@ -0,0 +1,127 @@
+package probe
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStartRefusesBrokenRuleFiles(t *testing.T) {
+	dir := t.TempDir()
+	pluginDir := filepath.Join(dir, "mysql")
+	if err := os.Mkdir(pluginDir, 0755); err != nil {
+		t.Fatalf("cannot create plugin dir: %s", err)
+	}
+
+	files := map[string]string{
+		"main.yaml": `
+scrape_configs:
+- job_name: 'mysql'
+  static_configs:
+  - targets: ['127.0.0.1:1']
+  scrape_rule_files: ['rule.toml']
+`,
+		"rule.toml": `
+[global]
+user = 'root'
+read_only = true
+
+[[queries]]
+mesurement = 'sessions'
+request = "SELECT COUNT(*) AS n FROM information_schema.processlist"
+
+[[queries]]
+mesurement = 'cleanup'
+request = "DELETE FROM audit.sessions"
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(pluginDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("cannot write %s: %s", name, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := Start(ctx, dir)
+	if err == nil {
+		t.Fatalf("expected the start refused")
+	}
+	if !strings.Contains(err.Error(), "job(mysql)") || !strings.Contains(err.Error(), "read_only") || !strings.Contains(err.Error(), ": cleanup") {
+		t.Fatalf("expected the non-read query listed, got: %s", err)
+	}
+	if len(Jobs["mysql"]) != 0 {
+		t.Fatalf("expected no job started")
+	}
+}
+
+func TestReloadKeepsJobWithBrokenRuleFiles(t *testing.T) {
+	dir := t.TempDir()
+	pluginDir := filepath.Join(dir, "mysql")
+	if err := os.Mkdir(pluginDir, 0755); err != nil {
+		t.Fatalf("cannot create plugin dir: %s", err)
+	}
+
+	write := func(files map[string]string) {
+		t.Helper()
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(pluginDir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("cannot write %s: %s", name, err)
+			}
+		}
+	}
+	write(map[string]string{
+		"main.yaml": `
+scrape_configs:
+- job_name: 'mysql'
+  static_configs:
+  - targets: ['127.0.0.1:1']
+  scrape_rule_files: ['rule.toml']
+`,
+		"rule.toml": "[global]\nuser = 'root'\nread_only = true\n",
+	})
+
+	jobs, err := readFiles(dir)
+	if err != nil {
+		t.Fatalf("cannot read config: %s", err)
+	}
+	for jobID, j := range jobs["mysql"] {
+		Jobs["mysql"][jobID] = j
+	}
+	defer func() {
+		for jobID, j := range Jobs["mysql"] {
+			j.Stop()
+			delete(Jobs["mysql"], jobID)
+		}
+	}()
+	old := Jobs["mysql"][JobID{YamlFile: filepath.Join(pluginDir, "main.yaml"), JobName: "mysql"}]
+	oldConfig := old.scrapeConfig
+
+	// the existing job gets a non-read query, a new job refers to a missing rule file
+	write(map[string]string{
+		"main.yaml": `
+scrape_configs:
+- job_name: 'mysql'
+  static_configs:
+  - targets: ['127.0.0.1:1']
+  scrape_rule_files: ['rule.toml']
+- job_name: 'mysql_new'
+  static_configs:
+  - targets: ['127.0.0.1:2']
+  scrape_rule_files: ['rule_missing.toml']
+`,
+		"rule.toml": "[global]\nuser = 'root'\nread_only = true\n\n[[queries]]\nmesurement = 'cleanup'\nrequest = \"DELETE FROM audit.sessions\"\n",
+	})
+
+	Reload(context.Background(), dir)
+
+	if len(Jobs["mysql"]) != 1 {
+		t.Fatalf("expected the new job with invalid rule files not started, got %d jobs", len(Jobs["mysql"]))
+	}
+	if old.scrapeConfig != oldConfig {
+		t.Fatalf("expected the job to keep the previous config")
+	}
+}
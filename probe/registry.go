@@ -6,9 +6,13 @@ import (
 	_ "github.com/cprobe/cprobe/plugins/blackbox"
 	_ "github.com/cprobe/cprobe/plugins/json"
 	_ "github.com/cprobe/cprobe/plugins/kafka"
+	_ "github.com/cprobe/cprobe/plugins/memcached"
 	_ "github.com/cprobe/cprobe/plugins/mysql"
+	_ "github.com/cprobe/cprobe/plugins/postgresql"
 	_ "github.com/cprobe/cprobe/plugins/prometheus"
+	_ "github.com/cprobe/cprobe/plugins/rabbitmq"
 	_ "github.com/cprobe/cprobe/plugins/redis"
+	_ "github.com/cprobe/cprobe/plugins/snmp"
 )
 
 func makeJobs() map[string]map[JobID]*JobGoroutine {
@@ -22,5 +26,8 @@ func makeJobs() map[string]map[JobID]*JobGoroutine {
 		types.PluginBlackbox:      make(map[JobID]*JobGoroutine),
 		types.PluginJson:          make(map[JobID]*JobGoroutine),
 		types.PluginPrometheus:    make(map[JobID]*JobGoroutine),
+		types.PluginSNMP:          make(map[JobID]*JobGoroutine),
+		types.PluginMemcached:     make(map[JobID]*JobGoroutine),
+		types.PluginRabbitMQ:      make(map[JobID]*JobGoroutine),
 	}
 }
@@ -0,0 +1,190 @@
+package probe
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cprobe/cprobe/lib/prompbmarshal"
+	"github.com/patrickmn/go-cache"
+)
+
+var (
+	cacheScrapeResults = flag.Bool("scrape.cacheResults", false, "Whether to cache the last scrape result of every target and expose it at /metrics. "+
+		"The cached result is served until the next scrape, so the targets are not queried by the http requests")
+	cacheResultsOnFailure = flag.String("scrape.cacheResultsOnFailure", "drop", "What to serve at /metrics for the series missing from a failed scrape, "+
+		"drop: only serve what the failed scrape returned, Prometheus marks the missing series stale itself on the next scrape of /metrics; "+
+		"hold: serve the last successful values")
+
+	results = cache.New(time.Minute, time.Minute)
+
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// checkCacheResultsOnFailure rejects the unknown -scrape.cacheResultsOnFailure, a typo must not silently behave as drop.
+// There is no stale mode, the text format has no staleness marker, a NaN would be stored as a real sample
+func checkCacheResultsOnFailure() error {
+	switch *cacheResultsOnFailure {
+	case "drop", "hold":
+		return nil
+	default:
+		return fmt.Errorf("unknown -scrape.cacheResultsOnFailure=%q, must be drop or hold", *cacheResultsOnFailure)
+	}
+}
+
+type scrapeResult struct {
+	job    string
+	target string
+	tss    []prompbmarshal.TimeSeries
+	at     time.Time
+	// series of the last successful scrape, used when a scrape fails, see -scrape.cacheResultsOnFailure
+	succeeded []prompbmarshal.TimeSeries
+}
+
+// cacheScrapeResult keeps the time series of the target, the result of a target that has gone
+// is expired after 3 scrape intervals
+func cacheScrapeResult(job, target string, tss []prompbmarshal.TimeSeries, interval time.Duration, failed bool) {
+	if !*cacheScrapeResults {
+		return
+	}
+
+	// writer relabels the slice in place, keep a copy
+	cached := make([]prompbmarshal.TimeSeries, len(tss))
+	copy(cached, tss)
+
+	key := job + "/" + target
+	succeeded := cached
+	if failed {
+		succeeded = nil
+		if v, ok := results.Get(key); ok {
+			succeeded = v.(*scrapeResult).succeeded
+		}
+
+		if *cacheResultsOnFailure == "hold" {
+			cached = mergeMissingSeries(cached, succeeded)
+		}
+	}
+
+	results.Set(key, &scrapeResult{
+		job:       job,
+		target:    target,
+		tss:       cached,
+		at:        time.Now(),
+		succeeded: succeeded,
+	}, 3*interval)
+}
+
+// mergeMissingSeries appends the series of prev that are missing from tss
+func mergeMissingSeries(tss, prev []prompbmarshal.TimeSeries) []prompbmarshal.TimeSeries {
+	seen := make(map[string]struct{}, len(tss))
+	for i := range tss {
+		seen[seriesKey(&tss[i])] = struct{}{}
+	}
+
+	for i := range prev {
+		if _, ok := seen[seriesKey(&prev[i])]; ok {
+			continue
+		}
+		tss = append(tss, prev[i])
+	}
+	return tss
+}
+
+func seriesKey(ts *prompbmarshal.TimeSeries) string {
+	labels := make([]string, 0, len(ts.Labels))
+	for _, label := range ts.Labels {
+		labels = append(labels, label.Name+"="+strconv.Quote(label.Value))
+	}
+	sort.Strings(labels)
+	return strings.Join(labels, ",")
+}
+
+// WriteScrapeResults writes the cached scrape results in Prometheus text format,
+// cprobe_scrape_result_age_seconds tells how old the result of each target is.
+// The series of a metric name are written contiguously across the targets, preceded by its # TYPE line,
+// the type is unknown after the scrape so every family but the age is untyped
+func WriteScrapeResults(w io.Writer) {
+	if !*cacheScrapeResults {
+		return
+	}
+
+	items := results.Items()
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	if len(keys) > 0 {
+		bw.WriteString("# TYPE cprobe_scrape_result_age_seconds gauge\n")
+	}
+
+	families := make(map[string][]*prompbmarshal.TimeSeries)
+	for _, key := range keys {
+		r := items[key].Object.(*scrapeResult)
+		fmt.Fprintf(bw, "cprobe_scrape_result_age_seconds{job=\"%s\",target=\"%s\"} %g\n",
+			labelValueEscaper.Replace(r.job), labelValueEscaper.Replace(r.target), time.Since(r.at).Seconds())
+
+		for i := range r.tss {
+			ts := &r.tss[i]
+			name := seriesName(ts)
+			if name == "" || len(ts.Samples) == 0 {
+				continue
+			}
+
+			families[name] = append(families[name], ts)
+		}
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		bw.WriteString("# TYPE ")
+		bw.WriteString(name)
+		bw.WriteString(" untyped\n")
+		for _, ts := range families[name] {
+			writeTimeSeries(bw, name, ts)
+		}
+	}
+}
+
+func seriesName(ts *prompbmarshal.TimeSeries) string {
+	for _, label := range ts.Labels {
+		if label.Name == "__name__" {
+			return label.Value
+		}
+	}
+	return ""
+}
+
+func writeTimeSeries(bw *bufio.Writer, name string, ts *prompbmarshal.TimeSeries) {
+	var labels []string
+	for _, label := range ts.Labels {
+		if label.Name == "__name__" {
+			continue
+		}
+		labels = append(labels, label.Name+"=\""+labelValueEscaper.Replace(label.Value)+"\"")
+	}
+
+	bw.WriteString(name)
+	if len(labels) > 0 {
+		bw.WriteString("{")
+		bw.WriteString(strings.Join(labels, ","))
+		bw.WriteString("}")
+	}
+	bw.WriteString(" ")
+	bw.WriteString(strconv.FormatFloat(ts.Samples[0].Value, 'g', -1, 64))
+	bw.WriteString("\n")
+}
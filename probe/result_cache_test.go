@@ -0,0 +1,137 @@
+package probe
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cprobe/cprobe/lib/prompbmarshal"
+)
+
+func TestWriteScrapeResults(t *testing.T) {
+	*cacheScrapeResults = true
+	defer func() {
+		*cacheScrapeResults = false
+		results.Flush()
+	}()
+
+	tss := []prompbmarshal.TimeSeries{
+		{
+			Labels: []prompbmarshal.Label{
+				{Name: "__name__", Value: "mysql_up"},
+				{Name: "instance", Value: `a"b`},
+			},
+			Samples: []prompbmarshal.Sample{{Value: 1, Timestamp: 1}},
+		},
+		{
+			Labels:  []prompbmarshal.Label{{Name: "__name__", Value: "mysql_global_status_uptime"}},
+			Samples: []prompbmarshal.Sample{{Value: 12345678, Timestamp: 1}},
+		},
+	}
+	cacheScrapeResult("mysql", "127.0.0.1:3306", tss, time.Minute, false)
+
+	// the writer relabels in place, must not change the cached result
+	tss[0].Labels = nil
+
+	// a second target, the series of the same name are grouped across the targets
+	cacheScrapeResult("mysql", "127.0.0.2:3306", []prompbmarshal.TimeSeries{
+		{
+			Labels: []prompbmarshal.Label{
+				{Name: "__name__", Value: "mysql_up"},
+				{Name: "instance", Value: "b"},
+			},
+			Samples: []prompbmarshal.Sample{{Value: 0, Timestamp: 1}},
+		},
+	}, time.Minute, false)
+
+	var bb bytes.Buffer
+	WriteScrapeResults(&bb)
+
+	lines := strings.Split(strings.TrimSpace(bb.String()), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("unexpected output: %q", bb.String())
+	}
+	if lines[0] != "# TYPE cprobe_scrape_result_age_seconds gauge" {
+		t.Errorf("unexpected type line: %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], `cprobe_scrape_result_age_seconds{job="mysql",target="127.0.0.1:3306"} `) {
+		t.Errorf("unexpected age line: %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], `cprobe_scrape_result_age_seconds{job="mysql",target="127.0.0.2:3306"} `) {
+		t.Errorf("unexpected age line: %q", lines[2])
+	}
+
+	expected := []string{
+		"# TYPE mysql_global_status_uptime untyped",
+		"mysql_global_status_uptime 1.2345678e+07",
+		"# TYPE mysql_up untyped",
+		`mysql_up{instance="a\"b"} 1`,
+		`mysql_up{instance="b"} 0`,
+	}
+	for i, line := range expected {
+		if lines[i+3] != line {
+			t.Errorf("unexpected line %d: %q; want %q", i+3, lines[i+3], line)
+		}
+	}
+}
+
+func TestCacheScrapeResultOnFailure(t *testing.T) {
+	*cacheScrapeResults = true
+	defer func() {
+		*cacheScrapeResults = false
+		*cacheResultsOnFailure = "drop"
+		results.Flush()
+	}()
+
+	up := func(v float64) prompbmarshal.TimeSeries {
+		return prompbmarshal.TimeSeries{
+			Labels:  []prompbmarshal.Label{{Name: "__name__", Value: "mysql_up"}},
+			Samples: []prompbmarshal.Sample{{Value: v, Timestamp: 1}},
+		}
+	}
+	uptime := prompbmarshal.TimeSeries{
+		Labels:  []prompbmarshal.Label{{Name: "__name__", Value: "mysql_global_status_uptime"}},
+		Samples: []prompbmarshal.Sample{{Value: 100, Timestamp: 1}},
+	}
+
+	f := func(mode string, expected string) {
+		t.Helper()
+		*cacheResultsOnFailure = mode
+		results.Flush()
+
+		cacheScrapeResult("mysql", "127.0.0.1:3306", []prompbmarshal.TimeSeries{up(1), uptime}, time.Minute, false)
+		cacheScrapeResult("mysql", "127.0.0.1:3306", []prompbmarshal.TimeSeries{up(0)}, time.Minute, true)
+		// a second failure still falls back to the last successful scrape
+		cacheScrapeResult("mysql", "127.0.0.1:3306", []prompbmarshal.TimeSeries{up(0)}, time.Minute, true)
+
+		var bb bytes.Buffer
+		WriteScrapeResults(&bb)
+
+		lines := strings.SplitN(strings.TrimSpace(bb.String()), "\n", 3)
+		if len(lines) != 3 || lines[2] != expected {
+			t.Errorf("unexpected output of mode %s: %q", mode, bb.String())
+		}
+	}
+
+	f("drop", "# TYPE mysql_up untyped\nmysql_up 0")
+	f("hold", "# TYPE mysql_global_status_uptime untyped\nmysql_global_status_uptime 100\n# TYPE mysql_up untyped\nmysql_up 0")
+}
+
+func TestCheckCacheResultsOnFailure(t *testing.T) {
+	defer func() { *cacheResultsOnFailure = "drop" }()
+
+	for _, mode := range []string{"drop", "hold"} {
+		*cacheResultsOnFailure = mode
+		if err := checkCacheResultsOnFailure(); err != nil {
+			t.Errorf("unexpected error for %s: %s", mode, err)
+		}
+	}
+
+	for _, mode := range []string{"stal", "stale"} {
+		*cacheResultsOnFailure = mode
+		if err := checkCacheResultsOnFailure(); err == nil {
+			t.Errorf("expected an error for the unknown mode %s", mode)
+		}
+	}
+}
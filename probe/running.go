@@ -0,0 +1,195 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/cprobe/cprobe/lib/logger"
+)
+
+// scrapeSlots 是所有 job、所有插件共享的抓取信号量，上限是各个 main*.yaml 的 global.max_concurrent_scrapes 中最大的那个，
+// 0 表示不限制。reload 时上限可能变化，所以不用固定容量的 channel，而是计数加上唤醒等待者的 channel
+var scrapeSlots = &scrapeSemaphore{wake: make(chan struct{})}
+
+type scrapeSemaphore struct {
+	sync.Mutex
+	limit    int
+	inFlight int
+	// 名额释放或者上限变化时关闭，唤醒所有等待的抓取，然后换一个新的
+	wake chan struct{}
+}
+
+func (s *scrapeSemaphore) broadcast() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+func init() {
+	metrics.NewGauge("cprobe_scrapes_in_flight", func() float64 {
+		scrapeSlots.Lock()
+		defer scrapeSlots.Unlock()
+		return float64(scrapeSlots.inFlight)
+	})
+}
+
+// setMaxConcurrentScrapes 修改全局的抓取并发上限，启动和 reload 时调用，正在抓取的不受影响，
+// 调大之后排队的抓取会被唤醒
+func setMaxConcurrentScrapes(limit int) {
+	scrapeSlots.Lock()
+	defer scrapeSlots.Unlock()
+	if limit < 0 {
+		limit = 0
+	}
+	if limit != scrapeSlots.limit {
+		logger.Infof("max_concurrent_scrapes of all the plugins is set to %d, 0 means no limit", limit)
+	}
+	scrapeSlots.limit = limit
+	scrapeSlots.broadcast()
+}
+
+// maxConcurrentScrapesOf returns the largest global.max_concurrent_scrapes of the configs of the jobs, the configs
+// are separated by the plugin dirs, there is no config shared by all the plugins. The configs setting different
+// values are logged, the largest one applies to every plugin
+func maxConcurrentScrapesOf(jobs map[string]map[JobID]*JobGoroutine) int {
+	var limit int
+	settings := make(map[string]int)
+	for pluginName, pluginJobs := range jobs {
+		for jobID, j := range pluginJobs {
+			j.RLock()
+			n := j.scrapeConfig.ConfigRef.Global.MaxConcurrentScrapes
+			j.RUnlock()
+			if n <= 0 {
+				continue
+			}
+			settings[pluginName+":"+jobID.YamlFile] = n
+			if n > limit {
+				limit = n
+			}
+		}
+	}
+
+	var disagree []string
+	for file, n := range settings {
+		if n != limit {
+			disagree = append(disagree, fmt.Sprintf("%s=%d", file, n))
+		}
+	}
+	if len(disagree) > 0 {
+		sort.Strings(disagree)
+		logger.Warnf("max_concurrent_scrapes is set differently in the main*.yaml files, the largest %d applies to all the plugins, ignored: %s",
+			limit, strings.Join(disagree, ", "))
+	}
+
+	return limit
+}
+
+// acquireScrapeSlot 等待一个全局的抓取名额，ctx 结束或者 job 被 Stop（quit 关闭）时返回 false，
+// 避免 reload 之后旧的 job 拿到名额再用旧的配置抓取，等待的时间记录在 cprobe_scrape_queue_wait_seconds
+func acquireScrapeSlot(ctx context.Context, quit <-chan struct{}, plugin string) bool {
+	start := time.Now()
+	limited := false
+	defer func() {
+		// 没有配置上限时不记录，否则全是 0
+		if limited {
+			metrics.GetOrCreateHistogram(fmt.Sprintf(`cprobe_scrape_queue_wait_seconds{plugin=%q}`, plugin)).UpdateDuration(start)
+		}
+	}()
+
+	for {
+		scrapeSlots.Lock()
+		if scrapeSlots.limit > 0 {
+			limited = true
+		}
+		if scrapeSlots.limit <= 0 || scrapeSlots.inFlight < scrapeSlots.limit {
+			scrapeSlots.inFlight++
+			scrapeSlots.Unlock()
+			return true
+		}
+		wake := scrapeSlots.wake
+		scrapeSlots.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return false
+		case <-quit:
+			return false
+		}
+	}
+}
+
+func releaseScrapeSlot() {
+	scrapeSlots.Lock()
+	defer scrapeSlots.Unlock()
+	scrapeSlots.inFlight--
+	scrapeSlots.broadcast()
+}
+
+// runningTargets 记录正在抓取的 target，同一个 job 的同一个 target 同时只允许有一个抓取，
+// 配置 reload 时旧的 job 可能还没抓完新的 job 就开始了，所以这里是全局的，按 job 区分，
+// 不同的 job 抓取同一个地址（比如 blackbox 的不同 module）互不影响
+var runningTargets sync.Map
+
+func runningTargetKey(plugin, job, target string) string {
+	return plugin + "\x00" + job + "\x00" + target
+}
+
+// acquireTarget returns false if the previous scrape of the target by the job is still running
+func acquireTarget(plugin, job, target string) bool {
+	_, running := runningTargets.LoadOrStore(runningTargetKey(plugin, job, target), struct{}{})
+	return !running
+}
+
+func releaseTarget(plugin, job, target string) {
+	runningTargets.Delete(runningTargetKey(plugin, job, target))
+}
+
+func scrapeSkippedName(plugin, job, target string) string {
+	return fmt.Sprintf(`%s_exporter_scrape_skipped_total{job=%q,target=%q}`, plugin, job, target)
+}
+
+func incScrapeSkipped(plugin, job, target string) {
+	metrics.GetOrCreateCounter(scrapeSkippedName(plugin, job, target)).Inc()
+}
+
+// jobTarget 是 job 抓取过的一个 target，用于在 target 消失之后清理按 target 创建的自监控指标
+type jobTarget struct {
+	job    string
+	target string
+}
+
+// forgetTarget unregisters the self metrics of the target created by the job, the target is no longer
+// discovered or the job is stopped, otherwise the series of the churning targets, e.g. the pod IPs, pile up
+func forgetTarget(plugin, job, target string) {
+	metrics.UnregisterMetric(scrapeSkippedName(plugin, job, target))
+
+	name := lastSuccessName(plugin, job, target)
+	metrics.UnregisterMetric(name)
+	lastSuccess.Delete(name)
+}
+
+// lastSuccess 记录每个 target 最近一次抓取成功的时间，key 是指标名，gauge 回调时读取
+var lastSuccess sync.Map
+
+func lastSuccessName(plugin, job, target string) string {
+	return fmt.Sprintf(`cprobe_target_last_success_timestamp_seconds{plugin=%q,job=%q,target=%q}`, plugin, job, target)
+}
+
+// setLastSuccess updates cprobe_target_last_success_timestamp_seconds, a target that never errors
+// but never succeeds either, e.g. stuck in a reload loop, can be alerted on with time() - this
+func setLastSuccess(plugin, job, target string, t time.Time) {
+	name := lastSuccessName(plugin, job, target)
+	lastSuccess.Store(name, float64(t.UnixMilli())/1e3)
+	metrics.GetOrCreateGauge(name, func() float64 {
+		v, ok := lastSuccess.Load(name)
+		if !ok {
+			return 0
+		}
+		return v.(float64)
+	})
+}
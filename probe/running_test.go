@@ -0,0 +1,244 @@
+package probe
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+func TestAcquireTarget(t *testing.T) {
+	if !acquireTarget("mysql", "mysql", "127.0.0.1:3306") {
+		t.Fatalf("expected to acquire an idle target")
+	}
+	if acquireTarget("mysql", "mysql", "127.0.0.1:3306") {
+		t.Fatalf("expected to skip a running target")
+	}
+	if !acquireTarget("redis", "mysql", "127.0.0.1:3306") {
+		t.Fatalf("expected targets of different plugins to be independent")
+	}
+	if !acquireTarget("mysql", "mysql_slow", "127.0.0.1:3306") {
+		t.Fatalf("expected targets of different jobs to be independent")
+	}
+
+	releaseTarget("mysql", "mysql", "127.0.0.1:3306")
+	releaseTarget("redis", "mysql", "127.0.0.1:3306")
+	releaseTarget("mysql", "mysql_slow", "127.0.0.1:3306")
+	if !acquireTarget("mysql", "mysql", "127.0.0.1:3306") {
+		t.Fatalf("expected to acquire a released target")
+	}
+	releaseTarget("mysql", "mysql", "127.0.0.1:3306")
+}
+
+func hasMetric(name string) bool {
+	for _, n := range metrics.ListMetricNames() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestForgetGoneTargets(t *testing.T) {
+	j := NewJobGoroutine("mysql", &ScrapeConfig{JobName: "mysql"})
+
+	a := jobTarget{job: "mysql", target: "10.0.0.1:3306"}
+	b := jobTarget{job: "mysql", target: "10.0.0.2:3306"}
+	incScrapeSkipped("mysql", a.job, a.target)
+	incScrapeSkipped("mysql", b.job, b.target)
+
+	j.forgetGoneTargets(map[jobTarget]struct{}{a: {}, b: {}})
+	j.forgetGoneTargets(map[jobTarget]struct{}{a: {}})
+
+	if !hasMetric(scrapeSkippedName("mysql", a.job, a.target)) {
+		t.Fatalf("expected the counter of the remaining target kept")
+	}
+	if hasMetric(scrapeSkippedName("mysql", b.job, b.target)) {
+		t.Fatalf("expected the counter of the gone target unregistered")
+	}
+
+	// the stopped job forgets all its targets
+	j.Stop()
+	if hasMetric(scrapeSkippedName("mysql", a.job, a.target)) {
+		t.Fatalf("expected the counters of the stopped job unregistered")
+	}
+}
+
+func TestSetLastSuccess(t *testing.T) {
+	name := `cprobe_target_last_success_timestamp_seconds{plugin="mysql",job="mysql",target="127.0.0.1:3306"}`
+
+	setLastSuccess("mysql", "mysql", "127.0.0.1:3306", time.UnixMilli(1700000000500))
+	setLastSuccess("mysql", "mysql", "127.0.0.1:3306", time.UnixMilli(1700000015500))
+
+	v, ok := lastSuccess.Load(name)
+	if !ok {
+		t.Fatalf("expected %s to be set", name)
+	}
+	if v.(float64) != 1700000015.5 {
+		t.Fatalf("unexpected last success timestamp: %v", v)
+	}
+
+	forgetTarget("mysql", "mysql", "127.0.0.1:3306")
+	if _, ok := lastSuccess.Load(name); ok {
+		t.Fatalf("expected the last success of the gone target deleted")
+	}
+	if hasMetric(name) {
+		t.Fatalf("expected the gauge of the gone target unregistered")
+	}
+}
+
+func TestAcquireScrapeSlot(t *testing.T) {
+	setMaxConcurrentScrapes(1)
+	defer setMaxConcurrentScrapes(0)
+
+	if !acquireScrapeSlot(context.Background(), nil, "mysql") {
+		t.Fatalf("expected to acquire a free slot")
+	}
+
+	acquired := make(chan bool)
+	go func() {
+		acquired <- acquireScrapeSlot(context.Background(), nil, "mysql")
+	}()
+	select {
+	case <-acquired:
+		t.Fatalf("expected to wait for the slot in use")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	releaseScrapeSlot()
+	if !<-acquired {
+		t.Fatalf("expected to acquire the released slot")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if acquireScrapeSlot(ctx, nil, "mysql") {
+		t.Fatalf("expected to give up waiting when the context is done")
+	}
+	releaseScrapeSlot()
+}
+
+func TestSetMaxConcurrentScrapes(t *testing.T) {
+	setMaxConcurrentScrapes(1)
+	defer setMaxConcurrentScrapes(0)
+
+	if !acquireScrapeSlot(context.Background(), nil, "mysql") {
+		t.Fatalf("expected to acquire a free slot")
+	}
+	defer releaseScrapeSlot()
+
+	acquired := make(chan bool)
+	go func() {
+		acquired <- acquireScrapeSlot(context.Background(), nil, "mysql")
+	}()
+	select {
+	case <-acquired:
+		t.Fatalf("expected to wait for the slot in use")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// a reload raising the limit wakes up the queued scrapes
+	setMaxConcurrentScrapes(2)
+	select {
+	case ok := <-acquired:
+		if !ok {
+			t.Fatalf("expected to acquire the added slot")
+		}
+		releaseScrapeSlot()
+	case <-time.After(time.Second):
+		t.Fatalf("expected the queued scrape woken up by the raised limit")
+	}
+}
+
+func TestMaxConcurrentScrapesOf(t *testing.T) {
+	job := func(limit int) *JobGoroutine {
+		return NewJobGoroutine("mysql", &ScrapeConfig{ConfigRef: &Config{Global: GlobalConfig{MaxConcurrentScrapes: limit}}})
+	}
+	jobs := map[string]map[JobID]*JobGoroutine{
+		"mysql": {
+			{YamlFile: "mysql/main.yaml", JobName: "a"}:   job(0),
+			{YamlFile: "mysql/main_2.yaml", JobName: "b"}: job(20),
+		},
+		"redis": {
+			{YamlFile: "redis/main.yaml", JobName: "c"}: job(50),
+		},
+	}
+	if n := maxConcurrentScrapesOf(jobs); n != 50 {
+		t.Fatalf("expected the largest max_concurrent_scrapes, got %d", n)
+	}
+}
+
+func TestStopJobWhileQueued(t *testing.T) {
+	dir := t.TempDir()
+	pluginDir := filepath.Join(dir, "mysql")
+	if err := os.Mkdir(pluginDir, 0755); err != nil {
+		t.Fatalf("cannot create plugin dir: %s", err)
+	}
+
+	files := map[string]string{
+		"main.yaml": `
+scrape_configs:
+- job_name: 'mysql'
+  static_configs:
+  - targets: ['127.0.0.1:1']
+  scrape_rule_files: ['rule.toml']
+`,
+		"rule.toml": "[global]\nuser = 'root'\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(pluginDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("cannot write %s: %s", name, err)
+		}
+	}
+
+	jobs, err := readFiles(dir)
+	if err != nil {
+		t.Fatalf("cannot read config: %s", err)
+	}
+	var j *JobGoroutine
+	for _, job := range jobs["mysql"] {
+		j = job
+	}
+	if j == nil {
+		t.Fatalf("expected the mysql job")
+	}
+
+	// the only slot is in use, the scrape of the job is queued
+	setMaxConcurrentScrapes(1)
+	defer setMaxConcurrentScrapes(0)
+	if !acquireScrapeSlot(context.Background(), nil, "mysql") {
+		t.Fatalf("expected to acquire a free slot")
+	}
+	defer releaseScrapeSlot()
+
+	type result struct{ total, failed int }
+	done := make(chan result)
+	go func() {
+		total, failed := j.run(context.Background())
+		done <- result{total, failed}
+	}()
+
+	select {
+	case <-done:
+		t.Fatalf("expected the scrape queued for the slot in use")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	j.Stop()
+	select {
+	case r := <-done:
+		if r.total != 1 || r.failed != 1 {
+			t.Fatalf("expected the queued target given up, got %d of %d failed", r.failed, r.total)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the stopped job to give up the queue")
+	}
+	scrapeSlots.Lock()
+	defer scrapeSlots.Unlock()
+	if scrapeSlots.inFlight != 1 {
+		t.Fatalf("expected the stopped job not to take a slot")
+	}
+}
@@ -0,0 +1,68 @@
+package probe
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// SampleFilterDropZero drops the samples whose value is zero
+const SampleFilterDropZero = "drop_zero"
+
+// SampleFilter drops the samples whose metric name matches Match and value meets Action, e.g. the Com_* counters
+// always zero on a workload: match: 'mysql_global_status_commands_total' action: 'drop_zero'.
+// Match is required so that the gauges where zero is meaningful, such as up, are never dropped by accident.
+type SampleFilter struct {
+	Match  string `yaml:"match"`
+	Action string `yaml:"action,omitempty"`
+
+	re *regexp.Regexp
+}
+
+func (f *SampleFilter) parse() error {
+	if f.Match == "" {
+		return fmt.Errorf("match is required")
+	}
+
+	switch f.Action {
+	case "":
+		f.Action = SampleFilterDropZero
+	case SampleFilterDropZero:
+	default:
+		return fmt.Errorf("unknown action %q of match %q, should be drop_zero", f.Action, f.Match)
+	}
+
+	re, err := regexp.Compile("^(?:" + f.Match + ")$")
+	if err != nil {
+		return fmt.Errorf("cannot parse match %q: %w", f.Match, err)
+	}
+	f.re = re
+	return nil
+}
+
+func parseSampleFilters(fs []*SampleFilter) error {
+	for i := range fs {
+		if err := fs[i].parse(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropSample reports whether any filter matching the metric name drops the sample
+func dropSample(fs []*SampleFilter, name string, value float64) bool {
+	for _, f := range fs {
+		if !f.re.MatchString(name) {
+			continue
+		}
+		if f.Action == SampleFilterDropZero && value == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func incSampleFilterDropped(jobName string) {
+	metrics.GetOrCreateCounter(fmt.Sprintf(`cprobe_sample_filter_dropped_total{job=%q}`, jobName)).Inc()
+}
@@ -0,0 +1,37 @@
+package probe
+
+import "testing"
+
+func TestSampleFilters(t *testing.T) {
+	fs := []*SampleFilter{{Match: "mysql_global_status_commands_total|mysql_perf_.*_total"}}
+	if err := parseSampleFilters(fs); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if fs[0].Action != SampleFilterDropZero {
+		t.Errorf("expected the default action drop_zero, got %q", fs[0].Action)
+	}
+
+	tests := []struct {
+		name    string
+		value   float64
+		dropped bool
+	}{
+		{"mysql_global_status_commands_total", 0, true},
+		{"mysql_global_status_commands_total", 3, false},
+		{"mysql_perf_schema_events_total", 0, true},
+		// the match is anchored, the zero gauges not matched are kept
+		{"mysql_global_status_commands_total_x", 0, false},
+		{"mysql_up", 0, false},
+	}
+	for _, test := range tests {
+		if got := dropSample(fs, test.name, test.value); got != test.dropped {
+			t.Errorf("dropSample(%s, %v) = %v, expected %v", test.name, test.value, got, test.dropped)
+		}
+	}
+
+	for _, f := range []*SampleFilter{{}, {Match: "mysql_.*", Action: "drop_nan"}, {Match: "("}} {
+		if err := f.parse(); err == nil {
+			t.Errorf("expected error of %+v", f)
+		}
+	}
+}
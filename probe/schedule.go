@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"math/rand"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/VictoriaMetrics/metrics"
 	"github.com/cprobe/cprobe/lib/conv"
 	"github.com/cprobe/cprobe/lib/envtemplate"
 	"github.com/cprobe/cprobe/lib/fs"
@@ -17,6 +21,7 @@ import (
 	"github.com/cprobe/cprobe/lib/promutils"
 	"github.com/cprobe/cprobe/plugins"
 	"github.com/cprobe/cprobe/types"
+	"github.com/cprobe/cprobe/types/metric"
 	"github.com/cprobe/cprobe/writer"
 	"gopkg.in/yaml.v2"
 )
@@ -35,6 +40,10 @@ type JobGoroutine struct {
 	scrapeConfig *ScrapeConfig
 	quitChan     chan struct{}
 	sync.RWMutex
+
+	// 上一次抓取的 target，target 消失之后清理它的自监控指标
+	targetsLock sync.Mutex
+	targets     map[jobTarget]struct{}
 }
 
 func NewJobGoroutine(plugin string, scrapeConfig *ScrapeConfig) *JobGoroutine {
@@ -48,6 +57,9 @@ func NewJobGoroutine(plugin string, scrapeConfig *ScrapeConfig) *JobGoroutine {
 func (j *JobGoroutine) UpdateConfig(scrapeConfig *ScrapeConfig) {
 	j.Lock()
 	defer j.Unlock()
+	if j.scrapeConfig != scrapeConfig {
+		stopSDConfigs(j.scrapeConfig)
+	}
 	j.scrapeConfig = scrapeConfig
 }
 
@@ -57,6 +69,12 @@ func (j *JobGoroutine) GetInterval() time.Duration {
 	return j.scrapeConfig.ScrapeInterval.Duration()
 }
 
+func (j *JobGoroutine) GetInitialDelay() time.Duration {
+	j.RLock()
+	defer j.RUnlock()
+	return j.scrapeConfig.InitialDelay.Duration()
+}
+
 func (j *JobGoroutine) GetJobName() string {
 	j.RLock()
 	defer j.RUnlock()
@@ -69,8 +87,21 @@ func (j *JobGoroutine) GetRuleFiles() []string {
 	return j.scrapeConfig.ScrapeRuleFiles
 }
 
+// firstScrapeDelay 是 initial_delay 加上 [0, interval) 的随机抖动，把各个 job 的首次抓取打散到一个抓取周期里，
+// 避免 cprobe 重启后所有 job 在同一时刻开始抓取。没有配置 initial_delay 时立即抓取，不加抖动
+func firstScrapeDelay(initialDelay, interval time.Duration) time.Duration {
+	if initialDelay <= 0 {
+		return 0
+	}
+	if interval <= 0 {
+		return initialDelay
+	}
+	return initialDelay + time.Duration(rand.Int63n(int64(interval)))
+}
+
 func (j *JobGoroutine) Start(ctx context.Context) {
-	timer := time.NewTimer(0)
+	// 首次抓取延迟 initial_delay 加上随机抖动，等待期间退出也能及时响应
+	timer := time.NewTimer(firstScrapeDelay(j.GetInitialDelay(), j.GetInterval()))
 	defer timer.Stop()
 
 	var start time.Time
@@ -96,61 +127,45 @@ func (j *JobGoroutine) Start(ctx context.Context) {
 // 不同的 job 其 targets 获取方式和列表可能是类似的，scrape_rules 也可能是一样的，所以这里可以缓存，缓存时间可以短一点，比如 5s
 // targets 可能很多，要做一下并发度控制，并发度可以在 job 粒度自定义，每个 yaml 的 global 部分也可以有一个全局的并发度配置
 // 通过 wait group 等待所有的 goroutine 抓取完毕，统一做 metric_relabel_configs，然后发送给 writer
-func (j *JobGoroutine) run(ctx context.Context) {
+// 返回抓取的 target 数量和失败的数量，rule 文件或插件有问题导致整个 job 无法抓取时，算作一个失败的 target
+func (j *JobGoroutine) run(ctx context.Context) (total, failed int) {
 	jobName := j.GetJobName()
 
 	// rule 文件都是 toml 格式，可以直接拼在一起，用户要自己保证正确性
 	// json 和 yaml 格式的文件，很难直接拼在一起，所以 rule 选择 toml 格式
+	// target 上有 __scrape_rule_files__ 标签的，使用标签里的 rule 文件，job 的 scrape_rule_files 作为兜底
 	ruleFiles := j.GetRuleFiles()
-	if len(ruleFiles) == 0 {
-		logger.Errorf("job(%s) has no rule files", jobName)
-		return
-	}
-
-	var bytesBuffer bytes.Buffer
-	var err error
-	for _, ruleFile := range ruleFiles {
-		ruleFilePath := fs.GetFilepath(j.scrapeConfig.ConfigRef.BaseDir, ruleFile)
-
-		data := CacheGetBytes(ruleFilePath)
-		if data == nil {
-			data, err = fs.ReadFileOrHTTP(ruleFilePath)
-			if err != nil {
-				logger.Errorf("job(%s) read rule file(%s) error: %s", jobName, ruleFile, err)
-				return
-			}
-
-			data, err = envtemplate.ReplaceBytes(data)
-			if err != nil {
-				logger.Errorf("job(%s) replace env in rule file(%s) error: %s", jobName, ruleFile, err)
-				return
-			}
-
-			CacheSetBytes(ruleFilePath, data, time.Second*5)
+	var tomlBytes []byte
+	if len(ruleFiles) > 0 {
+		var err error
+		tomlBytes, err = j.readRuleFiles(ruleFiles)
+		if err != nil {
+			logger.Errorf("job(%s) %s", jobName, err)
+			return 1, 1
 		}
-
-		bytesBuffer.Write(data)
-		bytesBuffer.Write([]byte("\n"))
-		bytesBuffer.Write([]byte("\n"))
 	}
 
-	tomlBytes := bytesBuffer.Bytes()
-
 	plugin, has := plugins.GetPlugin(j.plugin)
 	if !has {
 		logger.Errorf("job(%s) unknown plugin: %s", jobName, j.plugin)
-		return
+		return 1, 1
 	}
 
 	// 等待所有 target 抓取完毕的 wait group
 	var wg sync.WaitGroup
 
+	// 失败的 target 数量，各个 goroutine 并发累加
+	var failedTargets atomic.Int32
+
 	// 控制并发度的 channel，大量的 target 并发抓取的话可能会有问题，比如 icmp 的抓取，一次性启动太多，会导致 icmp 的抓取超时
 	var se = make(chan struct{}, j.scrapeConfig.ScrapeConcurrency)
 
 	// 拿到这个 job 相关的 targets
 	targets := j.getTargets()
 
+	// 本次抓取的 target，抓取结束之后和上一次的对比
+	current := make(map[jobTarget]struct{}, len(targets))
+
 	// 每个 target 分别去抓取数据，注意要控制并发度
 	for _, target := range targets {
 		parsedTarget := j.parseTarget(jobName, target)
@@ -158,6 +173,8 @@ func (j *JobGoroutine) run(ctx context.Context) {
 			continue
 		}
 
+		current[jobTarget{job: jobName, target: parsedTarget.Get("__address__")}] = struct{}{}
+		total++
 		se <- struct{}{}
 		wg.Add(1)
 		go func(pt *promutils.Labels) {
@@ -168,35 +185,85 @@ func (j *JobGoroutine) run(ctx context.Context) {
 
 			targetAddress := pt.Get("__address__")
 
+			targetTomlBytes := tomlBytes
+			if targetRuleFiles := parseRuleFilesLabel(pt.Get(ruleFilesLabel)); len(targetRuleFiles) > 0 {
+				var err error
+				targetTomlBytes, err = j.readRuleFiles(targetRuleFiles)
+				if err != nil {
+					logger.Errorf("job(%s) target(%s) %s", jobName, targetAddress, err)
+					failedTargets.Add(1)
+					return
+				}
+			}
+			if len(targetTomlBytes) == 0 {
+				logger.Errorf("job(%s) target(%s) has no rule files", jobName, targetAddress)
+				failedTargets.Add(1)
+				return
+			}
+
+			// 上一次抓取还没结束就跳过本次，避免慢实例上的抓取越堆越多
+			if !acquireTarget(j.plugin, jobName, targetAddress) {
+				logger.Warnf("skip scraping, previous scrape is still running. job: %s, plugin: %s, target: %s", jobName, j.plugin, targetAddress)
+				incScrapeSkipped(j.plugin, jobName, targetAddress)
+				return
+			}
+			defer releaseTarget(j.plugin, jobName, targetAddress)
+
+			// 所有 job 共享的并发上限，超出的抓取在这里排队，job 退出时放弃排队
+			if !acquireScrapeSlot(ctx, j.quitChan, j.plugin) {
+				logger.Warnf("skip scraping, job is stopped while waiting for the scrape queue. job: %s, plugin: %s, target: %s", jobName, j.plugin, targetAddress)
+				failedTargets.Add(1)
+				return
+			}
+			defer releaseScrapeSlot()
+
+			// __server_label__ 是 target 级别的，插件用它代替 target 地址作为 instance 标签和日志里的 target
+			scrapeCtx := ctx
+			if serverLabel := pt.Get(serverLabelLabel); serverLabel != "" {
+				scrapeCtx = plugins.WithServerLabel(ctx, serverLabel)
+			}
+
 			// 准备一个并发安全的容器，传给 Scrape 方法，Scrape 方法会把抓取到的数据放进去，外层还要做 relabel 然后最终发给 writer
 			ss := types.NewSamples()
 
 			// 每个 target 分别 ParseConfig，对性能有一丢丢影响，好处是插件里就可以放心大胆的更新 config 了，不用担心并发安全问题
 			// 后面再看看是否有更好的提升性能的办法
-			config, err := plugin.ParseConfig(j.scrapeConfig.ConfigRef.BaseDir, tomlBytes)
+			config, err := plugin.ParseConfig(j.scrapeConfig.ConfigRef.BaseDir, targetTomlBytes)
 			if err != nil {
 				logger.Errorf("job(%s) parse plugin config error: %s", jobName, err)
+				failedTargets.Add(1)
 				return
 			}
 
 			now := time.Now()
-			if err = plugin.Scrape(ctx, targetAddress, config, ss); err != nil {
+			if err = plugin.Scrape(scrapeCtx, targetAddress, config, ss); err != nil {
 				logger.Errorf("failed to scrape. job: %s, plugin: %s, target: %s, error: %s", jobName, j.plugin, targetAddress, err)
 			}
 
-			ss.AddMetric(j.plugin, map[string]interface{}{"scrape_duration_seconds": time.Since(now).Seconds()})
+			duration := time.Since(now).Seconds()
+			ss.AddMetric(j.plugin, map[string]interface{}{"scrape_duration_seconds": duration})
+
+			// 自监控指标，所有 target 的抓取耗时分布，用于观察整体的 p99 之类的，暴露在 cprobe 自身的 /metrics 接口
+			metrics.GetOrCreateHistogram(fmt.Sprintf(`cprobe_scrape_duration_seconds{plugin=%q}`, j.plugin)).Update(duration)
 
 			if err != nil {
+				failedTargets.Add(1)
 				ss.AddMetric(j.plugin, map[string]interface{}{"up": 0.0})
 				ss.AddMetric(j.plugin, map[string]interface{}{"scrape_error": 1.0}, map[string]string{"error": err.Error()})
 			} else {
 				ss.AddMetric(j.plugin, map[string]interface{}{"up": 1.0})
+				setLastSuccess(j.plugin, jobName, targetAddress, time.Now())
 				ss.AddMetric(j.plugin, map[string]interface{}{"scrape_error": 0.0}, map[string]string{"error": "null"})
 			}
 
 			// 把抓取到的数据做格式转换，转换成 []prompbmarshal.TimeSeries
 			metrics := ss.PopBackAll()
 
+			// 敏感标签脱敏，在 relabel 之前做，这样 metric_relabel_configs 看到的也是脱敏后的值
+			if len(j.scrapeConfig.LabelMasks) > 0 {
+				applyLabelMasks(j.scrapeConfig.LabelMasks, metrics)
+			}
+
 			// 最终转换之后的数据结果集
 			var ret []prompbmarshal.TimeSeries
 
@@ -219,30 +286,40 @@ func (j *JobGoroutine) run(ctx context.Context) {
 
 					item := promutils.NewLabels(len(tags) + pt.Len())
 
-					for _, lb := range pt.GetLabels() {
-						if lb.Name == "__address__" {
-							continue
-						}
-						item.Add(lb.Name, lb.Value)
-					}
+					addTargetLabels(item, pt)
 
 					for tagk, tagv := range tags {
 						item.Add(tagk, tagv)
 					}
 
-					if len(k) == 0 {
-						item.Add("__name__", metrics[i].Name())
-					} else {
-						name := metrics[i].Name()
+					name := metrics[i].Name()
+					if len(k) != 0 {
 						if len(name) == 0 {
-							item.Add("__name__", k)
+							name = k
 						} else {
-							item.Add("__name__", name+"_"+k)
+							name = name + "_" + k
 						}
 					}
+					item.Add("__name__", name)
 
 					item.RemoveDuplicates()
 
+					if len(j.scrapeConfig.SampleTransforms) > 0 {
+						var applied, ok bool
+						if float64v, applied, ok = applySampleTransforms(j.scrapeConfig.SampleTransforms, name, float64v); !ok {
+							incSampleTransformDropped(jobName)
+							continue
+						}
+						if applied {
+							incSampleTransformApplied(jobName)
+						}
+					}
+
+					if len(j.scrapeConfig.SampleFilters) > 0 && dropSample(j.scrapeConfig.SampleFilters, name, float64v) {
+						incSampleFilterDropped(jobName)
+						continue
+					}
+
 					// metric relabel
 					item.Labels = j.scrapeConfig.ParsedMetricRelabelConfigs.Apply(item.Labels, 0)
 					item.RemoveMetaLabels()
@@ -257,16 +334,174 @@ func (j *JobGoroutine) run(ctx context.Context) {
 						Samples: []prompbmarshal.Sample{point},
 					}
 
+					if e := metrics[i].Exemplar(); e != nil {
+						ts.Exemplars = []prompbmarshal.Exemplar{convertExemplar(e, now.UnixMilli())}
+					}
+
 					ret = append(ret, ts)
 				}
 			}
 
-			writer.WriteTimeSeries(ret)
+			// 统计最终发出去的数据量，在所有的脱敏、变换、过滤、relabel 之后，用于排查哪些 target 的 series 过多
+			ret = append(ret, j.scrapedSizeSeries(pt, targetAddress, ret, now.UnixMilli())...)
+
+			cacheScrapeResult(jobName, targetAddress, ret, j.GetInterval(), err != nil)
+			publishSamples(jobName, targetAddress, ret, now.UnixMilli())
+			writer.WriteTextfile(j.plugin, jobName, targetAddress, ret)
+			writer.WriteTimeSeries(ret, j.targetWriters(pt)...)
 
 		}(parsedTarget)
 	}
 
 	wg.Wait()
+
+	j.forgetGoneTargets(current)
+
+	return total, int(failedTargets.Load())
+}
+
+// forgetGoneTargets 清理上一次抓取过、这一次没有了的 target 的自监控指标，current 为 nil 表示全部清理
+func (j *JobGoroutine) forgetGoneTargets(current map[jobTarget]struct{}) {
+	j.targetsLock.Lock()
+	defer j.targetsLock.Unlock()
+
+	for t := range j.targets {
+		if _, ok := current[t]; !ok {
+			forgetTarget(j.plugin, t.job, t.target)
+		}
+	}
+	j.targets = current
+}
+
+// scrapedSizeSeries 返回 <plugin>_exporter_samples_scraped 和 <plugin>_exporter_samples_scraped_bytes 两个 series，
+// 分别是 ret 的 series 数量和 remote write 编码后的大小，target 标签优先使用 __server_label__
+func (j *JobGoroutine) scrapedSizeSeries(pt *promutils.Labels, targetAddress string, ret []prompbmarshal.TimeSeries, timestamp int64) []prompbmarshal.TimeSeries {
+	var bytes int
+	for i := range ret {
+		bytes += ret[i].Size()
+	}
+
+	target := targetAddress
+	serverLabel := pt.Get(serverLabelLabel)
+	if serverLabel != "" {
+		target = serverLabel
+	}
+
+	newSeries := func(name string, value float64) prompbmarshal.TimeSeries {
+		item := promutils.NewLabels(pt.Len() + 3)
+		addTargetLabels(item, pt)
+		if serverLabel != "" {
+			item.Add("instance", serverLabel)
+		}
+		item.Add("target", target)
+		item.Add("__name__", name)
+		item.RemoveDuplicates()
+		item.RemoveMetaLabels()
+
+		return prompbmarshal.TimeSeries{
+			Labels:  item.Labels,
+			Samples: []prompbmarshal.Sample{{Value: value, Timestamp: timestamp}},
+		}
+	}
+
+	return []prompbmarshal.TimeSeries{
+		newSeries(j.plugin+"_exporter_samples_scraped", float64(len(ret))),
+		newSeries(j.plugin+"_exporter_samples_scraped_bytes", float64(bytes)),
+	}
+}
+
+// ruleFilesLabel 可以通过 relabel_configs 从服务发现的元信息里设置，比如 consul 的 service meta，
+// 值是逗号分隔的 rule 文件列表，相对路径基于 BaseDir，用于给不同的 target 设置不同的认证信息和采集配置
+const ruleFilesLabel = "__scrape_rule_files__"
+
+func parseRuleFilesLabel(value string) []string {
+	var ruleFiles []string
+	for _, f := range strings.Split(value, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			ruleFiles = append(ruleFiles, f)
+		}
+	}
+	return ruleFiles
+}
+
+// writersLabel 和 ruleFilesLabel 类似，值是逗号分隔的 writer name 列表，指定 target 的数据发给哪些 writer，
+// 解析方式和 ruleFilesLabel 相同
+const writersLabel = "__writers__"
+
+// serverLabelLabel 可以通过 relabel_configs 给每个 target 单独设置，比如通过 proxy 或者 VIP 抓取时设置成实例的名字，
+// 插件用它代替 target 地址作为 instance 标签和日志里的 target，见 plugins.ServerLabel
+const serverLabelLabel = "__server_label__"
+
+// targetWriters 返回 target 的数据要发给的 writer，优先级：__writers__ 标签 > job 的 writers > global 的 writers，
+// 都没有配置就返回空，发给所有 writer
+func (j *JobGoroutine) targetWriters(pt *promutils.Labels) []string {
+	if writers := parseRuleFilesLabel(pt.Get(writersLabel)); len(writers) > 0 {
+		return writers
+	}
+	if len(j.scrapeConfig.Writers) > 0 {
+		return j.scrapeConfig.Writers
+	}
+	return j.scrapeConfig.ConfigRef.Global.Writers
+}
+
+// readRuleFiles 把 rule 文件拼在一起，读取结果缓存 5s，不同的 job 和 target 共用
+func (j *JobGoroutine) readRuleFiles(ruleFiles []string) ([]byte, error) {
+	var bytesBuffer bytes.Buffer
+	for _, ruleFile := range ruleFiles {
+		ruleFilePath := fs.GetFilepath(j.scrapeConfig.ConfigRef.BaseDir, ruleFile)
+
+		data := CacheGetBytes(ruleFilePath)
+		if data == nil {
+			var err error
+			data, err = fs.ReadFileOrHTTP(ruleFilePath)
+			if err != nil {
+				return nil, fmt.Errorf("read rule file(%s) error: %s", ruleFile, err)
+			}
+
+			data, err = envtemplate.ReplaceBytes(data)
+			if err != nil {
+				return nil, fmt.Errorf("replace env in rule file(%s) error: %s", ruleFile, err)
+			}
+
+			CacheSetBytes(ruleFilePath, data, time.Second*5)
+		}
+
+		bytesBuffer.Write(data)
+		bytesBuffer.Write([]byte("\n"))
+		bytesBuffer.Write([]byte("\n"))
+	}
+	return bytesBuffer.Bytes(), nil
+}
+
+// convertExemplar 把 metric.Exemplar 转换成 remote write 协议的格式，没有时间戳的使用抓取时间
+func convertExemplar(e *metric.Exemplar, defaultTimestamp int64) prompbmarshal.Exemplar {
+	labels := make([]prompbmarshal.Label, 0, len(e.Labels))
+	for k, v := range e.Labels {
+		labels = append(labels, prompbmarshal.Label{Name: k, Value: v})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+	ts := e.Timestamp
+	if ts == 0 {
+		ts = defaultTimestamp
+	}
+
+	return prompbmarshal.Exemplar{
+		Labels:    labels,
+		Value:     e.Value,
+		Timestamp: ts,
+	}
+}
+
+// addTargetLabels 把 target 的标签加到 item 上，__ 开头的是内部标签（__address__、__writers__ 等），
+// 和 Prometheus 一样不会出现在采集到的数据里
+func addTargetLabels(item *promutils.Labels, pt *promutils.Labels) {
+	for _, lb := range pt.GetLabels() {
+		if strings.HasPrefix(lb.Name, "__") {
+			continue
+		}
+		item.Add(lb.Name, lb.Value)
+	}
 }
 
 func (j *JobGoroutine) parseTarget(job string, target *promutils.Labels) *promutils.Labels {
@@ -308,6 +543,22 @@ func (j *JobGoroutine) parseTarget(job string, target *promutils.Labels) *promut
 
 func (j *JobGoroutine) Stop() {
 	close(j.quitChan)
+
+	j.forgetGoneTargets(nil)
+
+	j.RLock()
+	defer j.RUnlock()
+	stopSDConfigs(j.scrapeConfig)
+}
+
+// stopSDConfigs 释放 scrapeConfig 的服务发现缓存的 api client，job 停止或者配置被替换时调用
+func stopSDConfigs(scrapeConfig *ScrapeConfig) {
+	for i := range scrapeConfig.ConsulSDConfigs {
+		scrapeConfig.ConsulSDConfigs[i].MustStop()
+	}
+	for i := range scrapeConfig.KubernetesSDConfigs {
+		scrapeConfig.KubernetesSDConfigs[i].MustStop()
+	}
 }
 
 func loadStaticConfigs(path string) ([]StaticConfig, error) {
@@ -329,57 +580,9 @@ func loadStaticConfigs(path string) ([]StaticConfig, error) {
 func (j *JobGoroutine) getTargets() (targets []*promutils.Labels) {
 	baseDir := j.scrapeConfig.ConfigRef.BaseDir
 
-	for _, c := range j.scrapeConfig.StaticConfigs {
-		for _, t := range c.Targets {
-			m := promutils.NewLabels(1 + c.Labels.Len())
-			m.AddFrom(c.Labels)
-			m.Add("__address__", t)
-			m.RemoveDuplicates()
-			targets = append(targets, m)
-		}
-	}
-
-	for _, c := range j.scrapeConfig.FileSDConfigs {
-		for _, file := range c.Files {
-			pathPattern := fs.GetFilepath(baseDir, file)
-			paths := []string{pathPattern}
-			if strings.Contains(pathPattern, "*") {
-				var err error
-				paths, err = filepath.Glob(pathPattern)
-				if err != nil {
-					// Do not return this error, since other files may contain valid scrape configs.
-					logger.Errorf("skipping entry %q in `file_sd_config->files` for job_name=%s because of error: %s", file, j.scrapeConfig.JobName, err)
-					continue
-				}
-			}
-			for _, path := range paths {
-				stcs, err := loadStaticConfigs(path)
-				if err != nil {
-					// Do not return this error, since other paths may contain valid scrape configs.
-					logger.Errorf("skipping file %s for job_name=%s at `file_sd_configs` because of error: %s", path, j.scrapeConfig.JobName, err)
-					continue
-				}
-
-				pathShort := path
-				if strings.HasPrefix(pathShort, baseDir) {
-					pathShort = path[len(baseDir):]
-					if len(pathShort) > 0 && pathShort[0] == filepath.Separator {
-						pathShort = pathShort[1:]
-					}
-				}
-
-				for _, stc := range stcs {
-					for _, t := range stc.Targets {
-						m := promutils.NewLabels(2 + stc.Labels.Len())
-						m.AddFrom(stc.Labels)
-						m.Add("__address__", t)
-						m.Add("__meta_filepath", pathShort)
-						m.RemoveDuplicates()
-						targets = append(targets, m)
-					}
-				}
-			}
-		}
+	targets, errs := j.getLocalTargets()
+	for _, err := range errs {
+		logger.Errorf("%s", err)
 	}
 
 	for _, c := range j.scrapeConfig.HTTPSDConfigs {
@@ -438,6 +641,27 @@ func (j *JobGoroutine) getTargets() (targets []*promutils.Labels) {
 		targets = append(targets, arr...)
 	}
 
+	// consul 和 kubernetes 的 api client 按 SDConfig 的指针缓存，所以要按下标取元素的地址，不能取循环变量的地址
+	for i := range j.scrapeConfig.ConsulSDConfigs {
+		sdc := &j.scrapeConfig.ConsulSDConfigs[i]
+		arr, err := sdc.GetLabels(baseDir)
+		if err != nil {
+			logger.Errorf("job(%s) consul_sd_configs(%s) get targets error: %s", j.scrapeConfig.JobName, sdc.Server, err)
+			continue
+		}
+		targets = append(targets, arr...)
+	}
+
+	for i := range j.scrapeConfig.KubernetesSDConfigs {
+		sdc := &j.scrapeConfig.KubernetesSDConfigs[i]
+		arr, err := sdc.GetLabels(baseDir)
+		if err != nil {
+			logger.Errorf("job(%s) kubernetes_sd_configs(%s) get targets error: %s", j.scrapeConfig.JobName, sdc.APIServer, err)
+			continue
+		}
+		targets = append(targets, arr...)
+	}
+
 	for _, c := range j.scrapeConfig.EurekaSDConfigs {
 		arr, err := c.GetLabels(baseDir)
 		if err != nil {
@@ -485,3 +709,64 @@ func (j *JobGoroutine) getTargets() (targets []*promutils.Labels) {
 
 	return
 }
+
+// getLocalTargets returns the targets of static_configs and file_sd_configs, no remote service discovery is involved,
+// the broken file_sd_configs entries are skipped and returned as errs
+func (j *JobGoroutine) getLocalTargets() (targets []*promutils.Labels, errs []error) {
+	baseDir := j.scrapeConfig.ConfigRef.BaseDir
+
+	for _, c := range j.scrapeConfig.StaticConfigs {
+		for _, t := range c.Targets {
+			m := promutils.NewLabels(1 + c.Labels.Len())
+			m.AddFrom(c.Labels)
+			m.Add("__address__", t)
+			m.RemoveDuplicates()
+			targets = append(targets, m)
+		}
+	}
+
+	for _, c := range j.scrapeConfig.FileSDConfigs {
+		for _, file := range c.Files {
+			pathPattern := fs.GetFilepath(baseDir, file)
+			paths := []string{pathPattern}
+			if strings.Contains(pathPattern, "*") {
+				var err error
+				paths, err = filepath.Glob(pathPattern)
+				if err != nil {
+					// Do not return this error, since other files may contain valid scrape configs.
+					errs = append(errs, fmt.Errorf("skipping entry %q in `file_sd_config->files` for job_name=%s because of error: %s", file, j.scrapeConfig.JobName, err))
+					continue
+				}
+			}
+			for _, path := range paths {
+				stcs, err := loadStaticConfigs(path)
+				if err != nil {
+					// Do not return this error, since other paths may contain valid scrape configs.
+					errs = append(errs, fmt.Errorf("skipping file %s for job_name=%s at `file_sd_configs` because of error: %s", path, j.scrapeConfig.JobName, err))
+					continue
+				}
+
+				pathShort := path
+				if strings.HasPrefix(pathShort, baseDir) {
+					pathShort = path[len(baseDir):]
+					if len(pathShort) > 0 && pathShort[0] == filepath.Separator {
+						pathShort = pathShort[1:]
+					}
+				}
+
+				for _, stc := range stcs {
+					for _, t := range stc.Targets {
+						m := promutils.NewLabels(2 + stc.Labels.Len())
+						m.AddFrom(stc.Labels)
+						m.Add("__address__", t)
+						m.Add("__meta_filepath", pathShort)
+						m.RemoveDuplicates()
+						targets = append(targets, m)
+					}
+				}
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,156 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	nethttp "net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/cprobe/cprobe/discovery/consul"
+	"github.com/cprobe/cprobe/lib/prompbmarshal"
+	"github.com/cprobe/cprobe/lib/promutils"
+)
+
+func TestParseRuleFilesLabel(t *testing.T) {
+	f := func(value string, expected []string) {
+		t.Helper()
+		if got := parseRuleFilesLabel(value); !reflect.DeepEqual(got, expected) {
+			t.Fatalf("unexpected rule files for %q; got %v; want %v", value, got, expected)
+		}
+	}
+	f("", nil)
+	f(" , ", nil)
+	f("rule_head.toml", []string{"rule_head.toml"})
+	f("rule_head.toml, rule_coll.toml,", []string{"rule_head.toml", "rule_coll.toml"})
+}
+
+func TestGetTargetsMultipleConsulSDConfigs(t *testing.T) {
+	newConsul := func(service, addr string) *httptest.Server {
+		return httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			switch r.URL.Path {
+			case "/v1/catalog/services":
+				fmt.Fprintf(w, `{%q: []}`, service)
+			case "/v1/health/service/" + service:
+				fmt.Fprintf(w, `[{"Node": {"Node": "n1", "Address": %q}, "Service": {"ID": %q, "Service": %q, "Port": 3306}}]`, addr, service, service)
+			default:
+				nethttp.NotFound(w, r)
+			}
+		}))
+	}
+	s1 := newConsul("mysql-a", "10.0.0.1")
+	defer s1.Close()
+	s2 := newConsul("mysql-b", "10.0.0.2")
+	defer s2.Close()
+
+	sc := &ScrapeConfig{
+		ConfigRef: &Config{},
+		JobName:   "mysql",
+		ConsulSDConfigs: []consul.SDConfig{
+			{Server: s1.URL, Datacenter: "dc1"},
+			{Server: s2.URL, Datacenter: "dc2"},
+		},
+	}
+	j := NewJobGoroutine("mysql", sc)
+
+	entries := metrics.GetOrCreateCounter(`vm_promscrape_discoveryutils_configmap_entries_count`)
+	before := entries.Get()
+
+	// 多次发现复用同一批 api client，不会每次都新建
+	for i := 0; i < 3; i++ {
+		var got []string
+		for _, target := range j.getTargets() {
+			got = append(got, target.Get("__meta_consul_dc")+"/"+target.Get("__address__"))
+		}
+		sort.Strings(got)
+		expected := []string{"dc1/10.0.0.1:3306", "dc2/10.0.0.2:3306"}
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("unexpected targets; got %v; want %v", got, expected)
+		}
+	}
+	if n := entries.Get() - before; n != 2 {
+		t.Fatalf("expected 2 cached api configs, got %d", n)
+	}
+
+	j.Stop()
+	if n := entries.Get() - before; n != 0 {
+		t.Fatalf("expected the api configs released by Stop, got %d left", n)
+	}
+}
+
+func TestScrapedSizeSeries(t *testing.T) {
+	j := NewJobGoroutine("mysql", &ScrapeConfig{JobName: "mysql"})
+	pt := promutils.NewLabelsFromMap(map[string]string{
+		"__address__":      "10.0.0.1:3306",
+		"__server_label__": "db1",
+		"__writers__":      "default",
+		"__internal__":     "x",
+		"job":              "mysql",
+		"instance":         "10.0.0.1:3306",
+	})
+	ret := []prompbmarshal.TimeSeries{
+		{Labels: []prompbmarshal.Label{{Name: "__name__", Value: "mysql_up"}}, Samples: []prompbmarshal.Sample{{Value: 1}}},
+		{Labels: []prompbmarshal.Label{{Name: "__name__", Value: "mysql_global_status_threads_connected"}}, Samples: []prompbmarshal.Sample{{Value: 3}}},
+	}
+
+	series := j.scrapedSizeSeries(pt, "10.0.0.1:3306", ret, 1000)
+	if len(series) != 2 {
+		t.Fatalf("expected 2 series, got %d", len(series))
+	}
+
+	expected := []prompbmarshal.Label{
+		{Name: "__name__", Value: "mysql_exporter_samples_scraped"},
+		{Name: "instance", Value: "db1"},
+		{Name: "job", Value: "mysql"},
+		{Name: "target", Value: "db1"},
+	}
+	if !reflect.DeepEqual(series[0].Labels, expected) {
+		t.Fatalf("unexpected labels; got %v; want %v", series[0].Labels, expected)
+	}
+	if v := series[0].Samples[0].Value; v != 2 {
+		t.Fatalf("expected 2 samples scraped, got %v", v)
+	}
+	if v := series[1].Samples[0].Value; v != float64(ret[0].Size()+ret[1].Size()) {
+		t.Fatalf("expected the encoded size of the series, got %v", v)
+	}
+}
+
+func TestFirstScrapeDelay(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := firstScrapeDelay(30*time.Second, 15*time.Second)
+		if d < 30*time.Second || d >= 45*time.Second {
+			t.Fatalf("expected the delay within [30s, 45s), got %s", d)
+		}
+	}
+	if d := firstScrapeDelay(time.Second, 0); d != time.Second {
+		t.Fatalf("expected no jitter without an interval, got %s", d)
+	}
+	if d := firstScrapeDelay(0, 5*time.Minute); d != 0 {
+		t.Fatalf("expected the first scrape at once without initial_delay, got %s", d)
+	}
+}
+
+func TestStartStopsDuringInitialDelay(t *testing.T) {
+	j := NewJobGoroutine("mysql", &ScrapeConfig{
+		JobName:        "mysql",
+		ScrapeInterval: promutils.NewDuration(time.Hour),
+		InitialDelay:   promutils.NewDuration(time.Hour),
+	})
+
+	done := make(chan struct{})
+	go func() {
+		j.Start(context.Background())
+		close(done)
+	}()
+
+	j.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the stopped job not to wait for the initial delay")
+	}
+}
@@ -0,0 +1,140 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cprobe/cprobe/plugins"
+	"github.com/cprobe/cprobe/types"
+)
+
+// StartupProbe connects to every static and file_sd target once, concurrently and within timeout, and prints
+// the status of every target to w. It fails if the ratio of the failed targets is greater than maxFailedRatio,
+// so that the wrong credentials or hosts block the rollout instead of slipping by as up=0 later.
+// The plugins implementing plugins.TargetProber only connect, the others are scraped once.
+// The concurrency is limited like the scheduled scrapes, by the scrape_concurrency of every job and max_concurrent_scrapes.
+func StartupProbe(configDirectory string, w io.Writer, maxFailedRatio float64, timeout time.Duration) error {
+	pluginDirs, err := listPlugins(configDirectory)
+	if err != nil {
+		return err
+	}
+
+	// 先解析所有的 job 和 target，max_concurrent_scrapes 要在所有配置都加载之后才知道
+	type probeJob struct {
+		plugin  plugins.Plugin
+		job     *JobGoroutine
+		targets []localTarget
+	}
+	var probeJobs []probeJob
+	jobs := makeJobs()
+
+	for _, pluginDir := range pluginDirs {
+		plugin, has := plugins.GetPlugin(pluginDir)
+		if _, supported := Jobs[pluginDir]; !has || !supported {
+			continue
+		}
+
+		entryYamlFilePaths, err := filepath.Glob(filepath.Join(configDirectory, pluginDir, "main*.yaml"))
+		if err != nil {
+			return fmt.Errorf("cannot glob main*.yaml under %s: %s", pluginDir, err)
+		}
+
+		for _, entryYamlFilePath := range entryYamlFilePaths {
+			cfg, err := loadConfig(entryYamlFilePath)
+			if err != nil {
+				return err
+			}
+
+			for _, sc := range cfg.ScrapeConfigs {
+				if sc == nil {
+					continue
+				}
+
+				j := NewJobGoroutine(pluginDir, sc)
+				targets, errs := resolveLocalTargets(j, plugin)
+				if len(errs) > 0 {
+					return fmt.Errorf("job(%s) %s", sc.JobName, errs[0])
+				}
+
+				jobs[pluginDir][JobID{YamlFile: entryYamlFilePath, JobName: sc.JobName}] = j
+				probeJobs = append(probeJobs, probeJob{plugin: plugin, job: j, targets: targets})
+			}
+		}
+	}
+
+	setMaxConcurrentScrapes(maxConcurrentScrapesOf(jobs))
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var (
+		lock          sync.Mutex
+		wg            sync.WaitGroup
+		total, failed int
+	)
+	report := func(plugin, job, target string, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		total++
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "  FAIL %s job(%s) target(%s): %s\n", plugin, job, target, err)
+			return
+		}
+		fmt.Fprintf(w, "  OK   %s job(%s) target(%s)\n", plugin, job, target)
+	}
+
+	for _, pj := range probeJobs {
+		// 和 JobGoroutine.run 一样按照 job 的 scrape_concurrency 控制并发度，避免一次性连接所有的 target
+		se := make(chan struct{}, pj.job.scrapeConfig.ScrapeConcurrency)
+		for _, target := range pj.targets {
+			wg.Add(1)
+			go func(pj probeJob, target localTarget) {
+				defer wg.Done()
+
+				err := target.err
+				if err == nil {
+					err = probeTargetLimited(ctx, se, pj.job.plugin, pj.plugin, target)
+				}
+				report(pj.job.plugin, pj.job.scrapeConfig.JobName, target.address, err)
+			}(pj, target)
+		}
+	}
+
+	wg.Wait()
+
+	fmt.Fprintf(w, "\n%d targets probed, %d failed\n", total, failed)
+	if total > 0 && float64(failed)/float64(total) > maxFailedRatio {
+		return fmt.Errorf("%d of %d targets failed, more than the ratio %g allowed", failed, total, maxFailedRatio)
+	}
+	return nil
+}
+
+// probeTargetLimited waits for a slot of the job in se and a global scrape slot, the target is failed
+// if the timeout of the startup probe is reached while waiting
+func probeTargetLimited(ctx context.Context, se chan struct{}, pluginName string, plugin plugins.Plugin, target localTarget) error {
+	select {
+	case se <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-se }()
+
+	if !acquireScrapeSlot(ctx, nil, pluginName) {
+		return ctx.Err()
+	}
+	defer releaseScrapeSlot()
+
+	return probeTarget(ctx, plugin, target)
+}
+
+func probeTarget(ctx context.Context, plugin plugins.Plugin, target localTarget) error {
+	if prober, ok := plugin.(plugins.TargetProber); ok {
+		return prober.ProbeTarget(ctx, target.address, target.config)
+	}
+	return plugin.Scrape(ctx, target.address, target.config, types.NewSamples())
+}
@@ -0,0 +1,69 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartupProbe(t *testing.T) {
+	dir := t.TempDir()
+	pluginDir := filepath.Join(dir, "mysql")
+	if err := os.Mkdir(pluginDir, 0755); err != nil {
+		t.Fatalf("cannot create plugin dir: %s", err)
+	}
+
+	files := map[string]string{
+		"main.yaml": `
+scrape_configs:
+- job_name: 'mysql'
+  static_configs:
+  - targets: ['127.0.0.1:1']
+  scrape_rule_files: ['rule.toml']
+`,
+		"rule.toml": "[global]\nuser = 'root'\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(pluginDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("cannot write %s: %s", name, err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := StartupProbe(dir, &out, 0, 5*time.Second); err == nil {
+		t.Fatalf("expected the unreachable target to fail, got output:\n%s", out.String())
+	}
+	for _, want := range []string{
+		"FAIL mysql job(mysql) target(127.0.0.1:1)",
+		"1 targets probed, 1 failed",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := StartupProbe(dir, &out, 1, 5*time.Second); err != nil {
+		t.Fatalf("expected the failure to be allowed by the ratio, got %s", err)
+	}
+}
+
+func TestProbeTargetLimited(t *testing.T) {
+	// the only slot of the job is in use, the target waits and fails at the timeout without connecting
+	se := make(chan struct{}, 1)
+	se <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := probeTargetLimited(ctx, se, "mysql", nil, localTarget{address: "127.0.0.1:1"}); err != context.DeadlineExceeded {
+		t.Fatalf("expected the probe to time out waiting for the slot, got %v", err)
+	}
+	if len(se) != 1 {
+		t.Fatalf("expected the slot in use untouched")
+	}
+}
@@ -0,0 +1,116 @@
+package probe
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/cprobe/cprobe/lib/prompbmarshal"
+)
+
+// the messages are dropped for a subscriber whose buffer is full, the scrapes are never blocked by a slow client
+const streamBufferSize = 16
+
+// StreamMessage is the samples of one scrape of a target sent to the subscribers in JSON
+type StreamMessage struct {
+	Job       string         `json:"job"`
+	Target    string         `json:"target"`
+	Timestamp int64          `json:"timestamp"`
+	Samples   []StreamSample `json:"samples"`
+}
+
+// StreamSample is a sample of StreamMessage, the value is a string like the Prometheus API, NaN and Inf are not valid JSON numbers
+type StreamSample struct {
+	Labels    map[string]string `json:"labels"`
+	Value     string            `json:"value"`
+	Timestamp int64             `json:"timestamp"`
+}
+
+type streamSubscriber struct {
+	job    string
+	target string
+	ch     chan []byte
+}
+
+var (
+	streamLock        sync.RWMutex
+	streamSubscribers = make(map[*streamSubscriber]struct{})
+)
+
+func init() {
+	metrics.GetOrCreateGauge("cprobe_stream_subscribers", func() float64 {
+		streamLock.RLock()
+		defer streamLock.RUnlock()
+		return float64(len(streamSubscribers))
+	})
+}
+
+// SubscribeSamples returns the channel of the JSON encoded StreamMessage of every scrape of the target,
+// of all the jobs if job is blank. The returned func must be called to unsubscribe.
+func SubscribeSamples(job, target string) (<-chan []byte, func()) {
+	s := &streamSubscriber{job: job, target: target, ch: make(chan []byte, streamBufferSize)}
+
+	streamLock.Lock()
+	streamSubscribers[s] = struct{}{}
+	streamLock.Unlock()
+
+	return s.ch, func() {
+		streamLock.Lock()
+		delete(streamSubscribers, s)
+		streamLock.Unlock()
+	}
+}
+
+// publishSamples sends the samples to the subscribers of the target, it is encoded only if there are subscribers
+// and before the writer relabels them in place
+func publishSamples(job, target string, tss []prompbmarshal.TimeSeries, timestamp int64) {
+	streamLock.RLock()
+	defer streamLock.RUnlock()
+
+	var msg []byte
+	for s := range streamSubscribers {
+		if s.target != target || (s.job != "" && s.job != job) {
+			continue
+		}
+
+		if msg == nil {
+			var err error
+			if msg, err = encodeStreamMessage(job, target, tss, timestamp); err != nil {
+				return
+			}
+		}
+
+		select {
+		case s.ch <- msg:
+		default:
+			metrics.GetOrCreateCounter(fmt.Sprintf(`cprobe_stream_messages_dropped_total{job=%q}`, job)).Inc()
+		}
+	}
+}
+
+func encodeStreamMessage(job, target string, tss []prompbmarshal.TimeSeries, timestamp int64) ([]byte, error) {
+	m := StreamMessage{
+		Job:       job,
+		Target:    target,
+		Timestamp: timestamp,
+		Samples:   make([]StreamSample, 0, len(tss)),
+	}
+
+	for i := range tss {
+		labels := make(map[string]string, len(tss[i].Labels))
+		for _, label := range tss[i].Labels {
+			labels[label.Name] = label.Value
+		}
+		for _, sample := range tss[i].Samples {
+			m.Samples = append(m.Samples, StreamSample{
+				Labels:    labels,
+				Value:     strconv.FormatFloat(sample.Value, 'g', -1, 64),
+				Timestamp: sample.Timestamp,
+			})
+		}
+	}
+
+	return json.Marshal(m)
+}
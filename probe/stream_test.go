@@ -0,0 +1,63 @@
+package probe
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/cprobe/cprobe/lib/prompbmarshal"
+)
+
+func TestPublishSamples(t *testing.T) {
+	ch, unsubscribe := SubscribeSamples("", "127.0.0.1:3306")
+	other, unsubscribeOther := SubscribeSamples("redis", "127.0.0.1:3306")
+	defer unsubscribeOther()
+
+	tss := []prompbmarshal.TimeSeries{
+		{
+			Labels: []prompbmarshal.Label{
+				{Name: "__name__", Value: "mysql_up"},
+				{Name: "instance", Value: "db1"},
+			},
+			Samples: []prompbmarshal.Sample{{Value: 1, Timestamp: 1000}},
+		},
+		{
+			Labels:  []prompbmarshal.Label{{Name: "__name__", Value: "mysql_stale"}},
+			Samples: []prompbmarshal.Sample{{Value: math.NaN(), Timestamp: 1000}},
+		},
+	}
+	publishSamples("mysql", "127.0.0.1:3306", tss, 1000)
+	publishSamples("mysql", "127.0.0.1:3307", tss, 1000)
+
+	var m StreamMessage
+	if err := json.Unmarshal(<-ch, &m); err != nil {
+		t.Fatalf("failed to decode the message: %s", err)
+	}
+	if m.Job != "mysql" || m.Target != "127.0.0.1:3306" || len(m.Samples) != 2 {
+		t.Fatalf("unexpected message: %+v", m)
+	}
+	if m.Samples[0].Labels["instance"] != "db1" || m.Samples[0].Value != "1" || m.Samples[1].Value != "NaN" {
+		t.Errorf("unexpected samples: %+v", m.Samples)
+	}
+
+	select {
+	case msg := <-ch:
+		t.Errorf("unexpected message of the other target: %s", msg)
+	case msg := <-other:
+		t.Errorf("unexpected message of the other job: %s", msg)
+	default:
+	}
+
+	// a slow subscriber never blocks the scrapes
+	for i := 0; i < streamBufferSize+1; i++ {
+		publishSamples("mysql", "127.0.0.1:3306", tss, 1000)
+	}
+
+	unsubscribe()
+	streamLock.RLock()
+	n := len(streamSubscribers)
+	streamLock.RUnlock()
+	if n != 1 {
+		t.Errorf("expected 1 subscriber left, got %d", n)
+	}
+}
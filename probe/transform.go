@@ -0,0 +1,244 @@
+package probe
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// SampleTransform rewrites the value of the samples whose metric name matches Match,
+// e.g. match: 'mysql_global_status_.*_bytes' expr: 'value / 1024 / 1024'
+type SampleTransform struct {
+	Match string `yaml:"match"`
+	Expr  string `yaml:"expr"`
+	// Scale is a shorthand of expr 'value * scale' to normalize the units, e.g. 16384 for the number of pages
+	Scale float64 `yaml:"scale"`
+
+	re   *regexp.Regexp
+	expr exprNode
+}
+
+func (t *SampleTransform) parse() error {
+	re, err := regexp.Compile("^(?:" + t.Match + ")$")
+	if err != nil {
+		return fmt.Errorf("cannot parse match %q: %w", t.Match, err)
+	}
+
+	var expr exprNode
+	switch {
+	case t.Scale != 0 && t.Expr != "":
+		return fmt.Errorf("only one of expr and scale can be set for match %q", t.Match)
+	case t.Scale != 0:
+		expr = binaryNode{op: '*', l: valueNode{}, r: numberNode(t.Scale)}
+	default:
+		if expr, err = parseExpr(t.Expr); err != nil {
+			return fmt.Errorf("cannot parse expr %q: %w", t.Expr, err)
+		}
+	}
+
+	t.re = re
+	t.expr = expr
+	return nil
+}
+
+func parseSampleTransforms(ts []*SampleTransform) error {
+	for i := range ts {
+		if err := ts[i].parse(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applySampleTransforms applies all the matched transforms in order, applied is whether any transform matched,
+// ok is false if the result is not finite, e.g. division by zero
+func applySampleTransforms(ts []*SampleTransform, name string, value float64) (result float64, applied, ok bool) {
+	for _, t := range ts {
+		if !t.re.MatchString(name) {
+			continue
+		}
+		applied = true
+		value = t.expr.eval(value)
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return 0, true, false
+		}
+	}
+	return value, applied, true
+}
+
+func incSampleTransformDropped(jobName string) {
+	metrics.GetOrCreateCounter(fmt.Sprintf(`cprobe_sample_transform_dropped_total{job=%q}`, jobName)).Inc()
+}
+
+func incSampleTransformApplied(jobName string) {
+	metrics.GetOrCreateCounter(fmt.Sprintf(`cprobe_sample_transform_applied_total{job=%q}`, jobName)).Inc()
+}
+
+// exprNode is an arithmetic expression on the sample value
+type exprNode interface {
+	eval(value float64) float64
+}
+
+type numberNode float64
+
+func (n numberNode) eval(float64) float64 { return float64(n) }
+
+type valueNode struct{}
+
+func (valueNode) eval(value float64) float64 { return value }
+
+type negNode struct {
+	x exprNode
+}
+
+func (n negNode) eval(value float64) float64 { return -n.x.eval(value) }
+
+type binaryNode struct {
+	op   byte
+	l, r exprNode
+}
+
+func (n binaryNode) eval(value float64) float64 {
+	l, r := n.l.eval(value), n.r.eval(value)
+	switch n.op {
+	case '+':
+		return l + r
+	case '-':
+		return l - r
+	case '*':
+		return l * r
+	default:
+		return l / r
+	}
+}
+
+// exprParser is a recursive descent parser for:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/") unary }
+//	unary   = "-" unary | primary
+//	primary = number | "value" | "(" expr ")"
+type exprParser struct {
+	s   string
+	pos int
+}
+
+func parseExpr(s string) (exprNode, error) {
+	p := &exprParser{s: s}
+	node, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.s) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.s[p.pos:], p.pos)
+	}
+	return node, nil
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *exprParser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *exprParser) parseSum() (exprNode, error) {
+	node, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return node, nil
+		}
+		p.pos++
+		r, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		node = binaryNode{op: op, l: node, r: r}
+	}
+}
+
+func (p *exprParser) parseProduct() (exprNode, error) {
+	node, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return node, nil
+		}
+		p.pos++
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		node = binaryNode{op: op, l: node, r: r}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.peek() == '-' {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		node, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ')' at position %d", p.pos)
+		}
+		p.pos++
+		return node, nil
+	case strings.HasPrefix(p.s[p.pos:], "value"):
+		p.pos += len("value")
+		return valueNode{}, nil
+	case (c >= '0' && c <= '9') || c == '.':
+		start := p.pos
+		for p.pos < len(p.s) {
+			c := p.s[p.pos]
+			isExpSign := (c == '+' || c == '-') && (p.s[p.pos-1] == 'e' || p.s[p.pos-1] == 'E')
+			if !(c >= '0' && c <= '9') && c != '.' && c != 'e' && c != 'E' && !isExpSign {
+				break
+			}
+			p.pos++
+		}
+		f, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", p.s[start:p.pos])
+		}
+		return numberNode(f), nil
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", c, p.pos)
+	}
+}
@@ -0,0 +1,74 @@
+package probe
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseExpr(t *testing.T) {
+	tests := []struct {
+		expr     string
+		value    float64
+		expected float64
+	}{
+		{"value", 3, 3},
+		{"value / 1024 / 1024", 1048576, 1},
+		{"value * 1000", 1.5, 1500},
+		{"(value + 2) * 3", 1, 9},
+		{"value + 2 * 3", 1, 7},
+		{"-value", 5, -5},
+		{"value - -1", 5, 6},
+		{"value * 1e-3", 2000, 2},
+		{"100 - value", 40, 60},
+	}
+
+	for _, test := range tests {
+		node, err := parseExpr(test.expr)
+		if err != nil {
+			t.Errorf("parseExpr(%q) unexpected error: %s", test.expr, err)
+			continue
+		}
+		if got := node.eval(test.value); math.Abs(got-test.expected) > 1e-9 {
+			t.Errorf("eval %q with value=%v got %v, expected %v", test.expr, test.value, got, test.expected)
+		}
+	}
+
+	for _, expr := range []string{"", "value +", "(value", "value)", "foo", "1..2", "value value"} {
+		if _, err := parseExpr(expr); err == nil {
+			t.Errorf("parseExpr(%q) expected error", expr)
+		}
+	}
+}
+
+func TestApplySampleTransforms(t *testing.T) {
+	ts := []*SampleTransform{
+		{Match: "mysql_.*_bytes", Expr: "value / 1024"},
+		{Match: "mysql_ratio", Expr: "1 / value"},
+		{Match: "mysql_.*_pages", Scale: 16384},
+	}
+	if err := parseSampleTransforms(ts); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if v, applied, ok := applySampleTransforms(ts, "mysql_data_bytes", 2048); !ok || !applied || v != 2 {
+		t.Errorf("expected 2, got %v, ok: %v", v, ok)
+	}
+
+	// match is anchored
+	if v, applied, ok := applySampleTransforms(ts, "mysql_data_bytes_total", 2048); !ok || applied || v != 2048 {
+		t.Errorf("expected untouched value, got %v, ok: %v", v, ok)
+	}
+
+	// division by zero is dropped
+	if _, _, ok := applySampleTransforms(ts, "mysql_ratio", 0); ok {
+		t.Errorf("expected non-finite result to be dropped")
+	}
+
+	if v, applied, ok := applySampleTransforms(ts, "mysql_buffer_pool_pages", 2); !ok || !applied || v != 32768 {
+		t.Errorf("expected 32768, got %v, ok: %v", v, ok)
+	}
+
+	if err := parseSampleTransforms([]*SampleTransform{{Match: "mysql_.*", Expr: "value", Scale: 2}}); err == nil {
+		t.Errorf("expected error for both expr and scale")
+	}
+}
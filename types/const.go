@@ -10,4 +10,7 @@ const (
 	PluginBlackbox      = "blackbox"
 	PluginJson          = "json"
 	PluginPrometheus    = "prometheus"
+	PluginSNMP          = "snmp"
+	PluginMemcached     = "memcached"
+	PluginRabbitMQ      = "rabbitmq"
 )
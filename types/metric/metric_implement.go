@@ -13,6 +13,8 @@ type metric struct {
 	tm     int64
 
 	tp ValueType
+
+	exemplar *Exemplar
 }
 
 func New(
@@ -64,11 +66,12 @@ func New(
 // removed.
 func FromMetric(other Metric) Metric {
 	m := &metric{
-		name:   other.Name(),
-		tags:   make([]*Tag, len(other.TagList())),
-		fields: make([]*Field, len(other.FieldList())),
-		tm:     other.Time(),
-		tp:     other.Type(),
+		name:     other.Name(),
+		tags:     make([]*Tag, len(other.TagList())),
+		fields:   make([]*Field, len(other.FieldList())),
+		tm:       other.Time(),
+		tp:       other.Type(),
+		exemplar: other.Exemplar(),
 	}
 
 	for i, tag := range other.TagList() {
@@ -238,13 +241,22 @@ func (m *metric) SetTime(t int64) {
 	m.tm = t
 }
 
+func (m *metric) Exemplar() *Exemplar {
+	return m.exemplar
+}
+
+func (m *metric) SetExemplar(e *Exemplar) {
+	m.exemplar = e
+}
+
 func (m *metric) Copy() Metric {
 	m2 := &metric{
-		name:   m.name,
-		tags:   make([]*Tag, len(m.tags)),
-		fields: make([]*Field, len(m.fields)),
-		tm:     m.tm,
-		tp:     m.tp,
+		name:     m.name,
+		tags:     make([]*Tag, len(m.tags)),
+		fields:   make([]*Field, len(m.fields)),
+		tm:       m.tm,
+		tp:       m.tp,
+		exemplar: m.exemplar,
 	}
 
 	for i, tag := range m.tags {
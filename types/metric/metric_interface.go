@@ -25,6 +25,14 @@ type Field struct {
 	Value interface{}
 }
 
+// Exemplar is an exemplar attached to a counter or histogram sample,
+// e.g. the trace id of a slow request.
+type Exemplar struct {
+	Labels    map[string]string
+	Value     float64
+	Timestamp int64
+}
+
 // Metric is the type of data that is processed by Telegraf.  Input plugins,
 // and to a lesser degree, Processor and Aggregator plugins create new Metrics
 // and Output plugins write them.
@@ -102,6 +110,12 @@ type Metric interface {
 	// SetTime sets the timestamp mills of the Metric.
 	SetTime(t int64)
 
+	// Exemplar returns the exemplar of the Metric, nil if not set.
+	Exemplar() *Exemplar
+
+	// SetExemplar sets the exemplar of the Metric.
+	SetExemplar(e *Exemplar)
+
 	// HashID returns an unique identifier for the series.
 	HashID() uint64
 
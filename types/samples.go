@@ -54,9 +54,9 @@ func (s *Samples) AddPromMetric(m prometheus.Metric) error {
 			"": pb.Gauge.GetValue(),
 		}, tags)
 	} else if pb.Counter != nil {
-		s.AddMetric(desc.Name(), map[string]interface{}{
+		s.addMetricWithExemplar(desc.Name(), map[string]interface{}{
 			"": pb.Counter.GetValue(),
-		}, tags)
+		}, pb.Counter.GetExemplar(), tags)
 	} else if pb.Summary != nil {
 		s.handleSummary(pb, desc.Name(), tags)
 	} else if pb.Histogram != nil {
@@ -106,9 +106,9 @@ func (s *Samples) handleHistogram(pb *dto.Metric, metricName string, tags map[st
 	for _, b := range pb.GetHistogram().Bucket {
 		le := fmt.Sprint(b.GetUpperBound())
 		value := float64(b.GetCumulativeCount())
-		s.AddMetric(metricName, map[string]interface{}{
+		s.addMetricWithExemplar(metricName, map[string]interface{}{
 			"bucket": value,
-		}, tags, map[string]string{
+		}, b.GetExemplar(), tags, map[string]string{
 			"le": le,
 		})
 	}
@@ -232,6 +232,36 @@ func (s *Samples) AddMetric(mesurement string, fields map[string]interface{}, ta
 	s.slist.PushFront(m)
 }
 
+// addMetricWithExemplar is the same as AddMetric, but keeps the exemplar of counters and histogram buckets
+func (s *Samples) addMetricWithExemplar(mesurement string, fields map[string]interface{}, e *dto.Exemplar, tagss ...map[string]string) {
+	if e == nil {
+		s.AddMetric(mesurement, fields, tagss...)
+		return
+	}
+
+	tags := make(map[string]string)
+	for i := range tagss {
+		for k, v := range tagss[i] {
+			tags[k] = v
+		}
+	}
+
+	exemplar := &metric.Exemplar{
+		Labels: make(map[string]string, len(e.Label)),
+		Value:  e.GetValue(),
+	}
+	for _, lp := range e.Label {
+		exemplar.Labels[lp.GetName()] = lp.GetValue()
+	}
+	if e.Timestamp != nil {
+		exemplar.Timestamp = e.Timestamp.AsTime().UnixMilli()
+	}
+
+	m := metric.New(mesurement, tags, fields, 0)
+	m.SetExemplar(exemplar)
+	s.slist.PushFront(m)
+}
+
 func (s *Samples) PushFront(m metric.Metric) {
 	s.slist.PushFront(m)
 }
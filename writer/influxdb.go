@@ -0,0 +1,141 @@
+package writer
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/cprobe/cprobe/lib/prompbmarshal"
+)
+
+// Formats of the writers.
+const (
+	FormatRemoteWrite = "remote_write"
+	FormatInfluxDB    = "influxdb"
+)
+
+const defaultInfluxDBBatchSize = 5000
+
+// InfluxDB posts the samples in line protocol to the url of the writer, e.g. http://127.0.0.1:8086/api/v2/write,
+// the metric name is the measurement, the labels are the tags and the value is the field named value
+type InfluxDB struct {
+	// Token, Org and Bucket are for InfluxDB v2
+	Token  string `yaml:"token"`
+	Org    string `yaml:"org"`
+	Bucket string `yaml:"bucket"`
+	// Database is for InfluxDB v1, basic_auth_user and basic_auth_pass of the writer are used for the credentials
+	Database string `yaml:"database"`
+	// BatchSize is the max lines of a request
+	BatchSize int `yaml:"batch_size"`
+}
+
+// parse checks the options and adds them to the query string of the url
+func (i *InfluxDB) parse(rawURL string) (string, error) {
+	if i.BatchSize <= 0 {
+		i.BatchSize = defaultInfluxDBBatchSize
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url %q: %w", rawURL, err)
+	}
+
+	q := u.Query()
+	switch {
+	case i.Bucket != "" && i.Database != "":
+		return "", fmt.Errorf("influxdb.bucket and influxdb.database are exclusive")
+	case i.Bucket != "":
+		if i.Org == "" {
+			return "", fmt.Errorf("influxdb.org is required with influxdb.bucket")
+		}
+		q.Set("org", i.Org)
+		q.Set("bucket", i.Bucket)
+	case i.Database != "":
+		q.Set("db", i.Database)
+	default:
+		return "", fmt.Errorf("influxdb.bucket or influxdb.database is required")
+	}
+	// the timestamps of the samples are in milliseconds
+	q.Set("precision", "ms")
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+func (w *Writer) writeInfluxDB(tss []prompbmarshal.TimeSeries) {
+	lines := make([]string, 0, len(tss))
+	for i := range tss {
+		if line, ok := formatInfluxDBLine(tss[i]); ok {
+			lines = append(lines, line)
+		}
+	}
+
+	for start := 0; start < len(lines); start += w.InfluxDB.BatchSize {
+		end := start + w.InfluxDB.BatchSize
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		httpReq, err := w.newInfluxDBRequest([]byte(strings.Join(lines[start:end], "\n") + "\n"))
+		if err != nil {
+			logger.Warnf("cannot create http request: %s", err)
+			return
+		}
+
+		w.enqueue(httpReq)
+	}
+}
+
+// formatInfluxDBLine returns false for the samples InfluxDB does not accept, i.e. NaN including the staleness markers and Inf
+func formatInfluxDBLine(ts prompbmarshal.TimeSeries) (string, bool) {
+	if len(ts.Samples) == 0 {
+		return "", false
+	}
+	sample := ts.Samples[0]
+	if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+		return "", false
+	}
+
+	var name string
+	tags := make([]prompbmarshal.Label, 0, len(ts.Labels))
+	for _, label := range ts.Labels {
+		if label.Name == "__name__" {
+			name = label.Value
+			continue
+		}
+		// empty tag values are not allowed
+		if label.Value != "" {
+			tags = append(tags, label)
+		}
+	}
+	if name == "" {
+		return "", false
+	}
+	// tags sorted by key perform better on write
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+
+	var sb strings.Builder
+	sb.WriteString(influxDBMeasurementReplacer.Replace(name))
+	for _, tag := range tags {
+		sb.WriteByte(',')
+		sb.WriteString(influxDBTagReplacer.Replace(tag.Name))
+		sb.WriteByte('=')
+		sb.WriteString(influxDBTagReplacer.Replace(tag.Value))
+	}
+	sb.WriteString(" value=")
+	sb.WriteString(strconv.FormatFloat(sample.Value, 'g', -1, 64))
+	if sample.Timestamp > 0 {
+		sb.WriteByte(' ')
+		sb.WriteString(strconv.FormatInt(sample.Timestamp, 10))
+	}
+	return sb.String(), true
+}
+
+var (
+	influxDBMeasurementReplacer = strings.NewReplacer(`,`, `\,`, ` `, `\ `, "\n", `\n`)
+	influxDBTagReplacer         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `, "\n", `\n`)
+)
@@ -0,0 +1,58 @@
+package writer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cprobe/cprobe/lib/decimal"
+)
+
+func TestFormatInfluxDBLine(t *testing.T) {
+	ts := newTimeSeries(0.5, "__name__", "mysql_up", "instance", "10.0.0.1:3306", "note", "a b,c=d", "empty", "")
+	ts.Samples[0].Timestamp = 1700000000000
+
+	line, ok := formatInfluxDBLine(ts)
+	if !ok {
+		t.Fatalf("sample should be formatted")
+	}
+	expected := `mysql_up,instance=10.0.0.1:3306,note=a\ b\,c\=d value=0.5 1700000000000`
+	if line != expected {
+		t.Fatalf("unexpected line\ngot:  %s\nwant: %s", line, expected)
+	}
+
+	for _, v := range []float64{math.NaN(), math.Inf(1), decimal.StaleNaN} {
+		if _, ok := formatInfluxDBLine(newTimeSeries(v, "__name__", "mysql_up")); ok {
+			t.Fatalf("value %v should be skipped", v)
+		}
+	}
+}
+
+func TestInfluxDBParse(t *testing.T) {
+	tests := []struct {
+		influxDB InfluxDB
+		url      string
+		expected string
+	}{
+		{InfluxDB{Org: "ops", Bucket: "cprobe"}, "http://127.0.0.1:8086/api/v2/write", "http://127.0.0.1:8086/api/v2/write?bucket=cprobe&org=ops&precision=ms"},
+		{InfluxDB{Database: "cprobe"}, "http://127.0.0.1:8086/write", "http://127.0.0.1:8086/write?db=cprobe&precision=ms"},
+		{InfluxDB{Bucket: "cprobe"}, "http://127.0.0.1:8086/api/v2/write", ""},
+		{InfluxDB{Bucket: "cprobe", Org: "ops", Database: "cprobe"}, "http://127.0.0.1:8086/api/v2/write", ""},
+		{InfluxDB{}, "http://127.0.0.1:8086/write", ""},
+	}
+
+	for _, test := range tests {
+		got, err := test.influxDB.parse(test.url)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("parse(%+v) should fail", test.influxDB)
+			}
+			continue
+		}
+		if err != nil || got != test.expected {
+			t.Errorf("parse(%+v) = %q, %v, want %q", test.influxDB, got, err, test.expected)
+		}
+		if test.influxDB.BatchSize != defaultInfluxDBBatchSize {
+			t.Errorf("default batch size is not set")
+		}
+	}
+}
@@ -9,7 +9,8 @@ import (
 	"github.com/golang/snappy"
 )
 
-func WriteTimeSeries(tss []prompbmarshal.TimeSeries) {
+// WriteTimeSeries sends tss to the writers of the names, or all the writers if no name is given
+func WriteTimeSeries(tss []prompbmarshal.TimeSeries, names ...string) {
 	if len(tss) == 0 {
 		return
 	}
@@ -29,7 +30,8 @@ func WriteTimeSeries(tss []prompbmarshal.TimeSeries) {
 		return
 	}
 
-	if len(WriterConfig.Writers) == 0 {
+	writers := selectWriters(names)
+	if len(writers) == 0 {
 		return
 	}
 
@@ -38,29 +40,58 @@ func WriteTimeSeries(tss []prompbmarshal.TimeSeries) {
 		new(relabelCtx).appendExtraLabels(tss, WriterConfig.Global.ExtraLabels.Labels)
 	}
 
-	if len(WriterConfig.Writers) == 1 {
-		WriterConfig.Writers[0].writeTimeSeries(tss)
+	if len(writers) == 1 {
+		writers[0].writeTimeSeries(tss)
 		return
 	}
 
-	for i := range WriterConfig.Writers {
-		if i == len(WriterConfig.Writers)-1 {
+	for i := range writers {
+		if i == len(writers)-1 {
 			// last one
-			WriterConfig.Writers[i].writeTimeSeries(tss)
+			writers[i].writeTimeSeries(tss)
 		} else {
 			newVectors := make([]prompbmarshal.TimeSeries, len(tss))
 			for j := range tss {
 				newVectors[j] = prompbmarshal.TimeSeries{
-					Labels:  make([]prompbmarshal.Label, 0, len(tss[j].Labels)),
-					Samples: tss[j].Samples,
+					Labels:    make([]prompbmarshal.Label, 0, len(tss[j].Labels)),
+					Samples:   tss[j].Samples,
+					Exemplars: tss[j].Exemplars,
 				}
 				newVectors[j].Labels = append(newVectors[j].Labels, tss[j].Labels...)
 			}
-			WriterConfig.Writers[i].writeTimeSeries(newVectors)
+			writers[i].writeTimeSeries(newVectors)
 		}
 	}
 }
 
+// selectWriters returns the writers of the names without duplicates, the unknown names are skipped
+func selectWriters(names []string) []*Writer {
+	if len(names) == 0 {
+		return WriterConfig.Writers
+	}
+
+	writers := make([]*Writer, 0, len(names))
+	for _, name := range names {
+		w := WriterConfig.getWriter(name)
+		if w == nil {
+			logger.Warnf("writer %s not found, the samples routed to it are dropped", name)
+			continue
+		}
+
+		duplicate := false
+		for i := range writers {
+			if writers[i] == w {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			writers = append(writers, w)
+		}
+	}
+	return writers
+}
+
 func (w *Writer) writeTimeSeries(tss []prompbmarshal.TimeSeries) {
 	// append writer extra labels
 	if w.ExtraLabels != nil && len(w.ExtraLabels.Labels) > 0 {
@@ -76,6 +107,23 @@ func (w *Writer) writeTimeSeries(tss []prompbmarshal.TimeSeries) {
 		tss = new(relabelCtx).applyRelabeling(tss, w.ParsedRelabelConfigs)
 	}
 
+	// most of the remote write backends do not accept exemplars, only send them if enabled
+	if !w.SendExemplars {
+		for i := range tss {
+			tss[i].Exemplars = nil
+		}
+	}
+
+	if w.Format == FormatInfluxDB {
+		w.writeInfluxDB(tss)
+		return
+	}
+
+	if w.Format == FormatKafka {
+		w.writeKafka(tss)
+		return
+	}
+
 	req := prompbmarshal.WriteRequest{
 		Timeseries: tss,
 	}
@@ -92,5 +140,5 @@ func (w *Writer) writeTimeSeries(tss []prompbmarshal.TimeSeries) {
 		return
 	}
 
-	w.RequestQueue.PushFront(httpReq)
+	w.enqueue(httpReq)
 }
@@ -0,0 +1,52 @@
+package writer
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cprobe/cprobe/lib/listx"
+	"github.com/cprobe/cprobe/lib/prompbmarshal"
+)
+
+func TestWriteTimeSeriesRouting(t *testing.T) {
+	saved := WriterConfig
+	defer func() { WriterConfig = saved }()
+
+	newWriter := func(name string) *Writer {
+		return &Writer{
+			Name:         name,
+			URL:          "http://127.0.0.1:9090/api/v1/write",
+			Format:       FormatRemoteWrite,
+			RequestQueue: listx.NewSafeList[*http.Request](),
+		}
+	}
+	dba, sre := newWriter("dba"), newWriter("sre")
+	WriterConfig = &WriterYaml{Global: &Global{}, Writers: []*Writer{dba, sre}}
+
+	write := func(names ...string) {
+		WriteTimeSeries([]prompbmarshal.TimeSeries{newTimeSeries(1, "__name__", "mysql_up")}, names...)
+	}
+
+	f := func(dbaRequests, sreRequests int) {
+		t.Helper()
+		if dba.RequestQueue.Len() != dbaRequests || sre.RequestQueue.Len() != sreRequests {
+			t.Fatalf("unexpected requests; got %d and %d; want %d and %d", dba.RequestQueue.Len(), sre.RequestQueue.Len(), dbaRequests, sreRequests)
+		}
+	}
+
+	write()
+	f(1, 1)
+
+	write("dba")
+	f(2, 1)
+
+	write("sre", "sre", "unknown")
+	f(2, 2)
+
+	if err := CheckWriters([]string{"dba", "sre"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := CheckWriters([]string{"unknown"}); err == nil {
+		t.Fatalf("expected error for the unknown writer")
+	}
+}
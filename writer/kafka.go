@@ -0,0 +1,322 @@
+package writer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/cprobe/cprobe/lib/prompbmarshal"
+	"github.com/cprobe/cprobe/lib/scramclient"
+)
+
+// FormatKafka publishes the samples to a Kafka topic instead of posting them to the url.
+const FormatKafka = "kafka"
+
+// Encodings of the Kafka messages.
+const (
+	KafkaEncodingJSON     = "json"
+	KafkaEncodingProtobuf = "protobuf"
+)
+
+const defaultKafkaBatchSize = 5000
+
+// Kafka publishes the samples of every target to the topic keyed by the instance label, so that the samples of a target
+// are kept in order in a partition. A message is a JSON array of the samples, or a remote write WriteRequest in protobuf.
+type Kafka struct {
+	Brokers []string `yaml:"brokers"`
+	Topic   string   `yaml:"topic"`
+	// Encoding is json (default) or protobuf
+	Encoding string `yaml:"encoding"`
+	// Compression is none, gzip, snappy (default, like remote write), lz4 or zstd
+	Compression string `yaml:"compression"`
+	// BatchSize is the max samples of a message
+	BatchSize int `yaml:"batch_size"`
+	// Version is the version of the brokers, e.g. 2.1.0
+	Version string `yaml:"version"`
+	// SASLMechanism is PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512, SASL is disabled if blank
+	SASLMechanism string `yaml:"sasl_mechanism"`
+	SASLUsername  string `yaml:"sasl_username"`
+	SASLPassword  string `yaml:"sasl_password"`
+	// TLSEnable connects to the brokers by TLS with the tls options of the writer
+	TLSEnable bool `yaml:"tls_enable"`
+
+	config *sarama.Config
+
+	// the producer is created on the first write, so that cprobe starts while the brokers are unavailable
+	mu       sync.Mutex
+	producer sarama.AsyncProducer
+	// closed when the results of the producer are all collected
+	done chan struct{}
+}
+
+var kafkaCompressions = map[string]sarama.CompressionCodec{
+	"none":   sarama.CompressionNone,
+	"gzip":   sarama.CompressionGZIP,
+	"snappy": sarama.CompressionSnappy,
+	"lz4":    sarama.CompressionLZ4,
+	"zstd":   sarama.CompressionZSTD,
+}
+
+// parse checks the options and builds the producer config, the tls options of the writer are used if TLSEnable is set
+func (k *Kafka) parse(w *Writer) error {
+	if len(k.Brokers) == 0 {
+		return fmt.Errorf("kafka.brokers is required")
+	}
+
+	if k.Topic == "" {
+		return fmt.Errorf("kafka.topic is required")
+	}
+
+	switch k.Encoding {
+	case "":
+		k.Encoding = KafkaEncodingJSON
+	case KafkaEncodingJSON, KafkaEncodingProtobuf:
+	default:
+		return fmt.Errorf("invalid kafka.encoding %q, should be json or protobuf", k.Encoding)
+	}
+
+	if k.Compression == "" {
+		k.Compression = "snappy"
+	}
+	codec, ok := kafkaCompressions[k.Compression]
+	if !ok {
+		return fmt.Errorf("invalid kafka.compression %q, should be none, gzip, snappy, lz4 or zstd", k.Compression)
+	}
+
+	if k.BatchSize <= 0 {
+		k.BatchSize = defaultKafkaBatchSize
+	}
+
+	config := sarama.NewConfig()
+	config.ClientID = "cprobe"
+	config.Producer.Compression = codec
+	config.Producer.Return.Successes = true
+	config.Producer.Return.Errors = true
+	config.Producer.Retry.Max = w.RetryTimes
+	config.Producer.Retry.Backoff = time.Duration(w.RetryIntervalMillis) * time.Millisecond
+	config.Producer.Timeout = time.Duration(w.RequestTimeoutMillis) * time.Millisecond
+	config.Net.DialTimeout = time.Duration(w.ConnectTimeoutMillis) * time.Millisecond
+
+	if k.Version != "" {
+		version, err := sarama.ParseKafkaVersion(k.Version)
+		if err != nil {
+			return fmt.Errorf("invalid kafka.version: %s", err)
+		}
+		config.Version = version
+	}
+
+	switch strings.ToUpper(k.SASLMechanism) {
+	case "":
+	case sarama.SASLTypePlaintext:
+		config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+	case sarama.SASLTypeSCRAMSHA256:
+		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramclient.XDGSCRAMClient{HashGeneratorFcn: scramclient.SHA256}
+		}
+	case sarama.SASLTypeSCRAMSHA512:
+		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramclient.XDGSCRAMClient{HashGeneratorFcn: scramclient.SHA512}
+		}
+	default:
+		return fmt.Errorf("invalid kafka.sasl_mechanism %q, should be PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512", k.SASLMechanism)
+	}
+	if config.Net.SASL.Mechanism != "" {
+		config.Net.SASL.Enable = true
+		config.Net.SASL.User = k.SASLUsername
+		config.Net.SASL.Password = k.SASLPassword
+	}
+
+	if k.TLSEnable {
+		tlsConfig, err := w.ClientConfig.TLSConfig()
+		if err != nil {
+			return err
+		}
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	}
+
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid kafka config: %s", err)
+	}
+	k.config = config
+
+	return nil
+}
+
+// getProducer returns the producer, it is created if not yet
+func (k *Kafka) getProducer(name string) (sarama.AsyncProducer, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.producer != nil {
+		return k.producer, nil
+	}
+
+	producer, err := sarama.NewAsyncProducer(k.Brokers, k.config)
+	if err != nil {
+		return nil, err
+	}
+	k.producer = producer
+	k.done = make(chan struct{})
+	go k.collectResults(name, producer, k.done)
+
+	return producer, nil
+}
+
+// collectResults counts the messages delivered and failed after the retries
+func (k *Kafka) collectResults(name string, producer sarama.AsyncProducer, done chan struct{}) {
+	defer close(done)
+
+	successes, errs := producer.Successes(), producer.Errors()
+	for successes != nil || errs != nil {
+		select {
+		case _, ok := <-successes:
+			if !ok {
+				successes = nil
+				continue
+			}
+			incKafkaMessages(name, "success")
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			incKafkaMessages(name, "failure")
+			logger.Errorf("failed to publish the samples to kafka topic %s of writer %s: %s", k.Topic, name, err.Err)
+		}
+	}
+}
+
+// flush closes the producer after the buffered messages are delivered or failed, the next write creates a new one
+func (k *Kafka) flush() {
+	k.mu.Lock()
+	producer, done := k.producer, k.done
+	k.producer, k.done = nil, nil
+	k.mu.Unlock()
+
+	if producer == nil {
+		return
+	}
+	producer.AsyncClose()
+	<-done
+}
+
+func incKafkaMessages(name, status string) {
+	metrics.GetOrCreateCounter(fmt.Sprintf(`cprobe_writer_kafka_messages_total{writer=%q,status=%q}`, name, status)).Inc()
+}
+
+func (w *Writer) writeKafka(tss []prompbmarshal.TimeSeries) {
+	msgs, err := w.Kafka.encodeMessages(tss)
+	if err != nil {
+		logger.Warnf("cannot encode the kafka messages of writer %s: %s", w.Name, err)
+		return
+	}
+	if len(msgs) == 0 {
+		return
+	}
+
+	producer, err := w.Kafka.getProducer(w.Name)
+	if err != nil {
+		for range msgs {
+			incKafkaMessages(w.Name, "failure")
+		}
+		logger.Errorf("cannot connect to the kafka brokers of writer %s: %s", w.Name, err)
+		return
+	}
+
+	// the scrapes are never blocked by the brokers, the messages are dropped if the buffer of the producer is full
+	for _, msg := range msgs {
+		select {
+		case producer.Input() <- msg:
+		default:
+			incKafkaMessages(w.Name, "failure")
+		}
+	}
+}
+
+type kafkaSample struct {
+	Labels    map[string]string `json:"labels"`
+	Value     float64           `json:"value"`
+	Timestamp int64             `json:"timestamp"`
+}
+
+// encodeMessages groups the samples by the instance label in the order first seen, at most BatchSize samples a message.
+// NaN, including the staleness markers, and Inf are not valid JSON, they are skipped in json.
+func (k *Kafka) encodeMessages(tss []prompbmarshal.TimeSeries) ([]*sarama.ProducerMessage, error) {
+	var instances []string
+	groups := make(map[string][]prompbmarshal.TimeSeries)
+	for i := range tss {
+		if len(tss[i].Samples) == 0 {
+			continue
+		}
+		if k.Encoding == KafkaEncodingJSON {
+			if v := tss[i].Samples[0].Value; math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+		}
+
+		var instance string
+		for _, label := range tss[i].Labels {
+			if label.Name == "instance" {
+				instance = label.Value
+				break
+			}
+		}
+		if _, has := groups[instance]; !has {
+			instances = append(instances, instance)
+		}
+		groups[instance] = append(groups[instance], tss[i])
+	}
+
+	var msgs []*sarama.ProducerMessage
+	for _, instance := range instances {
+		group := groups[instance]
+		for start := 0; start < len(group); start += k.BatchSize {
+			end := start + k.BatchSize
+			if end > len(group) {
+				end = len(group)
+			}
+
+			value, err := k.encode(group[start:end])
+			if err != nil {
+				return nil, err
+			}
+
+			msg := &sarama.ProducerMessage{Topic: k.Topic, Value: sarama.ByteEncoder(value)}
+			if instance != "" {
+				msg.Key = sarama.StringEncoder(instance)
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+func (k *Kafka) encode(tss []prompbmarshal.TimeSeries) ([]byte, error) {
+	if k.Encoding == KafkaEncodingProtobuf {
+		req := prompbmarshal.WriteRequest{Timeseries: tss}
+		return req.Marshal()
+	}
+
+	samples := make([]kafkaSample, 0, len(tss))
+	for i := range tss {
+		labels := make(map[string]string, len(tss[i].Labels))
+		for _, label := range tss[i].Labels {
+			labels[label.Name] = label.Value
+		}
+		samples = append(samples, kafkaSample{
+			Labels:    labels,
+			Value:     tss[i].Samples[0].Value,
+			Timestamp: tss[i].Samples[0].Timestamp,
+		})
+	}
+	return json.Marshal(samples)
+}
@@ -0,0 +1,111 @@
+package writer
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/cprobe/cprobe/lib/prompbmarshal"
+)
+
+func TestKafkaParse(t *testing.T) {
+	w := &Writer{Format: FormatKafka, Kafka: &Kafka{Brokers: []string{"127.0.0.1:9092"}, Topic: "cprobe", SASLMechanism: "scram-sha-512", SASLUsername: "cprobe", SASLPassword: "secret"}}
+	if err := w.Parse(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if w.Name != "kafka://127.0.0.1:9092/cprobe" || w.Kafka.Encoding != KafkaEncodingJSON || w.Kafka.BatchSize != defaultKafkaBatchSize {
+		t.Errorf("unexpected defaults: %s %s %d", w.Name, w.Kafka.Encoding, w.Kafka.BatchSize)
+	}
+	if w.Kafka.config.Producer.Compression != sarama.CompressionSnappy || !w.Kafka.config.Net.SASL.Enable {
+		t.Errorf("unexpected producer config: %+v", w.Kafka.config.Producer)
+	}
+
+	for _, k := range []*Kafka{
+		nil,
+		{Topic: "cprobe"},
+		{Brokers: []string{"127.0.0.1:9092"}},
+		{Brokers: []string{"127.0.0.1:9092"}, Topic: "cprobe", Encoding: "avro"},
+		{Brokers: []string{"127.0.0.1:9092"}, Topic: "cprobe", Compression: "brotli"},
+		{Brokers: []string{"127.0.0.1:9092"}, Topic: "cprobe", SASLMechanism: "GSSAPI"},
+	} {
+		if err := (&Writer{Name: "kafka", Format: FormatKafka, Kafka: k}).Parse(); err == nil {
+			t.Errorf("expected error for %+v", k)
+		}
+	}
+}
+
+func TestKafkaEncodeMessages(t *testing.T) {
+	k := &Kafka{Topic: "cprobe", Encoding: KafkaEncodingJSON, BatchSize: 2}
+	msgs, err := k.encodeMessages(tssOfInstances())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// db1 has 3 samples in 2 messages, NaN of db2 is skipped
+	keys := []string{"db1", "db1", "db2"}
+	if len(msgs) != len(keys) {
+		t.Fatalf("expected %d messages, got %d", len(keys), len(msgs))
+	}
+	for i, msg := range msgs {
+		if key, _ := msg.Key.Encode(); string(key) != keys[i] {
+			t.Errorf("unexpected key of message %d: %s", i, key)
+		}
+	}
+
+	value, _ := msgs[2].Value.Encode()
+	var samples []kafkaSample
+	if err := json.Unmarshal(value, &samples); err != nil {
+		t.Fatalf("failed to decode the message: %s", err)
+	}
+	if len(samples) != 1 || samples[0].Labels["__name__"] != "mysql_up" || samples[0].Value != 1 {
+		t.Errorf("unexpected samples: %+v", samples)
+	}
+
+	// the staleness markers are kept in protobuf
+	k.Encoding, k.BatchSize = KafkaEncodingProtobuf, defaultKafkaBatchSize
+	if msgs, _ = k.encodeMessages(tssOfInstances()); len(msgs) != 2 {
+		t.Errorf("expected 2 messages, got %d", len(msgs))
+	}
+}
+
+func TestKafkaDelivery(t *testing.T) {
+	config := sarama.NewConfig()
+	config.Producer.Return.Successes = true
+	producer := mocks.NewAsyncProducer(t, config)
+	producer.ExpectInputAndSucceed().ExpectInputAndFail(errors.New("leader not available"))
+
+	w := &Writer{Name: "kafka-test", Format: FormatKafka, Kafka: &Kafka{Topic: "cprobe", Encoding: KafkaEncodingJSON, BatchSize: 5, producer: producer, done: make(chan struct{})}}
+	go w.Kafka.collectResults(w.Name, producer, w.Kafka.done)
+	w.writeKafka(tssOfInstances())
+
+	success := metrics.GetOrCreateCounter(`cprobe_writer_kafka_messages_total{writer="kafka-test",status="success"}`)
+	failure := metrics.GetOrCreateCounter(`cprobe_writer_kafka_messages_total{writer="kafka-test",status="failure"}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for (success.Get() != 1 || failure.Get() != 1) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if success.Get() != 1 || failure.Get() != 1 {
+		t.Errorf("expected 1 delivered and 1 failed, got %d and %d", success.Get(), failure.Get())
+	}
+
+	// flush returns after the results are collected, the next write creates a new producer
+	w.Kafka.flush()
+	if w.Kafka.producer != nil {
+		t.Errorf("expected the producer to be closed by flush")
+	}
+}
+
+func tssOfInstances() []prompbmarshal.TimeSeries {
+	return []prompbmarshal.TimeSeries{
+		newTimeSeries(1, "__name__", "mysql_up", "instance", "db1"),
+		newTimeSeries(math.NaN(), "__name__", "mysql_stale", "instance", "db2"),
+		newTimeSeries(2, "__name__", "mysql_connections", "instance", "db1"),
+		newTimeSeries(1, "__name__", "mysql_up", "instance", "db2"),
+		newTimeSeries(3, "__name__", "mysql_threads", "instance", "db1"),
+	}
+}
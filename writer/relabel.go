@@ -42,8 +42,9 @@ func (rctx *relabelCtx) applyRelabeling(tss []prompbmarshal.TimeSeries, pcs *pro
 		}
 		fixPromCompatibleNaming(labels[labelsLen:])
 		tssDst = append(tssDst, prompbmarshal.TimeSeries{
-			Labels:  labels[labelsLen:],
-			Samples: ts.Samples,
+			Labels:    labels[labelsLen:],
+			Samples:   ts.Samples,
+			Exemplars: ts.Exemplars,
 		})
 	}
 	rctx.labels = labels
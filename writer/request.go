@@ -9,6 +9,33 @@ import (
 )
 
 func (w *Writer) NewRequest(body []byte) (*http.Request, error) {
+	req, err := w.newRequest(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	return req, nil
+}
+
+func (w *Writer) newInfluxDBRequest(body []byte) (*http.Request, error) {
+	req, err := w.newRequest(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.InfluxDB.Token != "" {
+		req.Header.Set("Authorization", "Token "+w.InfluxDB.Token)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	return req, nil
+}
+
+func (w *Writer) newRequest(body []byte) (*http.Request, error) {
 	reqBody := bytes.NewBuffer(body)
 	req, err := http.NewRequest(http.MethodPost, w.URL, reqBody)
 	if err != nil {
@@ -35,9 +62,6 @@ func (w *Writer) NewRequest(body []byte) (*http.Request, error) {
 	}
 
 	req.Header.Set("User-Agent", "cprobe")
-	req.Header.Set("Content-Type", "application/x-protobuf")
-	req.Header.Set("Content-Encoding", "snappy")
-	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
 
 	return req, nil
 }
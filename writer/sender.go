@@ -1,6 +1,7 @@
 package writer
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -8,6 +9,12 @@ import (
 	"github.com/cprobe/cprobe/lib/logger"
 )
 
+// enqueue queues the request for the sender, it is pending until sent or given up
+func (w *Writer) enqueue(req *http.Request) {
+	w.pending.Add(1)
+	w.RequestQueue.PushFront(req)
+}
+
 func (w *Writer) StartSender() {
 	semaphone := make(chan struct{}, w.Concurrency)
 
@@ -22,6 +29,7 @@ func (w *Writer) StartSender() {
 		go func(req *http.Request) {
 			defer func() {
 				<-semaphone
+				w.pending.Done()
 			}()
 
 			w.send(req)
@@ -48,3 +56,31 @@ func (w *Writer) send(req *http.Request) {
 		logger.Errorf("error sending request to %q: %s", req.URL, err)
 	}
 }
+
+// Flush waits for the requests queued by the writers to be sent and closes the kafka producers, so that nothing
+// is lost when cprobe exits after a scrape in the oneshot mode. It gives up after timeout, the rest are dropped.
+func Flush(timeout time.Duration) error {
+	if *writerDisable {
+		return nil
+	}
+
+	deadline := time.Now().Add(timeout)
+	for _, w := range WriterConfig.Writers {
+		done := make(chan struct{})
+		go func(w *Writer) {
+			defer close(done)
+			if w.Format == FormatKafka {
+				w.Kafka.flush()
+				return
+			}
+			w.pending.Wait()
+		}(w)
+
+		select {
+		case <-done:
+		case <-time.After(time.Until(deadline)):
+			return fmt.Errorf("writer %s is not flushed within %s", w.Name, timeout)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package writer
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cprobe/cprobe/lib/decimal"
+	"github.com/cprobe/cprobe/lib/logger"
+	"github.com/cprobe/cprobe/lib/prompbmarshal"
+)
+
+const textfilePrefix = "cprobe_"
+
+var textfileNameRE = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// Textfile writes the samples of every scrape to .prom files for the textfile collector of node_exporter
+type Textfile struct {
+	Directory string `yaml:"directory"`
+	// plugin: one file per plugin, target: one file per job and target
+	SplitBy string `yaml:"split_by"`
+	// Files and targets not updated within ttl are removed
+	TTL time.Duration `yaml:"ttl"`
+
+	sync.Mutex `yaml:"-"`
+	// file name => target => rendered lines
+	entries map[string]map[string]*textfileEntry
+}
+
+type textfileEntry struct {
+	lines   []string
+	updated time.Time
+}
+
+func (t *Textfile) Parse() error {
+	if t.Directory == "" {
+		return fmt.Errorf("textfile.directory is blank")
+	}
+
+	switch t.SplitBy {
+	case "":
+		t.SplitBy = "plugin"
+	case "plugin", "target":
+	default:
+		return fmt.Errorf("invalid textfile.split_by: %s, should be plugin or target", t.SplitBy)
+	}
+
+	if t.TTL <= 0 {
+		t.TTL = 5 * time.Minute
+	}
+
+	if err := checkTextfileDirectory(t.Directory); err != nil {
+		return err
+	}
+
+	t.entries = make(map[string]map[string]*textfileEntry)
+
+	return nil
+}
+
+// start creates the directory and starts the pruner, Parse has no side effect so -check-config leaves the disk untouched
+func (t *Textfile) start() error {
+	if err := os.MkdirAll(t.Directory, 0755); err != nil {
+		return fmt.Errorf("cannot create textfile.directory: %s", err)
+	}
+
+	go t.startPruner()
+
+	return nil
+}
+
+// checkTextfileDirectory checks the directory, or the nearest existing parent if it is created by start, is a directory
+func checkTextfileDirectory(dir string) error {
+	for path := filepath.Clean(dir); ; path = filepath.Dir(path) {
+		info, err := os.Stat(path)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("textfile.directory %s is not a directory: %s is a file", dir, path)
+			}
+			return nil
+		}
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("cannot check textfile.directory: %s", err)
+		}
+		if parent := filepath.Dir(path); parent == path {
+			return nil
+		}
+	}
+}
+
+// WriteTextfile writes the samples scraped from the target to the textfile directory if configured
+func WriteTextfile(plugin, job, target string, tss []prompbmarshal.TimeSeries) {
+	if WriterConfig.Textfile == nil {
+		return
+	}
+
+	if err := WriterConfig.Textfile.write(plugin, job, target, tss, time.Now()); err != nil {
+		logger.Warnf("cannot write textfile, plugin: %s, target: %s, error: %s", plugin, target, err)
+	}
+}
+
+func (t *Textfile) fileName(plugin, job, target string) string {
+	name := textfilePrefix + plugin
+	if t.SplitBy == "target" {
+		name += "_" + job + "_" + target
+	}
+	return textfileNameRE.ReplaceAllString(name, "_") + ".prom"
+}
+
+func (t *Textfile) write(plugin, job, target string, tss []prompbmarshal.TimeSeries, now time.Time) error {
+	var extraLabels []prompbmarshal.Label
+	if WriterConfig.Global != nil && WriterConfig.Global.ExtraLabels != nil {
+		extraLabels = WriterConfig.Global.ExtraLabels.Labels
+	}
+
+	lines := make([]string, 0, len(tss))
+	for i := range tss {
+		if len(tss[i].Samples) == 0 || decimal.IsStaleNaN(tss[i].Samples[0].Value) {
+			continue
+		}
+		lines = append(lines, formatTextfileLine(tss[i], extraLabels))
+	}
+
+	name := t.fileName(plugin, job, target)
+
+	t.Lock()
+	defer t.Unlock()
+
+	targets := t.entries[name]
+	if targets == nil {
+		targets = make(map[string]*textfileEntry)
+		t.entries[name] = targets
+	}
+	targets[job+"\x00"+target] = &textfileEntry{lines: lines, updated: now}
+
+	var all []string
+	for key, entry := range targets {
+		if now.Sub(entry.updated) > t.TTL {
+			delete(targets, key)
+			continue
+		}
+		all = append(all, entry.lines...)
+	}
+
+	// the lines of the same metric must be grouped together
+	sort.Strings(all)
+
+	var sb strings.Builder
+	sb.WriteString("# Generated by cprobe at " + now.Format(time.RFC3339) + "\n")
+	for _, line := range all {
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+
+	return writeFileAtomic(filepath.Join(t.Directory, name), []byte(sb.String()))
+}
+
+// writeFileAtomic writes to a temp file and renames it, so the textfile collector never reads a partial file
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func formatTextfileLine(ts prompbmarshal.TimeSeries, extraLabels []prompbmarshal.Label) string {
+	var name string
+	labels := make([]prompbmarshal.Label, 0, len(ts.Labels)+len(extraLabels))
+	for _, label := range ts.Labels {
+		if label.Name == "__name__" {
+			name = label.Value
+			continue
+		}
+		labels = append(labels, label)
+	}
+	for _, extra := range extraLabels {
+		if !hasLabel(labels, extra.Name) {
+			labels = append(labels, extra)
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+
+	var sb strings.Builder
+	sb.WriteString(name)
+	if len(labels) > 0 {
+		sb.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(label.Name)
+			sb.WriteString(`="`)
+			sb.WriteString(escapeLabelValue(label.Value))
+			sb.WriteByte('"')
+		}
+		sb.WriteByte('}')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(formatFloat(ts.Samples[0].Value))
+	return sb.String()
+}
+
+func hasLabel(labels []prompbmarshal.Label, name string) bool {
+	for _, label := range labels {
+		if label.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// startPruner removes the files generated by cprobe which are not updated within ttl,
+// e.g. the target is removed from the config
+func (t *Textfile) startPruner() {
+	ticker := time.NewTicker(t.TTL / 2)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		t.prune(time.Now())
+	}
+}
+
+func (t *Textfile) prune(now time.Time) {
+	paths, err := filepath.Glob(filepath.Join(t.Directory, textfilePrefix+"*.prom"))
+	if err != nil {
+		logger.Warnf("cannot list textfile directory: %s", err)
+		return
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || now.Sub(info.ModTime()) <= t.TTL {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			logger.Warnf("cannot remove stale textfile %s: %s", path, err)
+			continue
+		}
+		delete(t.entries, filepath.Base(path))
+	}
+}
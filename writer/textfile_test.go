@@ -0,0 +1,114 @@
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cprobe/cprobe/lib/prompbmarshal"
+)
+
+func newTimeSeries(value float64, labels ...string) prompbmarshal.TimeSeries {
+	ts := prompbmarshal.TimeSeries{Samples: []prompbmarshal.Sample{{Value: value}}}
+	for i := 0; i < len(labels); i += 2 {
+		ts.Labels = append(ts.Labels, prompbmarshal.Label{Name: labels[i], Value: labels[i+1]})
+	}
+	return ts
+}
+
+func TestTextfileWrite(t *testing.T) {
+	tf := &Textfile{Directory: t.TempDir(), TTL: time.Minute}
+	if err := tf.Parse(); err != nil {
+		t.Fatalf("failed to parse textfile config: %s", err)
+	}
+
+	now := time.Now()
+	err := tf.write("mysql", "mysql", "10.0.0.1:3306", []prompbmarshal.TimeSeries{
+		newTimeSeries(1, "__name__", "mysql_up", "instance", "10.0.0.1:3306"),
+		newTimeSeries(0.5, "__name__", "mysql_scrape_duration_seconds", "instance", "10.0.0.1:3306", "note", "a\"b"),
+	}, now.Add(-2*time.Minute))
+	if err != nil {
+		t.Fatalf("failed to write textfile: %s", err)
+	}
+	err = tf.write("mysql", "mysql", "10.0.0.2:3306", []prompbmarshal.TimeSeries{
+		newTimeSeries(0, "__name__", "mysql_up", "instance", "10.0.0.2:3306"),
+	}, now)
+	if err != nil {
+		t.Fatalf("failed to write textfile: %s", err)
+	}
+
+	bs, err := os.ReadFile(filepath.Join(tf.Directory, "cprobe_mysql.prom"))
+	if err != nil {
+		t.Fatalf("failed to read textfile: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(bs)), "\n")
+	if !strings.HasPrefix(lines[0], "# Generated by cprobe at ") {
+		t.Errorf("unexpected header: %s", lines[0])
+	}
+	// the samples of 10.0.0.1:3306 are older than ttl
+	expected := []string{`mysql_up{instance="10.0.0.2:3306"} 0`}
+	if strings.Join(lines[1:], "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected textfile content:\n%s", bs)
+	}
+
+	line := formatTextfileLine(newTimeSeries(0.5, "__name__", "x", "note", "a\"b", "instance", "i"), []prompbmarshal.Label{{Name: "colld", Value: "cprobe"}})
+	if line != `x{colld="cprobe",instance="i",note="a\"b"} 0.5` {
+		t.Errorf("unexpected line: %s", line)
+	}
+
+	if name := (&Textfile{SplitBy: "target"}).fileName("redis", "cache", "10.0.0.1:6379"); name != "cprobe_redis_cache_10.0.0.1_6379.prom" {
+		t.Errorf("unexpected file name: %s", name)
+	}
+}
+
+func TestTextfilePrune(t *testing.T) {
+	tf := &Textfile{Directory: t.TempDir(), TTL: time.Minute}
+	if err := tf.Parse(); err != nil {
+		t.Fatalf("failed to parse textfile config: %s", err)
+	}
+
+	stale := filepath.Join(tf.Directory, "cprobe_redis.prom")
+	other := filepath.Join(tf.Directory, "node.prom")
+	for _, path := range []string{stale, other} {
+		if err := os.WriteFile(path, []byte("x 1\n"), 0644); err != nil {
+			t.Fatalf("failed to write file: %s", err)
+		}
+	}
+
+	tf.prune(time.Now().Add(2 * time.Minute))
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("expected stale file to be removed")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("expected files not generated by cprobe to be kept")
+	}
+}
+
+func TestTextfileParseDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "textfile", "cprobe")
+	tf := &Textfile{Directory: dir}
+	if err := tf.Parse(); err != nil {
+		t.Fatalf("failed to parse textfile config: %s", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expected the directory not created by parse")
+	}
+
+	if err := tf.start(); err != nil {
+		t.Fatalf("failed to start textfile: %s", err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Fatalf("expected the directory created by start: %v", err)
+	}
+
+	file := filepath.Join(dir, "node.prom")
+	if err := os.WriteFile(file, []byte("x 1\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %s", err)
+	}
+	if err := (&Textfile{Directory: filepath.Join(file, "sub")}).Parse(); err == nil {
+		t.Fatalf("expected error for a directory under a file")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cprobe/cprobe/lib/cgroup"
@@ -27,6 +28,8 @@ var (
 )
 
 type Writer struct {
+	// Name is referred by the writers of the jobs and the __writers__ label of the targets, defaults to the url
+	Name                 string                      `yaml:"name"`
 	URL                  string                      `yaml:"url"`
 	RetryTimes           int                         `yaml:"retry_times"`
 	RetryIntervalMillis  int64                       `yaml:"retry_interval_millis"`
@@ -40,16 +43,54 @@ type Writer struct {
 	ProxyURL             string                      `yaml:"proxy_url"`
 	Interface            string                      `yaml:"interface"`
 	FollowRedirects      bool                        `yaml:"follow_redirects"`
+	SendExemplars        bool                        `yaml:"send_exemplars"`
 	ExtraLabels          *promutils.Labels           `yaml:"extra_labels"`
 	RelabelConfigs       []promrelabel.RelabelConfig `yaml:"metric_relabel_configs"`
 	ParsedRelabelConfigs *promrelabel.ParsedConfigs  `yaml:"-"`
+	// Format is remote_write (default), influxdb or kafka
+	Format   string    `yaml:"format"`
+	InfluxDB *InfluxDB `yaml:"influxdb"`
+	Kafka    *Kafka    `yaml:"kafka"`
 
 	clienttls.ClientConfig `yaml:",inline"`
 	Client                 *http.Client                   `yaml:"-"`
 	RequestQueue           *listx.SafeList[*http.Request] `yaml:"-"`
+
+	// the requests queued or being sent, waited by Flush
+	pending sync.WaitGroup
 }
 
 func (w *Writer) Parse() error {
+	// the url of a kafka writer is only its default name
+	if w.Format == FormatKafka && w.URL == "" && w.Kafka != nil {
+		w.URL = "kafka://" + strings.Join(w.Kafka.Brokers, ",") + "/" + w.Kafka.Topic
+	}
+
+	if w.Name == "" {
+		w.Name = w.URL
+	}
+
+	switch w.Format {
+	case "":
+		w.Format = FormatRemoteWrite
+	case FormatRemoteWrite:
+	case FormatInfluxDB:
+		if w.InfluxDB == nil {
+			return fmt.Errorf("influxdb is required for the writer %s with format influxdb", w.URL)
+		}
+		u, err := w.InfluxDB.parse(w.URL)
+		if err != nil {
+			return err
+		}
+		w.URL = u
+	case FormatKafka:
+		if w.Kafka == nil {
+			return fmt.Errorf("kafka is required for the writer %s with format kafka", w.Name)
+		}
+	default:
+		return fmt.Errorf("invalid format %q of the writer %s, should be remote_write, influxdb or kafka", w.Format, w.URL)
+	}
+
 	if w.Concurrency <= 0 {
 		w.Concurrency = cgroup.AvailableCPUs() * 2
 	}
@@ -127,6 +168,11 @@ func (w *Writer) Parse() error {
 		w.RetryIntervalMillis = 3000
 	}
 
+	// kafka has its own producer queue and retries
+	if w.Format == FormatKafka {
+		return w.Kafka.parse(w)
+	}
+
 	go w.StartSender()
 
 	return nil
@@ -139,15 +185,22 @@ type Global struct {
 }
 
 type WriterYaml struct {
-	Global  *Global   `yaml:"global"`
-	Writers []*Writer `yaml:"writers"`
+	Global   *Global   `yaml:"global"`
+	Writers  []*Writer `yaml:"writers"`
+	Textfile *Textfile `yaml:"textfile"`
 }
 
 func (wy *WriterYaml) Parse() (err error) {
+	names := make(map[string]struct{}, len(wy.Writers))
 	for i := range wy.Writers {
 		if err = wy.Writers[i].Parse(); err != nil {
 			return err
 		}
+
+		if _, has := names[wy.Writers[i].Name]; has {
+			return fmt.Errorf("duplicate writer name %s", wy.Writers[i].Name)
+		}
+		names[wy.Writers[i].Name] = struct{}{}
 	}
 
 	wy.Global.ParsedRelabelConfigs, err = promrelabel.ParseRelabelConfigs(wy.Global.RelabelConfigs)
@@ -155,14 +208,70 @@ func (wy *WriterYaml) Parse() (err error) {
 		return err
 	}
 
+	if wy.Textfile != nil {
+		if err = wy.Textfile.Parse(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// getWriter returns the writer of the name, nil if not found
+func (wy *WriterYaml) getWriter(name string) *Writer {
+	for _, w := range wy.Writers {
+		if w.Name == name {
+			return w
+		}
+	}
+	return nil
+}
+
+// CheckWriters checks the writers referred by the jobs and targets are configured,
+// nothing is checked if the writer is disabled
+func CheckWriters(names []string) error {
+	if *writerDisable {
+		return nil
+	}
+
+	for _, name := range names {
+		if WriterConfig.getWriter(name) == nil {
+			return fmt.Errorf("writer %s not found in writer.yaml", name)
+		}
+	}
+	return nil
+}
+
+// Init reads writer.yaml and starts the writers
 func Init(configDirectory string) error {
 	if *writerDisable {
 		return nil
 	}
 
+	if err := readConfig(configDirectory); err != nil {
+		return err
+	}
+
+	if WriterConfig.Textfile != nil {
+		if err := WriterConfig.Textfile.start(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Check reads writer.yaml for -check-config, the textfile directory is checked but not created
+func Check(configDirectory string) error {
+	if *writerDisable {
+		return nil
+	}
+
+	return readConfig(configDirectory)
+}
+
+func readConfig(configDirectory string) error {
+
 	writerFile := filepath.Join(configDirectory, "writer.yaml")
 
 	if !fileutil.IsExist(writerFile) {